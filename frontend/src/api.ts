@@ -1,11 +1,24 @@
 ﻿import { API_PREFIX, ApiCallInput, ApiCallResult, JsonValue } from "./types";
 
+const SESSION_KEY = "ticketing.frontend.session";
+
+function authHeaders(): Record<string, string> {
+  try {
+    const raw = localStorage.getItem(SESSION_KEY);
+    const token = raw ? (JSON.parse(raw) as { token?: string }).token : "";
+    return token ? { Authorization: `Bearer ${token}` } : {};
+  } catch {
+    return {};
+  }
+}
+
 export async function request(input: ApiCallInput): Promise<ApiCallResult> {
   const start = performance.now();
   const response = await fetch(`${API_PREFIX[input.service]}${input.path}`, {
     method: input.method,
     headers: {
-      "Content-Type": "application/json"
+      "Content-Type": "application/json",
+      ...authHeaders()
     },
     body: input.body === undefined ? undefined : JSON.stringify(input.body)
   });
@@ -1,32 +1,50 @@
 import { FormEvent, useState } from "react";
 import { useNavigate } from "react-router-dom";
+import { request } from "../api";
 import { useAppState } from "../state/AppState";
 
 export function LoginPage() {
   const navigate = useNavigate();
   const { setSession } = useAppState();
   const [username, setUsername] = useState("demo-user");
-  const [password, setPassword] = useState("123456");
+  const [password, setPassword] = useState("");
   const [error, setError] = useState("");
+  const [submitting, setSubmitting] = useState(false);
 
-  const onSubmit = (e: FormEvent) => {
+  const onSubmit = async (e: FormEvent) => {
     e.preventDefault();
     if (!username.trim() || !password.trim()) {
       setError("用户名和密码不能为空");
       return;
     }
-    setSession({
-      username: username.trim(),
-      token: `mock-${Date.now()}`
-    });
-    navigate("/app/dashboard", { replace: true });
+    setSubmitting(true);
+    setError("");
+    try {
+      const result = await request({
+        service: "order",
+        method: "POST",
+        path: "/auth/login",
+        body: { username: username.trim(), password }
+      });
+      const data = result.data as { Token?: string; error?: string } | null;
+      if (!result.ok || !data?.Token) {
+        setError(data?.error ?? `登录失败（HTTP ${result.status}）`);
+        return;
+      }
+      setSession({ username: username.trim(), token: data.Token });
+      navigate("/app/dashboard", { replace: true });
+    } catch (err) {
+      setError(err instanceof Error ? err.message : "登录请求失败");
+    } finally {
+      setSubmitting(false);
+    }
   };
 
   return (
     <div className="auth-layout">
       <section className="card auth-card">
         <h2>Ticketing 登录</h2>
-        <p>当前为前端演示登录（Mock Session），用于流程联调。</p>
+        <p>使用 order-service 账号登录（POST /auth/register 注册），令牌随后续请求携带。</p>
         <form className="form" onSubmit={onSubmit}>
           <label>
             用户名
@@ -41,7 +59,9 @@ export function LoginPage() {
             />
           </label>
           {error ? <p className="error-text">{error}</p> : null}
-          <button type="submit">登录进入控制台</button>
+          <button type="submit" disabled={submitting}>
+            {submitting ? "登录中..." : "登录进入控制台"}
+          </button>
         </form>
       </section>
     </div>
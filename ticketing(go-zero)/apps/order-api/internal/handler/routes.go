@@ -14,6 +14,11 @@ import (
 func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 	server.AddRoutes(
 		[]rest.Route{
+			{
+				Method:  http.MethodGet,
+				Path:    "/orders",
+				Handler: ListOrdersHandler(serverCtx),
+			},
 			{
 				Method:  http.MethodPost,
 				Path:    "/orders",
@@ -30,6 +30,7 @@ func NewCreateOrderLogic(ctx context.Context, svcCtx *svc.ServiceContext) *Creat
 func (l *CreateOrderLogic) CreateOrder(req *types.CreateOrderReq) (resp *types.OrderResp, err error) {
 	order, err := l.svcCtx.OrderService.CreateOrder(l.ctx, ordersvc.CreateOrderInput{
 		IdempotencyKey: req.IdempotencyKey,
+		UserID:         req.UserID,
 		AmountCents:    req.AmountCents,
 	})
 	if err != nil {
@@ -0,0 +1,59 @@
+// Code scaffolded by goctl. Safe to edit.
+// goctl 1.9.2
+
+package logic
+
+import (
+	"context"
+
+	"ticketing-gozero/apps/order-api/internal/svc"
+	"ticketing-gozero/apps/order-api/internal/types"
+	ordersvc "ticketing-gozero/pkg/core/order"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type ListOrdersLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewListOrdersLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ListOrdersLogic {
+	return &ListOrdersLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *ListOrdersLogic) ListOrders(req *types.ListOrdersReq) (resp *types.ListOrdersResp, err error) {
+	page, err := l.svcCtx.OrderService.ListOrders(l.ctx, ordersvc.ListOrdersInput{
+		UserID:      req.UserID,
+		Status:      req.Status,
+		CreatedFrom: req.CreatedFrom,
+		CreatedTo:   req.CreatedTo,
+		TrainNo:     req.TrainNo,
+		TravelDate:  req.TravelDate,
+		Cursor:      req.Cursor,
+		Limit:       req.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp = &types.ListOrdersResp{
+		Orders:     make([]types.OrderResp, 0, len(page.Orders)),
+		NextCursor: page.NextCursor,
+	}
+	for _, order := range page.Orders {
+		resp.Orders = append(resp.Orders, types.OrderResp{
+			OrderID:        order.OrderID,
+			IdempotencyKey: order.IdempotencyKey,
+			Status:         string(order.Status),
+			AmountCents:    order.AmountCents,
+			CreatedAt:      order.CreatedAt.String(),
+			UpdatedAt:      order.UpdatedAt.String(),
+		})
+	}
+	return resp, nil
+}
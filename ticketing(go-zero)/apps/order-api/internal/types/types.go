@@ -11,6 +11,7 @@ type CancelOrderReq struct {
 
 type CreateOrderReq struct {
 	IdempotencyKey string `json:"idempotency_key"`
+	UserID         string `json:"user_id,optional"`
 	AmountCents    int64  `json:"amount_cents"`
 }
 
@@ -18,6 +19,22 @@ type GetOrderReq struct {
 	OrderID string `form:"order_id"`
 }
 
+type ListOrdersReq struct {
+	UserID      string `form:"user_id,optional"`
+	Status      string `form:"status,optional"`
+	CreatedFrom string `form:"created_from,optional"`
+	CreatedTo   string `form:"created_to,optional"`
+	TrainNo     string `form:"train_no,optional"`
+	TravelDate  string `form:"travel_date,optional"`
+	Cursor      string `form:"cursor,optional"`
+	Limit       int    `form:"limit,optional"`
+}
+
+type ListOrdersResp struct {
+	Orders     []OrderResp `json:"Orders"`
+	NextCursor string      `json:"NextCursor"`
+}
+
 type OrderResp struct {
 	OrderID        string `json:"OrderID"`
 	IdempotencyKey string `json:"IdempotencyKey"`
@@ -24,8 +24,11 @@ var (
 type Order struct {
 	OrderID        string
 	IdempotencyKey string
+	UserID         string
 	Status         Status
 	AmountCents    int64
+	TrainNo        string
+	TravelDate     string
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
 }
@@ -73,4 +76,3 @@ func (o *Order) Cancel() error {
 	o.Status = StatusCancelled
 	return nil
 }
-
@@ -34,9 +34,21 @@ type Config struct {
 	PaymentSignKey      string
 }
 
+// orderRepository is the part of repository.Repository the service uses.
+type orderRepository interface {
+	DB() *sql.DB
+	FindByID(ctx context.Context, orderID string) (*domain.Order, error)
+	FindByIdempotencyKey(ctx context.Context, key string) (*domain.Order, error)
+	InsertTx(ctx context.Context, tx *sql.Tx, order *domain.Order) error
+	UpdateStatusTx(ctx context.Context, tx *sql.Tx, orderID string, expected domain.Status, next domain.Status) (bool, error)
+	UpdateItineraryTx(ctx context.Context, tx *sql.Tx, orderID string, partitionKey string) error
+	ListOrders(ctx context.Context, f domain.OrderFilter) ([]*domain.Order, error)
+	InsertPaymentTx(ctx context.Context, tx *sql.Tx, paymentID string, orderID string, providerTxnID string, status string) error
+}
+
 type Service struct {
 	logger          *slog.Logger
-	repo            *repository.Repository
+	repo            orderRepository
 	outbox          *outbox.Repository
 	publisher       *event.Publisher
 	inventoryClient *inventory.Client
@@ -45,9 +57,23 @@ type Service struct {
 
 type CreateOrderInput struct {
 	IdempotencyKey string
+	UserID         string
 	AmountCents    int64
 }
 
+// ListOrdersInput carries the raw listing filters; timestamps are RFC 3339
+// and TravelDate is YYYY-MM-DD.
+type ListOrdersInput struct {
+	UserID      string
+	Status      string
+	CreatedFrom string
+	CreatedTo   string
+	TrainNo     string
+	TravelDate  string
+	Cursor      string
+	Limit       int
+}
+
 type ReserveOrderInput struct {
 	OrderID      string
 	PartitionKey string
@@ -111,6 +137,7 @@ func (s *Service) CreateOrder(ctx context.Context, in CreateOrderInput) (*domain
 	if err != nil {
 		return nil, err
 	}
+	order.UserID = strings.TrimSpace(in.UserID)
 
 	tx, err := s.repo.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
 	if err != nil {
@@ -200,6 +227,9 @@ func (s *Service) ReserveOrder(ctx context.Context, in ReserveOrderInput) (*doma
 		}
 		return nil, domain.ErrInvalidStateTransfer
 	}
+	if err := s.repo.UpdateItineraryTx(ctx, tx, in.OrderID, partitionKey); err != nil {
+		return nil, err
+	}
 
 	if err := s.outbox.InsertTx(ctx, tx, uuid.NewString(), in.OrderID, "OrderReserved", map[string]any{
 		"order_id":      in.OrderID,
@@ -398,6 +428,58 @@ func (s *Service) GetOrder(ctx context.Context, orderID string) (*domain.Order,
 	return order, nil
 }
 
+func (s *Service) ListOrders(ctx context.Context, in ListOrdersInput) (*domain.OrderPage, error) {
+	f := domain.OrderFilter{
+		UserID:  strings.TrimSpace(in.UserID),
+		Status:  domain.Status(strings.ToUpper(strings.TrimSpace(in.Status))),
+		TrainNo: strings.TrimSpace(in.TrainNo),
+		Limit:   in.Limit,
+	}
+	if f.Limit <= 0 {
+		f.Limit = domain.DefaultPageSize
+	}
+	if f.Limit > domain.MaxPageSize {
+		f.Limit = domain.MaxPageSize
+	}
+	var err error
+	if v := strings.TrimSpace(in.CreatedFrom); v != "" {
+		if f.CreatedFrom, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, fmt.Errorf("%w: created_from", domain.ErrInvalidFilter)
+		}
+	}
+	if v := strings.TrimSpace(in.CreatedTo); v != "" {
+		if f.CreatedTo, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, fmt.Errorf("%w: created_to", domain.ErrInvalidFilter)
+		}
+	}
+	if v := strings.TrimSpace(in.TravelDate); v != "" {
+		if _, err := time.Parse(time.DateOnly, v); err != nil {
+			return nil, fmt.Errorf("%w: travel_date", domain.ErrInvalidFilter)
+		}
+		f.TravelDate = v
+	}
+	if v := strings.TrimSpace(in.Cursor); v != "" {
+		if f.After, err = domain.DecodeOrderCursor(v); err != nil {
+			return nil, err
+		}
+	}
+
+	// One extra row tells whether another page exists.
+	limit := f.Limit
+	f.Limit++
+	orders, err := s.repo.ListOrders(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	page := &domain.OrderPage{Orders: orders}
+	if len(orders) > limit {
+		page.Orders = orders[:limit]
+		last := page.Orders[limit-1]
+		page.NextCursor = domain.OrderCursor{CreatedAt: last.CreatedAt, OrderID: last.OrderID}.Encode()
+	}
+	return page, nil
+}
+
 func (s *Service) resolveHoldConfig(orderID string, partitionKey string, holdID string, qty int, capacity int) (string, string, int, int) {
 	resolvedPartition := strings.TrimSpace(partitionKey)
 	if resolvedPartition == "" {
@@ -446,5 +528,3 @@ func signHMACSHA256(secret string, payload string) string {
 	_, _ = mac.Write([]byte(payload))
 	return hex.EncodeToString(mac.Sum(nil))
 }
-
-
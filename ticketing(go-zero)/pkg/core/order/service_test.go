@@ -3,7 +3,11 @@ package order
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
+
+	"ticketing-gozero/pkg/core/order/domain"
 )
 
 func TestPaymentCallback_InvalidSignatureRejected(t *testing.T) {
@@ -47,4 +51,166 @@ func TestPaymentCallback_StatusWhitelist(t *testing.T) {
 	}
 }
 
+// fakeOrderRepo lists its orders newest first, the way the SQL query does,
+// and keeps the filters it was asked for.
+type fakeOrderRepo struct {
+	orderRepository
+	orders  []*domain.Order
+	filters []domain.OrderFilter
+}
+
+func (r *fakeOrderRepo) ListOrders(_ context.Context, f domain.OrderFilter) ([]*domain.Order, error) {
+	r.filters = append(r.filters, f)
+	out := make([]*domain.Order, 0, f.Limit)
+	for _, o := range r.orders {
+		if f.UserID != "" && o.UserID != f.UserID || f.Status != "" && o.Status != f.Status {
+			continue
+		}
+		if f.After != nil && (o.CreatedAt.After(f.After.CreatedAt) ||
+			o.CreatedAt.Equal(f.After.CreatedAt) && o.OrderID >= f.After.OrderID) {
+			continue
+		}
+		if len(out) == f.Limit {
+			break
+		}
+		out = append(out, o)
+	}
+	return out, nil
+}
+
+// newListingRepo holds n orders of user-1 created a minute apart; the two
+// newest share a timestamp so the cursor has to break the tie by order ID.
+func newListingRepo(n int) *fakeOrderRepo {
+	base := time.Date(2026, 2, 1, 8, 0, 0, 0, time.UTC)
+	repo := &fakeOrderRepo{}
+	for i := n - 1; i >= 0; i-- {
+		createdAt := base.Add(time.Duration(min(i, n-2)) * time.Minute)
+		repo.orders = append(repo.orders, &domain.Order{
+			OrderID:   fmt.Sprintf("order-%02d", i),
+			UserID:    "user-1",
+			Status:    domain.StatusPaid,
+			CreatedAt: createdAt,
+		})
+	}
+	return repo
+}
+
+func TestListOrders_CursorWalksEveryOrderOnce(t *testing.T) {
+	t.Parallel()
+
+	repo := newListingRepo(7)
+	svc := &Service{repo: repo}
+
+	var (
+		seen   []string
+		cursor string
+		pages  int
+	)
+	for {
+		page, err := svc.ListOrders(context.Background(), ListOrdersInput{UserID: "user-1", Cursor: cursor, Limit: 3})
+		if err != nil {
+			t.Fatalf("expected page %d to load, got: %v", pages+1, err)
+		}
+		pages++
+		for _, o := range page.Orders {
+			seen = append(seen, o.OrderID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	if pages != 3 || len(seen) != 7 {
+		t.Fatalf("expected 7 orders over 3 pages, got %d over %d: %v", len(seen), pages, seen)
+	}
+	for i, id := range seen {
+		if want := fmt.Sprintf("order-%02d", 6-i); id != want {
+			t.Fatalf("expected %s at position %d, got %v", want, i, seen)
+		}
+	}
+	if f := repo.filters[1]; f.After == nil || f.After.OrderID != "order-04" {
+		t.Fatalf("expected the second page to start after order-04, got %+v", f.After)
+	}
+}
+
+func TestListOrders_LastPageHasNoCursor(t *testing.T) {
+	t.Parallel()
+
+	svc := &Service{repo: newListingRepo(3)}
+
+	page, err := svc.ListOrders(context.Background(), ListOrdersInput{UserID: "user-1", Limit: 3})
+	if err != nil {
+		t.Fatalf("expected the page to load, got: %v", err)
+	}
+	if len(page.Orders) != 3 || page.NextCursor != "" {
+		t.Fatalf("expected all 3 orders and no cursor, got %d (%q)", len(page.Orders), page.NextCursor)
+	}
 
+	page, err = svc.ListOrders(context.Background(), ListOrdersInput{UserID: "user-2"})
+	if err != nil || len(page.Orders) != 0 || page.NextCursor != "" {
+		t.Fatalf("expected an empty last page, got %+v (%v)", page, err)
+	}
+}
+
+func TestListOrders_NormalisesFilters(t *testing.T) {
+	t.Parallel()
+
+	repo := newListingRepo(1)
+	svc := &Service{repo: repo}
+
+	_, err := svc.ListOrders(context.Background(), ListOrdersInput{
+		UserID:      " user-1 ",
+		Status:      " paid ",
+		CreatedFrom: "2026-02-01T00:00:00+08:00",
+		CreatedTo:   "2026-02-02T00:00:00Z",
+		TrainNo:     " G123 ",
+		TravelDate:  "2026-02-11",
+		Limit:       domain.MaxPageSize + 1,
+	})
+	if err != nil {
+		t.Fatalf("expected the filters to be accepted, got: %v", err)
+	}
+	f := repo.filters[0]
+	if f.UserID != "user-1" || f.Status != domain.StatusPaid || f.TrainNo != "G123" || f.TravelDate != "2026-02-11" {
+		t.Fatalf("expected trimmed filters, got %+v", f)
+	}
+	if !f.CreatedFrom.Equal(time.Date(2026, 1, 31, 16, 0, 0, 0, time.UTC)) || f.CreatedTo.IsZero() {
+		t.Fatalf("expected parsed creation bounds, got %v and %v", f.CreatedFrom, f.CreatedTo)
+	}
+	// One extra row is fetched to tell whether another page exists.
+	if f.Limit != domain.MaxPageSize+1 {
+		t.Fatalf("expected the limit to be capped at %d, got %d", domain.MaxPageSize, f.Limit-1)
+	}
+
+	if _, err := svc.ListOrders(context.Background(), ListOrdersInput{}); err != nil {
+		t.Fatalf("expected no filters to be fine, got: %v", err)
+	}
+	if got := repo.filters[1].Limit; got != domain.DefaultPageSize+1 {
+		t.Fatalf("expected the default page size, got %d", got-1)
+	}
+}
+
+func TestListOrders_RejectsMalformedFilters(t *testing.T) {
+	t.Parallel()
+
+	repo := newListingRepo(1)
+	svc := &Service{repo: repo}
+	cases := []struct {
+		in   ListOrdersInput
+		want error
+	}{
+		{ListOrdersInput{CreatedFrom: "2026-02-01"}, domain.ErrInvalidFilter},
+		{ListOrdersInput{CreatedTo: "yesterday"}, domain.ErrInvalidFilter},
+		{ListOrdersInput{TravelDate: "2026/02/11"}, domain.ErrInvalidFilter},
+		{ListOrdersInput{Cursor: "not a cursor"}, domain.ErrInvalidCursor},
+		{ListOrdersInput{Cursor: "b3JkZXItMQ"}, domain.ErrInvalidCursor},
+	}
+	for _, tc := range cases {
+		if _, err := svc.ListOrders(context.Background(), tc.in); !errors.Is(err, tc.want) {
+			t.Fatalf("expected %v for %+v, got: %v", tc.want, tc.in, err)
+		}
+	}
+	if len(repo.filters) != 0 {
+		t.Fatalf("expected malformed filters not to reach the repository, got %d queries", len(repo.filters))
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/go-sql-driver/mysql"
 
@@ -19,6 +20,9 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
+const orderColumns = `order_id, idempotency_key, user_id, status, amount_cents, train_no, travel_date,
+		        created_at, updated_at`
+
 func (r *Repository) DB() *sql.DB {
 	return r.db
 }
@@ -26,7 +30,7 @@ func (r *Repository) DB() *sql.DB {
 func (r *Repository) FindByID(ctx context.Context, orderID string) (*domain.Order, error) {
 	row := r.db.QueryRowContext(
 		ctx,
-		`SELECT order_id, idempotency_key, status, amount_cents, created_at, updated_at
+		`SELECT `+orderColumns+`
 		 FROM orders WHERE order_id=?`,
 		orderID,
 	)
@@ -36,7 +40,7 @@ func (r *Repository) FindByID(ctx context.Context, orderID string) (*domain.Orde
 func (r *Repository) FindByIdempotencyKey(ctx context.Context, key string) (*domain.Order, error) {
 	row := r.db.QueryRowContext(
 		ctx,
-		`SELECT order_id, idempotency_key, status, amount_cents, created_at, updated_at
+		`SELECT `+orderColumns+`
 		 FROM orders WHERE idempotency_key=?`,
 		key,
 	)
@@ -46,8 +50,8 @@ func (r *Repository) FindByIdempotencyKey(ctx context.Context, key string) (*dom
 func (r *Repository) InsertTx(ctx context.Context, tx *sql.Tx, order *domain.Order) error {
 	_, err := tx.ExecContext(
 		ctx,
-		`INSERT INTO orders(order_id, idempotency_key, status, amount_cents) VALUES(?, ?, ?, ?)`,
-		order.OrderID, order.IdempotencyKey, string(order.Status), order.AmountCents,
+		`INSERT INTO orders(order_id, idempotency_key, user_id, status, amount_cents) VALUES(?, ?, ?, ?, ?)`,
+		order.OrderID, order.IdempotencyKey, order.UserID, string(order.Status), order.AmountCents,
 	)
 	if err == nil {
 		return nil
@@ -75,6 +79,76 @@ func (r *Repository) UpdateStatusTx(ctx context.Context, tx *sql.Tx, orderID str
 	return affected > 0, nil
 }
 
+// UpdateItineraryTx stores the train and travel date parsed from the
+// partition key so orders can be listed by them.
+func (r *Repository) UpdateItineraryTx(ctx context.Context, tx *sql.Tx, orderID string, partitionKey string) error {
+	trainNo, travelDate := domain.ParsePartitionKey(partitionKey)
+	_, err := tx.ExecContext(
+		ctx,
+		`UPDATE orders SET train_no=?, travel_date=?, updated_at=CURRENT_TIMESTAMP WHERE order_id=?`,
+		trainNo, travelDate, orderID,
+	)
+	return err
+}
+
+// ListOrders returns up to f.Limit orders matching f, newest first, starting
+// after f.After. Each filter combination is served by one of the
+// idx_orders_* indexes ending in (created_at, order_id).
+func (r *Repository) ListOrders(ctx context.Context, f domain.OrderFilter) ([]*domain.Order, error) {
+	where := make([]string, 0, 8)
+	args := make([]any, 0, 10)
+	if f.UserID != "" {
+		where = append(where, "user_id=?")
+		args = append(args, f.UserID)
+	}
+	if f.Status != "" {
+		where = append(where, "status=?")
+		args = append(args, string(f.Status))
+	}
+	if f.TrainNo != "" {
+		where = append(where, "train_no=?")
+		args = append(args, f.TrainNo)
+	}
+	if f.TravelDate != "" {
+		where = append(where, "travel_date=?")
+		args = append(args, f.TravelDate)
+	}
+	if !f.CreatedFrom.IsZero() {
+		where = append(where, "created_at>=?")
+		args = append(args, f.CreatedFrom)
+	}
+	if !f.CreatedTo.IsZero() {
+		where = append(where, "created_at<?")
+		args = append(args, f.CreatedTo)
+	}
+	if f.After != nil {
+		where = append(where, "(created_at<? OR (created_at=? AND order_id<?))")
+		args = append(args, f.After.CreatedAt, f.After.CreatedAt, f.After.OrderID)
+	}
+	query := `SELECT ` + orderColumns + ` FROM orders`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += ` ORDER BY created_at DESC, order_id DESC LIMIT ?`
+	args = append(args, f.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*domain.Order, 0, f.Limit)
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
+
 func (r *Repository) InsertPaymentTx(ctx context.Context, tx *sql.Tx, paymentID string, orderID string, providerTxnID string, status string) error {
 	_, err := tx.ExecContext(
 		ctx,
@@ -89,7 +163,17 @@ func scanOrder(row interface {
 }) (*domain.Order, error) {
 	o := &domain.Order{}
 	var status string
-	if err := row.Scan(&o.OrderID, &o.IdempotencyKey, &status, &o.AmountCents, &o.CreatedAt, &o.UpdatedAt); err != nil {
+	if err := row.Scan(
+		&o.OrderID,
+		&o.IdempotencyKey,
+		&o.UserID,
+		&status,
+		&o.AmountCents,
+		&o.TrainNo,
+		&o.TravelDate,
+		&o.CreatedAt,
+		&o.UpdatedAt,
+	); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, domain.ErrOrderNotFound
 		}
@@ -98,5 +182,3 @@ func scanOrder(row interface {
 	o.Status = domain.Status(status)
 	return o, nil
 }
-
-
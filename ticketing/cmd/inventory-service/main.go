@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,6 +13,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
 
 	commonconfig "ticketing/internal/common/config"
 	commonkafka "ticketing/internal/common/kafka"
@@ -25,7 +27,9 @@ import (
 	"ticketing/internal/inventory/infrastructure/snapshot"
 	"ticketing/internal/inventory/infrastructure/ttl"
 	"ticketing/internal/inventory/infrastructure/wal"
+	inventorygrpc "ticketing/internal/inventory/interfaces/grpc_server"
 	inventoryhttp "ticketing/internal/inventory/interfaces/http"
+	inventoryv1 "ticketing/proto/inventory/v1"
 )
 
 func main() {
@@ -113,13 +117,24 @@ func run() error {
 		ReadHeaderTimeout: 3 * time.Second,
 	}
 
-	logger.Info("inventory-service starting", "addr", server.Addr)
-	serverErr := make(chan error, 1)
+	grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.InventoryGRPCPort))
+	if err != nil {
+		return fmt.Errorf("grpc listen failed: %w", err)
+	}
+	grpcServer := grpc.NewServer()
+	inventoryv1.RegisterInventoryServiceServer(grpcServer, inventorygrpc.NewServer(svc))
+
+	logger.Info("inventory-service starting", "addr", server.Addr, "grpc_addr", grpcListener.Addr().String())
+	serverErr := make(chan error, 2)
 	go func() {
 		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			serverErr <- err
 		}
-		close(serverErr)
+	}()
+	go func() {
+		if err := grpcServer.Serve(grpcListener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			serverErr <- fmt.Errorf("grpc server: %w", err)
+		}
 	}()
 
 	sigCh := make(chan os.Signal, 1)
@@ -128,9 +143,8 @@ func run() error {
 
 	select {
 	case err := <-serverErr:
-		if err != nil {
-			return err
-		}
+		grpcServer.Stop()
+		return err
 	case sig := <-sigCh:
 		logger.Info("shutdown signal received", "signal", sig.String())
 	}
@@ -138,5 +152,16 @@ func run() error {
 	cancel()
 	shutdownCtx, stop := context.WithTimeout(context.Background(), 5*time.Second)
 	defer stop()
-	return server.Shutdown(shutdownCtx)
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	err = server.Shutdown(shutdownCtx)
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		grpcServer.Stop()
+	}
+	return err
 }
@@ -5,13 +5,18 @@ import (
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"ticketing/internal/common/auth"
 	commonconfig "ticketing/internal/common/config"
 	commonkafka "ticketing/internal/common/kafka"
 	"ticketing/internal/common/logging"
@@ -20,11 +25,18 @@ import (
 	commonmysql "ticketing/internal/common/mysql"
 	commonredis "ticketing/internal/common/redis"
 	"ticketing/internal/order/application"
+	"ticketing/internal/order/infrastructure/change"
 	"ticketing/internal/order/infrastructure/event"
 	inventoryclient "ticketing/internal/order/infrastructure/inventory"
 	"ticketing/internal/order/infrastructure/outbox"
+	"ticketing/internal/order/infrastructure/payment"
+	"ticketing/internal/order/infrastructure/reconciliation"
 	"ticketing/internal/order/infrastructure/repository"
+	"ticketing/internal/order/infrastructure/saga"
 	orderhttp "ticketing/internal/order/interfaces/http"
+	userapp "ticketing/internal/user/application"
+	userrepo "ticketing/internal/user/infrastructure/repository"
+	userhttp "ticketing/internal/user/interfaces/http"
 )
 
 func main() {
@@ -36,6 +48,9 @@ func main() {
 func run() error {
 	cfg := commonconfig.Load("order-service")
 	logger := logging.New(cfg.ServiceName, cfg.Env, cfg.Version)
+	if cfg.AuthJWTSecret == "" {
+		return errors.New("AUTH_JWT_SECRET is required")
+	}
 
 	mysqlDB, err := commonmysql.New(cfg.MySQLDSN)
 	if err != nil {
@@ -57,27 +72,50 @@ func run() error {
 
 	repo := repository.NewRepository(mysqlDB)
 	outboxRepo := outbox.NewRepository(mysqlDB)
+	changeRepo := change.NewRepository(mysqlDB)
+	sagaRepo := saga.NewRepository(mysqlDB)
 	publisher := event.NewPublisher(kafkaProducer, "order.events")
-	inventoryAPI := inventoryclient.NewClient(cfg.InventoryServiceURL)
+	inventoryAPI, closeInventory, err := newInventoryClient(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer closeInventory()
+	paymentProvider, err := newPaymentProvider(cfg, logger, redisClient)
+	if err != nil {
+		return err
+	}
 	svc := application.NewService(
 		logger,
 		repo,
 		outboxRepo,
+		changeRepo,
+		sagaRepo,
 		publisher,
 		inventoryAPI,
+		paymentProvider,
 		application.Config{
-			DefaultPartitionKey: cfg.OrderInventoryPartitionKey,
-			DefaultHoldQty:      cfg.OrderInventoryDefaultQty,
-			DefaultCapacity:     cfg.OrderInventoryCapacity,
-			PaymentSignKey:      cfg.PaymentCallbackSignKey,
+			DefaultPartitionKey:    cfg.OrderInventoryPartitionKey,
+			DefaultHoldQty:         cfg.OrderInventoryDefaultQty,
+			DefaultCapacity:        cfg.OrderInventoryCapacity,
+			PaymentTTL:             time.Duration(cfg.PaymentTTLSecs) * time.Second,
+			PaymentReconcileAfter:  time.Duration(cfg.PaymentReconcileAfterSecs) * time.Second,
+			CancelOnPaymentClosed:  cfg.PaymentClosedCancelsOrder,
+			DailyBookingLimitPerID: cfg.OrderDailyBookingLimit,
+			OutboxMaxAttempts:      cfg.OrderOutboxMaxAttempts,
 		},
 	)
 
+	tokens := auth.NewTokenManager(cfg.AuthJWTSecret, time.Duration(cfg.AuthTokenTTLSecs)*time.Second)
+	userSvc := userapp.NewService(logger, userrepo.NewRepository(mysqlDB), tokens)
+
 	rootCtx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	go svc.StartOutboxPublisher(rootCtx)
+	go svc.StartSagaRecovery(rootCtx)
+	go svc.StartPaymentReconciler(rootCtx)
 
 	metrics := commonmetrics.New(cfg.ServiceName)
+	metrics.MustRegister(inventoryAPI.Collectors()...)
 	router := gin.New()
 	router.Use(gin.Recovery(), middleware.WithRequestContextGin(), metrics.MiddlewareGin(cfg.ServiceName))
 	router.GET("/healthz", func(c *gin.Context) {
@@ -101,7 +139,15 @@ func run() error {
 		c.JSON(http.StatusOK, gin.H{"status": "ready"})
 	})
 	router.GET("/metrics", metrics.HandlerGin())
-	orderhttp.NewHandler(svc).Register(router)
+	requireAuth := middleware.RequireAuthGin(tokens)
+	orderhttp.NewHandler(svc, requireAuth).Register(router)
+	orderhttp.NewReconciliationHandler(
+		application.NewReconciler(logger, reconciliation.NewRepository(mysqlDB)),
+		requireAuth,
+		middleware.RequireRoleGin(auth.RoleSupport),
+	).Register(router)
+	orderhttp.NewOutboxHandler(svc, requireAuth, middleware.RequireRoleGin(auth.RoleSupport)).Register(router)
+	userhttp.NewHandler(userSvc, requireAuth).Register(router)
 
 	server := &http.Server{
 		Addr:              fmt.Sprintf(":%d", cfg.HTTPPort),
@@ -136,3 +182,72 @@ func run() error {
 	defer stop()
 	return server.Shutdown(shutdownCtx)
 }
+
+// inventoryClient is an inventory client with its metrics.
+type inventoryClient interface {
+	inventoryclient.API
+	Collectors() []prometheus.Collector
+}
+
+// newInventoryClient picks the inventory transport from INVENTORY_CLIENT_MODE.
+func newInventoryClient(cfg commonconfig.Config, logger *slog.Logger) (inventoryClient, func(), error) {
+	clientCfg := inventoryclient.Config{
+		Timeout:         time.Duration(cfg.InventoryClientTimeoutMS) * time.Millisecond,
+		MaxAttempts:     cfg.InventoryClientMaxAttempts,
+		BreakerFailures: cfg.InventoryBreakerFailures,
+		BreakerOpenFor:  time.Duration(cfg.InventoryBreakerOpenSecs) * time.Second,
+	}
+	mode := strings.ToLower(cfg.InventoryClientMode)
+	switch mode {
+	case "grpc":
+		client, err := inventoryclient.NewGRPCClient(cfg.InventoryGRPCAddr, clientCfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("init grpc inventory client failed: %w", err)
+		}
+		logger.Info("inventory client selected", "mode", mode, "addr", cfg.InventoryGRPCAddr)
+		return client, func() { _ = client.Close() }, nil
+	case "http", "":
+		logger.Info("inventory client selected", "mode", "http", "addr", cfg.InventoryServiceURL)
+		return inventoryclient.NewClient(cfg.InventoryServiceURL, clientCfg), func() {}, nil
+	}
+	return nil, nil, fmt.Errorf("unknown INVENTORY_CLIENT_MODE %q", cfg.InventoryClientMode)
+}
+
+// newPaymentProvider builds the configured provider. Callback keys come from
+// PAYMENT_CALLBACK_KEYS; the legacy PAYMENT_CALLBACK_SIGN_KEY stays active
+// as the HMAC key "default" and is what the simulated provider signs with.
+func newPaymentProvider(cfg commonconfig.Config, logger *slog.Logger, redisClient *redis.Client) (payment.Provider, error) {
+	keys, err := payment.ParseKeySpecs(cfg.PaymentCallbackKeys)
+	if err != nil {
+		return nil, fmt.Errorf("payment callback keys: %w", err)
+	}
+	var signer payment.Signer
+	if cfg.PaymentCallbackSignKey != "" {
+		key := payment.NewHMACKey(payment.DefaultKeyID, cfg.PaymentCallbackSignKey)
+		if _, ok := keys[payment.DefaultKeyID]; !ok {
+			keys[payment.DefaultKeyID] = key
+		}
+		signer = key
+	}
+	verifier := payment.NewCallbackVerifier(
+		keys,
+		time.Duration(cfg.PaymentCallbackWindowSecs)*time.Second,
+		payment.NewRedisNonceStore(redisClient, "payment:callback:nonce:"),
+	)
+
+	switch cfg.PaymentProvider {
+	case "simulated":
+		callbackURL := cfg.PaymentSimCallbackURL
+		if callbackURL == "" {
+			callbackURL = fmt.Sprintf("http://127.0.0.1:%d/payments/callback", cfg.HTTPPort)
+		}
+		return payment.NewSimulated(logger, payment.SimulatedConfig{
+			CallbackURL:   callbackURL,
+			CallbackDelay: time.Duration(cfg.PaymentSimCallbackDelayMS) * time.Millisecond,
+			Signer:        signer,
+			Verifier:      verifier,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", cfg.PaymentProvider)
+	}
+}
@@ -0,0 +1,109 @@
+// Command payment-reconcile matches a provider settlement file against the
+// payments recorded by order-service, prints the discrepancies and stores
+// the run for GET /admin/reconciliations.
+//
+//	payment-reconcile -file settlement-2026-02-11.csv -date 2026-02-11
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	commonconfig "ticketing/internal/common/config"
+	"ticketing/internal/common/logging"
+	commonmysql "ticketing/internal/common/mysql"
+	"ticketing/internal/order/application"
+	"ticketing/internal/order/domain"
+	"ticketing/internal/order/infrastructure/reconciliation"
+	"ticketing/internal/order/infrastructure/settlement"
+)
+
+// exitDiscrepancies is the exit status of a run that found discrepancies
+// under -strict, so schedulers can alert on it.
+const exitDiscrepancies = 2
+
+func main() {
+	cfg := commonconfig.Load("payment-reconcile")
+	file := flag.String("file", "", "provider settlement CSV (required)")
+	date := flag.String("date", time.Now().AddDate(0, 0, -1).Format("2006-01-02"), "settlement date, YYYY-MM-DD in China Standard Time")
+	provider := flag.String("provider", cfg.PaymentProvider, "payment provider the file comes from")
+	strict := flag.Bool("strict", false, fmt.Sprintf("exit with status %d when any discrepancy is found", exitDiscrepancies))
+	flag.Parse()
+
+	run, err := reconcile(cfg, *file, *date, *provider)
+	if err != nil {
+		log.Fatalf("payment-reconcile failed: %v", err)
+	}
+	printReport(os.Stdout, run)
+	if *strict && len(run.Items) > 0 {
+		os.Exit(exitDiscrepancies)
+	}
+}
+
+func reconcile(cfg commonconfig.Config, file string, date string, provider string) (*domain.ReconciliationRun, error) {
+	if file == "" {
+		return nil, fmt.Errorf("-file is required")
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	records, err := settlement.ParseCSV(f)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := commonmysql.New(cfg.MySQLDSN)
+	if err != nil {
+		return nil, fmt.Errorf("mysql init failed: %w", err)
+	}
+	defer db.Close()
+
+	logger := logging.New(cfg.ServiceName, cfg.Env, cfg.Version)
+	reconciler := application.NewReconciler(logger, reconciliation.NewRepository(db))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	return reconciler.Reconcile(ctx, application.ReconcileInput{
+		Provider:       provider,
+		SettlementDate: date,
+		SourceFile:     filepath.Base(file),
+		Records:        records,
+	})
+}
+
+func printReport(w io.Writer, run *domain.ReconciliationRun) {
+	fmt.Fprintf(w, "run %s  provider=%s  date=%s  file=%s\n", run.RunID, run.Provider, run.SettlementDate, run.SourceFile)
+	fmt.Fprintf(w, "provider records: %d  local payments: %d  matched: %d  missing: %d  extra: %d  amount mismatched: %d\n",
+		run.ProviderRecords, run.LocalRecords, run.Matched, run.Missing, run.Extra, run.Mismatched)
+	if len(run.Items) == 0 {
+		fmt.Fprintln(w, "no discrepancies")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nKIND\tPROVIDER TXN\tPAYMENT\tORDER\tLOCAL\tPROVIDER\tDETAIL")
+	for _, d := range run.Items {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			d.Kind, d.ProviderTxnID, d.PaymentID, d.OrderID,
+			formatAmount(d.LocalAmountCents, d.LocalCurrency),
+			formatAmount(d.ProviderAmountCents, d.ProviderCurrency),
+			d.Detail,
+		)
+	}
+	_ = tw.Flush()
+}
+
+func formatAmount(cents int64, currency string) string {
+	if currency == "" {
+		return "-"
+	}
+	return fmt.Sprintf("%d.%02d %s", cents/100, cents%100, currency)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
@@ -13,6 +14,7 @@ import (
 	"syscall"
 	"time"
 
+	"ticketing/internal/common/auth"
 	commonconfig "ticketing/internal/common/config"
 	commonkafka "ticketing/internal/common/kafka"
 	"ticketing/internal/common/logging"
@@ -21,9 +23,14 @@ import (
 	commonmysql "ticketing/internal/common/mysql"
 	commonredis "ticketing/internal/common/redis"
 	"ticketing/internal/ticket/application"
+	"ticketing/internal/ticket/domain"
+	"ticketing/internal/ticket/infrastructure/eticket"
 	grpcclient "ticketing/internal/ticket/infrastructure/grpc_client"
 	"ticketing/internal/ticket/infrastructure/outbox"
+	"ticketing/internal/ticket/infrastructure/parking"
 	"ticketing/internal/ticket/infrastructure/repository"
+	"ticketing/internal/ticket/infrastructure/seatalloc"
+	tickethttp "ticketing/internal/ticket/interfaces/http"
 )
 
 func main() {
@@ -35,6 +42,12 @@ func main() {
 func run() error {
 	cfg := commonconfig.Load("ticket-worker")
 	logger := logging.New(cfg.ServiceName, cfg.Env, cfg.Version)
+	if cfg.AuthJWTSecret == "" {
+		return errors.New("AUTH_JWT_SECRET is required")
+	}
+	if cfg.TicketSigningKey == "" {
+		return errors.New("TICKET_SIGNING_KEY is required")
+	}
 
 	mysqlDB, err := commonmysql.New(cfg.MySQLDSN)
 	if err != nil {
@@ -55,33 +68,63 @@ func run() error {
 	}
 	consumer := commonkafka.NewConsumer(cfg.KafkaBrokers, "order.events", "ticket-worker")
 	defer consumer.Close()
+	retryConsumer := commonkafka.NewConsumer(cfg.KafkaBrokers, "order.events.retry", "ticket-worker-retry")
+	defer retryConsumer.Close()
 
 	seatAllocator := grpcclient.SeatAllocatorClient(grpcclient.NewMockSeatAllocator())
 	mode := strings.ToLower(cfg.SeatAllocatorMode)
-	if mode == "grpc" {
-		client, err := grpcclient.NewGRPCSeatAllocator(
-			cfg.SeatAllocatorAddr,
-			cfg.SeatAllocatorTrainID,
-			cfg.SeatAllocatorTravelDate,
-			cfg.SeatAllocatorCoachType,
-			cfg.SeatAllocatorFromIndex,
-			cfg.SeatAllocatorToIndex,
-		)
+	switch mode {
+	case "grpc":
+		client, err := grpcclient.NewGRPCSeatAllocator(cfg.SeatAllocatorAddr)
 		if err != nil {
 			return fmt.Errorf("init grpc seat allocator failed: %w", err)
 		}
 		seatAllocator = client
 		defer client.Close()
+	case "native":
+		allocator, err := seatalloc.NewAllocator(seatalloc.NewRepository(mysqlDB), cfg.SeatAllocatorCoaches)
+		if err != nil {
+			return fmt.Errorf("init native seat allocator failed: %w", err)
+		}
+		seatAllocator = allocator
 	}
 	logger.Info("seat allocator selected", "mode", mode, "addr", cfg.SeatAllocatorAddr)
 
+	seed, err := base64.StdEncoding.DecodeString(cfg.TicketSigningKey)
+	if err != nil {
+		return fmt.Errorf("decode TICKET_SIGNING_KEY failed: %w", err)
+	}
+	signer, err := eticket.NewSigner(seed)
+	if err != nil {
+		return err
+	}
+	ticketRepo := repository.NewRepository(mysqlDB)
+	outboxStore := outbox.NewRepository(mysqlDB)
+
 	worker := application.NewWorker(
 		logger,
 		consumer,
+		retryConsumer,
 		producer,
-		repository.NewRepository(mysqlDB),
-		outbox.NewRepository(mysqlDB),
+		parking.NewRepository(mysqlDB),
+		ticketRepo,
+		outboxStore,
 		seatAllocator,
+		eticket.NewIssuer(signer, cfg.TicketPDFFontPath),
+		application.ItineraryDefaults{
+			TrainID:    cfg.SeatAllocatorTrainID,
+			TravelDate: cfg.SeatAllocatorTravelDate,
+			CoachType:  cfg.SeatAllocatorCoachType,
+			FromIndex:  cfg.SeatAllocatorFromIndex,
+			ToIndex:    cfg.SeatAllocatorToIndex,
+		},
+		application.RetryPolicy{
+			Attempts:      cfg.TicketWorkerMaxAttempts,
+			Backoff:       time.Duration(cfg.TicketWorkerRetryBackoffMS) * time.Millisecond,
+			TopicAttempts: cfg.TicketWorkerRetryTopicAttempts,
+			Delay:         time.Duration(cfg.TicketWorkerRetryDelayMS) * time.Millisecond,
+		},
+		cfg.TicketWorkerConcurrency,
 	)
 	rootCtx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -92,6 +135,7 @@ func run() error {
 	}()
 
 	metrics := commonmetrics.New(cfg.ServiceName)
+	metrics.MustRegister(worker.Collectors()...)
 	router := gin.New()
 	router.Use(gin.Recovery(), middleware.WithRequestContextGin(), metrics.MiddlewareGin(cfg.ServiceName))
 	router.GET("/healthz", func(c *gin.Context) {
@@ -115,6 +159,41 @@ func run() error {
 		c.JSON(http.StatusOK, gin.H{"status": "ready"})
 	})
 	router.GET("/metrics", metrics.HandlerGin())
+	router.GET("/seat-map", func(c *gin.Context) {
+		in := grpcclient.SeatMapInput{
+			TrainID:    c.Query("train_id"),
+			TravelDate: c.Query("travel_date"),
+			CoachType:  c.Query("coach_type"),
+		}
+		if in.TrainID == "" || in.TravelDate == "" || in.CoachType == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "train_id, travel_date and coach_type are required"})
+			return
+		}
+		ctx, stop := context.WithTimeout(c.Request.Context(), grpcclient.DefaultTimeout())
+		defer stop()
+		seats, err := seatAllocator.GetSeatMap(ctx, in)
+		if errors.Is(err, domain.ErrUnknownCoachType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			return
+		}
+		out := make([]gin.H, 0, len(seats))
+		for _, seat := range seats {
+			out = append(out, gin.H{"seat_no": seat.SeatNo, "occupied_segments": seat.OccupiedSegments})
+		}
+		c.JSON(http.StatusOK, gin.H{"seats": out})
+	})
+	tokens := auth.NewTokenManager(cfg.AuthJWTSecret, time.Duration(cfg.AuthTokenTTLSecs)*time.Second)
+	tickethttp.NewHandler(
+		application.NewTicketService(ticketRepo, outboxStore, signer),
+		signer.PublicKey(),
+		middleware.RequireAuthGin(tokens),
+		middleware.RequireRoleGin(auth.RoleGateStaff, auth.RoleSupport),
+		middleware.RequireRoleGin(auth.RoleSupport),
+	).Register(router)
 
 	server := &http.Server{
 		Addr:              fmt.Sprintf(":%d", cfg.HTTPPort),
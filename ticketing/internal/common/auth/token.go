@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	RoleCustomer = "customer"
+	RoleSupport  = "support"
+	// RoleGateStaff may verify e-tickets at the gate, which uses them up.
+	RoleGateStaff = "gate_staff"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+)
+
+type Claims struct {
+	UserID    string
+	Role      string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+type jwtClaims struct {
+	Subject   string `json:"sub"`
+	Role      string `json:"role"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// jwtHeader is fixed: only HS256 tokens are issued and accepted.
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// TokenManager issues and verifies HS256 JWTs shared by every service that
+// knows the secret.
+type TokenManager struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+func NewTokenManager(secret string, ttl time.Duration) *TokenManager {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return &TokenManager{secret: []byte(secret), ttl: ttl, now: time.Now}
+}
+
+func (m *TokenManager) Issue(userID string, role string) (string, time.Time, error) {
+	now := m.now()
+	expiresAt := now.Add(m.ttl)
+	body, err := json.Marshal(jwtClaims{
+		Subject:   userID,
+		Role:      role,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	signingInput := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(body)
+	return signingInput + "." + m.sign(signingInput), expiresAt, nil
+}
+
+func (m *TokenManager) Parse(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return nil, ErrInvalidToken
+	}
+	signingInput := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(m.sign(signingInput))) {
+		return nil, ErrInvalidToken
+	}
+	body, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var c jwtClaims
+	if err := json.Unmarshal(body, &c); err != nil || c.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+	expiresAt := time.Unix(c.ExpiresAt, 0)
+	if !m.now().Before(expiresAt) {
+		return nil, ErrTokenExpired
+	}
+	return &Claims{
+		UserID:    c.Subject,
+		Role:      c.Role,
+		IssuedAt:  time.Unix(c.IssuedAt, 0),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (m *TokenManager) sign(signingInput string) string {
+	mac := hmac.New(sha256.New, m.secret)
+	_, _ = mac.Write([]byte(signingInput))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
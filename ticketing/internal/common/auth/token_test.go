@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenManager_IssueAndParse(t *testing.T) {
+	t.Parallel()
+
+	m := NewTokenManager("unit-test-secret", time.Hour)
+	token, expiresAt, err := m.Issue("user-1", RoleCustomer)
+	if err != nil {
+		t.Fatalf("issue failed: %v", err)
+	}
+	claims, err := m.Parse(token)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if claims.UserID != "user-1" || claims.Role != RoleCustomer || claims.ExpiresAt.Unix() != expiresAt.Unix() {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+
+	other := NewTokenManager("another-secret", time.Hour)
+	if _, err := other.Parse(token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken for foreign secret, got: %v", err)
+	}
+	if _, err := m.Parse(token + "x"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken for tampered token, got: %v", err)
+	}
+}
+
+func TestTokenManager_RejectsExpiredToken(t *testing.T) {
+	t.Parallel()
+
+	m := NewTokenManager("unit-test-secret", time.Minute)
+	issuedAt := time.Date(2026, 2, 11, 8, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return issuedAt }
+	token, _, err := m.Issue("user-1", RoleCustomer)
+	if err != nil {
+		t.Fatalf("issue failed: %v", err)
+	}
+
+	m.now = func() time.Time { return issuedAt.Add(2 * time.Minute) }
+	if _, err := m.Parse(token); !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("expected ErrTokenExpired, got: %v", err)
+	}
+}
@@ -22,16 +22,38 @@ type Config struct {
 	KafkaBrokers []string
 
 	InventoryServiceURL        string
+	InventoryClientMode        string
+	InventoryGRPCAddr          string
+	InventoryClientTimeoutMS   int
+	InventoryClientMaxAttempts int
+	InventoryBreakerFailures   int
+	InventoryBreakerOpenSecs   int
 	OrderInventoryPartitionKey string
 	OrderInventoryDefaultQty   int
 	OrderInventoryCapacity     int
 	PaymentCallbackSignKey     string
+	PaymentCallbackKeys        string
+	PaymentCallbackWindowSecs  int
+	PaymentProvider            string
+	PaymentTTLSecs             int
+	PaymentReconcileAfterSecs  int
+	PaymentSimCallbackURL      string
+	PaymentSimCallbackDelayMS  int
+	PaymentClosedCancelsOrder  bool
+	OrderDailyBookingLimit     int
+	OrderOutboxMaxAttempts     int
+
+	// AuthJWTSecret signs access tokens. It has no default: order-service
+	// and ticket-worker refuse to start without it.
+	AuthJWTSecret    string
+	AuthTokenTTLSecs int
 
 	InventoryShardCount           int
 	InventoryWALBuffer            int
 	InventorySnapshotIntervalSecs int
 	InventorySnapshotOpsThreshold int64
 	InventoryHoldTTLSecs          int
+	InventoryGRPCPort             int
 
 	SeatAllocatorMode       string
 	SeatAllocatorAddr       string
@@ -40,36 +62,74 @@ type Config struct {
 	SeatAllocatorCoachType  string
 	SeatAllocatorFromIndex  int
 	SeatAllocatorToIndex    int
+	SeatAllocatorCoaches    int
+
+	TicketWorkerMaxAttempts        int
+	TicketWorkerRetryBackoffMS     int
+	TicketWorkerRetryTopicAttempts int
+	TicketWorkerRetryDelayMS       int
+	TicketWorkerConcurrency        int
+	// TicketSigningKey is the base64 Ed25519 seed e-tickets are signed with.
+	// It has no default: ticket-worker refuses to start without it.
+	TicketSigningKey  string
+	TicketPDFFontPath string
 }
 
 func Load(serviceName string) Config {
 	return Config{
-		ServiceName:                   serviceName,
-		Env:                           getenv("APP_ENV", "dev"),
-		Version:                       getenv("APP_VERSION", "stage1"),
-		HTTPPort:                      getenvInt("HTTP_PORT", 8080),
-		MySQLDSN:                      getenv("MYSQL_DSN", "root:root@tcp(127.0.0.1:3306)/ticketing?parseTime=true"),
-		RedisAddr:                     getenv("REDIS_ADDR", "127.0.0.1:6379"),
-		RedisPassword:                 getenv("REDIS_PASSWORD", ""),
-		RedisDB:                       getenvInt("REDIS_DB", 0),
-		KafkaBrokers:                  splitCSV(getenv("KAFKA_BROKERS", "127.0.0.1:9092")),
-		InventoryServiceURL:           getenv("INVENTORY_SERVICE_URL", "http://127.0.0.1:8082"),
-		OrderInventoryPartitionKey:    getenv("ORDER_INVENTORY_PARTITION_KEY", "G123|2026-02-11|2nd"),
-		OrderInventoryDefaultQty:      getenvInt("ORDER_INVENTORY_DEFAULT_QTY", 1),
-		OrderInventoryCapacity:        getenvInt("ORDER_INVENTORY_CAPACITY", 500),
-		PaymentCallbackSignKey:        getenv("PAYMENT_CALLBACK_SIGN_KEY", ""),
-		InventoryShardCount:           getenvInt("INVENTORY_SHARD_COUNT", 32),
-		InventoryWALBuffer:            getenvInt("INVENTORY_WAL_BUFFER", 4096),
-		InventorySnapshotIntervalSecs: getenvInt("INVENTORY_SNAPSHOT_INTERVAL_SECS", 10),
-		InventorySnapshotOpsThreshold: int64(getenvInt("INVENTORY_SNAPSHOT_OPS_THRESHOLD", 500)),
-		InventoryHoldTTLSecs:          getenvInt("INVENTORY_HOLD_TTL_SECS", 120),
-		SeatAllocatorMode:             getenv("SEAT_ALLOCATOR_MODE", "mock"),
-		SeatAllocatorAddr:             getenv("SEAT_ALLOCATOR_ADDR", "127.0.0.1:50051"),
-		SeatAllocatorTrainID:          getenv("SEAT_ALLOCATOR_TRAIN_ID", "G123"),
-		SeatAllocatorTravelDate:       getenv("SEAT_ALLOCATOR_TRAVEL_DATE", "2026-02-11"),
-		SeatAllocatorCoachType:        getenv("SEAT_ALLOCATOR_COACH_TYPE", "2nd"),
-		SeatAllocatorFromIndex:        getenvInt("SEAT_ALLOCATOR_FROM_INDEX", 1),
-		SeatAllocatorToIndex:          getenvInt("SEAT_ALLOCATOR_TO_INDEX", 3),
+		ServiceName:                    serviceName,
+		Env:                            getenv("APP_ENV", "dev"),
+		Version:                        getenv("APP_VERSION", "stage1"),
+		HTTPPort:                       getenvInt("HTTP_PORT", 8080),
+		MySQLDSN:                       getenv("MYSQL_DSN", "root:root@tcp(127.0.0.1:3306)/ticketing?parseTime=true"),
+		RedisAddr:                      getenv("REDIS_ADDR", "127.0.0.1:6379"),
+		RedisPassword:                  getenv("REDIS_PASSWORD", ""),
+		RedisDB:                        getenvInt("REDIS_DB", 0),
+		KafkaBrokers:                   splitCSV(getenv("KAFKA_BROKERS", "127.0.0.1:9092")),
+		InventoryServiceURL:            getenv("INVENTORY_SERVICE_URL", "http://127.0.0.1:8082"),
+		InventoryClientMode:            getenv("INVENTORY_CLIENT_MODE", "http"),
+		InventoryGRPCAddr:              getenv("INVENTORY_GRPC_ADDR", "127.0.0.1:9082"),
+		InventoryClientTimeoutMS:       getenvInt("INVENTORY_CLIENT_TIMEOUT_MS", 3000),
+		InventoryClientMaxAttempts:     getenvInt("INVENTORY_CLIENT_MAX_ATTEMPTS", 3),
+		InventoryBreakerFailures:       getenvInt("INVENTORY_BREAKER_FAILURES", 5),
+		InventoryBreakerOpenSecs:       getenvInt("INVENTORY_BREAKER_OPEN_SECS", 10),
+		OrderInventoryPartitionKey:     getenv("ORDER_INVENTORY_PARTITION_KEY", "G123|2026-02-11|2nd"),
+		OrderInventoryDefaultQty:       getenvInt("ORDER_INVENTORY_DEFAULT_QTY", 1),
+		OrderInventoryCapacity:         getenvInt("ORDER_INVENTORY_CAPACITY", 500),
+		PaymentCallbackSignKey:         getenv("PAYMENT_CALLBACK_SIGN_KEY", ""),
+		PaymentCallbackKeys:            getenv("PAYMENT_CALLBACK_KEYS", ""),
+		PaymentCallbackWindowSecs:      getenvInt("PAYMENT_CALLBACK_WINDOW_SECS", 300),
+		PaymentProvider:                getenv("PAYMENT_PROVIDER", "simulated"),
+		PaymentTTLSecs:                 getenvInt("PAYMENT_TTL_SECS", 900),
+		PaymentReconcileAfterSecs:      getenvInt("PAYMENT_RECONCILE_AFTER_SECS", 30),
+		PaymentSimCallbackURL:          getenv("PAYMENT_SIM_CALLBACK_URL", ""),
+		PaymentSimCallbackDelayMS:      getenvInt("PAYMENT_SIM_CALLBACK_DELAY_MS", 1000),
+		PaymentClosedCancelsOrder:      getenvBool("PAYMENT_CLOSED_CANCELS_ORDER", false),
+		OrderDailyBookingLimit:         getenvInt("ORDER_DAILY_BOOKING_LIMIT_PER_ID", 5),
+		OrderOutboxMaxAttempts:         getenvInt("ORDER_OUTBOX_MAX_ATTEMPTS", 10),
+		AuthJWTSecret:                  getenv("AUTH_JWT_SECRET", ""),
+		AuthTokenTTLSecs:               getenvInt("AUTH_TOKEN_TTL_SECS", 86400),
+		InventoryShardCount:            getenvInt("INVENTORY_SHARD_COUNT", 32),
+		InventoryWALBuffer:             getenvInt("INVENTORY_WAL_BUFFER", 4096),
+		InventorySnapshotIntervalSecs:  getenvInt("INVENTORY_SNAPSHOT_INTERVAL_SECS", 10),
+		InventorySnapshotOpsThreshold:  int64(getenvInt("INVENTORY_SNAPSHOT_OPS_THRESHOLD", 500)),
+		InventoryHoldTTLSecs:           getenvInt("INVENTORY_HOLD_TTL_SECS", 120),
+		InventoryGRPCPort:              getenvInt("INVENTORY_GRPC_PORT", 9082),
+		SeatAllocatorMode:              getenv("SEAT_ALLOCATOR_MODE", "mock"),
+		SeatAllocatorAddr:              getenv("SEAT_ALLOCATOR_ADDR", "127.0.0.1:50051"),
+		SeatAllocatorTrainID:           getenv("SEAT_ALLOCATOR_TRAIN_ID", "G123"),
+		SeatAllocatorTravelDate:        getenv("SEAT_ALLOCATOR_TRAVEL_DATE", "2026-02-11"),
+		SeatAllocatorCoachType:         getenv("SEAT_ALLOCATOR_COACH_TYPE", "2nd"),
+		SeatAllocatorFromIndex:         getenvInt("SEAT_ALLOCATOR_FROM_INDEX", 1),
+		SeatAllocatorToIndex:           getenvInt("SEAT_ALLOCATOR_TO_INDEX", 3),
+		SeatAllocatorCoaches:           getenvInt("SEAT_ALLOCATOR_COACHES", 8),
+		TicketWorkerMaxAttempts:        getenvInt("TICKET_WORKER_MAX_ATTEMPTS", 3),
+		TicketWorkerRetryBackoffMS:     getenvInt("TICKET_WORKER_RETRY_BACKOFF_MS", 200),
+		TicketWorkerRetryTopicAttempts: getenvInt("TICKET_WORKER_RETRY_TOPIC_ATTEMPTS", 5),
+		TicketWorkerRetryDelayMS:       getenvInt("TICKET_WORKER_RETRY_DELAY_MS", 5000),
+		TicketWorkerConcurrency:        getenvInt("TICKET_WORKER_CONCURRENCY", 8),
+		TicketSigningKey:               getenv("TICKET_SIGNING_KEY", ""),
+		TicketPDFFontPath:              getenv("TICKET_PDF_FONT_PATH", ""),
 	}
 }
 
@@ -97,6 +157,18 @@ func getenvInt(key string, defaultValue int) int {
 	return v
 }
 
+func getenvBool(key string, defaultValue bool) bool {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return defaultValue
+	}
+	return v
+}
+
 func splitCSV(raw string) []string {
 	parts := strings.Split(raw, ",")
 	out := make([]string, 0, len(parts))
@@ -0,0 +1,73 @@
+// Package idcard validates the identity documents passengers travel on:
+// mainland resident identity cards (GB 11643-1999) and passports.
+package idcard
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Document types a passenger can be identified by.
+const (
+	TypeIDCard   = "ID_CARD"
+	TypePassport = "PASSPORT"
+)
+
+var (
+	ErrInvalidNumber   = errors.New("invalid resident id number")
+	ErrInvalidDocument = errors.New("invalid id document")
+)
+
+var (
+	weights    = [17]int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	checkCodes = "10X98765432"
+)
+
+// Validate checks the length, the embedded birth date and the ISO 7064
+// MOD 11-2 check character of an 18-digit number. A trailing lowercase x is
+// not accepted; callers normalize to upper case first.
+func Validate(number string) error {
+	if len(number) != 18 {
+		return ErrInvalidNumber
+	}
+	sum := 0
+	for i := 0; i < 17; i++ {
+		c := number[i]
+		if c < '0' || c > '9' {
+			return ErrInvalidNumber
+		}
+		sum += int(c-'0') * weights[i]
+	}
+	if number[17] != checkCodes[sum%11] {
+		return ErrInvalidNumber
+	}
+	birth, err := time.Parse("20060102", number[6:14])
+	if err != nil || birth.After(time.Now()) {
+		return ErrInvalidNumber
+	}
+	return nil
+}
+
+// NormalizeDocument trims and upper-cases a document, defaulting the type to
+// a resident identity card, and checks the number against its type.
+func NormalizeDocument(idType string, number string) (string, string, error) {
+	idType = strings.ToUpper(strings.TrimSpace(idType))
+	number = strings.ToUpper(strings.TrimSpace(number))
+	if idType == "" {
+		idType = TypeIDCard
+	}
+	if number == "" || len(number) > 64 {
+		return "", "", ErrInvalidDocument
+	}
+	switch idType {
+	case TypeIDCard:
+		if err := Validate(number); err != nil {
+			return "", "", ErrInvalidDocument
+		}
+	case TypePassport:
+	default:
+		return "", "", ErrInvalidDocument
+	}
+	return idType, number, nil
+}
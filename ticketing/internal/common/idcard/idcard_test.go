@@ -0,0 +1,49 @@
+package idcard
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	for _, number := range []string{"11010519491231002X", "440524188001010014"} {
+		if err := Validate(number); err != nil {
+			t.Fatalf("expected %s to be valid, got: %v", number, err)
+		}
+	}
+	for _, number := range []string{
+		"",
+		"110105194912310021", // wrong check character
+		"11010519491331002X", // month 13
+		"11010519491231002x", // not normalized
+		"1101051949123100",
+	} {
+		if err := Validate(number); !errors.Is(err, ErrInvalidNumber) {
+			t.Fatalf("expected ErrInvalidNumber for %q, got: %v", number, err)
+		}
+	}
+}
+
+func TestNormalizeDocument(t *testing.T) {
+	t.Parallel()
+
+	idType, number, err := NormalizeDocument("", " 11010519491231002x ")
+	if err != nil || idType != TypeIDCard || number != "11010519491231002X" {
+		t.Fatalf("expected a normalized ID card, got %s %s (%v)", idType, number, err)
+	}
+	idType, number, err = NormalizeDocument(" passport", "e12345678")
+	if err != nil || idType != TypePassport || number != "E12345678" {
+		t.Fatalf("expected a normalized passport, got %s %s (%v)", idType, number, err)
+	}
+	for _, doc := range [][2]string{
+		{TypeIDCard, "110105194912310021"},
+		{TypePassport, ""},
+		{"DRIVER_LICENSE", "X1"},
+	} {
+		if _, _, err := NormalizeDocument(doc[0], doc[1]); !errors.Is(err, ErrInvalidDocument) {
+			t.Fatalf("expected ErrInvalidDocument for %v, got: %v", doc, err)
+		}
+	}
+}
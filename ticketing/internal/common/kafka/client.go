@@ -32,6 +32,18 @@ func (p *Producer) Publish(ctx context.Context, topic string, key []byte, value
 	})
 }
 
+// PublishWithHeaders is Publish with message headers, e.g. the retry
+// count and failure reason of an event moved to a retry topic.
+func (p *Producer) PublishWithHeaders(ctx context.Context, topic string, key []byte, value []byte, headers []segmentkafka.Header) error {
+	return p.writer.WriteMessages(ctx, segmentkafka.Message{
+		Topic:   topic,
+		Key:     key,
+		Value:   value,
+		Headers: headers,
+		Time:    time.Now(),
+	})
+}
+
 func (p *Producer) Close() error {
 	return p.writer.Close()
 }
@@ -27,5 +27,9 @@ func WithContext(ctx context.Context, logger *slog.Logger) *slog.Logger {
 	if traceID == "" && reqID == "" {
 		return logger
 	}
-	return logger.With("trace_id", traceID, "req_id", reqID)
+	logger = logger.With("trace_id", traceID, "req_id", reqID)
+	if userID := tracing.UserID(ctx); userID != "" {
+		logger = logger.With("user_id", userID)
+	}
+	return logger
 }
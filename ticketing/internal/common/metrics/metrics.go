@@ -76,6 +76,12 @@ func (m *Metrics) MiddlewareGin(service string) gin.HandlerFunc {
 	}
 }
 
+// MustRegister adds collectors owned by other packages, such as clients of
+// downstream services, to the service registry.
+func (m *Metrics) MustRegister(cs ...prometheus.Collector) {
+	m.registry.MustRegister(cs...)
+}
+
 func (m *Metrics) HandlerGin() gin.HandlerFunc {
 	return gin.WrapH(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
 }
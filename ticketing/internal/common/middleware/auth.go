@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"ticketing/internal/common/auth"
+	"ticketing/internal/common/tracing"
+)
+
+// RequireAuthGin rejects requests without a valid bearer token and puts the
+// caller's user ID and role on the request context.
+func RequireAuthGin(tokens *auth.TokenManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || strings.TrimSpace(token) == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, map[string]any{"error": "missing bearer token"})
+			return
+		}
+		claims, err := tokens.Parse(strings.TrimSpace(token))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, map[string]any{"error": err.Error()})
+			return
+		}
+
+		ctx := tracing.WithUser(c.Request.Context(), claims.UserID, claims.Role)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// RequireRoleGin rejects authenticated callers without one of roles. It runs
+// after RequireAuthGin.
+func RequireRoleGin(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := tracing.UserRole(c.Request.Context())
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, map[string]any{"error": "insufficient role"})
+	}
+}
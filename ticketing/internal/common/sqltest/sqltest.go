@@ -0,0 +1,41 @@
+// Package sqltest provides a database handle for tests of services whose
+// repositories are faked: it opens transactions that commit and roll back
+// without doing anything and rejects every statement.
+package sqltest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+)
+
+// ErrNoStatements is returned for any statement run on a NewDB handle.
+var ErrNoStatements = errors.New("sqltest: statements are not supported")
+
+// NewDB returns a handle whose transactions are no-ops.
+func NewDB() *sql.DB {
+	return sql.OpenDB(connector{})
+}
+
+type connector struct{}
+
+func (connector) Connect(context.Context) (driver.Conn, error) { return conn{}, nil }
+func (connector) Driver() driver.Driver                        { return noopDriver{} }
+
+type noopDriver struct{}
+
+func (noopDriver) Open(string) (driver.Conn, error) { return conn{}, nil }
+
+type conn struct{}
+
+func (conn) Prepare(string) (driver.Stmt, error) { return nil, ErrNoStatements }
+func (conn) Close() error                        { return nil }
+func (conn) Begin() (driver.Tx, error)           { return tx{}, nil }
+
+func (conn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) { return tx{}, nil }
+
+type tx struct{}
+
+func (tx) Commit() error   { return nil }
+func (tx) Rollback() error { return nil }
@@ -9,8 +9,10 @@ import (
 type contextKey string
 
 const (
-	traceIDKey contextKey = "trace_id"
-	reqIDKey   contextKey = "req_id"
+	traceIDKey  contextKey = "trace_id"
+	reqIDKey    contextKey = "req_id"
+	userIDKey   contextKey = "user_id"
+	userRoleKey contextKey = "user_role"
 )
 
 func WithTraceAndRequestID(ctx context.Context, traceID string, reqID string) context.Context {
@@ -29,6 +31,23 @@ func RequestID(ctx context.Context) string {
 	return v
 }
 
+// WithUser records the authenticated caller next to the trace ID.
+func WithUser(ctx context.Context, userID string, role string) context.Context {
+	ctx = context.WithValue(ctx, userIDKey, userID)
+	ctx = context.WithValue(ctx, userRoleKey, role)
+	return ctx
+}
+
+func UserID(ctx context.Context) string {
+	v, _ := ctx.Value(userIDKey).(string)
+	return v
+}
+
+func UserRole(ctx context.Context) string {
+	v, _ := ctx.Value(userRoleKey).(string)
+	return v
+}
+
 func EnsureTraceID(v string) string {
 	if v != "" {
 		return v
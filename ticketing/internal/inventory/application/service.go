@@ -54,6 +54,11 @@ type ConfirmInput struct {
 	HoldID       string
 }
 
+type ReturnInput struct {
+	PartitionKey string
+	HoldID       string
+}
+
 func NewService(
 	logger *slog.Logger,
 	walRepo *wal.Repository,
@@ -176,6 +181,18 @@ func (s *Service) ConfirmHold(ctx context.Context, in ConfirmInput) (*domain.Par
 	return state, nil
 }
 
+func (s *Service) ReturnConfirmed(ctx context.Context, in ReturnInput) (*domain.PartitionState, error) {
+	state, err := s.partitionMgr.ReturnConfirmed(ctx, partition.ReturnInput{
+		PartitionKey: in.PartitionKey,
+		HoldID:       in.HoldID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	atomic.AddInt64(&s.opCounter, 1)
+	return state, nil
+}
+
 func (s *Service) GetAvailability(ctx context.Context, partitionKey string) (int, bool, error) {
 	return s.partitionMgr.GetAvailability(ctx, partitionKey)
 }
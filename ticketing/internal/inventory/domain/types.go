@@ -8,6 +8,7 @@ const (
 	EventTypeHoldCreated   EventType = "hold_created"
 	EventTypeHoldReleased  EventType = "hold_released"
 	EventTypeHoldConfirmed EventType = "hold_confirmed"
+	EventTypeHoldReturned  EventType = "hold_returned"
 )
 
 var (
@@ -23,21 +24,23 @@ type Hold struct {
 }
 
 type PartitionState struct {
-	PartitionKey string          `json:"partition_key"`
-	Capacity     int             `json:"capacity"`
-	Available    int             `json:"available"`
-	Confirmed    int             `json:"confirmed"`
-	LastSeq      int64           `json:"last_seq"`
-	Holds        map[string]Hold `json:"holds"`
+	PartitionKey   string          `json:"partition_key"`
+	Capacity       int             `json:"capacity"`
+	Available      int             `json:"available"`
+	Confirmed      int             `json:"confirmed"`
+	LastSeq        int64           `json:"last_seq"`
+	Holds          map[string]Hold `json:"holds"`
+	ConfirmedHolds map[string]Hold `json:"confirmed_holds"`
 }
 
 func NewPartitionState(partitionKey string, capacity int) *PartitionState {
 	return &PartitionState{
-		PartitionKey: partitionKey,
-		Capacity:     capacity,
-		Available:    capacity,
-		Confirmed:    0,
-		LastSeq:      0,
-		Holds:        map[string]Hold{},
+		PartitionKey:   partitionKey,
+		Capacity:       capacity,
+		Available:      capacity,
+		Confirmed:      0,
+		LastSeq:        0,
+		Holds:          map[string]Hold{},
+		ConfirmedHolds: map[string]Hold{},
 	}
 }
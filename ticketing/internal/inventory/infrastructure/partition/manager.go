@@ -34,6 +34,11 @@ type ConfirmInput struct {
 	HoldID       string
 }
 
+type ReturnInput struct {
+	PartitionKey string
+	HoldID       string
+}
+
 type tryHoldCmd struct {
 	in   TryHoldInput
 	resp chan commandResult
@@ -49,6 +54,11 @@ type confirmCmd struct {
 	resp chan commandResult
 }
 
+type returnCmd struct {
+	in   ReturnInput
+	resp chan commandResult
+}
+
 type availabilityCmd struct {
 	partitionKey string
 	resp         chan availabilityResult
@@ -143,6 +153,17 @@ func (m *Manager) ConfirmHold(ctx context.Context, in ConfirmInput) (*domain.Par
 	return res.state, res.err
 }
 
+// ReturnConfirmed puts a confirmed hold back into available stock, e.g. when
+// the ticket it backs is changed to another train.
+func (m *Manager) ReturnConfirmed(ctx context.Context, in ReturnInput) (*domain.PartitionState, error) {
+	resp := make(chan commandResult, 1)
+	if err := m.send(ctx, in.PartitionKey, returnCmd{in: in, resp: resp}); err != nil {
+		return nil, err
+	}
+	res := <-resp
+	return res.state, res.err
+}
+
 func (m *Manager) GetAvailability(ctx context.Context, partitionKey string) (int, bool, error) {
 	resp := make(chan availabilityResult, 1)
 	if err := m.send(ctx, partitionKey, availabilityCmd{partitionKey: partitionKey, resp: resp}); err != nil {
@@ -208,6 +229,8 @@ func (s *shard) loop(walQueue chan MutationRecord) {
 			cmd.resp <- s.handleRelease(cmd.in, walQueue)
 		case confirmCmd:
 			cmd.resp <- s.handleConfirm(cmd.in, walQueue)
+		case returnCmd:
+			cmd.resp <- s.handleReturn(cmd.in, walQueue)
 		case availabilityCmd:
 			st, ok := s.states[cmd.partitionKey]
 			if !ok {
@@ -312,10 +335,14 @@ func (s *shard) handleConfirm(in ConfirmInput, walQueue chan MutationRecord) com
 	}
 	hold, ok := st.Holds[in.HoldID]
 	if !ok {
+		if _, confirmed := st.ConfirmedHolds[in.HoldID]; confirmed {
+			return commandResult{state: cloneState(st)}
+		}
 		return commandResult{err: domain.ErrHoldNotFound}
 	}
 
 	delete(st.Holds, in.HoldID)
+	st.ConfirmedHolds[in.HoldID] = hold
 	st.Confirmed += hold.Qty
 	st.LastSeq++
 
@@ -336,11 +363,50 @@ func (s *shard) handleConfirm(in ConfirmInput, walQueue chan MutationRecord) com
 		// Roll back to preserve replayability when WAL cannot be accepted.
 		st.LastSeq--
 		st.Confirmed -= hold.Qty
+		delete(st.ConfirmedHolds, in.HoldID)
 		st.Holds[in.HoldID] = hold
 		return commandResult{err: domain.ErrBackpressure}
 	}
 }
 
+func (s *shard) handleReturn(in ReturnInput, walQueue chan MutationRecord) commandResult {
+	st, ok := s.states[in.PartitionKey]
+	if !ok {
+		return commandResult{err: domain.ErrHoldNotFound}
+	}
+	hold, ok := st.ConfirmedHolds[in.HoldID]
+	if !ok {
+		return commandResult{err: domain.ErrHoldNotFound}
+	}
+
+	delete(st.ConfirmedHolds, in.HoldID)
+	st.Confirmed -= hold.Qty
+	st.Available += hold.Qty
+	st.LastSeq++
+
+	rec := MutationRecord{
+		PartitionKey: in.PartitionKey,
+		Seq:          st.LastSeq,
+		EventType:    domain.EventTypeHoldReturned,
+		Payload: map[string]any{
+			"hold_id": in.HoldID,
+			"qty":     hold.Qty,
+		},
+		OccurredAt: time.Now().UTC(),
+	}
+	select {
+	case walQueue <- rec:
+		return commandResult{state: cloneState(st), record: &rec}
+	default:
+		// Roll back to preserve replayability when WAL cannot be accepted.
+		st.LastSeq--
+		st.Available -= hold.Qty
+		st.Confirmed += hold.Qty
+		st.ConfirmedHolds[in.HoldID] = hold
+		return commandResult{err: domain.ErrBackpressure}
+	}
+}
+
 func (s *shard) applyRecovered(record MutationRecord) error {
 	st := s.getOrInit(record.PartitionKey, intFromPayload(record.Payload, "capacity"))
 	if record.Seq <= st.LastSeq {
@@ -366,8 +432,17 @@ func (s *shard) applyRecovered(record MutationRecord) error {
 		hold, ok := st.Holds[holdID]
 		if ok {
 			delete(st.Holds, holdID)
+			st.ConfirmedHolds[holdID] = hold
 			st.Confirmed += hold.Qty
 		}
+	case domain.EventTypeHoldReturned:
+		holdID := stringFromPayload(record.Payload, "hold_id")
+		hold, ok := st.ConfirmedHolds[holdID]
+		if ok {
+			delete(st.ConfirmedHolds, holdID)
+			st.Confirmed -= hold.Qty
+			st.Available += hold.Qty
+		}
 	}
 	st.LastSeq = record.Seq
 	return nil
@@ -391,13 +466,18 @@ func cloneState(in *domain.PartitionState) *domain.PartitionState {
 	for k, v := range in.Holds {
 		holds[k] = v
 	}
+	confirmedHolds := make(map[string]domain.Hold, len(in.ConfirmedHolds))
+	for k, v := range in.ConfirmedHolds {
+		confirmedHolds[k] = v
+	}
 	return &domain.PartitionState{
-		PartitionKey: in.PartitionKey,
-		Capacity:     in.Capacity,
-		Available:    in.Available,
-		Confirmed:    in.Confirmed,
-		LastSeq:      in.LastSeq,
-		Holds:        holds,
+		PartitionKey:   in.PartitionKey,
+		Capacity:       in.Capacity,
+		Available:      in.Available,
+		Confirmed:      in.Confirmed,
+		LastSeq:        in.LastSeq,
+		Holds:          holds,
+		ConfirmedHolds: confirmedHolds,
 	}
 }
 
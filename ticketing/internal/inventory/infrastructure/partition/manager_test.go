@@ -126,3 +126,37 @@ func TestConfirmHold_BackpressureRollsBackAndReturns(t *testing.T) {
 	}
 }
 
+func TestReturnConfirmed_RestoresAvailability(t *testing.T) {
+	t.Parallel()
+
+	walQueue := make(chan MutationRecord, 8)
+	mgr := NewManager(1, walQueue)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if _, err := mgr.TryHold(ctx, TryHoldInput{PartitionKey: "p1", HoldID: "h1", Qty: 2, Capacity: 10}); err != nil {
+		t.Fatalf("setup hold failed: %v", err)
+	}
+	if _, err := mgr.ConfirmHold(ctx, ConfirmInput{PartitionKey: "p1", HoldID: "h1"}); err != nil {
+		t.Fatalf("confirm failed: %v", err)
+	}
+	if _, err := mgr.ConfirmHold(ctx, ConfirmInput{PartitionKey: "p1", HoldID: "h1"}); err != nil {
+		t.Fatalf("repeated confirm should be idempotent, got: %v", err)
+	}
+
+	st, err := mgr.ReturnConfirmed(ctx, ReturnInput{PartitionKey: "p1", HoldID: "h1"})
+	if err != nil {
+		t.Fatalf("return confirmed failed: %v", err)
+	}
+	if st.Available != 10 || st.Confirmed != 0 {
+		t.Fatalf("expected Available=10 Confirmed=0, got Available=%d Confirmed=%d", st.Available, st.Confirmed)
+	}
+	if len(st.ConfirmedHolds) != 0 {
+		t.Fatalf("expected confirmed hold to be removed, got %d", len(st.ConfirmedHolds))
+	}
+
+	_, err = mgr.ReturnConfirmed(ctx, ReturnInput{PartitionKey: "p1", HoldID: "h1"})
+	if !errors.Is(err, domain.ErrHoldNotFound) {
+		t.Fatalf("expected ErrHoldNotFound on second return, got: %v", err)
+	}
+}
@@ -16,3 +16,25 @@ type ConfirmHoldRequest struct {
 	PartitionKey string `json:"partition_key"`
 	HoldID       string `json:"hold_id"`
 }
+
+type ReturnConfirmedRequest struct {
+	PartitionKey string `json:"partition_key"`
+	HoldID       string `json:"hold_id"`
+}
+
+// Error codes returned in ErrorResponse.Code. Clients branch on the code
+// rather than on the HTTP status or the message.
+const (
+	CodeInvalidRequest    = "INVALID_REQUEST"
+	CodeInvalidQuantity   = "INVALID_QUANTITY"
+	CodeInsufficientStock = "INSUFFICIENT_STOCK"
+	CodeBackpressure      = "BACKPRESSURE"
+	CodeHoldNotFound      = "HOLD_NOT_FOUND"
+	CodePartitionNotFound = "PARTITION_NOT_FOUND"
+	CodeInternal          = "INTERNAL"
+)
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
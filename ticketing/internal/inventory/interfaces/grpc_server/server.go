@@ -0,0 +1,233 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"ticketing/internal/inventory/application"
+	"ticketing/internal/inventory/domain"
+	inventoryv1 "ticketing/proto/inventory/v1"
+)
+
+// ErrorDomain is the ErrorInfo domain of inventory errors.
+const ErrorDomain = "inventory.v1"
+
+// maxBatchItems bounds a batch so one call cannot monopolise the shards.
+const maxBatchItems = 500
+
+// inventoryService is the part of application.Service the server calls.
+type inventoryService interface {
+	TryHold(ctx context.Context, in application.TryHoldInput) (*domain.PartitionState, error)
+	ReleaseHold(ctx context.Context, in application.ReleaseInput) (*domain.PartitionState, error)
+	ConfirmHold(ctx context.Context, in application.ConfirmInput) (*domain.PartitionState, error)
+	ReturnConfirmed(ctx context.Context, in application.ReturnInput) (*domain.PartitionState, error)
+	GetAvailability(ctx context.Context, partitionKey string) (int, bool, error)
+}
+
+// Server serves inventoryv1.InventoryService with the same service as the
+// HTTP handler.
+type Server struct {
+	inventoryv1.UnimplementedInventoryServiceServer
+	service inventoryService
+}
+
+func NewServer(service *application.Service) *Server {
+	return &Server{service: service}
+}
+
+func (s *Server) TryHold(ctx context.Context, req *inventoryv1.TryHoldRequest) (*inventoryv1.HoldResponse, error) {
+	state, err := s.tryHold(ctx, req)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &inventoryv1.HoldResponse{State: toState(state)}, nil
+}
+
+func (s *Server) ReleaseHold(ctx context.Context, req *inventoryv1.HoldRequest) (*inventoryv1.HoldResponse, error) {
+	state, err := s.releaseHold(ctx, req)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &inventoryv1.HoldResponse{State: toState(state)}, nil
+}
+
+func (s *Server) ConfirmHold(ctx context.Context, req *inventoryv1.HoldRequest) (*inventoryv1.HoldResponse, error) {
+	state, err := s.confirmHold(ctx, req)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &inventoryv1.HoldResponse{State: toState(state)}, nil
+}
+
+func (s *Server) ReturnConfirmed(ctx context.Context, req *inventoryv1.HoldRequest) (*inventoryv1.HoldResponse, error) {
+	state, err := s.service.ReturnConfirmed(ctx, application.ReturnInput{
+		PartitionKey: req.GetPartitionKey(),
+		HoldID:       req.GetHoldId(),
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &inventoryv1.HoldResponse{State: toState(state)}, nil
+}
+
+func (s *Server) GetAvailability(ctx context.Context, req *inventoryv1.AvailabilityRequest) (*inventoryv1.AvailabilityResponse, error) {
+	if req.GetPartitionKey() == "" {
+		return nil, codeError(codes.InvalidArgument, inventoryv1.ErrorCode_INVALID_REQUEST, "partition_key is required")
+	}
+	resp, err := s.availability(ctx, req.GetPartitionKey())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return resp, nil
+}
+
+func (s *Server) BatchTryHold(ctx context.Context, req *inventoryv1.BatchTryHoldRequest) (*inventoryv1.BatchHoldResponse, error) {
+	if err := checkBatch(len(req.GetItems())); err != nil {
+		return nil, err
+	}
+	out := &inventoryv1.BatchHoldResponse{Results: make([]*inventoryv1.HoldResult, 0, len(req.GetItems()))}
+	for _, item := range req.GetItems() {
+		state, err := s.tryHold(ctx, item)
+		out.Results = append(out.Results, holdResult(item.GetPartitionKey(), item.GetHoldId(), state, err))
+	}
+	return out, nil
+}
+
+func (s *Server) BatchReleaseHold(ctx context.Context, req *inventoryv1.BatchHoldRequest) (*inventoryv1.BatchHoldResponse, error) {
+	return s.batchHold(ctx, req, s.releaseHold)
+}
+
+func (s *Server) BatchConfirmHold(ctx context.Context, req *inventoryv1.BatchHoldRequest) (*inventoryv1.BatchHoldResponse, error) {
+	return s.batchHold(ctx, req, s.confirmHold)
+}
+
+func (s *Server) BatchGetAvailability(ctx context.Context, req *inventoryv1.BatchAvailabilityRequest) (*inventoryv1.BatchAvailabilityResponse, error) {
+	if err := checkBatch(len(req.GetPartitionKeys())); err != nil {
+		return nil, err
+	}
+	out := &inventoryv1.BatchAvailabilityResponse{Results: make([]*inventoryv1.AvailabilityResponse, 0, len(req.GetPartitionKeys()))}
+	for _, key := range req.GetPartitionKeys() {
+		resp, err := s.availability(ctx, key)
+		if err != nil {
+			return nil, statusError(err)
+		}
+		out.Results = append(out.Results, resp)
+	}
+	return out, nil
+}
+
+func (s *Server) tryHold(ctx context.Context, req *inventoryv1.TryHoldRequest) (*domain.PartitionState, error) {
+	return s.service.TryHold(ctx, application.TryHoldInput{
+		PartitionKey: req.GetPartitionKey(),
+		HoldID:       req.GetHoldId(),
+		Qty:          int(req.GetQty()),
+		Capacity:     int(req.GetCapacity()),
+	})
+}
+
+func (s *Server) releaseHold(ctx context.Context, req *inventoryv1.HoldRequest) (*domain.PartitionState, error) {
+	return s.service.ReleaseHold(ctx, application.ReleaseInput{
+		PartitionKey: req.GetPartitionKey(),
+		HoldID:       req.GetHoldId(),
+	})
+}
+
+func (s *Server) confirmHold(ctx context.Context, req *inventoryv1.HoldRequest) (*domain.PartitionState, error) {
+	return s.service.ConfirmHold(ctx, application.ConfirmInput{
+		PartitionKey: req.GetPartitionKey(),
+		HoldID:       req.GetHoldId(),
+	})
+}
+
+func (s *Server) availability(ctx context.Context, partitionKey string) (*inventoryv1.AvailabilityResponse, error) {
+	available, ok, err := s.service.GetAvailability(ctx, partitionKey)
+	if err != nil {
+		return nil, err
+	}
+	return &inventoryv1.AvailabilityResponse{
+		PartitionKey: partitionKey,
+		Available:    int32(available),
+		Found:        ok,
+	}, nil
+}
+
+func (s *Server) batchHold(
+	ctx context.Context,
+	req *inventoryv1.BatchHoldRequest,
+	apply func(context.Context, *inventoryv1.HoldRequest) (*domain.PartitionState, error),
+) (*inventoryv1.BatchHoldResponse, error) {
+	if err := checkBatch(len(req.GetItems())); err != nil {
+		return nil, err
+	}
+	out := &inventoryv1.BatchHoldResponse{Results: make([]*inventoryv1.HoldResult, 0, len(req.GetItems()))}
+	for _, item := range req.GetItems() {
+		state, err := apply(ctx, item)
+		out.Results = append(out.Results, holdResult(item.GetPartitionKey(), item.GetHoldId(), state, err))
+	}
+	return out, nil
+}
+
+func checkBatch(n int) error {
+	if n == 0 || n > maxBatchItems {
+		return codeError(codes.InvalidArgument, inventoryv1.ErrorCode_INVALID_REQUEST, "batch must hold 1 to 500 items")
+	}
+	return nil
+}
+
+func holdResult(partitionKey string, holdID string, state *domain.PartitionState, err error) *inventoryv1.HoldResult {
+	out := &inventoryv1.HoldResult{PartitionKey: partitionKey, HoldId: holdID}
+	if err != nil {
+		_, out.ErrorCode = classify(err)
+		out.Error = err.Error()
+		return out
+	}
+	out.State = toState(state)
+	return out
+}
+
+func toState(st *domain.PartitionState) *inventoryv1.PartitionState {
+	return &inventoryv1.PartitionState{
+		PartitionKey: st.PartitionKey,
+		Capacity:     int32(st.Capacity),
+		Available:    int32(st.Available),
+		Confirmed:    int32(st.Confirmed),
+		LastSeq:      st.LastSeq,
+	}
+}
+
+// classify maps service errors as the HTTP handler does: backpressure is
+// Unavailable so callers retry it later.
+func classify(err error) (codes.Code, inventoryv1.ErrorCode) {
+	switch {
+	case errors.Is(err, domain.ErrHoldNotFound):
+		return codes.NotFound, inventoryv1.ErrorCode_HOLD_NOT_FOUND
+	case errors.Is(err, domain.ErrInsufficientStock):
+		return codes.FailedPrecondition, inventoryv1.ErrorCode_INSUFFICIENT_STOCK
+	case errors.Is(err, domain.ErrInvalidQuantity):
+		return codes.InvalidArgument, inventoryv1.ErrorCode_INVALID_QUANTITY
+	case errors.Is(err, domain.ErrBackpressure):
+		return codes.Unavailable, inventoryv1.ErrorCode_BACKPRESSURE
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled, inventoryv1.ErrorCode_INTERNAL
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded, inventoryv1.ErrorCode_INTERNAL
+	}
+	return codes.Internal, inventoryv1.ErrorCode_INTERNAL
+}
+
+func statusError(err error) error {
+	c, code := classify(err)
+	return codeError(c, code, err.Error())
+}
+
+func codeError(c codes.Code, code inventoryv1.ErrorCode, message string) error {
+	st := status.New(c, message)
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: code.String(), Domain: ErrorDomain}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
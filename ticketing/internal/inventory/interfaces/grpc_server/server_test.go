@@ -0,0 +1,157 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"ticketing/internal/inventory/application"
+	"ticketing/internal/inventory/domain"
+	inventoryv1 "ticketing/proto/inventory/v1"
+)
+
+// fakeInventory fails the holds listed in failures and grants the rest.
+type fakeInventory struct {
+	inventoryService
+	failures map[string]error
+	calls    int
+}
+
+func (f *fakeInventory) TryHold(_ context.Context, in application.TryHoldInput) (*domain.PartitionState, error) {
+	f.calls++
+	if err := f.failures[in.HoldID]; err != nil {
+		return nil, err
+	}
+	return &domain.PartitionState{PartitionKey: in.PartitionKey, Capacity: in.Capacity, Available: in.Capacity - in.Qty}, nil
+}
+
+func (f *fakeInventory) ReleaseHold(_ context.Context, in application.ReleaseInput) (*domain.PartitionState, error) {
+	f.calls++
+	if err := f.failures[in.HoldID]; err != nil {
+		return nil, err
+	}
+	return &domain.PartitionState{PartitionKey: in.PartitionKey}, nil
+}
+
+// errorInfo returns the gRPC code and the inventory ErrorInfo reason of err.
+func errorInfo(t *testing.T, err error) (codes.Code, string) {
+	t.Helper()
+	st, ok := status.FromError(err)
+	if !ok {
+		t.Fatalf("expected a gRPC status, got %v", err)
+	}
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok && info.GetDomain() == ErrorDomain {
+			return st.Code(), info.GetReason()
+		}
+	}
+	t.Fatalf("expected an %s ErrorInfo on %v", ErrorDomain, err)
+	return 0, ""
+}
+
+func TestServer_MapsErrorsToCodes(t *testing.T) {
+	t.Parallel()
+
+	// The order-side client matches on these reasons, so they are spelled
+	// out rather than taken from the enum.
+	cases := []struct {
+		err    error
+		code   codes.Code
+		reason string
+	}{
+		{domain.ErrHoldNotFound, codes.NotFound, "HOLD_NOT_FOUND"},
+		{fmt.Errorf("partition p: %w", domain.ErrInsufficientStock), codes.FailedPrecondition, "INSUFFICIENT_STOCK"},
+		{domain.ErrInvalidQuantity, codes.InvalidArgument, "INVALID_QUANTITY"},
+		{domain.ErrBackpressure, codes.Unavailable, "BACKPRESSURE"},
+		{context.DeadlineExceeded, codes.DeadlineExceeded, "INTERNAL"},
+		{errors.New("redis hold save failed"), codes.Internal, "INTERNAL"},
+	}
+	for _, tc := range cases {
+		srv := &Server{service: &fakeInventory{failures: map[string]error{"h": tc.err}}}
+		_, err := srv.TryHold(context.Background(), &inventoryv1.TryHoldRequest{PartitionKey: "p", HoldId: "h", Qty: 1})
+		code, reason := errorInfo(t, err)
+		if code != tc.code || reason != tc.reason {
+			t.Fatalf("%v: expected %s/%s, got %s/%s", tc.err, tc.code, tc.reason, code, reason)
+		}
+	}
+}
+
+func TestServer_RejectsEmptyAndOversizedBatches(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeInventory{}
+	srv := &Server{service: fake}
+	items := make([]*inventoryv1.TryHoldRequest, maxBatchItems+1)
+	for i := range items {
+		items[i] = &inventoryv1.TryHoldRequest{PartitionKey: "p", HoldId: fmt.Sprintf("h-%d", i), Qty: 1}
+	}
+
+	for _, n := range []int{0, maxBatchItems + 1} {
+		_, err := srv.BatchTryHold(context.Background(), &inventoryv1.BatchTryHoldRequest{Items: items[:n]})
+		if code, reason := errorInfo(t, err); code != codes.InvalidArgument || reason != "INVALID_REQUEST" {
+			t.Fatalf("expected INVALID_REQUEST for %d items, got %s/%s", n, code, reason)
+		}
+	}
+	if fake.calls != 0 {
+		t.Fatalf("expected a rejected batch not to reach the service, got %d calls", fake.calls)
+	}
+
+	resp, err := srv.BatchTryHold(context.Background(), &inventoryv1.BatchTryHoldRequest{Items: items[:maxBatchItems]})
+	if err != nil || len(resp.GetResults()) != maxBatchItems {
+		t.Fatalf("expected a full batch to be served, got %d results (%v)", len(resp.GetResults()), err)
+	}
+}
+
+func TestServer_BatchReportsFailuresPerItem(t *testing.T) {
+	t.Parallel()
+
+	srv := &Server{service: &fakeInventory{failures: map[string]error{
+		"h-2": domain.ErrInsufficientStock,
+		"h-3": domain.ErrBackpressure,
+	}}}
+	resp, err := srv.BatchTryHold(context.Background(), &inventoryv1.BatchTryHoldRequest{Items: []*inventoryv1.TryHoldRequest{
+		{PartitionKey: "p", HoldId: "h-1", Qty: 1, Capacity: 10},
+		{PartitionKey: "p", HoldId: "h-2", Qty: 1, Capacity: 10},
+		{PartitionKey: "q", HoldId: "h-3", Qty: 1, Capacity: 10},
+	}})
+	if err != nil {
+		t.Fatalf("expected item failures not to fail the batch, got: %v", err)
+	}
+	results := resp.GetResults()
+	if len(results) != 3 {
+		t.Fatalf("expected one result per item, got %d", len(results))
+	}
+	if r := results[0]; r.GetHoldId() != "h-1" || r.GetErrorCode() != inventoryv1.ErrorCode_ERROR_CODE_UNSPECIFIED || r.GetState().GetAvailable() != 9 {
+		t.Fatalf("expected h-1 to be held, got %+v", r)
+	}
+	if r := results[1]; r.GetHoldId() != "h-2" || r.GetErrorCode() != inventoryv1.ErrorCode_INSUFFICIENT_STOCK || r.GetState() != nil {
+		t.Fatalf("expected h-2 to fail with INSUFFICIENT_STOCK, got %+v", r)
+	}
+	if r := results[2]; r.GetPartitionKey() != "q" || r.GetErrorCode() != inventoryv1.ErrorCode_BACKPRESSURE || r.GetError() == "" {
+		t.Fatalf("expected h-3 to fail with BACKPRESSURE, got %+v", r)
+	}
+}
+
+func TestServer_BatchReleaseReportsMissingHolds(t *testing.T) {
+	t.Parallel()
+
+	srv := &Server{service: &fakeInventory{failures: map[string]error{"gone": domain.ErrHoldNotFound}}}
+	resp, err := srv.BatchReleaseHold(context.Background(), &inventoryv1.BatchHoldRequest{Items: []*inventoryv1.HoldRequest{
+		{PartitionKey: "p", HoldId: "gone"},
+		{PartitionKey: "p", HoldId: "kept"},
+	}})
+	if err != nil {
+		t.Fatalf("expected the batch to be served, got: %v", err)
+	}
+	if got := resp.GetResults()[0].GetErrorCode(); got != inventoryv1.ErrorCode_HOLD_NOT_FOUND {
+		t.Fatalf("expected HOLD_NOT_FOUND for the missing hold, got %s", got)
+	}
+	if got := resp.GetResults()[1]; got.GetErrorCode() != inventoryv1.ErrorCode_ERROR_CODE_UNSPECIFIED || got.GetState() == nil {
+		t.Fatalf("expected the other hold to be released, got %+v", got)
+	}
+}
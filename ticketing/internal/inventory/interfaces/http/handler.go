@@ -23,13 +23,14 @@ func (h *Handler) Register(r *gin.Engine) {
 	r.POST("/inventory/try-hold", h.tryHold)
 	r.POST("/inventory/release-hold", h.releaseHold)
 	r.POST("/inventory/confirm-hold", h.confirmHold)
+	r.POST("/inventory/return-confirmed", h.returnConfirmed)
 	r.GET("/inventory/availability", h.availability)
 }
 
 func (h *Handler) tryHold(c *gin.Context) {
 	var req dto.TryHoldRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		writeError(c, http.StatusBadRequest, "invalid json")
+		writeError(c, http.StatusBadRequest, dto.CodeInvalidRequest, "invalid json")
 		return
 	}
 	state, err := h.service.TryHold(c.Request.Context(), application.TryHoldInput{
@@ -39,11 +40,7 @@ func (h *Handler) tryHold(c *gin.Context) {
 		Capacity:     req.Capacity,
 	})
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, domain.ErrInsufficientStock) || errors.Is(err, domain.ErrInvalidQuantity) || errors.Is(err, domain.ErrBackpressure) {
-			status = http.StatusBadRequest
-		}
-		writeError(c, status, err.Error())
+		writeServiceError(c, err)
 		return
 	}
 	writeJSON(c, http.StatusOK, state)
@@ -52,7 +49,7 @@ func (h *Handler) tryHold(c *gin.Context) {
 func (h *Handler) releaseHold(c *gin.Context) {
 	var req dto.ReleaseHoldRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		writeError(c, http.StatusBadRequest, "invalid json")
+		writeError(c, http.StatusBadRequest, dto.CodeInvalidRequest, "invalid json")
 		return
 	}
 	state, err := h.service.ReleaseHold(c.Request.Context(), application.ReleaseInput{
@@ -60,11 +57,7 @@ func (h *Handler) releaseHold(c *gin.Context) {
 		HoldID:       req.HoldID,
 	})
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, domain.ErrHoldNotFound) {
-			status = http.StatusNotFound
-		}
-		writeError(c, status, err.Error())
+		writeServiceError(c, err)
 		return
 	}
 	writeJSON(c, http.StatusOK, state)
@@ -73,7 +66,7 @@ func (h *Handler) releaseHold(c *gin.Context) {
 func (h *Handler) confirmHold(c *gin.Context) {
 	var req dto.ConfirmHoldRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		writeError(c, http.StatusBadRequest, "invalid json")
+		writeError(c, http.StatusBadRequest, dto.CodeInvalidRequest, "invalid json")
 		return
 	}
 	state, err := h.service.ConfirmHold(c.Request.Context(), application.ConfirmInput{
@@ -81,11 +74,24 @@ func (h *Handler) confirmHold(c *gin.Context) {
 		HoldID:       req.HoldID,
 	})
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, domain.ErrHoldNotFound) {
-			status = http.StatusNotFound
-		}
-		writeError(c, status, err.Error())
+		writeServiceError(c, err)
+		return
+	}
+	writeJSON(c, http.StatusOK, state)
+}
+
+func (h *Handler) returnConfirmed(c *gin.Context) {
+	var req dto.ReturnConfirmedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, dto.CodeInvalidRequest, "invalid json")
+		return
+	}
+	state, err := h.service.ReturnConfirmed(c.Request.Context(), application.ReturnInput{
+		PartitionKey: req.PartitionKey,
+		HoldID:       req.HoldID,
+	})
+	if err != nil {
+		writeServiceError(c, err)
 		return
 	}
 	writeJSON(c, http.StatusOK, state)
@@ -94,16 +100,16 @@ func (h *Handler) confirmHold(c *gin.Context) {
 func (h *Handler) availability(c *gin.Context) {
 	key := c.Query("partition_key")
 	if key == "" {
-		writeError(c, http.StatusBadRequest, "partition_key is required")
+		writeError(c, http.StatusBadRequest, dto.CodeInvalidRequest, "partition_key is required")
 		return
 	}
 	available, ok, err := h.service.GetAvailability(c.Request.Context(), key)
 	if err != nil {
-		writeError(c, http.StatusInternalServerError, err.Error())
+		writeServiceError(c, err)
 		return
 	}
 	if !ok {
-		writeError(c, http.StatusNotFound, "partition not found")
+		writeError(c, http.StatusNotFound, dto.CodePartitionNotFound, "partition not found")
 		return
 	}
 	writeJSON(c, http.StatusOK, map[string]any{
@@ -116,6 +122,23 @@ func writeJSON(c *gin.Context, status int, body any) {
 	c.JSON(status, body)
 }
 
-func writeError(c *gin.Context, status int, message string) {
-	writeJSON(c, status, map[string]string{"error": message})
+// writeServiceError maps service errors to a status and an error code.
+// Backpressure is a 503: the request is valid and may succeed later.
+func writeServiceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrHoldNotFound):
+		writeError(c, http.StatusNotFound, dto.CodeHoldNotFound, err.Error())
+	case errors.Is(err, domain.ErrInsufficientStock):
+		writeError(c, http.StatusBadRequest, dto.CodeInsufficientStock, err.Error())
+	case errors.Is(err, domain.ErrInvalidQuantity):
+		writeError(c, http.StatusBadRequest, dto.CodeInvalidQuantity, err.Error())
+	case errors.Is(err, domain.ErrBackpressure):
+		writeError(c, http.StatusServiceUnavailable, dto.CodeBackpressure, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, dto.CodeInternal, err.Error())
+	}
+}
+
+func writeError(c *gin.Context, status int, code string, message string) {
+	writeJSON(c, status, dto.ErrorResponse{Error: message, Code: code})
 }
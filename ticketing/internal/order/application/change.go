@@ -0,0 +1,433 @@
+package application
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ticketing/internal/order/domain"
+	"ticketing/internal/order/infrastructure/inventory"
+)
+
+// errFareNotCollected means the payment of a fare increase was closed,
+// failed or did not pay the difference, so the change is undone.
+var errFareNotCollected = errors.New("fare difference not collected")
+
+// changeStore persists ticket changes.
+type changeStore interface {
+	FindByID(ctx context.Context, changeID string) (*domain.Change, error)
+	FindByIdempotencyKey(ctx context.Context, key string) (*domain.Change, error)
+	InsertTx(ctx context.Context, tx *sql.Tx, c *domain.Change) error
+	UpdateStatusTx(ctx context.Context, tx *sql.Tx, changeID string, status domain.ChangeStatus) error
+}
+
+type ChangeTicketInput struct {
+	OrderID         string
+	IdempotencyKey  string
+	NewPartitionKey string
+	NewAmountCents  int64
+	Qty             int
+	Capacity        int
+}
+
+// ChangeTicket moves a ticketed order onto another partition (改签). The
+// change row and its CHANGE saga are persisted before any cross-service call.
+// A dearer ticket also gets a payment intent for the difference, which the
+// customer pays through PayOrder; the old seats are only returned once it is
+// paid.
+func (s *Service) ChangeTicket(ctx context.Context, in ChangeTicketInput) (*domain.Change, error) {
+	if strings.TrimSpace(in.IdempotencyKey) == "" {
+		return nil, domain.ErrInvalidChange
+	}
+	existing, err := s.changes.FindByIdempotencyKey(ctx, in.IdempotencyKey)
+	if err == nil {
+		return s.authorizeChange(ctx, existing)
+	}
+	if !errors.Is(err, domain.ErrChangeNotFound) {
+		return nil, err
+	}
+
+	var (
+		created     *domain.Change
+		farePayment *domain.Payment
+	)
+	runErr := s.startSaga(ctx, in.OrderID, domain.SagaTypeChange, callerActor(ctx, domain.ActorCustomer), func(tx *sql.Tx, order *domain.Order) (map[string]any, error) {
+		// The new hold replaces the whole old one and ticket-worker reissues
+		// hold_qty seats, so the change moves exactly the seats the order
+		// holds.
+		var qty int
+		order.PartitionKey, order.HoldID, qty, _ = s.resolveHoldConfig(order.OrderID, order.PartitionKey, order.HoldID, order.HoldQty, 0)
+		if in.Qty > 0 && in.Qty != qty {
+			return nil, domain.ErrInvalidChange
+		}
+		_, _, _, capacity := s.resolveHoldConfig(order.OrderID, in.NewPartitionKey, "", qty, in.Capacity)
+		c, err := domain.NewChange(
+			uuid.NewString(),
+			in.IdempotencyKey,
+			order,
+			strings.TrimSpace(in.NewPartitionKey),
+			qty,
+			capacity,
+			in.NewAmountCents,
+		)
+		if err != nil {
+			return nil, err
+		}
+		passengers, err := s.repo.ListPassengersTx(ctx, tx, order.OrderID)
+		if err != nil {
+			return nil, err
+		}
+		if len(passengers) > 0 {
+			// A change is not a new booking, but the new trip must not
+			// overlap another trip of the same passengers.
+			window, err := travelWindow(c.NewPartitionKey, "", "")
+			if err != nil {
+				return nil, domain.ErrInvalidChange
+			}
+			if err := s.checkRealNameTx(ctx, tx, order.OrderID, passengers, window, false); err != nil {
+				return nil, err
+			}
+		}
+		if diff := c.FareDiffCents(); diff > 0 {
+			p := &domain.Payment{
+				PaymentID:   uuid.NewString(),
+				OrderID:     order.OrderID,
+				Provider:    s.payments.Name(),
+				Status:      domain.PaymentStatusPending,
+				AmountCents: diff,
+				Currency:    domain.DefaultCurrency,
+				ExpiresAt:   time.Now().Add(s.cfg.PaymentTTL),
+			}
+			if err := s.repo.InsertPaymentIntentTx(ctx, tx, p); err != nil {
+				return nil, err
+			}
+			c.FarePaymentID = p.PaymentID
+			farePayment = p
+		}
+		if err := s.changes.InsertTx(ctx, tx, c); err != nil {
+			return nil, err
+		}
+		created = c
+		return map[string]any{"change_id": c.ChangeID, "fare_payment_id": c.FarePaymentID}, nil
+	})
+	if created == nil {
+		if stringsHasDuplicate(runErr) {
+			existing, err := s.changes.FindByIdempotencyKey(ctx, in.IdempotencyKey)
+			if err != nil {
+				return nil, err
+			}
+			return s.authorizeChange(ctx, existing)
+		}
+		if errors.Is(runErr, ErrSagaPending) {
+			// Another change of this order is still running.
+			return nil, domain.ErrSagaInProgress
+		}
+		return nil, runErr
+	}
+	if runErr != nil && !errors.Is(runErr, ErrSagaPending) {
+		s.logger.Warn("ticket change failed", "change_id", created.ChangeID, "error", runErr)
+	}
+	if farePayment != nil && errors.Is(runErr, ErrSagaPending) {
+		// The saga waits for the difference; an intent the provider did not
+		// take is closed, which undoes the change.
+		if _, err := s.registerPayment(ctx, farePayment, "fare difference of change "+created.ChangeID); err != nil {
+			return nil, err
+		}
+	}
+	return s.changes.FindByID(ctx, created.ChangeID)
+}
+
+func (s *Service) GetChange(ctx context.Context, changeID string) (*domain.Change, error) {
+	c, err := s.changes.FindByID(ctx, changeID)
+	if err != nil {
+		return nil, err
+	}
+	return s.authorizeChange(ctx, c)
+}
+
+// authorizeChange applies the order's visibility to its change.
+func (s *Service) authorizeChange(ctx context.Context, c *domain.Change) (*domain.Change, error) {
+	order, err := s.repo.FindByID(ctx, c.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeOrder(ctx, order); err != nil {
+		return nil, domain.ErrChangeNotFound
+	}
+	return c, nil
+}
+
+// changeSaga holds and confirms the new seats and collects a fare increase
+// first; returning the old seats is the pivot, after which the change is
+// only ever driven forward. A fare decrease is refunded last.
+func (s *Service) changeSaga() *sagaDefinition {
+	return &sagaDefinition{
+		steps: []sagaStep{
+			{
+				name: "hold_new",
+				action: func(ctx context.Context, sg *domain.Saga) error {
+					c, err := s.changes.FindByID(ctx, sg.String("change_id"))
+					if err != nil {
+						return err
+					}
+					return s.inventoryClient.TryHold(ctx, inventory.TryHoldInput{
+						PartitionKey: c.NewPartitionKey,
+						HoldID:       c.NewHoldID,
+						Qty:          c.Qty,
+						Capacity:     c.Capacity,
+					})
+				},
+				compensate: func(ctx context.Context, sg *domain.Saga) error {
+					c, err := s.changes.FindByID(ctx, sg.String("change_id"))
+					if err != nil {
+						return err
+					}
+					return ignoreHoldNotFound(s.inventoryClient.ReleaseHold(ctx, inventory.ReleaseInput{
+						PartitionKey: c.NewPartitionKey,
+						HoldID:       c.NewHoldID,
+					}))
+				},
+			},
+			{
+				name: "confirm_new",
+				action: func(ctx context.Context, sg *domain.Saga) error {
+					c, err := s.changes.FindByID(ctx, sg.String("change_id"))
+					if err != nil {
+						return err
+					}
+					return s.inventoryClient.ConfirmHold(ctx, inventory.ConfirmInput{
+						PartitionKey: c.NewPartitionKey,
+						HoldID:       c.NewHoldID,
+					})
+				},
+				compensate: func(ctx context.Context, sg *domain.Saga) error {
+					c, err := s.changes.FindByID(ctx, sg.String("change_id"))
+					if err != nil {
+						return err
+					}
+					return ignoreHoldNotFound(s.inventoryClient.ReturnConfirmed(ctx, inventory.ReturnInput{
+						PartitionKey: c.NewPartitionKey,
+						HoldID:       c.NewHoldID,
+					}))
+				},
+			},
+			{
+				name: "collect_fare",
+				action: func(ctx context.Context, sg *domain.Saga) error {
+					c, err := s.changes.FindByID(ctx, sg.String("change_id"))
+					if err != nil {
+						return err
+					}
+					if c.FarePaymentID == "" {
+						return nil
+					}
+					p, err := s.repo.FindPayment(ctx, c.FarePaymentID)
+					if err != nil {
+						return err
+					}
+					switch {
+					case p.Status == domain.PaymentStatusSuccess && p.PaidAmountCents == p.AmountCents &&
+						strings.EqualFold(p.PaidCurrency, p.Currency):
+						return nil
+					case p.IsOpen(time.Now()):
+						return fmt.Errorf("%w: fare payment %s", errSagaWaiting, p.PaymentID)
+					default:
+						return fmt.Errorf("%w: payment %s is %s", errFareNotCollected, p.PaymentID, p.Status)
+					}
+				},
+			},
+			{
+				name:  "return_old",
+				pivot: true,
+				action: func(ctx context.Context, sg *domain.Saga) error {
+					c, err := s.changes.FindByID(ctx, sg.String("change_id"))
+					if err != nil {
+						return err
+					}
+					return ignoreHoldNotFound(s.inventoryClient.ReturnConfirmed(ctx, inventory.ReturnInput{
+						PartitionKey: c.OldPartitionKey,
+						HoldID:       c.OldHoldID,
+					}))
+				},
+			},
+			{
+				name: "reissue",
+				local: func(ctx context.Context, tx *sql.Tx, sg *domain.Saga) error {
+					c, err := s.changes.FindByID(ctx, sg.String("change_id"))
+					if err != nil {
+						return err
+					}
+					if err := s.transitionTx(ctx, tx, sg, domain.StatusTicketed, domain.EventChange, "changed to "+c.NewPartitionKey); err != nil {
+						return err
+					}
+					if err := s.repo.UpdateHoldTx(ctx, tx, c.OrderID, c.NewPartitionKey, c.NewHoldID); err != nil {
+						return err
+					}
+					if window, err := travelWindow(c.NewPartitionKey, "", ""); err == nil {
+						if err := s.repo.UpdatePassengerWindowTx(ctx, tx, c.OrderID, window); err != nil {
+							return err
+						}
+					}
+					return s.outbox.InsertTx(ctx, tx, uuid.NewString(), c.OrderID, "OrderChanged", map[string]any{
+						"order_id":          c.OrderID,
+						"change_id":         c.ChangeID,
+						"old_partition_key": c.OldPartitionKey,
+						"old_hold_id":       c.OldHoldID,
+						"new_partition_key": c.NewPartitionKey,
+						"new_hold_id":       c.NewHoldID,
+						"qty":               c.Qty,
+						"status":            domain.StatusTicketed,
+					})
+				},
+			},
+			{
+				name: "settle",
+				local: func(ctx context.Context, tx *sql.Tx, sg *domain.Saga) error {
+					c, err := s.changes.FindByID(ctx, sg.String("change_id"))
+					if err != nil {
+						return err
+					}
+					if err := s.repo.UpdateAmountTx(ctx, tx, c.OrderID, c.NewAmountCents); err != nil {
+						return err
+					}
+					refundID := ""
+					if diff := c.FareDiffCents(); diff < 0 {
+						rf, err := s.queueFareRefundTx(ctx, tx, c, -diff)
+						if err != nil {
+							return err
+						}
+						if rf != nil {
+							refundID = rf.RefundID
+						}
+					}
+					return s.outbox.InsertTx(ctx, tx, uuid.NewString(), c.OrderID, "OrderFareSettled", map[string]any{
+						"order_id":         c.OrderID,
+						"change_id":        c.ChangeID,
+						"old_amount_cents": c.OldAmountCents,
+						"new_amount_cents": c.NewAmountCents,
+						"fare_diff_cents":  c.FareDiffCents(),
+						"settlement":       fareSettlement(c.FareDiffCents()),
+						"payment_id":       c.FarePaymentID,
+						"refund_id":        refundID,
+						"status":           domain.StatusTicketed,
+					})
+				},
+			},
+			{
+				name: "refund_fare",
+				action: func(ctx context.Context, sg *domain.Saga) error {
+					// The refund of a fare decrease is keyed by the change.
+					rf, err := s.repo.FindRefund(ctx, sg.String("change_id"))
+					if errors.Is(err, domain.ErrRefundNotFound) {
+						return nil
+					}
+					if err != nil {
+						return err
+					}
+					if rf.Status == domain.RefundStatusPending {
+						// The reconciler retries the refund if this attempt
+						// does not go through.
+						s.sendRefund(ctx, rf)
+					}
+					return nil
+				},
+			},
+		},
+		onComplete: func(ctx context.Context, tx *sql.Tx, sg *domain.Saga) error {
+			return s.changes.UpdateStatusTx(ctx, tx, sg.String("change_id"), domain.ChangeStatusCompleted)
+		},
+		onFail: func(ctx context.Context, tx *sql.Tx, sg *domain.Saga) error {
+			changeID := sg.String("change_id")
+			if err := s.changes.UpdateStatusTx(ctx, tx, changeID, domain.ChangeStatusFailed); err != nil {
+				return err
+			}
+			if err := s.dropFarePaymentTx(ctx, tx, sg); err != nil {
+				return err
+			}
+			return s.outbox.InsertTx(ctx, tx, uuid.NewString(), sg.OrderID, "OrderChangeFailed", map[string]any{
+				"order_id":  sg.OrderID,
+				"change_id": changeID,
+				"saga_id":   sg.SagaID,
+				"reason":    sg.LastError,
+			})
+		},
+	}
+}
+
+// queueFareRefundTx records the refund of a fare decrease against the
+// order's payment; refund_fare sends it. An order without a successful
+// payment has nothing to refund from, which needs an operator.
+func (s *Service) queueFareRefundTx(ctx context.Context, tx *sql.Tx, c *domain.Change, amountCents int64) (*domain.Refund, error) {
+	p, err := s.repo.FindPaidPaymentTx(ctx, tx, c.OrderID, amountCents)
+	if errors.Is(err, domain.ErrPaymentNotFound) {
+		s.logger.Error("no payment to refund the fare difference from", "order_id", c.OrderID, "change_id", c.ChangeID, "amount_cents", amountCents)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rf := &domain.Refund{
+		RefundID:      c.ChangeID,
+		PaymentID:     p.PaymentID,
+		OrderID:       c.OrderID,
+		ProviderTxnID: p.ProviderTxnID,
+		AmountCents:   amountCents,
+		Currency:      domain.DefaultCurrency,
+		Reason:        "fare difference of change " + c.ChangeID,
+		Status:        domain.RefundStatusPending,
+	}
+	if err := s.repo.InsertRefundTx(ctx, tx, rf); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+// dropFarePaymentTx closes the fare payment of a failed change, or queues
+// its refund for the reconciler when the customer already paid it.
+func (s *Service) dropFarePaymentTx(ctx context.Context, tx *sql.Tx, sg *domain.Saga) error {
+	paymentID := sg.String("fare_payment_id")
+	if paymentID == "" {
+		return nil
+	}
+	closed, err := s.repo.UpdatePaymentStatusTx(ctx, tx, paymentID, domain.PaymentStatusPending, domain.PaymentStatusClosed)
+	if err != nil || closed {
+		return err
+	}
+	p, err := s.repo.FindPayment(ctx, paymentID)
+	if err != nil {
+		return err
+	}
+	if p.Status != domain.PaymentStatusSuccess {
+		return nil
+	}
+	refunding, err := s.repo.UpdatePaymentStatusTx(ctx, tx, paymentID, domain.PaymentStatusSuccess, domain.PaymentStatusRefunding)
+	if err != nil || !refunding {
+		return err
+	}
+	return s.repo.InsertRefundTx(ctx, tx, &domain.Refund{
+		RefundID:      uuid.NewString(),
+		PaymentID:     paymentID,
+		OrderID:       sg.OrderID,
+		ProviderTxnID: p.ProviderTxnID,
+		AmountCents:   p.PaidAmountCents,
+		Currency:      p.PaidCurrency,
+		Reason:        "change " + sg.String("change_id") + " failed",
+		Status:        domain.RefundStatusPending,
+	})
+}
+
+func fareSettlement(diffCents int64) string {
+	switch {
+	case diffCents > 0:
+		return "COLLECT"
+	case diffCents < 0:
+		return "REFUND"
+	default:
+		return "NONE"
+	}
+}
@@ -0,0 +1,285 @@
+package application
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"ticketing/internal/order/domain"
+)
+
+type fakeChangeStore struct {
+	changeStore
+	changes map[string]*domain.Change
+}
+
+func (f *fakeChangeStore) FindByID(_ context.Context, changeID string) (*domain.Change, error) {
+	c, ok := f.changes[changeID]
+	if !ok {
+		return nil, domain.ErrChangeNotFound
+	}
+	out := *c
+	return &out, nil
+}
+
+func (f *fakeChangeStore) FindByIdempotencyKey(_ context.Context, key string) (*domain.Change, error) {
+	for _, c := range f.changes {
+		if c.IdempotencyKey == key {
+			out := *c
+			return &out, nil
+		}
+	}
+	return nil, domain.ErrChangeNotFound
+}
+
+func (f *fakeChangeStore) InsertTx(_ context.Context, _ *sql.Tx, c *domain.Change) error {
+	out := *c
+	f.changes[c.ChangeID] = &out
+	return nil
+}
+
+func (f *fakeChangeStore) UpdateStatusTx(_ context.Context, _ *sql.Tx, changeID string, status domain.ChangeStatus) error {
+	f.changes[changeID].Status = status
+	return nil
+}
+
+func newChangeTestService(order *domain.Order) (*Service, *fakePaymentRepo, *fakeInventory) {
+	repo := newFakePaymentRepo(order)
+	repo.payments["pay-1"] = &domain.Payment{
+		PaymentID:       "pay-1",
+		OrderID:         order.OrderID,
+		ProviderTxnID:   "txn-1",
+		Status:          domain.PaymentStatusSuccess,
+		PaidAmountCents: order.AmountCents,
+		PaidCurrency:    domain.DefaultCurrency,
+	}
+	svc, _ := newPaymentTestService(repo, &fakeProvider{})
+	inv := &fakeInventory{}
+	svc.changes = &fakeChangeStore{changes: map[string]*domain.Change{}}
+	svc.inventoryClient = inv
+	svc.cfg.DefaultHoldQty = 1
+	return svc, repo, inv
+}
+
+func ticketedOrder(holdQty int) *domain.Order {
+	return &domain.Order{
+		OrderID:      "order-1",
+		Status:       domain.StatusTicketed,
+		AmountCents:  12000,
+		PartitionKey: "G1|2026-03-01|2nd",
+		HoldID:       "order-1",
+		HoldQty:      holdQty,
+	}
+}
+
+func TestChangeTicket_MovesTheSeatsTheOrderHolds(t *testing.T) {
+	t.Parallel()
+
+	svc, repo, inv := newChangeTestService(ticketedOrder(3))
+	c, err := svc.ChangeTicket(context.Background(), ChangeTicketInput{
+		OrderID:         "order-1",
+		IdempotencyKey:  "change-1",
+		NewPartitionKey: "G2|2026-03-02|2nd",
+		NewAmountCents:  12000,
+	})
+	if err != nil {
+		t.Fatalf("change failed: %v", err)
+	}
+	if c.Status != domain.ChangeStatusCompleted || c.Qty != 3 {
+		t.Fatalf("expected a COMPLETED change of 3 seats, got %s of %d", c.Status, c.Qty)
+	}
+	if len(inv.held) != 1 || inv.held[0].Qty != 3 {
+		t.Fatalf("expected the new hold to take 3 seats, got %+v", inv.held)
+	}
+	if len(inv.returned) != 1 || inv.returned[0].HoldID != "order-1" {
+		t.Fatalf("expected the old hold to be returned, got %+v", inv.returned)
+	}
+	if o := repo.orders["order-1"]; o.PartitionKey != "G2|2026-03-02|2nd" || o.HoldID != c.NewHoldID {
+		t.Fatalf("expected the order to point at the new hold, got %s/%s", o.PartitionKey, o.HoldID)
+	}
+}
+
+func TestChangeTicket_RejectsQtyOtherThanTheHeldSeats(t *testing.T) {
+	t.Parallel()
+
+	svc, _, inv := newChangeTestService(ticketedOrder(3))
+	_, err := svc.ChangeTicket(context.Background(), ChangeTicketInput{
+		OrderID:         "order-1",
+		IdempotencyKey:  "change-1",
+		NewPartitionKey: "G2|2026-03-02|2nd",
+		NewAmountCents:  12000,
+		Qty:             1,
+	})
+	if !errors.Is(err, domain.ErrInvalidChange) {
+		t.Fatalf("expected ErrInvalidChange, got: %v", err)
+	}
+	if len(inv.held) != 0 {
+		t.Fatalf("expected nothing to be held, got %+v", inv.held)
+	}
+}
+
+// lastSaga returns the saga as it was last saved, which is where recovery
+// would pick it up.
+func lastSaga(t *testing.T, svc *Service) *domain.Saga {
+	t.Helper()
+	saved := svc.sagas.(*fakeSagaStore).saved
+	if len(saved) == 0 {
+		t.Fatal("expected the saga to be saved")
+	}
+	sg := saved[len(saved)-1]
+	return &sg
+}
+
+func dearerChange() ChangeTicketInput {
+	return ChangeTicketInput{
+		OrderID:         "order-1",
+		IdempotencyKey:  "change-1",
+		NewPartitionKey: "G2|2026-03-02|1st",
+		NewAmountCents:  15000,
+	}
+}
+
+func TestChangeTicket_CollectsAFareIncreaseBeforeReturningTheOldSeats(t *testing.T) {
+	t.Parallel()
+
+	svc, repo, inv := newChangeTestService(ticketedOrder(1))
+	provider := svc.payments.(*fakeProvider)
+	c, err := svc.ChangeTicket(context.Background(), dearerChange())
+	if err != nil {
+		t.Fatalf("change failed: %v", err)
+	}
+	if c.Status != domain.ChangeStatusPending || c.FarePaymentID == "" {
+		t.Fatalf("expected a PENDING change with a fare payment, got %s (%q)", c.Status, c.FarePaymentID)
+	}
+	if len(provider.created) != 1 || provider.created[0].AmountCents != 3000 || provider.created[0].PaymentID != c.FarePaymentID {
+		t.Fatalf("expected the difference of 3000 to be asked for, got %+v", provider.created)
+	}
+	if len(inv.confirmed) != 1 || len(inv.returned) != 0 {
+		t.Fatalf("expected the new seats confirmed and the old ones kept, got %d confirmed, %d returned", len(inv.confirmed), len(inv.returned))
+	}
+	sg := lastSaga(t, svc)
+	if sg.Status != domain.SagaStatusRunning || sg.Attempts != 0 {
+		t.Fatalf("expected the saga to wait without using attempts, got %s after %d", sg.Status, sg.Attempts)
+	}
+
+	// The customer pays the difference through the order's open intent.
+	p, err := svc.PayOrder(context.Background(), "order-1")
+	if err != nil || p.PaymentID != c.FarePaymentID || p.PrepayID == "" {
+		t.Fatalf("expected PayOrder to return the fare payment, got %+v (%v)", p, err)
+	}
+	svc.sagas.(*fakeSagaStore).active = sg
+	if _, err := svc.applyPaymentSuccess(context.Background(), domain.ActorPaymentProvider, PaymentCallbackInput{
+		OrderID:       "order-1",
+		PaymentID:     c.FarePaymentID,
+		ProviderTxnID: "txn-2",
+		Status:        string(domain.PaymentStatusSuccess),
+		AmountCents:   3000,
+		Currency:      "cny",
+	}); err != nil {
+		t.Fatalf("expected the fare payment to be recorded, got: %v", err)
+	}
+	if len(repo.refunds) != 0 {
+		t.Fatalf("expected the fare payment not to be refunded, got %d refunds", len(repo.refunds))
+	}
+
+	if err := svc.runSaga(context.Background(), sg); err != nil {
+		t.Fatalf("expected the change to complete once paid, got: %v", err)
+	}
+	if len(inv.returned) != 1 || inv.returned[0].HoldID != "order-1" {
+		t.Fatalf("expected the old seats to be returned, got %+v", inv.returned)
+	}
+	if o := repo.orders["order-1"]; o.AmountCents != 15000 {
+		t.Fatalf("expected the order to cost 15000, got %d", o.AmountCents)
+	}
+	if c, _ := svc.changes.FindByID(context.Background(), c.ChangeID); c.Status != domain.ChangeStatusCompleted {
+		t.Fatalf("expected the change COMPLETED, got %s", c.Status)
+	}
+}
+
+func TestChangeTicket_UndoesTheChangeWhenTheFareIsUnderpaid(t *testing.T) {
+	t.Parallel()
+
+	svc, repo, inv := newChangeTestService(ticketedOrder(1))
+	c, err := svc.ChangeTicket(context.Background(), dearerChange())
+	if err != nil {
+		t.Fatalf("change failed: %v", err)
+	}
+	p := repo.payments[c.FarePaymentID]
+	p.Status, p.ProviderTxnID, p.PaidAmountCents, p.PaidCurrency = domain.PaymentStatusSuccess, "txn-2", 1000, domain.DefaultCurrency
+
+	err = svc.runSaga(context.Background(), lastSaga(t, svc))
+	if !errors.Is(err, errFareNotCollected) {
+		t.Fatalf("expected errFareNotCollected, got: %v", err)
+	}
+	if len(inv.returned) != 1 || inv.returned[0].HoldID != c.NewHoldID || len(inv.released) != 1 {
+		t.Fatalf("expected only the new seats to be given back, got %+v and %+v", inv.returned, inv.released)
+	}
+	if o := repo.orders["order-1"]; o.AmountCents != 12000 || o.HoldID != "order-1" {
+		t.Fatalf("expected the order unchanged, got %d on %s", o.AmountCents, o.HoldID)
+	}
+	if p.Status != domain.PaymentStatusRefunding {
+		t.Fatalf("expected the fare payment REFUNDING, got %s", p.Status)
+	}
+	rf := repo.onlyRefund(t)
+	if rf.PaymentID != c.FarePaymentID || rf.AmountCents != 1000 || rf.Status != domain.RefundStatusPending {
+		t.Fatalf("expected the 1000 paid to be queued for refund, got %+v", rf)
+	}
+}
+
+func TestChangeTicket_RefundsAFareDecreaseThroughTheProvider(t *testing.T) {
+	t.Parallel()
+
+	svc, repo, _ := newChangeTestService(ticketedOrder(1))
+	provider := svc.payments.(*fakeProvider)
+	store := svc.outbox.(*fakeOutbox)
+	in := dearerChange()
+	in.NewAmountCents = 9000
+	c, err := svc.ChangeTicket(context.Background(), in)
+	if err != nil {
+		t.Fatalf("change failed: %v", err)
+	}
+	if c.Status != domain.ChangeStatusCompleted || c.FarePaymentID != "" {
+		t.Fatalf("expected a COMPLETED change without a fare payment, got %s (%q)", c.Status, c.FarePaymentID)
+	}
+	if len(provider.created) != 0 {
+		t.Fatalf("expected nothing to be collected, got %+v", provider.created)
+	}
+	rf := repo.onlyRefund(t)
+	if rf.RefundID != c.ChangeID || rf.PaymentID != "pay-1" || rf.ProviderTxnID != "txn-1" || rf.AmountCents != 3000 {
+		t.Fatalf("expected 3000 refunded from pay-1, got %+v", rf)
+	}
+	if rf.Status != domain.RefundStatusSucceeded || len(provider.refunds) != 1 {
+		t.Fatalf("expected the provider to refund it, got %s after %d calls", rf.Status, len(provider.refunds))
+	}
+	if p := repo.payments["pay-1"]; p.Status != domain.PaymentStatusSuccess {
+		t.Fatalf("expected pay-1 to stay SUCCESS after a partial refund, got %s", p.Status)
+	}
+	if o := repo.orders["order-1"]; o.AmountCents != 9000 {
+		t.Fatalf("expected the order to cost 9000, got %d", o.AmountCents)
+	}
+	if !slices.Contains(store.inserted, "OrderFareSettled") {
+		t.Fatalf("expected OrderFareSettled, got %v", store.inserted)
+	}
+}
+
+func TestChangeTicket_ClosesAnExpiredFarePayment(t *testing.T) {
+	t.Parallel()
+
+	svc, repo, _ := newChangeTestService(ticketedOrder(1))
+	c, err := svc.ChangeTicket(context.Background(), dearerChange())
+	if err != nil {
+		t.Fatalf("change failed: %v", err)
+	}
+	p := repo.payments[c.FarePaymentID]
+	p.ExpiresAt = time.Now().Add(-time.Second)
+
+	if err := svc.runSaga(context.Background(), lastSaga(t, svc)); !errors.Is(err, errFareNotCollected) {
+		t.Fatalf("expected errFareNotCollected, got: %v", err)
+	}
+	if p.Status != domain.PaymentStatusClosed || len(repo.refunds) != 0 {
+		t.Fatalf("expected the unpaid fare payment CLOSED and nothing refunded, got %s and %d refunds", p.Status, len(repo.refunds))
+	}
+}
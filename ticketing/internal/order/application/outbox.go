@@ -0,0 +1,137 @@
+package application
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"ticketing/internal/order/infrastructure/outbox"
+)
+
+const (
+	outboxBatchSize = 100
+	// outboxClaimLease is how long a relay owns claimed events; events of a
+	// relay that died are claimed again once it runs out.
+	outboxClaimLease = 30 * time.Second
+	// outboxMaxRounds bounds the batches relayed per tick so a backlog
+	// drains without starving the ticker.
+	outboxMaxRounds = 10
+)
+
+type outboxStore interface {
+	InsertTx(ctx context.Context, tx *sql.Tx, eventID string, aggregateID string, eventType string, payload map[string]any) error
+	ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]outbox.Event, error)
+	MarkPublished(ctx context.Context, ev outbox.Event) error
+	MarkRetry(ctx context.Context, ev outbox.Event, retryCount int, nextRetryAt time.Time, lastError string) error
+	MarkDead(ctx context.Context, ev outbox.Event, retryCount int, lastError string) error
+	ListDead(ctx context.Context, limit int) ([]outbox.Event, error)
+	Replay(ctx context.Context, eventID string) error
+}
+
+type eventPublisher interface {
+	Publish(ctx context.Context, ev outbox.Event) error
+}
+
+// StartOutboxPublisher relays outbox events to Kafka. Any number of
+// replicas may run it: each claims its own rows.
+func (s *Service) StartOutboxPublisher(ctx context.Context) {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for round := 0; round < outboxMaxRounds; round++ {
+				if s.relayOutboxBatch(ctx, outboxBatchSize) == 0 {
+					break
+				}
+			}
+		}
+	}
+}
+
+// relayOutboxBatch publishes one claimed batch and returns its size.
+func (s *Service) relayOutboxBatch(ctx context.Context, limit int) int {
+	claimCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	events, err := s.outbox.ClaimPending(claimCtx, limit, outboxClaimLease)
+	cancel()
+	if err != nil {
+		s.logger.Error("claim outbox events failed", "error", err)
+		return 0
+	}
+
+	for _, ev := range events {
+		pubCtx, pubCancel := context.WithTimeout(ctx, 2*time.Second)
+		err := s.publisher.Publish(pubCtx, ev)
+		pubCancel()
+		if err != nil {
+			s.markOutboxFailed(ctx, ev, err)
+			continue
+		}
+		markCtx, markCancel := context.WithTimeout(ctx, 2*time.Second)
+		err = s.outbox.MarkPublished(markCtx, ev)
+		markCancel()
+		if err != nil {
+			s.logOutboxMarkError("mark outbox published failed", ev, err)
+		}
+	}
+	return len(events)
+}
+
+// markOutboxFailed schedules a retry with backoff, or dead-letters the
+// event once it has used up OutboxMaxAttempts.
+func (s *Service) markOutboxFailed(ctx context.Context, ev outbox.Event, cause error) {
+	retryCount := ev.RetryCount + 1
+	errMsg := truncateError(cause, 240)
+
+	markCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if retryCount >= s.cfg.OutboxMaxAttempts {
+		s.logger.Error("outbox event dead-lettered, later events of its aggregate wait for a replay",
+			"error", cause,
+			"event_id", ev.EventID,
+			"event_type", ev.EventType,
+			"aggregate_id", ev.AggregateID,
+			"attempts", retryCount,
+		)
+		if err := s.outbox.MarkDead(markCtx, ev, retryCount, errMsg); err != nil {
+			s.logOutboxMarkError("mark outbox dead failed", ev, err)
+		}
+		return
+	}
+	s.logger.Warn("publish outbox failed", "error", cause, "event_id", ev.EventID, "attempts", retryCount)
+	if err := s.outbox.MarkRetry(markCtx, ev, retryCount, time.Now().Add(retryBackoff(retryCount)), errMsg); err != nil {
+		s.logOutboxMarkError("mark outbox retry failed", ev, err)
+	}
+}
+
+func (s *Service) logOutboxMarkError(msg string, ev outbox.Event, err error) {
+	if errors.Is(err, outbox.ErrClaimLost) {
+		// Another relay owns the event now and records its own outcome.
+		s.logger.Warn(msg, "error", err, "event_id", ev.EventID)
+		return
+	}
+	s.logger.Error(msg, "error", err, "event_id", ev.EventID)
+}
+
+// ListDeadOutboxEvents returns the dead-lettered events, oldest first. Each
+// one holds back the later events of its order until it is replayed.
+func (s *Service) ListDeadOutboxEvents(ctx context.Context, limit int) ([]outbox.Event, error) {
+	if limit <= 0 || limit > outboxBatchSize {
+		limit = outboxBatchSize
+	}
+	return s.outbox.ListDead(ctx, limit)
+}
+
+// ReplayOutboxEvent queues a dead-lettered event for publishing again, once
+// whatever made it fail has been fixed.
+func (s *Service) ReplayOutboxEvent(ctx context.Context, eventID string) error {
+	if err := s.outbox.Replay(ctx, eventID); err != nil {
+		return err
+	}
+	s.logger.Info("outbox event replayed", "event_id", eventID, "actor", callerActor(ctx, "operator"))
+	return nil
+}
@@ -0,0 +1,106 @@
+package application
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"ticketing/internal/order/infrastructure/outbox"
+)
+
+type fakeOutbox struct {
+	events    []outbox.Event
+	inserted  []string
+	published []string
+	retried   map[string]int
+	dead      map[string]int
+}
+
+func (f *fakeOutbox) InsertTx(_ context.Context, _ *sql.Tx, _ string, _ string, eventType string, _ map[string]any) error {
+	f.inserted = append(f.inserted, eventType)
+	return nil
+}
+
+func (f *fakeOutbox) ClaimPending(_ context.Context, limit int, _ time.Duration) ([]outbox.Event, error) {
+	n := min(limit, len(f.events))
+	out := f.events[:n]
+	f.events = f.events[n:]
+	return out, nil
+}
+
+func (f *fakeOutbox) MarkPublished(_ context.Context, ev outbox.Event) error {
+	f.published = append(f.published, ev.EventID)
+	return nil
+}
+
+func (f *fakeOutbox) MarkRetry(_ context.Context, ev outbox.Event, retryCount int, nextRetryAt time.Time, _ string) error {
+	if !nextRetryAt.After(time.Now()) {
+		return errors.New("retry must be scheduled in the future")
+	}
+	f.retried[ev.EventID] = retryCount
+	return nil
+}
+
+func (f *fakeOutbox) MarkDead(_ context.Context, ev outbox.Event, retryCount int, _ string) error {
+	f.dead[ev.EventID] = retryCount
+	return nil
+}
+
+func (f *fakeOutbox) ListDead(context.Context, int) ([]outbox.Event, error) {
+	return nil, nil
+}
+
+func (f *fakeOutbox) Replay(context.Context, string) error {
+	return nil
+}
+
+type fakePublisher struct {
+	failing map[string]bool
+}
+
+func (p *fakePublisher) Publish(_ context.Context, ev outbox.Event) error {
+	if p.failing[ev.EventID] {
+		return errors.New("kafka unavailable")
+	}
+	return nil
+}
+
+func TestRelayOutboxBatch_RetriesThenDeadLetters(t *testing.T) {
+	t.Parallel()
+
+	store := &fakeOutbox{
+		events: []outbox.Event{
+			{EventID: "ok"},
+			{EventID: "flaky", RetryCount: 1},
+			{EventID: "doomed", RetryCount: 2},
+		},
+		retried: map[string]int{},
+		dead:    map[string]int{},
+	}
+	svc := &Service{
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		outbox:    store,
+		publisher: &fakePublisher{failing: map[string]bool{"flaky": true, "doomed": true}},
+		cfg:       Config{OutboxMaxAttempts: 3},
+	}
+
+	if n := svc.relayOutboxBatch(context.Background(), 10); n != 3 {
+		t.Fatalf("expected 3 claimed events, got %d", n)
+	}
+	if len(store.published) != 1 || store.published[0] != "ok" {
+		t.Fatalf("unexpected published events: %v", store.published)
+	}
+	if store.retried["flaky"] != 2 {
+		t.Fatalf("expected flaky to be retried as attempt 2, got %v", store.retried)
+	}
+	if store.dead["doomed"] != 3 {
+		t.Fatalf("expected doomed to be dead-lettered after 3 attempts, got %v", store.dead)
+	}
+	if n := svc.relayOutboxBatch(context.Background(), 10); n != 0 {
+		t.Fatalf("expected an empty second batch, got %d", n)
+	}
+}
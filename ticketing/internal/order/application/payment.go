@@ -0,0 +1,507 @@
+package application
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ticketing/internal/common/tracing"
+	"ticketing/internal/order/domain"
+	"ticketing/internal/order/infrastructure/payment"
+)
+
+// errOrderSettled means the order no longer takes payments, so a new
+// successful payment for it is surplus.
+var errOrderSettled = errors.New("order already settled")
+
+// PayOrder returns the open payment intent of a RESERVED order, creating it
+// with the provider when there is none. Paying twice while an intent is
+// open returns the same intent. A TICKETED order only has one while a
+// change to a dearer ticket waits for the fare difference.
+func (s *Service) PayOrder(ctx context.Context, orderID string) (*domain.Payment, error) {
+	tx, err := s.repo.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	order, err := s.repo.LockByIDTx(ctx, tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeOrder(ctx, order); err != nil {
+		return nil, err
+	}
+	if order.Status != domain.StatusReserved && order.Status != domain.StatusTicketed {
+		return nil, domain.ErrInvalidStateTransfer
+	}
+	now := time.Now()
+	open, err := s.repo.FindOpenPaymentTx(ctx, tx, orderID, now)
+	if err == nil {
+		return open, nil
+	}
+	if !errors.Is(err, domain.ErrPaymentNotFound) {
+		return nil, err
+	}
+	if order.Status != domain.StatusReserved {
+		return nil, domain.ErrInvalidStateTransfer
+	}
+
+	p := &domain.Payment{
+		PaymentID:   uuid.NewString(),
+		OrderID:     orderID,
+		Provider:    s.payments.Name(),
+		Status:      domain.PaymentStatusPending,
+		AmountCents: order.AmountCents,
+		Currency:    domain.DefaultCurrency,
+		ExpiresAt:   now.Add(s.cfg.PaymentTTL),
+	}
+	if err := s.repo.InsertPaymentIntentTx(ctx, tx, p); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.registerPayment(ctx, p, "ticket order "+orderID)
+}
+
+// registerPayment creates a stored payment intent with the provider and
+// keeps the prepay the customer pays with. The row exists before the
+// provider hears of the payment, so a callback always finds it. An intent
+// the provider did not take is closed.
+func (s *Service) registerPayment(ctx context.Context, p *domain.Payment, subject string) (*domain.Payment, error) {
+	callCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	intent, err := s.payments.CreatePayment(callCtx, payment.CreateInput{
+		PaymentID:   p.PaymentID,
+		OrderID:     p.OrderID,
+		AmountCents: p.AmountCents,
+		Currency:    p.Currency,
+		Subject:     subject,
+		ExpiresAt:   p.ExpiresAt,
+	})
+	if err != nil {
+		if _, closeErr := s.repo.UpdatePaymentStatus(ctx, p.PaymentID, domain.PaymentStatusPending, domain.PaymentStatusClosed); closeErr != nil {
+			s.logger.Error("close payment failed", "payment_id", p.PaymentID, "error", closeErr)
+		}
+		return nil, fmt.Errorf("create payment failed: %w", err)
+	}
+	if err := s.repo.UpdatePaymentPrepay(ctx, p.PaymentID, intent.PrepayID, intent.ExpiresAt); err != nil {
+		return nil, err
+	}
+	return s.repo.FindPayment(ctx, p.PaymentID)
+}
+
+// recordPaymentTx stores the successful outcome and the amount paid on the
+// payment intent, or inserts a payment when the callback does not belong to
+// one.
+func (s *Service) recordPaymentTx(ctx context.Context, tx *sql.Tx, sg *domain.Saga, providerTxnID string) error {
+	status := domain.PaymentStatus(sg.String("payment_status"))
+	paidAmount := sg.Int64("paid_amount_cents")
+	paidCurrency := sg.String("paid_currency")
+	if paymentID := sg.String("payment_id"); paymentID != "" {
+		completed, err := s.repo.CompletePaymentTx(ctx, tx, paymentID, sg.OrderID, providerTxnID, status, paidAmount, paidCurrency)
+		if err != nil || completed {
+			return err
+		}
+	}
+	return s.repo.InsertPaymentTx(ctx, tx, uuid.NewString(), sg.OrderID, providerTxnID, string(status), paidAmount, paidCurrency)
+}
+
+// applyPaymentFailure records a FAILED or CLOSED payment and tells
+// subscribers with OrderPaymentFailed. The order stays RESERVED so the
+// customer can pay again; with CancelOnPaymentClosed, a CLOSED payment that
+// leaves no other payment open cancels the order and releases the hold.
+func (s *Service) applyPaymentFailure(ctx context.Context, actor string, in PaymentCallbackInput) (*domain.Order, error) {
+	status := domain.PaymentStatus(strings.ToUpper(strings.TrimSpace(in.Status)))
+
+	tx, err := s.repo.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	order, err := s.repo.LockByIDTx(ctx, tx, in.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	recorded, err := s.recordPaymentFailureTx(ctx, tx, in, status)
+	if err != nil {
+		return nil, err
+	}
+	if !recorded || order.Status != domain.StatusReserved {
+		// A replay, or a late failure of an attempt the order no longer
+		// waits for.
+		return order, tx.Commit()
+	}
+
+	_, openErr := s.repo.FindOpenPaymentTx(ctx, tx, in.OrderID, time.Now())
+	if openErr != nil && !errors.Is(openErr, domain.ErrPaymentNotFound) {
+		return nil, openErr
+	}
+	cancel := s.cfg.CancelOnPaymentClosed && status == domain.PaymentStatusClosed && openErr != nil
+	if err := s.outbox.InsertTx(ctx, tx, uuid.NewString(), in.OrderID, "OrderPaymentFailed", map[string]any{
+		"order_id":        in.OrderID,
+		"payment_id":      in.PaymentID,
+		"provider_txn_id": in.ProviderTxnID,
+		"payment_status":  status,
+		"status":          order.Status,
+		"order_cancelled": cancel,
+		"trace_id":        tracing.TraceID(ctx),
+	}); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if !cancel {
+		return order, nil
+	}
+	return s.cancelOrder(ctx, CancelOrderInput{OrderID: in.OrderID}, actor, "payment "+strings.ToLower(string(status)))
+}
+
+// recordPaymentFailureTx stores the failure on the payment intent, or inserts
+// a payment when the notification does not belong to one. It reports false
+// when the outcome was already recorded.
+func (s *Service) recordPaymentFailureTx(ctx context.Context, tx *sql.Tx, in PaymentCallbackInput, status domain.PaymentStatus) (bool, error) {
+	paymentID := strings.TrimSpace(in.PaymentID)
+	if paymentID != "" {
+		failed, err := s.repo.FailPaymentTx(ctx, tx, paymentID, in.OrderID, in.ProviderTxnID, status)
+		if err != nil || failed {
+			return failed, err
+		}
+	} else {
+		paymentID = uuid.NewString()
+	}
+	err := s.repo.InsertFailedPaymentTx(ctx, tx, paymentID, in.OrderID, in.ProviderTxnID, status)
+	if stringsHasDuplicate(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// refundSurplusPayment records a successful payment for an order that was
+// already paid, parked as mismatched or cancelled, queues its refund in the
+// same transaction and publishes OrderPaymentDuplicated. A transaction that
+// is already recorded is a replay and changes nothing.
+func (s *Service) refundSurplusPayment(ctx context.Context, actor string, in PaymentCallbackInput) (*domain.Order, error) {
+	tx, err := s.repo.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	order, err := s.repo.LockByIDTx(ctx, tx, in.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	recorded, err := s.repo.PaymentTxnRecordedTx(ctx, tx, in.ProviderTxnID)
+	if err != nil || recorded {
+		return order, err
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(in.Currency))
+	paymentID := strings.TrimSpace(in.PaymentID)
+	completed := false
+	if paymentID != "" {
+		completed, err = s.repo.CompletePaymentTx(ctx, tx, paymentID, in.OrderID, in.ProviderTxnID, domain.PaymentStatusRefunding, in.AmountCents, currency)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !completed {
+		paymentID = uuid.NewString()
+		if err := s.repo.InsertPaymentTx(ctx, tx, paymentID, in.OrderID, in.ProviderTxnID, string(domain.PaymentStatusRefunding), in.AmountCents, currency); err != nil {
+			return nil, err
+		}
+	}
+	rf := &domain.Refund{
+		RefundID:      uuid.NewString(),
+		PaymentID:     paymentID,
+		OrderID:       in.OrderID,
+		ProviderTxnID: in.ProviderTxnID,
+		AmountCents:   in.AmountCents,
+		Currency:      currency,
+		Reason:        "surplus payment for " + strings.ToLower(string(order.Status)) + " order",
+		Status:        domain.RefundStatusPending,
+	}
+	if err := s.repo.InsertRefundTx(ctx, tx, rf); err != nil {
+		return nil, err
+	}
+	if err := s.outbox.InsertTx(ctx, tx, uuid.NewString(), in.OrderID, "OrderPaymentDuplicated", map[string]any{
+		"order_id":        in.OrderID,
+		"payment_id":      paymentID,
+		"provider_txn_id": in.ProviderTxnID,
+		"refund_id":       rf.RefundID,
+		"amount_cents":    rf.AmountCents,
+		"currency":        rf.Currency,
+		"status":          order.Status,
+		"trace_id":        tracing.TraceID(ctx),
+	}); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.logger.Warn("surplus payment queued for refund", "order_id", in.OrderID, "payment_id", paymentID, "refund_id", rf.RefundID, "actor", actor)
+
+	// The reconciler retries the refund if this attempt does not go through.
+	s.sendRefund(context.WithoutCancel(ctx), rf)
+	return order, nil
+}
+
+// applyPaymentBehindSaga handles a successful payment that arrived while a
+// saga of the order is running. A CHANGE saga may be waiting for exactly
+// this payment of the fare difference. A PAY saga applies one provider
+// transaction; any other is a second payment, which would otherwise be
+// acknowledged and never recorded, so it is refunded as a surplus. It gets
+// its own payment record: the intent belongs to the saga's payment.
+func (s *Service) applyPaymentBehindSaga(ctx context.Context, actor string, in PaymentCallbackInput) (*domain.Order, error) {
+	active, err := s.activeSaga(ctx, in.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case active == nil:
+		// The saga ended in the meantime; the order now tells what to do.
+		return s.applyPaymentSuccess(ctx, actor, in)
+	case active.SagaType == domain.SagaTypeChange && in.PaymentID != "" && in.PaymentID == active.String("fare_payment_id"):
+		return s.collectFarePayment(ctx, actor, in)
+	case active.SagaType != domain.SagaTypePay:
+		return nil, domain.ErrSagaInProgress
+	case active.String("provider_txn_id") != in.ProviderTxnID:
+		in.PaymentID = ""
+		return s.refundSurplusPayment(ctx, actor, in)
+	}
+	return s.sagaOutcome(ctx, in.OrderID, ErrSagaPending)
+}
+
+// collectFarePayment records the payment a change waits for; the CHANGE
+// saga picks it up on its next check. A payment the change no longer waits
+// for, because the intent was closed or already paid by another
+// transaction, is refunded as a surplus.
+func (s *Service) collectFarePayment(ctx context.Context, actor string, in PaymentCallbackInput) (*domain.Order, error) {
+	tx, err := s.repo.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	order, err := s.repo.LockByIDTx(ctx, tx, in.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	recorded, err := s.repo.PaymentTxnRecordedTx(ctx, tx, in.ProviderTxnID)
+	if err != nil || recorded {
+		return order, err
+	}
+	currency := strings.ToUpper(strings.TrimSpace(in.Currency))
+	completed, err := s.repo.CompletePaymentTx(ctx, tx, in.PaymentID, in.OrderID, in.ProviderTxnID, domain.PaymentStatusSuccess, in.AmountCents, currency)
+	if err != nil {
+		return nil, err
+	}
+	if !completed {
+		tx.Rollback()
+		in.PaymentID = ""
+		return s.refundSurplusPayment(ctx, actor, in)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.logger.Info("fare difference paid", "order_id", in.OrderID, "payment_id", in.PaymentID, "actor", actor)
+	return order, nil
+}
+
+// activeSaga returns the running saga of the order, or nil when there is
+// none.
+func (s *Service) activeSaga(ctx context.Context, orderID string) (*domain.Saga, error) {
+	tx, err := s.repo.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	sg, err := s.sagas.FindActiveByOrderTx(ctx, tx, orderID)
+	if errors.Is(err, domain.ErrSagaNotFound) {
+		return nil, nil
+	}
+	return sg, err
+}
+
+// sendRefund asks the provider for one refund. Transient errors leave it
+// PENDING for the next pass; a rejection needs an operator.
+func (s *Service) sendRefund(ctx context.Context, rf *domain.Refund) {
+	callCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	result, err := s.payments.Refund(callCtx, payment.RefundInput{
+		RefundID:      rf.RefundID,
+		PaymentID:     rf.PaymentID,
+		ProviderTxnID: rf.ProviderTxnID,
+		AmountCents:   rf.AmountCents,
+		Currency:      rf.Currency,
+		Reason:        rf.Reason,
+	})
+	cancel()
+
+	switch {
+	case err == nil:
+		err = s.finishRefund(ctx, rf, domain.RefundStatusSucceeded, result.ProviderRefundID, "")
+	case errors.Is(err, payment.ErrRefundRejected), errors.Is(err, payment.ErrTradeNotFound):
+		s.logger.Error("refund rejected by provider", "refund_id", rf.RefundID, "order_id", rf.OrderID, "error", err)
+		err = s.finishRefund(ctx, rf, domain.RefundStatusRejected, "", truncateError(err, 512))
+	default:
+		s.logger.Warn("refund attempt failed", "refund_id", rf.RefundID, "order_id", rf.OrderID, "error", err)
+		err = s.repo.RecordRefundAttempt(ctx, rf.RefundID, truncateError(err, 512))
+	}
+	if err != nil {
+		s.logger.Error("save refund outcome failed", "refund_id", rf.RefundID, "error", err)
+	}
+}
+
+func (s *Service) finishRefund(ctx context.Context, rf *domain.Refund, status domain.RefundStatus, providerRefundID string, lastError string) error {
+	tx, err := s.repo.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	finished, err := s.repo.FinishRefundTx(ctx, tx, rf.RefundID, status, providerRefundID, lastError)
+	if err != nil || !finished {
+		return err
+	}
+	if status == domain.RefundStatusSucceeded {
+		if _, err := s.repo.UpdatePaymentStatusTx(ctx, tx, rf.PaymentID, domain.PaymentStatusRefunding, domain.PaymentStatusRefunded); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Service) sendPendingRefunds(ctx context.Context, limit int) {
+	listCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	pending, err := s.repo.ListPendingRefunds(listCtx, limit)
+	cancel()
+	if err != nil {
+		s.logger.Error("load pending refunds failed", "error", err)
+		return
+	}
+	for _, rf := range pending {
+		s.sendRefund(ctx, rf)
+	}
+}
+
+// markPaymentMismatchTx parks an order whose payment did not match its fare
+// and publishes OrderPaymentMismatch so the difference can be settled; no
+// OrderPaid is emitted, so nothing is ticketed.
+func (s *Service) markPaymentMismatchTx(ctx context.Context, tx *sql.Tx, sg *domain.Saga) error {
+	expected := sg.Int64("expected_amount_cents")
+	paid := sg.Int64("paid_amount_cents")
+	currency := sg.String("paid_currency")
+	reason := fmt.Sprintf("paid %d %s, expected %d %s", paid, currency, expected, domain.DefaultCurrency)
+	if err := s.transitionTx(ctx, tx, sg, domain.StatusReserved, domain.EventPaymentMismatch, reason); err != nil {
+		return err
+	}
+	s.logger.Warn("payment amount mismatch", "order_id", sg.OrderID, "payment_id", sg.String("payment_id"), "reason", reason)
+	return s.outbox.InsertTx(ctx, tx, uuid.NewString(), sg.OrderID, "OrderPaymentMismatch", map[string]any{
+		"order_id":              sg.OrderID,
+		"payment_id":            sg.String("payment_id"),
+		"provider_txn_id":       sg.String("provider_txn_id"),
+		"expected_amount_cents": expected,
+		"expected_currency":     domain.DefaultCurrency,
+		"paid_amount_cents":     paid,
+		"paid_currency":         currency,
+		"partition_key":         sg.String("partition_key"),
+		"hold_id":               sg.String("hold_id"),
+		"status":                domain.StatusPaymentMismatch,
+		"trace_id":              sg.String("trace_id"),
+	})
+}
+
+// StartPaymentReconciler asks the provider about payments that stayed
+// PENDING, so a lost callback does not leave a paid order unpaid until its
+// hold expires, and resends refunds the provider has not accepted yet.
+func (s *Service) StartPaymentReconciler(ctx context.Context) {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.reconcilePayments(ctx, 50)
+			s.sendPendingRefunds(ctx, 50)
+		}
+	}
+}
+
+func (s *Service) reconcilePayments(ctx context.Context, limit int) {
+	listCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	stale, err := s.repo.ListStalePendingPayments(listCtx, time.Now().Add(-s.cfg.PaymentReconcileAfter), limit)
+	cancel()
+	if err != nil {
+		s.logger.Error("load pending payments failed", "error", err)
+		return
+	}
+	for _, p := range stale {
+		if err := s.reconcilePayment(ctx, p); err != nil {
+			s.logger.Warn("reconcile payment failed", "payment_id", p.PaymentID, "order_id", p.OrderID, "error", err)
+		}
+	}
+}
+
+func (s *Service) reconcilePayment(ctx context.Context, p *domain.Payment) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	trade, err := s.payments.QueryPayment(queryCtx, p.PaymentID)
+	cancel()
+	if errors.Is(err, payment.ErrTradeNotFound) {
+		if !time.Now().After(p.ExpiresAt) {
+			return nil
+		}
+		// The provider never saw it and the customer can no longer pay.
+		_, err = s.applyPaymentFailure(ctx, domain.ActorReconciler, PaymentCallbackInput{
+			OrderID:   p.OrderID,
+			PaymentID: p.PaymentID,
+			Status:    string(domain.PaymentStatusClosed),
+		})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	switch trade.Status {
+	case domain.PaymentStatusSuccess:
+		s.logger.Info("reconciler found paid payment", "payment_id", p.PaymentID, "order_id", p.OrderID)
+		_, err := s.applyPaymentSuccess(ctx, domain.ActorReconciler, PaymentCallbackInput{
+			OrderID:       p.OrderID,
+			PaymentID:     p.PaymentID,
+			ProviderTxnID: trade.ProviderTxnID,
+			Status:        string(trade.Status),
+			AmountCents:   trade.AmountCents,
+			Currency:      trade.Currency,
+		})
+		if errors.Is(err, ErrSagaPending) {
+			return nil
+		}
+		return err
+	case domain.PaymentStatusFailed, domain.PaymentStatusClosed:
+		_, err := s.applyPaymentFailure(ctx, domain.ActorReconciler, PaymentCallbackInput{
+			OrderID:       p.OrderID,
+			PaymentID:     p.PaymentID,
+			ProviderTxnID: trade.ProviderTxnID,
+			Status:        string(trade.Status),
+		})
+		if errors.Is(err, ErrSagaPending) {
+			return nil
+		}
+		return err
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,627 @@
+package application
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"ticketing/internal/common/sqltest"
+	"ticketing/internal/order/domain"
+	"ticketing/internal/order/infrastructure/inventory"
+	"ticketing/internal/order/infrastructure/payment"
+)
+
+// fakePaymentRepo keeps orders, payments and refunds in memory and applies
+// the same conditional updates as the MySQL repository.
+type fakePaymentRepo struct {
+	fakeOrderRepo
+	orders   map[string]*domain.Order
+	payments map[string]*domain.Payment
+	refunds  map[string]*domain.Refund
+}
+
+func newFakePaymentRepo(orders ...*domain.Order) *fakePaymentRepo {
+	r := &fakePaymentRepo{
+		fakeOrderRepo: fakeOrderRepo{db: sqltest.NewDB()},
+		orders:        map[string]*domain.Order{},
+		payments:      map[string]*domain.Payment{},
+		refunds:       map[string]*domain.Refund{},
+	}
+	for _, o := range orders {
+		r.orders[o.OrderID] = o
+	}
+	return r
+}
+
+func (r *fakePaymentRepo) LockByIDTx(_ context.Context, _ *sql.Tx, orderID string) (*domain.Order, error) {
+	o, ok := r.orders[orderID]
+	if !ok {
+		return nil, domain.ErrOrderNotFound
+	}
+	out := *o
+	return &out, nil
+}
+
+func (r *fakePaymentRepo) FindByID(ctx context.Context, orderID string) (*domain.Order, error) {
+	return r.LockByIDTx(ctx, nil, orderID)
+}
+
+func (r *fakePaymentRepo) TransitionTx(ctx context.Context, tx *sql.Tx, t domain.StatusTransition) (bool, error) {
+	o, ok := r.orders[t.OrderID]
+	if !ok || o.Status != t.FromStatus {
+		return false, nil
+	}
+	o.Status = t.ToStatus
+	return r.fakeOrderRepo.TransitionTx(ctx, tx, t)
+}
+
+func (r *fakePaymentRepo) FindOpenPaymentTx(_ context.Context, _ *sql.Tx, orderID string, now time.Time) (*domain.Payment, error) {
+	for _, p := range r.payments {
+		if p.OrderID == orderID && p.IsOpen(now) {
+			return p, nil
+		}
+	}
+	return nil, domain.ErrPaymentNotFound
+}
+
+func (r *fakePaymentRepo) InsertPaymentIntentTx(_ context.Context, _ *sql.Tx, p *domain.Payment) error {
+	out := *p
+	r.payments[p.PaymentID] = &out
+	return nil
+}
+
+func (r *fakePaymentRepo) UpdatePaymentPrepay(_ context.Context, paymentID string, prepayID string, expiresAt time.Time) error {
+	p := r.payments[paymentID]
+	p.PrepayID, p.ExpiresAt = prepayID, expiresAt
+	return nil
+}
+
+func (r *fakePaymentRepo) FindPayment(_ context.Context, paymentID string) (*domain.Payment, error) {
+	p, ok := r.payments[paymentID]
+	if !ok {
+		return nil, domain.ErrPaymentNotFound
+	}
+	out := *p
+	return &out, nil
+}
+
+func (r *fakePaymentRepo) FindPaidPaymentTx(_ context.Context, _ *sql.Tx, orderID string, amountCents int64) (*domain.Payment, error) {
+	var found *domain.Payment
+	for _, p := range r.payments {
+		if p.OrderID != orderID || p.Status != domain.PaymentStatusSuccess {
+			continue
+		}
+		if found == nil || (p.PaidAmountCents >= amountCents && found.PaidAmountCents < amountCents) {
+			found = p
+		}
+	}
+	if found == nil {
+		return nil, domain.ErrPaymentNotFound
+	}
+	out := *found
+	return &out, nil
+}
+
+func (r *fakePaymentRepo) FailPaymentTx(_ context.Context, _ *sql.Tx, paymentID string, orderID string, providerTxnID string, status domain.PaymentStatus) (bool, error) {
+	p, ok := r.payments[paymentID]
+	if !ok || p.OrderID != orderID || p.Status != domain.PaymentStatusPending {
+		return false, nil
+	}
+	p.ProviderTxnID, p.Status = providerTxnID, status
+	return true, nil
+}
+
+func (r *fakePaymentRepo) InsertFailedPaymentTx(_ context.Context, _ *sql.Tx, paymentID string, orderID string, providerTxnID string, status domain.PaymentStatus) error {
+	for _, p := range r.payments {
+		if p.PaymentID == paymentID || (providerTxnID != "" && p.ProviderTxnID == providerTxnID) {
+			return &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}
+		}
+	}
+	r.payments[paymentID] = &domain.Payment{PaymentID: paymentID, OrderID: orderID, ProviderTxnID: providerTxnID, Status: status}
+	return nil
+}
+
+func (r *fakePaymentRepo) PaymentTxnRecordedTx(_ context.Context, _ *sql.Tx, providerTxnID string) (bool, error) {
+	for _, p := range r.payments {
+		if p.ProviderTxnID == providerTxnID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakePaymentRepo) CompletePaymentTx(_ context.Context, _ *sql.Tx, paymentID string, orderID string, providerTxnID string, status domain.PaymentStatus, paidAmountCents int64, paidCurrency string) (bool, error) {
+	p, ok := r.payments[paymentID]
+	if !ok || p.OrderID != orderID || p.Status != domain.PaymentStatusPending {
+		return false, nil
+	}
+	p.ProviderTxnID, p.Status, p.PaidAmountCents, p.PaidCurrency = providerTxnID, status, paidAmountCents, paidCurrency
+	return true, nil
+}
+
+func (r *fakePaymentRepo) InsertPaymentTx(_ context.Context, _ *sql.Tx, paymentID string, orderID string, providerTxnID string, status string, paidAmountCents int64, paidCurrency string) error {
+	r.payments[paymentID] = &domain.Payment{
+		PaymentID:       paymentID,
+		OrderID:         orderID,
+		ProviderTxnID:   providerTxnID,
+		Status:          domain.PaymentStatus(status),
+		PaidAmountCents: paidAmountCents,
+		PaidCurrency:    paidCurrency,
+	}
+	return nil
+}
+
+func (r *fakePaymentRepo) UpdatePaymentStatusTx(_ context.Context, _ *sql.Tx, paymentID string, from domain.PaymentStatus, to domain.PaymentStatus) (bool, error) {
+	p, ok := r.payments[paymentID]
+	if !ok || p.Status != from {
+		return false, nil
+	}
+	p.Status = to
+	return true, nil
+}
+
+func (r *fakePaymentRepo) InsertRefundTx(_ context.Context, _ *sql.Tx, rf *domain.Refund) error {
+	out := *rf
+	r.refunds[rf.RefundID] = &out
+	return nil
+}
+
+func (r *fakePaymentRepo) FinishRefundTx(_ context.Context, _ *sql.Tx, refundID string, status domain.RefundStatus, providerRefundID string, lastError string) (bool, error) {
+	rf, ok := r.refunds[refundID]
+	if !ok || rf.Status != domain.RefundStatusPending {
+		return false, nil
+	}
+	rf.Status, rf.ProviderRefundID, rf.LastError = status, providerRefundID, lastError
+	rf.Attempts++
+	return true, nil
+}
+
+func (r *fakePaymentRepo) FindRefund(_ context.Context, refundID string) (*domain.Refund, error) {
+	rf, ok := r.refunds[refundID]
+	if !ok {
+		return nil, domain.ErrRefundNotFound
+	}
+	out := *rf
+	return &out, nil
+}
+
+func (r *fakePaymentRepo) RecordRefundAttempt(_ context.Context, refundID string, lastError string) error {
+	rf := r.refunds[refundID]
+	rf.Attempts++
+	rf.LastError = lastError
+	return nil
+}
+
+func (r *fakePaymentRepo) ListPendingRefunds(_ context.Context, limit int) ([]*domain.Refund, error) {
+	var out []*domain.Refund
+	for _, rf := range r.refunds {
+		if rf.Status == domain.RefundStatusPending && len(out) < limit {
+			cp := *rf
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakePaymentRepo) ListPassengersTx(context.Context, *sql.Tx, string) ([]domain.OrderPassenger, error) {
+	return nil, nil
+}
+
+func (r *fakePaymentRepo) UpdateHoldTx(_ context.Context, _ *sql.Tx, orderID string, partitionKey string, holdID string) error {
+	o := r.orders[orderID]
+	o.PartitionKey, o.HoldID = partitionKey, holdID
+	return nil
+}
+
+func (r *fakePaymentRepo) UpdatePassengerWindowTx(context.Context, *sql.Tx, string, domain.TravelWindow) error {
+	return nil
+}
+
+func (r *fakePaymentRepo) UpdateAmountTx(_ context.Context, _ *sql.Tx, orderID string, amountCents int64) error {
+	r.orders[orderID].AmountCents = amountCents
+	return nil
+}
+
+func (r *fakePaymentRepo) onlyRefund(t *testing.T) *domain.Refund {
+	t.Helper()
+	if len(r.refunds) != 1 {
+		t.Fatalf("expected one refund, got %d", len(r.refunds))
+	}
+	for _, rf := range r.refunds {
+		return rf
+	}
+	return nil
+}
+
+type fakeProvider struct {
+	payment.Provider
+	createErr error
+	created   []payment.CreateInput
+	refundErr error
+	refunds   []payment.RefundInput
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+func (p *fakeProvider) CreatePayment(_ context.Context, in payment.CreateInput) (*payment.Intent, error) {
+	p.created = append(p.created, in)
+	if p.createErr != nil {
+		return nil, p.createErr
+	}
+	return &payment.Intent{PaymentID: in.PaymentID, PrepayID: "prepay-" + in.PaymentID, ExpiresAt: in.ExpiresAt}, nil
+}
+
+func (p *fakeProvider) Refund(_ context.Context, in payment.RefundInput) (*payment.Refund, error) {
+	p.refunds = append(p.refunds, in)
+	if p.refundErr != nil {
+		return nil, p.refundErr
+	}
+	return &payment.Refund{RefundID: in.RefundID, ProviderRefundID: "provider-" + in.RefundID, Status: "SUCCESS"}, nil
+}
+
+type fakeInventory struct {
+	inventory.API
+	held      []inventory.TryHoldInput
+	confirmed []inventory.ConfirmInput
+	released  []inventory.ReleaseInput
+	returned  []inventory.ReturnInput
+}
+
+func (f *fakeInventory) TryHold(_ context.Context, in inventory.TryHoldInput) error {
+	f.held = append(f.held, in)
+	return nil
+}
+
+func (f *fakeInventory) ConfirmHold(_ context.Context, in inventory.ConfirmInput) error {
+	f.confirmed = append(f.confirmed, in)
+	return nil
+}
+
+func (f *fakeInventory) ReturnConfirmed(_ context.Context, in inventory.ReturnInput) error {
+	f.returned = append(f.returned, in)
+	return nil
+}
+
+func (f *fakeInventory) ReleaseHold(_ context.Context, in inventory.ReleaseInput) error {
+	f.released = append(f.released, in)
+	return nil
+}
+
+func newPaymentTestService(repo *fakePaymentRepo, provider *fakeProvider) (*Service, *fakeOutbox) {
+	store := &fakeOutbox{}
+	return &Service{
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		repo:     repo,
+		outbox:   store,
+		sagas:    &fakeSagaStore{},
+		payments: provider,
+		cfg:      Config{PaymentTTL: 15 * time.Minute},
+	}, store
+}
+
+func surplusCallback(paymentID string, providerTxnID string) PaymentCallbackInput {
+	return PaymentCallbackInput{
+		OrderID:       "order-1",
+		PaymentID:     paymentID,
+		ProviderTxnID: providerTxnID,
+		Status:        string(domain.PaymentStatusSuccess),
+		AmountCents:   12000,
+		Currency:      "cny",
+	}
+}
+
+func TestApplyPaymentSuccess_RefundsSecondPaymentOfPaidOrder(t *testing.T) {
+	t.Parallel()
+
+	repo := newFakePaymentRepo(&domain.Order{OrderID: "order-1", Status: domain.StatusPaid, AmountCents: 12000})
+	repo.payments["pay-2"] = &domain.Payment{PaymentID: "pay-2", OrderID: "order-1", Status: domain.PaymentStatusPending}
+	provider := &fakeProvider{}
+	svc, store := newPaymentTestService(repo, provider)
+
+	order, err := svc.applyPaymentSuccess(context.Background(), domain.ActorPaymentProvider, surplusCallback("pay-2", "txn-2"))
+	if err != nil {
+		t.Fatalf("apply payment failed: %v", err)
+	}
+	if order.Status != domain.StatusPaid {
+		t.Fatalf("expected the order to stay PAID, got %s", order.Status)
+	}
+	rf := repo.onlyRefund(t)
+	if rf.PaymentID != "pay-2" || rf.AmountCents != 12000 || rf.Currency != "CNY" || rf.Status != domain.RefundStatusSucceeded {
+		t.Fatalf("unexpected refund: %+v", rf)
+	}
+	if rf.ProviderRefundID != "provider-"+rf.RefundID {
+		t.Fatalf("expected the provider refund ID to be stored, got %q", rf.ProviderRefundID)
+	}
+	if p := repo.payments["pay-2"]; p.Status != domain.PaymentStatusRefunded || p.ProviderTxnID != "txn-2" {
+		t.Fatalf("expected pay-2 REFUNDED for txn-2, got %s for %s", p.Status, p.ProviderTxnID)
+	}
+	if !slices.Equal(store.inserted, []string{"OrderPaymentDuplicated"}) {
+		t.Fatalf("expected OrderPaymentDuplicated, got %v", store.inserted)
+	}
+}
+
+func TestApplyPaymentSuccess_RefundsSecondTxnWhileSagaPending(t *testing.T) {
+	t.Parallel()
+
+	repo := newFakePaymentRepo(&domain.Order{OrderID: "order-1", Status: domain.StatusReserved, AmountCents: 12000})
+	repo.payments["pay-1"] = &domain.Payment{PaymentID: "pay-1", OrderID: "order-1", Status: domain.PaymentStatusPending, ExpiresAt: time.Now().Add(time.Minute)}
+	provider := &fakeProvider{}
+	svc, store := newPaymentTestService(repo, provider)
+	svc.sagas = &fakeSagaStore{active: domain.NewSaga("saga-1", domain.SagaTypePay, "order-1", map[string]any{
+		"payment_id":      "pay-1",
+		"provider_txn_id": "txn-1",
+	})}
+
+	// Both transactions were paid against the same intent.
+	order, err := svc.applyPaymentSuccess(context.Background(), domain.ActorPaymentProvider, surplusCallback("pay-1", "txn-2"))
+	if err != nil {
+		t.Fatalf("expected the second payment to be taken care of, got: %v", err)
+	}
+	if order.Status != domain.StatusReserved {
+		t.Fatalf("expected the order to wait for the running saga, got %s", order.Status)
+	}
+	if p := repo.payments["pay-1"]; p.Status != domain.PaymentStatusPending {
+		t.Fatalf("expected the intent to be left to the saga, got %s", p.Status)
+	}
+	rf := repo.onlyRefund(t)
+	if rf.PaymentID == "pay-1" || rf.ProviderTxnID != "txn-2" || rf.Status != domain.RefundStatusSucceeded {
+		t.Fatalf("expected txn-2 refunded under its own payment, got %+v", rf)
+	}
+	if p := repo.payments[rf.PaymentID]; p == nil || p.ProviderTxnID != "txn-2" || p.Status != domain.PaymentStatusRefunded {
+		t.Fatalf("expected a REFUNDED payment for txn-2, got %+v", p)
+	}
+	if len(provider.refunds) != 1 || !slices.Equal(store.inserted, []string{"OrderPaymentDuplicated"}) {
+		t.Fatalf("expected one refund call and OrderPaymentDuplicated, got %d and %v", len(provider.refunds), store.inserted)
+	}
+
+	// The saga's own transaction is still just pending.
+	if _, err := svc.applyPaymentSuccess(context.Background(), domain.ActorPaymentProvider, surplusCallback("pay-1", "txn-1")); !errors.Is(err, ErrSagaPending) {
+		t.Fatalf("expected ErrSagaPending for the saga's transaction, got: %v", err)
+	}
+	if len(repo.refunds) != 1 {
+		t.Fatalf("expected no refund for the saga's transaction, got %d refunds", len(repo.refunds))
+	}
+}
+
+func TestRefundSurplusPayment_ReplayChangesNothing(t *testing.T) {
+	t.Parallel()
+
+	repo := newFakePaymentRepo(&domain.Order{OrderID: "order-1", Status: domain.StatusCancelled})
+	provider := &fakeProvider{}
+	svc, store := newPaymentTestService(repo, provider)
+
+	for i := 0; i < 2; i++ {
+		if _, err := svc.refundSurplusPayment(context.Background(), domain.ActorPaymentProvider, surplusCallback("", "txn-3")); err != nil {
+			t.Fatalf("attempt %d failed: %v", i, err)
+		}
+	}
+	repo.onlyRefund(t)
+	if len(repo.payments) != 1 || len(provider.refunds) != 1 || len(store.inserted) != 1 {
+		t.Fatalf("expected one payment, refund call and event, got %d, %d and %d", len(repo.payments), len(provider.refunds), len(store.inserted))
+	}
+	for _, p := range repo.payments {
+		if p.ProviderTxnID != "txn-3" || p.Status != domain.PaymentStatusRefunded {
+			t.Fatalf("expected a REFUNDED payment for txn-3, got %s for %s", p.Status, p.ProviderTxnID)
+		}
+	}
+}
+
+func TestSendRefund_RecordsProviderOutcome(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		refundErr   error
+		wantRefund  domain.RefundStatus
+		wantPayment domain.PaymentStatus
+		wantError   bool
+	}{
+		{name: "accepted", wantRefund: domain.RefundStatusSucceeded, wantPayment: domain.PaymentStatusRefunded},
+		{name: "rejected", refundErr: payment.ErrRefundRejected, wantRefund: domain.RefundStatusRejected, wantPayment: domain.PaymentStatusRefunding, wantError: true},
+		{name: "unknown trade", refundErr: payment.ErrTradeNotFound, wantRefund: domain.RefundStatusRejected, wantPayment: domain.PaymentStatusRefunding, wantError: true},
+		{name: "unavailable", refundErr: errors.New("provider timeout"), wantRefund: domain.RefundStatusPending, wantPayment: domain.PaymentStatusRefunding, wantError: true},
+	}
+	for _, tc := range cases {
+		repo := newFakePaymentRepo()
+		repo.payments["pay-1"] = &domain.Payment{PaymentID: "pay-1", OrderID: "order-1", Status: domain.PaymentStatusRefunding}
+		rf := &domain.Refund{RefundID: "refund-1", PaymentID: "pay-1", OrderID: "order-1", AmountCents: 500, Currency: "CNY", Status: domain.RefundStatusPending}
+		_ = repo.InsertRefundTx(context.Background(), nil, rf)
+		svc, _ := newPaymentTestService(repo, &fakeProvider{refundErr: tc.refundErr})
+
+		svc.sendRefund(context.Background(), rf)
+
+		got := repo.refunds["refund-1"]
+		if got.Status != tc.wantRefund || got.Attempts != 1 {
+			t.Fatalf("%s: expected %s after one attempt, got %s after %d", tc.name, tc.wantRefund, got.Status, got.Attempts)
+		}
+		if (got.LastError != "") != tc.wantError {
+			t.Fatalf("%s: unexpected last error %q", tc.name, got.LastError)
+		}
+		if p := repo.payments["pay-1"]; p.Status != tc.wantPayment {
+			t.Fatalf("%s: expected payment %s, got %s", tc.name, tc.wantPayment, p.Status)
+		}
+	}
+}
+
+func TestFinishRefund_KeepsFinishedRefund(t *testing.T) {
+	t.Parallel()
+
+	repo := newFakePaymentRepo()
+	repo.payments["pay-1"] = &domain.Payment{PaymentID: "pay-1", Status: domain.PaymentStatusRefunded}
+	rf := &domain.Refund{RefundID: "refund-1", PaymentID: "pay-1", Status: domain.RefundStatusSucceeded, ProviderRefundID: "provider-1"}
+	_ = repo.InsertRefundTx(context.Background(), nil, rf)
+	svc, _ := newPaymentTestService(repo, &fakeProvider{})
+
+	if err := svc.finishRefund(context.Background(), rf, domain.RefundStatusRejected, "", "late rejection"); err != nil {
+		t.Fatalf("finish refund failed: %v", err)
+	}
+	if got := repo.refunds["refund-1"]; got.Status != domain.RefundStatusSucceeded || got.ProviderRefundID != "provider-1" {
+		t.Fatalf("expected the refund to stay SUCCEEDED, got %+v", got)
+	}
+}
+
+func TestSendPendingRefunds_ResendsOnlyPendingRefunds(t *testing.T) {
+	t.Parallel()
+
+	repo := newFakePaymentRepo()
+	for _, rf := range []*domain.Refund{
+		{RefundID: "refund-1", PaymentID: "pay-1", Status: domain.RefundStatusPending},
+		{RefundID: "refund-2", PaymentID: "pay-2", Status: domain.RefundStatusPending, Attempts: 3},
+		{RefundID: "refund-3", PaymentID: "pay-3", Status: domain.RefundStatusSucceeded},
+		{RefundID: "refund-4", PaymentID: "pay-4", Status: domain.RefundStatusRejected},
+	} {
+		repo.payments[rf.PaymentID] = &domain.Payment{PaymentID: rf.PaymentID, Status: domain.PaymentStatusRefunding}
+		_ = repo.InsertRefundTx(context.Background(), nil, rf)
+	}
+	provider := &fakeProvider{}
+	svc, _ := newPaymentTestService(repo, provider)
+
+	svc.sendPendingRefunds(context.Background(), 10)
+
+	var sent []string
+	for _, in := range provider.refunds {
+		sent = append(sent, in.RefundID)
+	}
+	slices.Sort(sent)
+	if !slices.Equal(sent, []string{"refund-1", "refund-2"}) {
+		t.Fatalf("expected refund-1 and refund-2 to be sent, got %v", sent)
+	}
+	for _, id := range []string{"refund-1", "refund-2"} {
+		if repo.refunds[id].Status != domain.RefundStatusSucceeded {
+			t.Fatalf("expected %s SUCCEEDED, got %s", id, repo.refunds[id].Status)
+		}
+	}
+	if repo.payments["pay-4"].Status != domain.PaymentStatusRefunding {
+		t.Fatal("expected the rejected refund's payment to be left alone")
+	}
+}
+
+func failureCallback(paymentID string, providerTxnID string, status domain.PaymentStatus) PaymentCallbackInput {
+	return PaymentCallbackInput{OrderID: "order-1", PaymentID: paymentID, ProviderTxnID: providerTxnID, Status: string(status)}
+}
+
+func TestApplyPaymentFailure_RecordsFailureAndKeepsOrderReserved(t *testing.T) {
+	t.Parallel()
+
+	repo := newFakePaymentRepo(&domain.Order{OrderID: "order-1", Status: domain.StatusReserved})
+	repo.payments["pay-1"] = &domain.Payment{PaymentID: "pay-1", OrderID: "order-1", Status: domain.PaymentStatusPending, ExpiresAt: time.Now().Add(time.Minute)}
+	svc, store := newPaymentTestService(repo, &fakeProvider{})
+
+	order, err := svc.applyPaymentFailure(context.Background(), domain.ActorPaymentProvider, failureCallback("pay-1", "txn-1", " failed "))
+	if err != nil {
+		t.Fatalf("apply failure failed: %v", err)
+	}
+	if order.Status != domain.StatusReserved || len(repo.transitions) != 0 {
+		t.Fatalf("expected the order to stay RESERVED, got %s after %d transitions", order.Status, len(repo.transitions))
+	}
+	if p := repo.payments["pay-1"]; p.Status != domain.PaymentStatusFailed || p.ProviderTxnID != "txn-1" {
+		t.Fatalf("expected pay-1 FAILED for txn-1, got %s for %s", p.Status, p.ProviderTxnID)
+	}
+	if !slices.Equal(store.inserted, []string{"OrderPaymentFailed"}) {
+		t.Fatalf("expected OrderPaymentFailed, got %v", store.inserted)
+	}
+}
+
+func TestApplyPaymentFailure_DuplicateCallbackChangesNothing(t *testing.T) {
+	t.Parallel()
+
+	for _, paymentID := range []string{"pay-1", ""} {
+		repo := newFakePaymentRepo(&domain.Order{OrderID: "order-1", Status: domain.StatusReserved})
+		repo.payments["pay-1"] = &domain.Payment{PaymentID: "pay-1", OrderID: "order-1", Status: domain.PaymentStatusPending, ExpiresAt: time.Now().Add(time.Minute)}
+		svc, store := newPaymentTestService(repo, &fakeProvider{})
+
+		for i := 0; i < 2; i++ {
+			if _, err := svc.applyPaymentFailure(context.Background(), domain.ActorPaymentProvider, failureCallback(paymentID, "txn-1", domain.PaymentStatusFailed)); err != nil {
+				t.Fatalf("payment %q attempt %d failed: %v", paymentID, i, err)
+			}
+		}
+		if len(store.inserted) != 1 {
+			t.Fatalf("payment %q: expected one OrderPaymentFailed, got %v", paymentID, store.inserted)
+		}
+		var failed int
+		for _, p := range repo.payments {
+			if p.Status == domain.PaymentStatusFailed {
+				failed++
+			}
+		}
+		if failed != 1 {
+			t.Fatalf("payment %q: expected one FAILED payment, got %d", paymentID, failed)
+		}
+	}
+}
+
+func TestApplyPaymentFailure_IgnoresLateFailureOfPaidOrder(t *testing.T) {
+	t.Parallel()
+
+	repo := newFakePaymentRepo(&domain.Order{OrderID: "order-1", Status: domain.StatusPaid})
+	svc, store := newPaymentTestService(repo, &fakeProvider{})
+
+	order, err := svc.applyPaymentFailure(context.Background(), domain.ActorPaymentProvider, failureCallback("", "txn-9", domain.PaymentStatusClosed))
+	if err != nil {
+		t.Fatalf("apply failure failed: %v", err)
+	}
+	if order.Status != domain.StatusPaid || len(store.inserted) != 0 {
+		t.Fatalf("expected a PAID order and no event, got %s and %v", order.Status, store.inserted)
+	}
+	if len(repo.payments) != 1 {
+		t.Fatalf("expected the failed attempt to be recorded, got %d payments", len(repo.payments))
+	}
+}
+
+func TestApplyPaymentFailure_CancelsOrderWhenLastPaymentCloses(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name          string
+		status        domain.PaymentStatus
+		cancelEnabled bool
+		otherOpen     bool
+		wantCancelled bool
+	}{
+		{name: "closed", status: domain.PaymentStatusClosed, cancelEnabled: true, wantCancelled: true},
+		{name: "failed", status: domain.PaymentStatusFailed, cancelEnabled: true},
+		{name: "disabled", status: domain.PaymentStatusClosed},
+		{name: "another payment open", status: domain.PaymentStatusClosed, cancelEnabled: true, otherOpen: true},
+	}
+	for _, tc := range cases {
+		repo := newFakePaymentRepo(&domain.Order{OrderID: "order-1", Status: domain.StatusReserved, PartitionKey: "G123|2026-02-11|2nd", HoldID: "hold-1"})
+		repo.payments["pay-1"] = &domain.Payment{PaymentID: "pay-1", OrderID: "order-1", Status: domain.PaymentStatusPending, ExpiresAt: time.Now().Add(time.Minute)}
+		if tc.otherOpen {
+			repo.payments["pay-2"] = &domain.Payment{PaymentID: "pay-2", OrderID: "order-1", Status: domain.PaymentStatusPending, ExpiresAt: time.Now().Add(time.Minute)}
+		}
+		svc, store := newPaymentTestService(repo, &fakeProvider{})
+		inv := &fakeInventory{}
+		svc.inventoryClient = inv
+		svc.cfg.CancelOnPaymentClosed = tc.cancelEnabled
+
+		order, err := svc.applyPaymentFailure(context.Background(), domain.ActorPaymentProvider, failureCallback("pay-1", "txn-1", tc.status))
+		if err != nil {
+			t.Fatalf("%s: apply failure failed: %v", tc.name, err)
+		}
+		if !tc.wantCancelled {
+			if order.Status != domain.StatusReserved || len(inv.released) != 0 {
+				t.Fatalf("%s: expected a RESERVED order and its hold kept, got %s and %d releases", tc.name, order.Status, len(inv.released))
+			}
+			continue
+		}
+		if order.Status != domain.StatusCancelled {
+			t.Fatalf("%s: expected CANCELLED, got %s", tc.name, order.Status)
+		}
+		if len(repo.transitions) != 1 || repo.transitions[0].Reason != "payment closed" || repo.transitions[0].Actor != domain.ActorPaymentProvider {
+			t.Fatalf("%s: unexpected transitions %+v", tc.name, repo.transitions)
+		}
+		if len(inv.released) != 1 || inv.released[0].HoldID != "hold-1" {
+			t.Fatalf("%s: expected hold-1 to be released, got %+v", tc.name, inv.released)
+		}
+		if !slices.Equal(store.inserted, []string{"OrderPaymentFailed", "OrderCancelled"}) {
+			t.Fatalf("%s: unexpected events %v", tc.name, store.inserted)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package application
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ticketing/internal/order/domain"
+	"ticketing/internal/order/infrastructure/reconciliation"
+)
+
+// txnLookupBatch bounds the IN list when looking up transactions settled on
+// a neighbouring day.
+const txnLookupBatch = 500
+
+// Reconciler matches provider settlement files against the payments
+// order-service recorded. It only needs MySQL, so the payment-reconcile
+// command runs it without the rest of the service.
+type Reconciler struct {
+	logger *slog.Logger
+	repo   *reconciliation.Repository
+}
+
+func NewReconciler(logger *slog.Logger, repo *reconciliation.Repository) *Reconciler {
+	return &Reconciler{logger: logger, repo: repo}
+}
+
+type ReconcileInput struct {
+	Provider       string
+	SettlementDate string
+	SourceFile     string
+	Records        []domain.SettlementRecord
+}
+
+// Reconcile compares one settlement file with the payments paid on its
+// settlement day and stores the run with every discrepancy.
+func (r *Reconciler) Reconcile(ctx context.Context, in ReconcileInput) (*domain.ReconciliationRun, error) {
+	from, to, err := domain.SettlementDay(in.SettlementDate)
+	if err != nil {
+		return nil, err
+	}
+	ledger, err := r.repo.ListLedger(ctx, in.Provider, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// A payment made just before midnight may be settled on the next day;
+	// match it by transaction rather than report it as extra.
+	known := make(map[string]bool, len(ledger))
+	for _, e := range ledger {
+		known[e.ProviderTxnID] = true
+	}
+	var unknown []string
+	for _, rec := range in.Records {
+		if !known[rec.ProviderTxnID] {
+			known[rec.ProviderTxnID] = true
+			unknown = append(unknown, rec.ProviderTxnID)
+		}
+	}
+	for start := 0; start < len(unknown); start += txnLookupBatch {
+		end := min(start+txnLookupBatch, len(unknown))
+		late, err := r.repo.FindLedgerByTxnIDs(ctx, unknown[start:end])
+		if err != nil {
+			return nil, err
+		}
+		ledger = append(ledger, late...)
+	}
+
+	run := &domain.ReconciliationRun{
+		RunID:          uuid.NewString(),
+		Provider:       in.Provider,
+		SettlementDate: in.SettlementDate,
+		SourceFile:     in.SourceFile,
+		CreatedAt:      time.Now(),
+	}
+	domain.Reconcile(run, in.Records, ledger)
+
+	tx, err := r.repo.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := r.repo.InsertRunTx(ctx, tx, run); err != nil {
+		return nil, err
+	}
+	for _, d := range run.Items {
+		if err := r.repo.InsertItemTx(ctx, tx, run.RunID, d); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	r.logger.Info("payment reconciliation finished",
+		"run_id", run.RunID,
+		"settlement_date", run.SettlementDate,
+		"matched", run.Matched,
+		"missing", run.Missing,
+		"extra", run.Extra,
+		"mismatched", run.Mismatched,
+	)
+	return run, nil
+}
+
+func (r *Reconciler) ListRuns(ctx context.Context, settlementDate string, limit int) ([]*domain.ReconciliationRun, error) {
+	settlementDate = strings.TrimSpace(settlementDate)
+	if settlementDate != "" {
+		if _, _, err := domain.SettlementDay(settlementDate); err != nil {
+			return nil, err
+		}
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	return r.repo.ListRuns(ctx, settlementDate, limit)
+}
+
+// GetRun returns a run and its discrepancies, optionally only one kind.
+func (r *Reconciler) GetRun(ctx context.Context, runID string, kind string) (*domain.ReconciliationRun, error) {
+	k := domain.DiscrepancyKind(strings.ToUpper(strings.TrimSpace(kind)))
+	switch k {
+	case "", domain.DiscrepancyMissing, domain.DiscrepancyExtra, domain.DiscrepancyAmountMismatch:
+	default:
+		return nil, domain.ErrInvalidFilter
+	}
+	return r.repo.FindRun(ctx, runID, k)
+}
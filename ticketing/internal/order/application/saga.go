@@ -0,0 +1,480 @@
+package application
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ticketing/internal/common/tracing"
+	"ticketing/internal/order/domain"
+	"ticketing/internal/order/infrastructure/inventory"
+)
+
+const (
+	sagaStepLease   = 30 * time.Second
+	sagaMaxAttempts = 5
+	// sagaWaitPoll is how often a step waiting on the customer is checked.
+	sagaWaitPoll = 5 * time.Second
+)
+
+var (
+	// ErrSagaPending means the workflow was persisted but a step is waiting
+	// for a retry; StartSagaRecovery finishes it.
+	ErrSagaPending = errors.New("workflow accepted and still in progress")
+	ErrSagaFailed  = errors.New("workflow failed and was compensated")
+
+	errSagaNotNeeded = errors.New("workflow not needed")
+	// errSagaWaiting is returned by a step that waits on the customer, such
+	// as a payment; the step is checked again without using up its attempts.
+	errSagaWaiting = errors.New("workflow step waiting")
+)
+
+// sagaStep is one step of a saga. action calls another service and must be
+// idempotent, because a crash after the call and before the progress is
+// saved repeats it. local runs in the transaction that records the progress,
+// so it takes effect exactly once.
+type sagaStep struct {
+	name       string
+	action     func(ctx context.Context, sg *domain.Saga) error
+	local      func(ctx context.Context, tx *sql.Tx, sg *domain.Saga) error
+	compensate func(ctx context.Context, sg *domain.Saga) error
+	// pivot marks the point of no return: once a pivot step has been
+	// attempted the saga is only ever retried forward.
+	pivot bool
+}
+
+type sagaDefinition struct {
+	steps      []sagaStep
+	onComplete func(ctx context.Context, tx *sql.Tx, sg *domain.Saga) error
+	onFail     func(ctx context.Context, tx *sql.Tx, sg *domain.Saga) error
+}
+
+// sagaStore persists sagas and their step logs.
+type sagaStore interface {
+	AppendStep(ctx context.Context, entry domain.SagaStepLog) error
+	AppendStepTx(ctx context.Context, tx *sql.Tx, entry domain.SagaStepLog) error
+	Claim(ctx context.Context, sagaID string, leaseUntil time.Time) (bool, error)
+	FindActiveByOrderTx(ctx context.Context, tx *sql.Tx, orderID string) (*domain.Saga, error)
+	InsertTx(ctx context.Context, tx *sql.Tx, sg *domain.Saga) error
+	ListDue(ctx context.Context, limit int) ([]*domain.Saga, error)
+	Save(ctx context.Context, sg *domain.Saga) error
+	SaveTx(ctx context.Context, tx *sql.Tx, sg *domain.Saga) error
+}
+
+func (d *sagaDefinition) compensable(step int) bool {
+	for i := 0; i <= step && i < len(d.steps); i++ {
+		if d.steps[i].pivot {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Service) sagaDefinition(sagaType string) (*sagaDefinition, error) {
+	switch sagaType {
+	case domain.SagaTypeReserve:
+		return s.reserveSaga(), nil
+	case domain.SagaTypePay:
+		return s.paySaga(), nil
+	case domain.SagaTypeCancel:
+		return s.cancelSaga(), nil
+	case domain.SagaTypeChange:
+		return s.changeSaga(), nil
+	default:
+		return nil, fmt.Errorf("unknown saga type %q", sagaType)
+	}
+}
+
+// startSaga locks the order, lets prepare validate it and build the saga
+// data, persists the saga and then drives it inline. Only one saga runs per
+// order: a second request of the same type joins the running one, any other
+// type is rejected.
+func (s *Service) startSaga(
+	ctx context.Context,
+	orderID string,
+	sagaType string,
+	actor string,
+	prepare func(tx *sql.Tx, order *domain.Order) (map[string]any, error),
+) error {
+	tx, err := s.repo.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	order, err := s.repo.LockByIDTx(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+	if err := authorizeOrder(ctx, order); err != nil {
+		return err
+	}
+	active, err := s.sagas.FindActiveByOrderTx(ctx, tx, orderID)
+	if err == nil {
+		if active.SagaType == sagaType {
+			return ErrSagaPending
+		}
+		return domain.ErrSagaInProgress
+	}
+	if !errors.Is(err, domain.ErrSagaNotFound) {
+		return err
+	}
+
+	data, err := prepare(tx, order)
+	if errors.Is(err, errSagaNotNeeded) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	sg := domain.NewSaga(uuid.NewString(), sagaType, orderID, data)
+	// Recovery may finish the saga long after the request, so the history
+	// keeps who started it and under which trace.
+	sg.Data["actor"] = actor
+	sg.Data["trace_id"] = tracing.TraceID(ctx)
+	sg.NextRetryAt = time.Now().Add(sagaStepLease)
+	if err := s.sagas.InsertTx(ctx, tx, sg); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// The saga must not stop half way because the caller went away.
+	runCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sagaStepLease)
+	defer cancel()
+	return s.runSaga(runCtx, sg)
+}
+
+// transitionTx applies event to the order as part of a saga step and records
+// the transition with the saga's actor and trace ID.
+func (s *Service) transitionTx(ctx context.Context, tx *sql.Tx, sg *domain.Saga, from domain.Status, event domain.OrderEvent, reason string) error {
+	to, err := domain.NextStatus(from, event)
+	if err != nil {
+		return err
+	}
+	ok, err := s.repo.TransitionTx(ctx, tx, domain.StatusTransition{
+		OrderID:    sg.OrderID,
+		Event:      event,
+		FromStatus: from,
+		ToStatus:   to,
+		Actor:      sg.String("actor"),
+		Reason:     reason,
+		TraceID:    sg.String("trace_id"),
+	})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrInvalidStateTransfer
+	}
+	return nil
+}
+
+// sagaOutcome reloads the order after startSaga; a pending saga still
+// returns the order so callers can answer 202.
+func (s *Service) sagaOutcome(ctx context.Context, orderID string, runErr error) (*domain.Order, error) {
+	if runErr != nil && !errors.Is(runErr, ErrSagaPending) {
+		return nil, runErr
+	}
+	order, err := s.repo.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return order, runErr
+}
+
+// StartSagaRecovery resumes sagas that were interrupted by a failure or a
+// restart once their retry time is due.
+func (s *Service) StartSagaRecovery(ctx context.Context) {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.recoverSagas(ctx, 50)
+		}
+	}
+}
+
+func (s *Service) recoverSagas(ctx context.Context, limit int) {
+	listCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	due, err := s.sagas.ListDue(listCtx, limit)
+	cancel()
+	if err != nil {
+		s.logger.Error("load due sagas failed", "error", err)
+		return
+	}
+	for _, sg := range due {
+		claimed, err := s.sagas.Claim(ctx, sg.SagaID, time.Now().Add(sagaStepLease))
+		if err != nil {
+			s.logger.Error("claim saga failed", "error", err, "saga_id", sg.SagaID)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		runCtx, runCancel := context.WithTimeout(ctx, sagaStepLease)
+		err = s.runSaga(runCtx, sg)
+		runCancel()
+		if err != nil && !errors.Is(err, ErrSagaPending) {
+			s.logger.Warn("saga finished with failure", "saga_id", sg.SagaID, "saga_type", sg.SagaType, "error", err)
+		}
+	}
+}
+
+// runSaga drives the saga until it is terminal or a step has to wait for a
+// retry. It returns nil on completion, ErrSagaPending while waiting, and the
+// error that triggered compensation once the saga has failed.
+func (s *Service) runSaga(ctx context.Context, sg *domain.Saga) error {
+	def, err := s.sagaDefinition(sg.SagaType)
+	if err != nil {
+		return err
+	}
+	return s.driveSaga(ctx, def, sg)
+}
+
+func (s *Service) driveSaga(ctx context.Context, def *sagaDefinition, sg *domain.Saga) error {
+	var (
+		cause error
+		err   error
+	)
+	for !sg.IsTerminal() {
+		if sg.Step < 0 || sg.Step >= len(def.steps) {
+			return fmt.Errorf("saga %s step %d out of range", sg.SagaID, sg.Step)
+		}
+		step := def.steps[sg.Step]
+		if sg.Status == domain.SagaStatusRunning {
+			err = s.forwardSagaStep(ctx, def, sg, step)
+		} else {
+			err = s.compensateSagaStep(ctx, def, sg, step)
+		}
+		if err == nil {
+			continue
+		}
+		if !s.handleSagaFailure(ctx, def, sg, step, err) {
+			return ErrSagaPending
+		}
+		cause = err
+	}
+	if sg.Status == domain.SagaStatusFailed {
+		if cause != nil {
+			return cause
+		}
+		return fmt.Errorf("%w: %s", ErrSagaFailed, sg.LastError)
+	}
+	return nil
+}
+
+func (s *Service) forwardSagaStep(ctx context.Context, def *sagaDefinition, sg *domain.Saga, step sagaStep) error {
+	if step.action != nil {
+		if err := step.action(ctx, sg); err != nil {
+			return err
+		}
+	}
+	return s.advanceSaga(ctx, def, sg, step, domain.SagaPhaseForward, step.local)
+}
+
+func (s *Service) compensateSagaStep(ctx context.Context, def *sagaDefinition, sg *domain.Saga, step sagaStep) error {
+	if step.compensate != nil {
+		if err := step.compensate(ctx, sg); err != nil {
+			return err
+		}
+	}
+	return s.advanceSaga(ctx, def, sg, step, domain.SagaPhaseCompensate, nil)
+}
+
+// advanceSaga applies the local part of a step, moves the saga on and
+// appends to the step log in a single transaction.
+func (s *Service) advanceSaga(
+	ctx context.Context,
+	def *sagaDefinition,
+	sg *domain.Saga,
+	step sagaStep,
+	phase string,
+	local func(ctx context.Context, tx *sql.Tx, sg *domain.Saga) error,
+) error {
+	tx, err := s.repo.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if local != nil {
+		if err := local(ctx, tx, sg); err != nil {
+			return err
+		}
+	}
+	next := *sg
+	if phase == domain.SagaPhaseForward {
+		err = next.Forward(len(def.steps))
+	} else {
+		err = next.Backward()
+	}
+	if err != nil {
+		return err
+	}
+	next.NextRetryAt = time.Now().Add(sagaStepLease)
+	if next.Status == domain.SagaStatusCompleted && def.onComplete != nil {
+		if err := def.onComplete(ctx, tx, &next); err != nil {
+			return err
+		}
+	}
+	if next.Status == domain.SagaStatusFailed && def.onFail != nil {
+		if err := def.onFail(ctx, tx, &next); err != nil {
+			return err
+		}
+	}
+	if err := s.sagas.SaveTx(ctx, tx, &next); err != nil {
+		return err
+	}
+	if err := s.sagas.AppendStepTx(ctx, tx, domain.SagaStepLog{
+		SagaID:   sg.SagaID,
+		Step:     sg.Step,
+		StepName: step.name,
+		Phase:    phase,
+		Outcome:  domain.SagaOutcomeDone,
+	}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	*sg = next
+	return nil
+}
+
+// handleSagaFailure records a failed step and reports whether the saga can
+// keep running inline (it switched to compensation or failed for good) or
+// must wait for a retry. A waiting step is not a failure and is only checked
+// again later. Compensations are retried until they succeed. A
+// step past the pivot that hits an invalid order transition would fail the
+// same way on every retry, so the saga fails without compensation.
+func (s *Service) handleSagaFailure(ctx context.Context, def *sagaDefinition, sg *domain.Saga, step sagaStep, cause error) bool {
+	if errors.Is(cause, errSagaWaiting) {
+		sg.NextRetryAt = time.Now().Add(sagaWaitPoll)
+		if err := s.sagas.Save(ctx, sg); err != nil {
+			s.logger.Error("save saga wait failed", "error", err, "saga_id", sg.SagaID)
+		}
+		return false
+	}
+	s.logger.Warn("saga step failed", "saga_id", sg.SagaID, "saga_type", sg.SagaType, "step", step.name, "status", sg.Status, "error", cause)
+	phase := domain.SagaPhaseForward
+	if sg.Status == domain.SagaStatusCompensating {
+		phase = domain.SagaPhaseCompensate
+	}
+	sg.Attempts++
+	sg.LastError = truncateError(cause, 240)
+	if err := s.sagas.AppendStep(ctx, domain.SagaStepLog{
+		SagaID:   sg.SagaID,
+		Step:     sg.Step,
+		StepName: step.name,
+		Phase:    phase,
+		Outcome:  domain.SagaOutcomeFailed,
+		Error:    sg.LastError,
+	}); err != nil {
+		s.logger.Error("append saga step log failed", "error", err, "saga_id", sg.SagaID)
+	}
+
+	if sg.Status == domain.SagaStatusRunning && !def.compensable(sg.Step) &&
+		errors.Is(cause, domain.ErrInvalidStateTransfer) {
+		if err := s.abortSaga(ctx, def, sg); err != nil {
+			s.logger.Error("abort saga failed", "error", err, "saga_id", sg.SagaID)
+			sg.NextRetryAt = time.Now().Add(retryBackoff(sg.Attempts))
+			if err := s.sagas.Save(ctx, sg); err != nil {
+				s.logger.Error("save saga retry failed", "error", err, "saga_id", sg.SagaID)
+			}
+			return false
+		}
+		s.logger.Error("saga aborted past its pivot", "saga_id", sg.SagaID, "saga_type", sg.SagaType, "step", step.name, "error", cause)
+		return true
+	}
+
+	if sg.Status == domain.SagaStatusRunning && def.compensable(sg.Step) &&
+		(isPermanentSagaError(cause) || sg.Attempts >= sagaMaxAttempts) {
+		_ = sg.StartCompensation()
+		sg.NextRetryAt = time.Now().Add(sagaStepLease)
+		if err := s.sagas.Save(ctx, sg); err != nil {
+			s.logger.Error("save saga compensation failed", "error", err, "saga_id", sg.SagaID)
+			return false
+		}
+		return true
+	}
+
+	sg.NextRetryAt = time.Now().Add(retryBackoff(sg.Attempts))
+	if err := s.sagas.Save(ctx, sg); err != nil {
+		s.logger.Error("save saga retry failed", "error", err, "saga_id", sg.SagaID)
+	}
+	return false
+}
+
+// abortSaga fails sg for good and runs the definition's onFail in the same
+// transaction.
+func (s *Service) abortSaga(ctx context.Context, def *sagaDefinition, sg *domain.Saga) error {
+	tx, err := s.repo.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	next := *sg
+	if err := next.Abort(); err != nil {
+		return err
+	}
+	if def.onFail != nil {
+		if err := def.onFail(ctx, tx, &next); err != nil {
+			return err
+		}
+	}
+	if err := s.sagas.SaveTx(ctx, tx, &next); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	*sg = next
+	return nil
+}
+
+func isPermanentSagaError(err error) bool {
+	return errors.Is(err, inventory.ErrRequestRejected) ||
+		errors.Is(err, inventory.ErrHoldNotFound) ||
+		errors.Is(err, domain.ErrInvalidStateTransfer) ||
+		errors.Is(err, errFareNotCollected)
+}
+
+func retryBackoff(attempts int) time.Duration {
+	if attempts <= 0 {
+		return time.Second
+	}
+	if attempts > 6 {
+		attempts = 6
+	}
+	return time.Duration(1<<uint(attempts-1)) * time.Second
+}
+
+func truncateError(err error, maxLen int) string {
+	if err == nil {
+		return ""
+	}
+	msg := strings.TrimSpace(err.Error())
+	if len(msg) <= maxLen {
+		return msg
+	}
+	return msg[:maxLen]
+}
+
+func ignoreHoldNotFound(err error) error {
+	if errors.Is(err, inventory.ErrHoldNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,270 @@
+package application
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"ticketing/internal/common/sqltest"
+	"ticketing/internal/order/domain"
+	"ticketing/internal/order/infrastructure/inventory"
+)
+
+type fakeOrderRepo struct {
+	orderRepository
+	db          *sql.DB
+	rejected    bool
+	transitions []domain.StatusTransition
+}
+
+func (r *fakeOrderRepo) DB() *sql.DB { return r.db }
+
+func (r *fakeOrderRepo) TransitionTx(_ context.Context, _ *sql.Tx, t domain.StatusTransition) (bool, error) {
+	if r.rejected {
+		return false, nil
+	}
+	r.transitions = append(r.transitions, t)
+	return true, nil
+}
+
+type fakeSagaStore struct {
+	sagaStore
+	// active, if set, is the saga running on every order.
+	active   *domain.Saga
+	inserted []domain.Saga
+	saved    []domain.Saga
+	log      []domain.SagaStepLog
+}
+
+func (f *fakeSagaStore) FindActiveByOrderTx(context.Context, *sql.Tx, string) (*domain.Saga, error) {
+	if f.active != nil {
+		return f.active, nil
+	}
+	return nil, domain.ErrSagaNotFound
+}
+
+func (f *fakeSagaStore) InsertTx(_ context.Context, _ *sql.Tx, sg *domain.Saga) error {
+	f.inserted = append(f.inserted, *sg)
+	return nil
+}
+
+func (f *fakeSagaStore) Save(_ context.Context, sg *domain.Saga) error {
+	f.saved = append(f.saved, *sg)
+	return nil
+}
+
+func (f *fakeSagaStore) SaveTx(_ context.Context, _ *sql.Tx, sg *domain.Saga) error {
+	f.saved = append(f.saved, *sg)
+	return nil
+}
+
+func (f *fakeSagaStore) AppendStep(_ context.Context, entry domain.SagaStepLog) error {
+	f.log = append(f.log, entry)
+	return nil
+}
+
+func (f *fakeSagaStore) AppendStepTx(_ context.Context, _ *sql.Tx, entry domain.SagaStepLog) error {
+	f.log = append(f.log, entry)
+	return nil
+}
+
+func newSagaTestService() (*Service, *fakeOrderRepo, *fakeSagaStore) {
+	repo := &fakeOrderRepo{db: sqltest.NewDB()}
+	sagas := &fakeSagaStore{}
+	return &Service{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		repo:   repo,
+		sagas:  sagas,
+	}, repo, sagas
+}
+
+// recordingSaga builds a saga definition whose steps append to calls and
+// fail with the error failing holds for their name.
+func recordingSaga(names []string, pivot string, failing map[string]error, calls *[]string) *sagaDefinition {
+	def := &sagaDefinition{
+		onComplete: func(context.Context, *sql.Tx, *domain.Saga) error {
+			*calls = append(*calls, "complete")
+			return nil
+		},
+		onFail: func(context.Context, *sql.Tx, *domain.Saga) error {
+			*calls = append(*calls, "fail")
+			return nil
+		},
+	}
+	for _, name := range names {
+		def.steps = append(def.steps, sagaStep{
+			name:  name,
+			pivot: name == pivot,
+			action: func(context.Context, *domain.Saga) error {
+				*calls = append(*calls, name)
+				return failing[name]
+			},
+			compensate: func(context.Context, *domain.Saga) error {
+				*calls = append(*calls, "undo "+name)
+				return nil
+			},
+		})
+	}
+	return def
+}
+
+func TestRunSaga_RunsStepsInOrderUntilComplete(t *testing.T) {
+	t.Parallel()
+
+	svc, _, sagas := newSagaTestService()
+	var calls []string
+	def := recordingSaga([]string{"hold", "confirm", "mark"}, "", nil, &calls)
+	sg := domain.NewSaga("saga-1", domain.SagaTypeReserve, "order-1", nil)
+
+	if err := svc.driveSaga(context.Background(), def, sg); err != nil {
+		t.Fatalf("expected saga to complete, got: %v", err)
+	}
+	if got, want := calls, []string{"hold", "confirm", "mark", "complete"}; !slices.Equal(got, want) {
+		t.Fatalf("expected calls %v, got %v", want, got)
+	}
+	if sg.Status != domain.SagaStatusCompleted {
+		t.Fatalf("expected COMPLETED, got %s", sg.Status)
+	}
+	if len(sagas.log) != 3 || sagas.log[2].StepName != "mark" || sagas.log[2].Outcome != domain.SagaOutcomeDone {
+		t.Fatalf("expected three DONE step logs, got %+v", sagas.log)
+	}
+}
+
+func TestRunSaga_CompensatesFinishedStepsInReverseOrder(t *testing.T) {
+	t.Parallel()
+
+	svc, _, sagas := newSagaTestService()
+	var calls []string
+	def := recordingSaga([]string{"hold", "confirm", "mark"}, "", map[string]error{"mark": inventory.ErrRequestRejected}, &calls)
+	sg := domain.NewSaga("saga-2", domain.SagaTypeReserve, "order-2", nil)
+
+	err := svc.driveSaga(context.Background(), def, sg)
+	if !errors.Is(err, inventory.ErrRequestRejected) {
+		t.Fatalf("expected the failing step's error, got: %v", err)
+	}
+	want := []string{"hold", "confirm", "mark", "undo mark", "undo confirm", "undo hold", "fail"}
+	if !slices.Equal(calls, want) {
+		t.Fatalf("expected calls %v, got %v", want, calls)
+	}
+	if sg.Status != domain.SagaStatusFailed || sg.LastError == "" {
+		t.Fatalf("expected FAILED with the cause kept, got %s (%q)", sg.Status, sg.LastError)
+	}
+	var failed int
+	for _, entry := range sagas.log {
+		if entry.Outcome == domain.SagaOutcomeFailed {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Fatalf("expected one failed step log, got %d", failed)
+	}
+}
+
+func TestHandleSagaFailure_SchedulesRetryForTransientErrors(t *testing.T) {
+	t.Parallel()
+
+	svc, _, sagas := newSagaTestService()
+	var calls []string
+	def := recordingSaga([]string{"hold", "confirm"}, "", map[string]error{"confirm": errors.New("inventory unavailable")}, &calls)
+	sg := domain.NewSaga("saga-3", domain.SagaTypeReserve, "order-3", nil)
+
+	err := svc.driveSaga(context.Background(), def, sg)
+	if !errors.Is(err, ErrSagaPending) {
+		t.Fatalf("expected ErrSagaPending, got: %v", err)
+	}
+	if sg.Status != domain.SagaStatusRunning || sg.Step != 1 || sg.Attempts != 1 {
+		t.Fatalf("expected RUNNING at step 1 after one attempt, got %s at %d (%d)", sg.Status, sg.Step, sg.Attempts)
+	}
+	if !sg.NextRetryAt.After(time.Now()) {
+		t.Fatal("expected the retry to be scheduled in the future")
+	}
+	if last := sagas.saved[len(sagas.saved)-1]; last.Attempts != 1 || last.Status != domain.SagaStatusRunning {
+		t.Fatalf("expected the retry to be saved, got %+v", last)
+	}
+}
+
+func TestHandleSagaFailure_CompensatesAfterLastAttempt(t *testing.T) {
+	t.Parallel()
+
+	svc, _, _ := newSagaTestService()
+	var calls []string
+	def := recordingSaga([]string{"hold", "confirm"}, "", nil, &calls)
+	sg := domain.NewSaga("saga-4", domain.SagaTypeReserve, "order-4", nil)
+	sg.Step = 1
+	sg.Attempts = sagaMaxAttempts - 1
+
+	if !svc.handleSagaFailure(context.Background(), def, sg, def.steps[1], errors.New("inventory unavailable")) {
+		t.Fatal("expected the saga to keep running inline")
+	}
+	if sg.Status != domain.SagaStatusCompensating || sg.Step != 1 {
+		t.Fatalf("expected COMPENSATING from step 1, got %s at %d", sg.Status, sg.Step)
+	}
+}
+
+func TestHandleSagaFailure_RetriesPastThePivot(t *testing.T) {
+	t.Parallel()
+
+	svc, _, _ := newSagaTestService()
+	var calls []string
+	def := recordingSaga([]string{"hold", "return_old", "reissue"}, "return_old", nil, &calls)
+	sg := domain.NewSaga("saga-5", domain.SagaTypeChange, "order-5", nil)
+	sg.Step = 1
+	sg.Attempts = sagaMaxAttempts
+
+	if svc.handleSagaFailure(context.Background(), def, sg, def.steps[1], inventory.ErrRequestRejected) {
+		t.Fatal("expected the pivot to wait for a retry")
+	}
+	if sg.Status != domain.SagaStatusRunning || sg.Step != 1 {
+		t.Fatalf("expected RUNNING at the pivot, got %s at %d", sg.Status, sg.Step)
+	}
+	if len(calls) != 0 {
+		t.Fatalf("expected nothing to be compensated, got %v", calls)
+	}
+}
+
+func TestHandleSagaFailure_FailsOnInvalidTransitionPastThePivot(t *testing.T) {
+	t.Parallel()
+
+	svc, _, sagas := newSagaTestService()
+	var calls []string
+	def := recordingSaga([]string{"hold", "return_old", "reissue"}, "return_old", nil, &calls)
+	sg := domain.NewSaga("saga-6", domain.SagaTypeChange, "order-6", nil)
+	sg.Step = 2
+
+	if !svc.handleSagaFailure(context.Background(), def, sg, def.steps[2], domain.ErrInvalidStateTransfer) {
+		t.Fatal("expected the saga to end inline")
+	}
+	if sg.Status != domain.SagaStatusFailed {
+		t.Fatalf("expected FAILED, got %s", sg.Status)
+	}
+	if !slices.Equal(calls, []string{"fail"}) {
+		t.Fatalf("expected only onFail to run, got %v", calls)
+	}
+	if last := sagas.saved[len(sagas.saved)-1]; last.Status != domain.SagaStatusFailed {
+		t.Fatalf("expected FAILED to be saved, got %s", last.Status)
+	}
+}
+
+func TestRunSaga_CancelOfUncancellableOrderFails(t *testing.T) {
+	t.Parallel()
+
+	svc, repo, _ := newSagaTestService()
+	repo.rejected = true
+	sg := domain.NewSaga("saga-7", domain.SagaTypeCancel, "order-7", map[string]any{
+		"from_status": string(domain.StatusReserved),
+	})
+
+	// inventoryClient is nil: release_hold must not run.
+	err := svc.runSaga(context.Background(), sg)
+	if !errors.Is(err, domain.ErrInvalidStateTransfer) {
+		t.Fatalf("expected ErrInvalidStateTransfer, got: %v", err)
+	}
+	if sg.Status != domain.SagaStatusFailed || sg.Step != 0 {
+		t.Fatalf("expected FAILED at step 0, got %s at %d", sg.Status, sg.Step)
+	}
+}
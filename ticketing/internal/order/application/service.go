@@ -2,10 +2,7 @@ package application
 
 import (
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
 	"database/sql"
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -15,53 +12,154 @@ import (
 	"github.com/go-sql-driver/mysql"
 	"github.com/google/uuid"
 
+	"ticketing/internal/common/auth"
+	"ticketing/internal/common/tracing"
 	"ticketing/internal/order/domain"
+	"ticketing/internal/order/infrastructure/change"
 	"ticketing/internal/order/infrastructure/event"
 	"ticketing/internal/order/infrastructure/inventory"
 	"ticketing/internal/order/infrastructure/outbox"
+	"ticketing/internal/order/infrastructure/payment"
 	"ticketing/internal/order/infrastructure/repository"
+	"ticketing/internal/order/infrastructure/saga"
 )
 
 var (
-	ErrInvalidPaymentStatus = errors.New("invalid payment status")
-	ErrInvalidSignature     = errors.New("invalid payment signature")
+	ErrInvalidPaymentStatus   = errors.New("invalid payment status")
+	ErrInvalidPaymentAmount   = errors.New("payment amount and currency are required")
+	ErrInvalidSignature       = payment.ErrInvalidSignature
+	ErrIdempotencyKeyConflict = errors.New("idempotency key already used by another user")
 )
 
 type Config struct {
 	DefaultPartitionKey string
 	DefaultHoldQty      int
 	DefaultCapacity     int
-	PaymentSignKey      string
+	// PaymentTTL is how long a payment intent stays open.
+	PaymentTTL time.Duration
+	// PaymentReconcileAfter is how long a payment may stay PENDING before
+	// the reconciler asks the provider about it.
+	PaymentReconcileAfter time.Duration
+	// DailyBookingLimitPerID caps the orders one ID document can be booked
+	// on per day; zero disables the limit.
+	DailyBookingLimitPerID int
+	// CancelOnPaymentClosed cancels a RESERVED order and releases its hold
+	// as soon as its last open payment is CLOSED, instead of waiting for
+	// the hold to expire.
+	CancelOnPaymentClosed bool
+	// OutboxMaxAttempts is how many times an event is published before it
+	// is dead-lettered. A dead event blocks the later events of its order
+	// until it is replayed.
+	OutboxMaxAttempts int
+}
+
+// orderRepository is the order, passenger, payment and refund storage the
+// service works on.
+type orderRepository interface {
+	CompletePaymentTx(ctx context.Context, tx *sql.Tx, paymentID string, orderID string, providerTxnID string, status domain.PaymentStatus, paidAmountCents int64, paidCurrency string) (bool, error)
+	CountBookingsTx(ctx context.Context, tx *sql.Tx, p domain.OrderPassenger, bookingDate string) (int, error)
+	CountOverlappingTripsTx(ctx context.Context, tx *sql.Tx, p domain.OrderPassenger, w domain.TravelWindow, excludeOrderID string) (int, error)
+	DB() *sql.DB
+	DeletePassengersTx(ctx context.Context, tx *sql.Tx, orderID string) error
+	FailPaymentTx(ctx context.Context, tx *sql.Tx, paymentID string, orderID string, providerTxnID string, status domain.PaymentStatus) (bool, error)
+	FindByID(ctx context.Context, orderID string) (*domain.Order, error)
+	FindByIdempotencyKey(ctx context.Context, key string) (*domain.Order, error)
+	FindOpenPaymentTx(ctx context.Context, tx *sql.Tx, orderID string, now time.Time) (*domain.Payment, error)
+	FindPaidPaymentTx(ctx context.Context, tx *sql.Tx, orderID string, amountCents int64) (*domain.Payment, error)
+	FindPayment(ctx context.Context, paymentID string) (*domain.Payment, error)
+	FindRefund(ctx context.Context, refundID string) (*domain.Refund, error)
+	FinishRefundTx(ctx context.Context, tx *sql.Tx, refundID string, status domain.RefundStatus, providerRefundID string, lastError string) (bool, error)
+	InsertFailedPaymentTx(ctx context.Context, tx *sql.Tx, paymentID string, orderID string, providerTxnID string, status domain.PaymentStatus) error
+	InsertHistoryTx(ctx context.Context, tx *sql.Tx, t domain.StatusTransition) error
+	InsertPassengersTx(ctx context.Context, tx *sql.Tx, orderID string, passengers []domain.OrderPassenger, w domain.TravelWindow, bookingDate string) error
+	InsertPaymentIntentTx(ctx context.Context, tx *sql.Tx, p *domain.Payment) error
+	InsertPaymentTx(ctx context.Context, tx *sql.Tx, paymentID string, orderID string, providerTxnID string, status string, paidAmountCents int64, paidCurrency string) error
+	InsertRefundTx(ctx context.Context, tx *sql.Tx, rf *domain.Refund) error
+	InsertTx(ctx context.Context, tx *sql.Tx, order *domain.Order) error
+	ListHistory(ctx context.Context, orderID string) ([]domain.StatusTransition, error)
+	ListOrders(ctx context.Context, f domain.OrderFilter) ([]*domain.Order, error)
+	ListPassengersTx(ctx context.Context, tx *sql.Tx, orderID string) ([]domain.OrderPassenger, error)
+	ListPendingRefunds(ctx context.Context, limit int) ([]*domain.Refund, error)
+	ListStalePendingPayments(ctx context.Context, createdBefore time.Time, limit int) ([]*domain.Payment, error)
+	LockByIDTx(ctx context.Context, tx *sql.Tx, orderID string) (*domain.Order, error)
+	LockPassengerDocumentsTx(ctx context.Context, tx *sql.Tx, passengers []domain.OrderPassenger) error
+	PaymentTxnRecordedTx(ctx context.Context, tx *sql.Tx, providerTxnID string) (bool, error)
+	RecordRefundAttempt(ctx context.Context, refundID string, lastError string) error
+	TransitionTx(ctx context.Context, tx *sql.Tx, t domain.StatusTransition) (bool, error)
+	UpdateAmountTx(ctx context.Context, tx *sql.Tx, orderID string, amountCents int64) error
+	UpdateHoldTx(ctx context.Context, tx *sql.Tx, orderID string, partitionKey string, holdID string) error
+	UpdateItineraryTx(ctx context.Context, tx *sql.Tx, orderID string, qty int, fromIndex int, toIndex int, keepTogether bool) error
+	UpdatePassengerWindowTx(ctx context.Context, tx *sql.Tx, orderID string, w domain.TravelWindow) error
+	UpdatePaymentPrepay(ctx context.Context, paymentID string, prepayID string, expiresAt time.Time) error
+	UpdatePaymentStatus(ctx context.Context, paymentID string, from domain.PaymentStatus, to domain.PaymentStatus) (bool, error)
+	UpdatePaymentStatusTx(ctx context.Context, tx *sql.Tx, paymentID string, from domain.PaymentStatus, to domain.PaymentStatus) (bool, error)
 }
 
 type Service struct {
 	logger          *slog.Logger
-	repo            *repository.Repository
-	outbox          *outbox.Repository
-	publisher       *event.Publisher
-	inventoryClient *inventory.Client
+	repo            orderRepository
+	outbox          outboxStore
+	changes         changeStore
+	sagas           sagaStore
+	publisher       eventPublisher
+	inventoryClient inventory.API
+	payments        payment.Provider
 	cfg             Config
 }
 
 type CreateOrderInput struct {
 	IdempotencyKey string
+	UserID         string
 	AmountCents    int64
 }
 
+// ListOrdersInput carries the raw listing filters; timestamps are RFC 3339
+// and TravelDate is YYYY-MM-DD.
+type ListOrdersInput struct {
+	UserID      string
+	Status      string
+	CreatedFrom string
+	CreatedTo   string
+	TrainNo     string
+	TravelDate  string
+	Cursor      string
+	Limit       int
+}
+
+// ReserveOrderInput reserves one seat for each of Passengers, of which there
+// must be at least one, so every booking is bound by the real-name rules.
+// DepartAt and ArriveAt (RFC 3339) bound the trip for real-name checks and
+// default to the whole travel date. FromIndex and ToIndex are the stations
+// the seats are allocated between; ticket-worker picks a default range when
+// both are zero. KeepTogether asks for the passengers to sit side by side.
 type ReserveOrderInput struct {
 	OrderID      string
 	PartitionKey string
 	HoldID       string
-	Qty          int
 	Capacity     int
+	Passengers   []domain.OrderPassenger
+	DepartAt     string
+	ArriveAt     string
+	FromIndex    int
+	ToIndex      int
+	KeepTogether bool
 }
 
+// PaymentCallbackInput is a provider notification. PaymentID is set for
+// payments started through PayOrder; AmountCents and Currency are what was
+// actually paid. KeyID, Timestamp and Nonce are part of the signature.
 type PaymentCallbackInput struct {
 	OrderID       string
+	PaymentID     string
 	ProviderTxnID string
 	Status        string
+	AmountCents   int64
+	Currency      string
 	PartitionKey  string
 	HoldID        string
+	KeyID         string
+	Timestamp     int64
+	Nonce         string
 	Signature     string
 }
 
@@ -75,8 +173,11 @@ func NewService(
 	logger *slog.Logger,
 	repo *repository.Repository,
 	outboxRepo *outbox.Repository,
+	changeRepo *change.Repository,
+	sagaRepo *saga.Repository,
 	publisher *event.Publisher,
-	inventoryClient *inventory.Client,
+	inventoryClient inventory.API,
+	paymentProvider payment.Provider,
 	cfg Config,
 ) *Service {
 	if cfg.DefaultPartitionKey == "" {
@@ -88,12 +189,24 @@ func NewService(
 	if cfg.DefaultCapacity <= 0 {
 		cfg.DefaultCapacity = 500
 	}
+	if cfg.PaymentTTL <= 0 {
+		cfg.PaymentTTL = 15 * time.Minute
+	}
+	if cfg.PaymentReconcileAfter <= 0 {
+		cfg.PaymentReconcileAfter = 30 * time.Second
+	}
+	if cfg.OutboxMaxAttempts <= 0 {
+		cfg.OutboxMaxAttempts = 10
+	}
 	return &Service{
 		logger:          logger,
 		repo:            repo,
 		outbox:          outboxRepo,
+		changes:         changeRepo,
+		sagas:           sagaRepo,
 		publisher:       publisher,
 		inventoryClient: inventoryClient,
+		payments:        paymentProvider,
 		cfg:             cfg,
 	}
 }
@@ -101,7 +214,7 @@ func NewService(
 func (s *Service) CreateOrder(ctx context.Context, in CreateOrderInput) (*domain.Order, error) {
 	existing, err := s.repo.FindByIdempotencyKey(ctx, in.IdempotencyKey)
 	if err == nil {
-		return existing, nil
+		return s.replayCreate(ctx, existing)
 	}
 	if !errors.Is(err, domain.ErrOrderNotFound) {
 		return nil, err
@@ -111,6 +224,7 @@ func (s *Service) CreateOrder(ctx context.Context, in CreateOrderInput) (*domain
 	if err != nil {
 		return nil, err
 	}
+	order.UserID = strings.TrimSpace(in.UserID)
 
 	tx, err := s.repo.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
 	if err != nil {
@@ -122,12 +236,23 @@ func (s *Service) CreateOrder(ctx context.Context, in CreateOrderInput) (*domain
 		if stringsHasDuplicate(err) {
 			existingOrder, e := s.repo.FindByIdempotencyKey(ctx, in.IdempotencyKey)
 			if e == nil {
-				return existingOrder, nil
+				return s.replayCreate(ctx, existingOrder)
 			}
 		}
 		return nil, err
 	}
 
+	if err := s.repo.InsertHistoryTx(ctx, tx, domain.StatusTransition{
+		OrderID:  order.OrderID,
+		Event:    domain.EventCreate,
+		ToStatus: order.Status,
+		Actor:    callerActor(ctx, domain.ActorCustomer),
+		Reason:   "order created",
+		TraceID:  tracing.TraceID(ctx),
+	}); err != nil {
+		return nil, err
+	}
+
 	if err := s.outbox.InsertTx(ctx, tx, uuid.NewString(), order.OrderID, "OrderCreated", map[string]any{
 		"order_id":        order.OrderID,
 		"idempotency_key": order.IdempotencyKey,
@@ -143,259 +268,477 @@ func (s *Service) CreateOrder(ctx context.Context, in CreateOrderInput) (*domain
 	return s.repo.FindByID(ctx, order.OrderID)
 }
 
+// ReserveOrder holds inventory for the order through a RESERVE saga. It
+// returns ErrSagaPending with the order when a step is waiting for a retry.
 func (s *Service) ReserveOrder(ctx context.Context, in ReserveOrderInput) (*domain.Order, error) {
-	current, err := s.repo.FindByID(ctx, in.OrderID)
-	if err != nil {
+	if err := domain.ValidateStationRange(in.FromIndex, in.ToIndex); err != nil {
 		return nil, err
 	}
-	if current.Status == domain.StatusReserved {
-		return current, nil
+	passengers, err := domain.NormalizePassengers(in.Passengers)
+	if err != nil {
+		return nil, err
 	}
-	if current.Status != domain.StatusInit {
-		return nil, domain.ErrInvalidStateTransfer
+	if len(passengers) == 0 {
+		return nil, fmt.Errorf("%w: at least one passenger is required", domain.ErrInvalidPassenger)
 	}
-
-	partitionKey, holdID, qty, capacity := s.resolveHoldConfig(in.OrderID, in.PartitionKey, in.HoldID, in.Qty, in.Capacity)
-	if err := s.inventoryClient.TryHold(ctx, inventory.TryHoldInput{
-		PartitionKey: partitionKey,
-		HoldID:       holdID,
-		Qty:          qty,
-		Capacity:     capacity,
-	}); err != nil {
+	partitionKey, holdID, qty, capacity := s.resolveHoldConfig(in.OrderID, in.PartitionKey, in.HoldID, len(passengers), in.Capacity)
+	window, err := travelWindow(partitionKey, in.DepartAt, in.ArriveAt)
+	if err != nil {
 		return nil, err
 	}
-	shouldCompensateRelease := true
-	defer func() {
-		if !shouldCompensateRelease {
-			return
+	runErr := s.startSaga(ctx, in.OrderID, domain.SagaTypeReserve, callerActor(ctx, domain.ActorCustomer), func(tx *sql.Tx, current *domain.Order) (map[string]any, error) {
+		if current.Status == domain.StatusReserved {
+			return nil, errSagaNotNeeded
+		}
+		if current.Status != domain.StatusInit {
+			return nil, domain.ErrInvalidStateTransfer
 		}
-		releaseCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
-		if releaseErr := s.inventoryClient.ReleaseHold(releaseCtx, inventory.ReleaseInput{
-			PartitionKey: partitionKey,
-			HoldID:       holdID,
-		}); releaseErr != nil && !errors.Is(releaseErr, inventory.ErrHoldNotFound) {
-			s.logger.Error("reserve compensation release failed", "order_id", in.OrderID, "error", releaseErr)
+		if err := s.checkRealNameTx(ctx, tx, current.OrderID, passengers, window, true); err != nil {
+			return nil, err
+		}
+		if err := s.repo.InsertPassengersTx(ctx, tx, current.OrderID, passengers, window, domain.BookingDate(time.Now())); err != nil {
+			return nil, err
 		}
-	}()
+		return map[string]any{
+			"partition_key": partitionKey,
+			"hold_id":       holdID,
+			"qty":           qty,
+			"capacity":      capacity,
+			"from_index":    in.FromIndex,
+			"to_index":      in.ToIndex,
+			"keep_together": in.KeepTogether,
+		}, nil
+	})
+	return s.sagaOutcome(ctx, in.OrderID, runErr)
+}
 
-	tx, err := s.repo.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
-	if err != nil {
-		return nil, err
+func (s *Service) reserveSaga() *sagaDefinition {
+	return &sagaDefinition{
+		steps: []sagaStep{
+			{
+				name: "try_hold",
+				action: func(ctx context.Context, sg *domain.Saga) error {
+					return s.inventoryClient.TryHold(ctx, inventory.TryHoldInput{
+						PartitionKey: sg.String("partition_key"),
+						HoldID:       sg.String("hold_id"),
+						Qty:          sg.Int("qty"),
+						Capacity:     sg.Int("capacity"),
+					})
+				},
+				compensate: func(ctx context.Context, sg *domain.Saga) error {
+					return ignoreHoldNotFound(s.inventoryClient.ReleaseHold(ctx, inventory.ReleaseInput{
+						PartitionKey: sg.String("partition_key"),
+						HoldID:       sg.String("hold_id"),
+					}))
+				},
+			},
+			{
+				name: "mark_reserved",
+				local: func(ctx context.Context, tx *sql.Tx, sg *domain.Saga) error {
+					reason := fmt.Sprintf("hold %s on %s", sg.String("hold_id"), sg.String("partition_key"))
+					if err := s.transitionTx(ctx, tx, sg, domain.StatusInit, domain.EventReserve, reason); err != nil {
+						return err
+					}
+					if err := s.repo.UpdateHoldTx(ctx, tx, sg.OrderID, sg.String("partition_key"), sg.String("hold_id")); err != nil {
+						return err
+					}
+					if err := s.repo.UpdateItineraryTx(ctx, tx, sg.OrderID, sg.Int("qty"), sg.Int("from_index"), sg.Int("to_index"), sg.Bool("keep_together")); err != nil {
+						return err
+					}
+					return s.outbox.InsertTx(ctx, tx, uuid.NewString(), sg.OrderID, "OrderReserved", map[string]any{
+						"order_id":      sg.OrderID,
+						"status":        domain.StatusReserved,
+						"partition_key": sg.String("partition_key"),
+						"hold_id":       sg.String("hold_id"),
+						"hold_qty":      sg.Int("qty"),
+					})
+				},
+			},
+		},
+		onFail: func(ctx context.Context, tx *sql.Tx, sg *domain.Saga) error {
+			return s.repo.DeletePassengersTx(ctx, tx, sg.OrderID)
+		},
 	}
-	defer tx.Rollback()
+}
 
-	ok, err := s.repo.UpdateStatusTx(ctx, tx, in.OrderID, domain.StatusInit, domain.StatusReserved)
-	if err != nil {
-		return nil, err
-	}
-	if !ok {
-		current, qErr := s.repo.FindByID(ctx, in.OrderID)
-		if qErr != nil {
-			return nil, qErr
+// checkRealNameTx enforces the real-name rules for passengers travelling on
+// orderID during w: no document may hold another live order with an
+// overlapping trip, and a new booking must stay within the per-document
+// daily limit. The document locks keep concurrent reservations of the same
+// passenger from both passing until tx ends.
+func (s *Service) checkRealNameTx(ctx context.Context, tx *sql.Tx, orderID string, passengers []domain.OrderPassenger, w domain.TravelWindow, newBooking bool) error {
+	if err := s.repo.LockPassengerDocumentsTx(ctx, tx, passengers); err != nil {
+		return err
+	}
+	bookingDate := domain.BookingDate(time.Now())
+	for _, p := range passengers {
+		overlapping, err := s.repo.CountOverlappingTripsTx(ctx, tx, p, w, orderID)
+		if err != nil {
+			return err
 		}
-		if current.Status == domain.StatusReserved {
-			shouldCompensateRelease = false
-			return current, nil
+		if overlapping > 0 {
+			return fmt.Errorf("%w: %s", domain.ErrDuplicateTrip, p.MaskedIDNumber())
+		}
+		if !newBooking || s.cfg.DailyBookingLimitPerID <= 0 {
+			continue
+		}
+		booked, err := s.repo.CountBookingsTx(ctx, tx, p, bookingDate)
+		if err != nil {
+			return err
+		}
+		if booked >= s.cfg.DailyBookingLimitPerID {
+			return fmt.Errorf("%w: %s", domain.ErrDailyLimitExceeded, p.MaskedIDNumber())
 		}
-		return nil, domain.ErrInvalidStateTransfer
 	}
+	return nil
+}
 
-	if err := s.outbox.InsertTx(ctx, tx, uuid.NewString(), in.OrderID, "OrderReserved", map[string]any{
-		"order_id":      in.OrderID,
-		"status":        domain.StatusReserved,
-		"partition_key": partitionKey,
-		"hold_id":       holdID,
-		"hold_qty":      qty,
-	}); err != nil {
-		return nil, err
+// travelWindow is the explicit [departAt, arriveAt) when given, otherwise
+// the travel date of the partition key.
+func travelWindow(partitionKey string, departAt string, arriveAt string) (domain.TravelWindow, error) {
+	departAt, arriveAt = strings.TrimSpace(departAt), strings.TrimSpace(arriveAt)
+	if departAt == "" && arriveAt == "" {
+		_, travelDate := domain.ParsePartitionKey(partitionKey)
+		return domain.DefaultTravelWindow(travelDate)
 	}
-	if err := tx.Commit(); err != nil {
-		return nil, err
+	d, err := time.Parse(time.RFC3339, departAt)
+	if err != nil {
+		return domain.TravelWindow{}, fmt.Errorf("%w: depart_at", domain.ErrInvalidTravelWindow)
 	}
-	shouldCompensateRelease = false
-	return s.repo.FindByID(ctx, in.OrderID)
+	a, err := time.Parse(time.RFC3339, arriveAt)
+	if err != nil {
+		return domain.TravelWindow{}, fmt.Errorf("%w: arrive_at", domain.ErrInvalidTravelWindow)
+	}
+	return domain.NewTravelWindow(d, a)
 }
 
+// PaymentCallback handles a provider notification. Its nonce is accepted
+// only once handling succeeds, so the provider may retry a notification
+// that failed here.
 func (s *Service) PaymentCallback(ctx context.Context, in PaymentCallbackInput) (*domain.Order, error) {
-	if err := s.verifyPaymentSignature(in); err != nil {
+	n := payment.Notification{
+		OrderID:       in.OrderID,
+		PaymentID:     in.PaymentID,
+		ProviderTxnID: in.ProviderTxnID,
+		Status:        in.Status,
+		AmountCents:   in.AmountCents,
+		Currency:      in.Currency,
+		KeyID:         in.KeyID,
+		Timestamp:     in.Timestamp,
+		Nonce:         in.Nonce,
+		Signature:     in.Signature,
+	}
+	if err := s.payments.VerifyCallback(ctx, n); err != nil {
 		return nil, err
 	}
-	if !strings.EqualFold(in.Status, "SUCCESS") {
-		return nil, ErrInvalidPaymentStatus
-	}
-
-	partitionKey, holdID, _, _ := s.resolveHoldConfig(in.OrderID, in.PartitionKey, in.HoldID, 0, 0)
-	current, err := s.repo.FindByID(ctx, in.OrderID)
+	o, err := s.handlePaymentCallback(ctx, in)
 	if err != nil {
 		return nil, err
 	}
-	if current.Status == domain.StatusPaid || current.Status == domain.StatusTicketed {
-		return current, nil
-	}
-	if current.Status != domain.StatusReserved {
-		return nil, domain.ErrInvalidStateTransfer
-	}
-
-	if err := s.inventoryClient.ConfirmHold(ctx, inventory.ConfirmInput{
-		PartitionKey: partitionKey,
-		HoldID:       holdID,
-	}); err != nil && !errors.Is(err, inventory.ErrHoldNotFound) {
-		return nil, err
+	if err := s.payments.AcceptCallback(ctx, n); err != nil {
+		// Handling is idempotent, so a replay getting through is harmless.
+		s.logger.Warn("accept payment callback nonce failed", "error", err, "order_id", in.OrderID)
 	}
+	return o, nil
+}
 
-	tx, err := s.repo.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
-	if err != nil {
-		return nil, err
+func (s *Service) handlePaymentCallback(ctx context.Context, in PaymentCallbackInput) (*domain.Order, error) {
+	switch domain.PaymentStatus(strings.ToUpper(strings.TrimSpace(in.Status))) {
+	case domain.PaymentStatusSuccess:
+		if in.AmountCents <= 0 || strings.TrimSpace(in.Currency) == "" {
+			return nil, ErrInvalidPaymentAmount
+		}
+		return s.applyPaymentSuccess(ctx, domain.ActorPaymentProvider, in)
+	case domain.PaymentStatusFailed, domain.PaymentStatusClosed:
+		return s.applyPaymentFailure(ctx, domain.ActorPaymentProvider, in)
+	default:
+		return nil, ErrInvalidPaymentStatus
 	}
-	defer tx.Rollback()
+}
 
-	if err := s.repo.InsertPaymentTx(ctx, tx, uuid.NewString(), in.OrderID, in.ProviderTxnID, in.Status); err != nil {
-		var me *mysql.MySQLError
-		if errors.As(err, &me) && me.Number == 1062 {
-			existing, findErr := s.repo.FindByID(ctx, in.OrderID)
-			if findErr != nil {
-				return nil, findErr
-			}
-			return existing, nil
+// applyPaymentSuccess runs the PAY saga for a successful payment, whether a
+// callback reported it or the reconciler found it. A payment that does not
+// cover the order exactly parks it in PAYMENT_MISMATCH instead of PAID; one
+// for an order that no longer takes payments, or that another payment is
+// already being applied to, is refunded. The fare difference of a running
+// change is recorded for the change.
+func (s *Service) applyPaymentSuccess(ctx context.Context, actor string, in PaymentCallbackInput) (*domain.Order, error) {
+	runErr := s.startSaga(ctx, in.OrderID, domain.SagaTypePay, actor, func(_ *sql.Tx, current *domain.Order) (map[string]any, error) {
+		switch current.Status {
+		case domain.StatusPaid, domain.StatusTicketed, domain.StatusPaymentMismatch, domain.StatusCancelled:
+			return nil, errOrderSettled
 		}
-		return nil, err
-	}
+		if current.Status != domain.StatusReserved {
+			return nil, domain.ErrInvalidStateTransfer
+		}
+		partitionKey, holdID, _, _ := s.resolveHoldConfig(
+			in.OrderID,
+			firstNonEmpty(in.PartitionKey, current.PartitionKey),
+			firstNonEmpty(in.HoldID, current.HoldID),
+			0,
+			0,
+		)
+		return map[string]any{
+			"partition_key":         partitionKey,
+			"hold_id":               holdID,
+			"payment_id":            strings.TrimSpace(in.PaymentID),
+			"provider_txn_id":       in.ProviderTxnID,
+			"payment_status":        strings.ToUpper(strings.TrimSpace(in.Status)),
+			"paid_amount_cents":     in.AmountCents,
+			"paid_currency":         strings.ToUpper(strings.TrimSpace(in.Currency)),
+			"expected_amount_cents": current.AmountCents,
+			"amount_mismatch":       !current.PaymentCovers(in.AmountCents, in.Currency),
+		}, nil
+	})
+	if errors.Is(runErr, errOrderSettled) {
+		return s.refundSurplusPayment(ctx, actor, in)
+	}
+	if errors.Is(runErr, ErrSagaPending) || errors.Is(runErr, domain.ErrSagaInProgress) {
+		return s.applyPaymentBehindSaga(ctx, actor, in)
+	}
+	return s.sagaOutcome(ctx, in.OrderID, runErr)
+}
 
-	ok, err := s.repo.UpdateStatusTx(ctx, tx, in.OrderID, domain.StatusReserved, domain.StatusPaid)
-	if err != nil {
-		return nil, err
+func (s *Service) paySaga() *sagaDefinition {
+	return &sagaDefinition{
+		steps: []sagaStep{
+			{
+				name: "confirm_hold",
+				action: func(ctx context.Context, sg *domain.Saga) error {
+					if sg.Bool("amount_mismatch") {
+						// The seats stay held, not sold, until the mismatch is resolved.
+						return nil
+					}
+					return ignoreHoldNotFound(s.inventoryClient.ConfirmHold(ctx, inventory.ConfirmInput{
+						PartitionKey: sg.String("partition_key"),
+						HoldID:       sg.String("hold_id"),
+					}))
+				},
+				compensate: func(ctx context.Context, sg *domain.Saga) error {
+					if sg.Bool("amount_mismatch") {
+						return nil
+					}
+					return ignoreHoldNotFound(s.inventoryClient.ReturnConfirmed(ctx, inventory.ReturnInput{
+						PartitionKey: sg.String("partition_key"),
+						HoldID:       sg.String("hold_id"),
+					}))
+				},
+			},
+			{
+				name: "mark_paid",
+				local: func(ctx context.Context, tx *sql.Tx, sg *domain.Saga) error {
+					providerTxnID := sg.String("provider_txn_id")
+					if err := s.recordPaymentTx(ctx, tx, sg, providerTxnID); err != nil {
+						if stringsHasDuplicate(err) {
+							// The provider transaction is already recorded against another payment.
+							return domain.ErrInvalidStateTransfer
+						}
+						return err
+					}
+					if sg.Bool("amount_mismatch") {
+						return s.markPaymentMismatchTx(ctx, tx, sg)
+					}
+					if err := s.transitionTx(ctx, tx, sg, domain.StatusReserved, domain.EventPay, "payment "+providerTxnID); err != nil {
+						return err
+					}
+					return s.outbox.InsertTx(ctx, tx, uuid.NewString(), sg.OrderID, "OrderPaid", map[string]any{
+						"order_id":        sg.OrderID,
+						"provider_txn_id": providerTxnID,
+						"partition_key":   sg.String("partition_key"),
+						"hold_id":         sg.String("hold_id"),
+						"status":          domain.StatusPaid,
+						"trace_id":        sg.String("trace_id"),
+					})
+				},
+			},
+		},
 	}
-	if !ok {
-		current, qErr := s.repo.FindByID(ctx, in.OrderID)
-		if qErr != nil {
-			return nil, qErr
+}
+
+func (s *Service) CancelOrder(ctx context.Context, in CancelOrderInput) (*domain.Order, error) {
+	return s.cancelOrder(ctx, in, callerActor(ctx, domain.ActorCustomer), "cancelled on request")
+}
+
+func (s *Service) cancelOrder(ctx context.Context, in CancelOrderInput, actor string, reason string) (*domain.Order, error) {
+	runErr := s.startSaga(ctx, in.OrderID, domain.SagaTypeCancel, actor, func(_ *sql.Tx, current *domain.Order) (map[string]any, error) {
+		if current.Status == domain.StatusCancelled {
+			return nil, errSagaNotNeeded
 		}
-		if current.Status == domain.StatusPaid {
-			return current, nil
+		switch current.Status {
+		case domain.StatusInit, domain.StatusReserved:
+		case domain.StatusPaymentMismatch:
+			// Money was taken; only support cancels, after settling it.
+			if tracing.UserRole(ctx) != auth.RoleSupport {
+				return nil, domain.ErrInvalidStateTransfer
+			}
+		default:
+			return nil, domain.ErrInvalidStateTransfer
 		}
-		return nil, domain.ErrInvalidStateTransfer
-	}
+		partitionKey, holdID, _, _ := s.resolveHoldConfig(
+			in.OrderID,
+			firstNonEmpty(in.PartitionKey, current.PartitionKey),
+			firstNonEmpty(in.HoldID, current.HoldID),
+			0,
+			0,
+		)
+		return map[string]any{
+			"partition_key": partitionKey,
+			"hold_id":       holdID,
+			"from_status":   string(current.Status),
+			"reason":        reason,
+		}, nil
+	})
+	return s.sagaOutcome(ctx, in.OrderID, runErr)
+}
 
-	if err := s.outbox.InsertTx(ctx, tx, uuid.NewString(), in.OrderID, "OrderPaid", map[string]any{
-		"order_id":        in.OrderID,
-		"provider_txn_id": in.ProviderTxnID,
-		"partition_key":   partitionKey,
-		"hold_id":         holdID,
-		"status":          domain.StatusPaid,
-	}); err != nil {
-		return nil, err
+// cancelSaga commits the cancellation first and then releases the hold, so a
+// cancelled order never loses its release after a crash.
+func (s *Service) cancelSaga() *sagaDefinition {
+	return &sagaDefinition{
+		steps: []sagaStep{
+			{
+				name:  "mark_cancelled",
+				pivot: true,
+				local: func(ctx context.Context, tx *sql.Tx, sg *domain.Saga) error {
+					from := domain.Status(sg.String("from_status"))
+					if err := s.transitionTx(ctx, tx, sg, from, domain.EventCancel, firstNonEmpty(sg.String("reason"), "cancelled on request")); err != nil {
+						return err
+					}
+					return s.outbox.InsertTx(ctx, tx, uuid.NewString(), sg.OrderID, "OrderCancelled", map[string]any{
+						"order_id":      sg.OrderID,
+						"partition_key": sg.String("partition_key"),
+						"hold_id":       sg.String("hold_id"),
+						"status":        domain.StatusCancelled,
+					})
+				},
+			},
+			{
+				name: "release_hold",
+				action: func(ctx context.Context, sg *domain.Saga) error {
+					// Only reserved and mismatched orders still hold seats.
+					if from := domain.Status(sg.String("from_status")); from != domain.StatusReserved && from != domain.StatusPaymentMismatch {
+						return nil
+					}
+					return ignoreHoldNotFound(s.inventoryClient.ReleaseHold(ctx, inventory.ReleaseInput{
+						PartitionKey: sg.String("partition_key"),
+						HoldID:       sg.String("hold_id"),
+					}))
+				},
+			},
+		},
 	}
+}
 
-	if err := tx.Commit(); err != nil {
-		return nil, err
-	}
-	return s.repo.FindByID(ctx, in.OrderID)
+func stringsHasDuplicate(err error) bool {
+	var me *mysql.MySQLError
+	return errors.As(err, &me) && me.Number == 1062
 }
 
-func (s *Service) CancelOrder(ctx context.Context, in CancelOrderInput) (*domain.Order, error) {
-	current, err := s.repo.FindByID(ctx, in.OrderID)
+func (s *Service) GetOrder(ctx context.Context, orderID string) (*domain.Order, error) {
+	order, err := s.repo.FindByID(ctx, orderID)
 	if err != nil {
+		return nil, fmt.Errorf("query order failed: %w", err)
+	}
+	if err := authorizeOrder(ctx, order); err != nil {
 		return nil, err
 	}
-	if current.Status == domain.StatusCancelled {
-		return current, nil
+	return order, nil
+}
+
+func (s *Service) ListOrders(ctx context.Context, in ListOrdersInput) (*domain.OrderPage, error) {
+	f := domain.OrderFilter{
+		UserID:  strings.TrimSpace(in.UserID),
+		Status:  domain.Status(strings.ToUpper(strings.TrimSpace(in.Status))),
+		TrainNo: strings.TrimSpace(in.TrainNo),
+		Limit:   in.Limit,
 	}
-	if current.Status != domain.StatusInit && current.Status != domain.StatusReserved {
-		return nil, domain.ErrInvalidStateTransfer
+	if caller := tracing.UserID(ctx); caller != "" && tracing.UserRole(ctx) != auth.RoleSupport {
+		// Customers only ever see their own orders.
+		f.UserID = caller
 	}
-
-	partitionKey, holdID, _, _ := s.resolveHoldConfig(in.OrderID, in.PartitionKey, in.HoldID, 0, 0)
-	if current.Status == domain.StatusReserved {
-		if err := s.inventoryClient.ReleaseHold(ctx, inventory.ReleaseInput{
-			PartitionKey: partitionKey,
-			HoldID:       holdID,
-		}); err != nil && !errors.Is(err, inventory.ErrHoldNotFound) {
-			return nil, err
+	if f.Limit <= 0 {
+		f.Limit = domain.DefaultPageSize
+	}
+	if f.Limit > domain.MaxPageSize {
+		f.Limit = domain.MaxPageSize
+	}
+	var err error
+	if v := strings.TrimSpace(in.CreatedFrom); v != "" {
+		if f.CreatedFrom, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, fmt.Errorf("%w: created_from", domain.ErrInvalidFilter)
 		}
 	}
-
-	tx, err := s.repo.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
-	if err != nil {
-		return nil, err
+	if v := strings.TrimSpace(in.CreatedTo); v != "" {
+		if f.CreatedTo, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, fmt.Errorf("%w: created_to", domain.ErrInvalidFilter)
+		}
+	}
+	if v := strings.TrimSpace(in.TravelDate); v != "" {
+		if _, err := time.Parse(time.DateOnly, v); err != nil {
+			return nil, fmt.Errorf("%w: travel_date", domain.ErrInvalidFilter)
+		}
+		f.TravelDate = v
+	}
+	if v := strings.TrimSpace(in.Cursor); v != "" {
+		if f.After, err = domain.DecodeOrderCursor(v); err != nil {
+			return nil, err
+		}
 	}
-	defer tx.Rollback()
 
-	ok, err := s.repo.UpdateStatusTx(ctx, tx, in.OrderID, current.Status, domain.StatusCancelled)
+	// One extra row tells whether another page exists.
+	limit := f.Limit
+	f.Limit++
+	orders, err := s.repo.ListOrders(ctx, f)
 	if err != nil {
 		return nil, err
 	}
-	if !ok {
-		latest, qErr := s.repo.FindByID(ctx, in.OrderID)
-		if qErr != nil {
-			return nil, qErr
-		}
-		if latest.Status == domain.StatusCancelled {
-			return latest, nil
-		}
-		return nil, domain.ErrInvalidStateTransfer
+	page := &domain.OrderPage{Orders: orders}
+	if len(orders) > limit {
+		page.Orders = orders[:limit]
+		last := page.Orders[limit-1]
+		page.NextCursor = domain.OrderCursor{CreatedAt: last.CreatedAt, OrderID: last.OrderID}.Encode()
 	}
+	return page, nil
+}
 
-	if err := s.outbox.InsertTx(ctx, tx, uuid.NewString(), in.OrderID, "OrderCancelled", map[string]any{
-		"order_id":      in.OrderID,
-		"partition_key": partitionKey,
-		"hold_id":       holdID,
-		"status":        domain.StatusCancelled,
-	}); err != nil {
+func (s *Service) GetOrderHistory(ctx context.Context, orderID string) ([]domain.StatusTransition, error) {
+	order, err := s.repo.FindByID(ctx, orderID)
+	if err != nil {
 		return nil, err
 	}
-	if err := tx.Commit(); err != nil {
+	if err := authorizeOrder(ctx, order); err != nil {
 		return nil, err
 	}
-	return s.repo.FindByID(ctx, in.OrderID)
+	return s.repo.ListHistory(ctx, orderID)
 }
 
-func (s *Service) StartOutboxPublisher(ctx context.Context) {
-	ticker := time.NewTicker(500 * time.Millisecond)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			batchCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
-			events, err := s.outbox.ListPending(batchCtx, 100)
-			cancel()
-			if err != nil {
-				s.logger.Error("load pending outbox failed", "error", err)
-				continue
-			}
-			for _, ev := range events {
-				pubCtx, pubCancel := context.WithTimeout(ctx, 2*time.Second)
-				err := s.publisher.Publish(pubCtx, ev)
-				pubCancel()
-				if err != nil {
-					s.logger.Error("publish outbox failed", "error", err, "event_id", ev.EventID)
-					continue
-				}
-				markCtx, markCancel := context.WithTimeout(ctx, 2*time.Second)
-				err = s.outbox.MarkPublished(markCtx, ev.ID)
-				markCancel()
-				if err != nil {
-					s.logger.Error("mark outbox published failed", "error", err, "event_id", ev.EventID)
-				}
-			}
-		}
+// authorizeOrder hides orders of other users from an authenticated caller.
+// Support staff see every order, and orders created before accounts existed
+// stay reachable by ID.
+func authorizeOrder(ctx context.Context, order *domain.Order) error {
+	caller := tracing.UserID(ctx)
+	if caller == "" || order.UserID == "" || order.UserID == caller || tracing.UserRole(ctx) == auth.RoleSupport {
+		return nil
 	}
+	return domain.ErrOrderNotFound
 }
 
-func stringsHasDuplicate(err error) bool {
-	var me *mysql.MySQLError
-	return errors.As(err, &me) && me.Number == 1062
+// callerActor names the authenticated user in the status history and falls
+// back to the generic actor for unauthenticated paths.
+func callerActor(ctx context.Context, fallback string) string {
+	if caller := tracing.UserID(ctx); caller != "" {
+		return "user:" + caller
+	}
+	return fallback
 }
 
-func (s *Service) GetOrder(ctx context.Context, orderID string) (*domain.Order, error) {
-	order, err := s.repo.FindByID(ctx, orderID)
-	if err != nil {
-		return nil, fmt.Errorf("query order failed: %w", err)
+// replayCreate answers a retried create with the original order, unless the
+// key was used by somebody else.
+func (s *Service) replayCreate(ctx context.Context, existing *domain.Order) (*domain.Order, error) {
+	if caller := tracing.UserID(ctx); caller != "" && existing.UserID != caller {
+		return nil, ErrIdempotencyKeyConflict
 	}
-	return order, nil
+	return existing, nil
 }
 
 func (s *Service) resolveHoldConfig(orderID string, partitionKey string, holdID string, qty int, capacity int) (string, string, int, int) {
@@ -424,25 +767,11 @@ func (s *Service) resolveHoldConfig(orderID string, partitionKey string, holdID
 	return resolvedPartition, resolvedHoldID, resolvedQty, resolvedCapacity
 }
 
-func (s *Service) verifyPaymentSignature(in PaymentCallbackInput) error {
-	secret := strings.TrimSpace(s.cfg.PaymentSignKey)
-	if secret == "" {
-		return nil
-	}
-	provided := strings.ToLower(strings.TrimSpace(in.Signature))
-	if provided == "" {
-		return ErrInvalidSignature
-	}
-	payload := fmt.Sprintf("%s|%s|%s", in.OrderID, in.ProviderTxnID, strings.ToUpper(strings.TrimSpace(in.Status)))
-	expected := signHMACSHA256(secret, payload)
-	if !hmac.Equal([]byte(provided), []byte(expected)) {
-		return ErrInvalidSignature
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
 	}
-	return nil
-}
-
-func signHMACSHA256(secret string, payload string) string {
-	mac := hmac.New(sha256.New, []byte(secret))
-	_, _ = mac.Write([]byte(payload))
-	return hex.EncodeToString(mac.Sum(nil))
+	return ""
 }
@@ -4,15 +4,22 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
+
+	"ticketing/internal/common/auth"
+	"ticketing/internal/common/tracing"
+	"ticketing/internal/order/domain"
+	"ticketing/internal/order/infrastructure/payment"
 )
 
 func TestPaymentCallback_InvalidSignatureRejected(t *testing.T) {
 	t.Parallel()
 
+	key := payment.NewHMACKey(payment.DefaultKeyID, "unit-test-key")
 	svc := &Service{
-		cfg: Config{
-			PaymentSignKey: "unit-test-key",
-		},
+		payments: payment.NewSimulated(nil, payment.SimulatedConfig{
+			Verifier: payment.NewCallbackVerifier(map[string]payment.KeyVerifier{payment.DefaultKeyID: key}, time.Minute, nil),
+		}),
 	}
 
 	_, err := svc.PaymentCallback(context.Background(), PaymentCallbackInput{
@@ -29,21 +36,161 @@ func TestPaymentCallback_InvalidSignatureRejected(t *testing.T) {
 func TestPaymentCallback_StatusWhitelist(t *testing.T) {
 	t.Parallel()
 
+	key := payment.NewHMACKey(payment.DefaultKeyID, "unit-test-key")
 	svc := &Service{
-		cfg: Config{
-			PaymentSignKey: "unit-test-key",
-		},
+		payments: payment.NewSimulated(nil, payment.SimulatedConfig{
+			Verifier: payment.NewCallbackVerifier(map[string]payment.KeyVerifier{payment.DefaultKeyID: key}, time.Minute, nil),
+		}),
 	}
-	signature := signHMACSHA256("unit-test-key", "order-2|txn-2|FAILED")
-
-	_, err := svc.PaymentCallback(context.Background(), PaymentCallbackInput{
+	signed, err := payment.SignNotification(key, payment.Notification{
 		OrderID:       "order-2",
 		ProviderTxnID: "txn-2",
-		Status:        "FAILED",
-		Signature:     signature,
+		Status:        "REFUNDING",
+		Timestamp:     time.Now().Unix(),
+		Nonce:         "nonce-2",
+	})
+	if err != nil {
+		t.Fatalf("sign failed: %v", err)
+	}
+
+	_, err = svc.PaymentCallback(context.Background(), PaymentCallbackInput{
+		OrderID:       signed.OrderID,
+		ProviderTxnID: signed.ProviderTxnID,
+		Status:        signed.Status,
+		KeyID:         signed.KeyID,
+		Timestamp:     signed.Timestamp,
+		Nonce:         signed.Nonce,
+		Signature:     signed.Signature,
 	})
 	if !errors.Is(err, ErrInvalidPaymentStatus) {
 		t.Fatalf("expected ErrInvalidPaymentStatus, got: %v", err)
 	}
 }
 
+func TestPaymentCallback_RequiresPaidAmount(t *testing.T) {
+	t.Parallel()
+
+	key := payment.NewHMACKey(payment.DefaultKeyID, "unit-test-key")
+	svc := &Service{
+		payments: payment.NewSimulated(nil, payment.SimulatedConfig{
+			Verifier: payment.NewCallbackVerifier(map[string]payment.KeyVerifier{payment.DefaultKeyID: key}, time.Minute, nil),
+		}),
+	}
+	signed, err := payment.SignNotification(key, payment.Notification{
+		OrderID:       "order-3",
+		ProviderTxnID: "txn-3",
+		Status:        "SUCCESS",
+		Timestamp:     time.Now().Unix(),
+		Nonce:         "nonce-3",
+	})
+	if err != nil {
+		t.Fatalf("sign failed: %v", err)
+	}
+
+	_, err = svc.PaymentCallback(context.Background(), PaymentCallbackInput{
+		OrderID:       signed.OrderID,
+		ProviderTxnID: signed.ProviderTxnID,
+		Status:        signed.Status,
+		KeyID:         signed.KeyID,
+		Timestamp:     signed.Timestamp,
+		Nonce:         signed.Nonce,
+		Signature:     signed.Signature,
+	})
+	if !errors.Is(err, ErrInvalidPaymentAmount) {
+		t.Fatalf("expected ErrInvalidPaymentAmount, got: %v", err)
+	}
+}
+
+func TestListOrders_RejectsMalformedFilters(t *testing.T) {
+	t.Parallel()
+
+	svc := &Service{}
+	cases := []ListOrdersInput{
+		{CreatedFrom: "yesterday"},
+		{CreatedTo: "2026-02-11"},
+		{TravelDate: "11/02/2026"},
+	}
+	for _, in := range cases {
+		if _, err := svc.ListOrders(context.Background(), in); !errors.Is(err, domain.ErrInvalidFilter) {
+			t.Fatalf("expected ErrInvalidFilter for %+v, got: %v", in, err)
+		}
+	}
+	if _, err := svc.ListOrders(context.Background(), ListOrdersInput{Cursor: "%%%"}); !errors.Is(err, domain.ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor, got: %v", err)
+	}
+}
+
+func TestAuthorizeOrder_HidesOtherUsersOrders(t *testing.T) {
+	t.Parallel()
+
+	order := &domain.Order{OrderID: "o-1", UserID: "u-1"}
+	owner := tracing.WithUser(context.Background(), "u-1", auth.RoleCustomer)
+	stranger := tracing.WithUser(context.Background(), "u-2", auth.RoleCustomer)
+	support := tracing.WithUser(context.Background(), "u-3", auth.RoleSupport)
+
+	if err := authorizeOrder(owner, order); err != nil {
+		t.Fatalf("owner should see the order, got: %v", err)
+	}
+	if err := authorizeOrder(support, order); err != nil {
+		t.Fatalf("support should see the order, got: %v", err)
+	}
+	if err := authorizeOrder(stranger, order); !errors.Is(err, domain.ErrOrderNotFound) {
+		t.Fatalf("expected ErrOrderNotFound for another user, got: %v", err)
+	}
+	if got := callerActor(stranger, domain.ActorCustomer); got != "user:u-2" {
+		t.Fatalf("unexpected actor: %s", got)
+	}
+}
+
+func TestReserveOrder_RejectsInvalidPassengersBeforeLocking(t *testing.T) {
+	t.Parallel()
+
+	svc := &Service{}
+	cases := []ReserveOrderInput{
+		{OrderID: "o-1", PartitionKey: "G123"},
+		{OrderID: "o-1", Passengers: []domain.OrderPassenger{{Name: "张三", IDNumber: "110105194912310021"}}},
+	}
+	for _, in := range cases {
+		if _, err := svc.ReserveOrder(context.Background(), in); !errors.Is(err, domain.ErrInvalidPassenger) {
+			t.Fatalf("expected ErrInvalidPassenger for %+v, got: %v", in, err)
+		}
+	}
+}
+
+func TestReserveOrder_RejectsUnknownTravelWindow(t *testing.T) {
+	t.Parallel()
+
+	svc := &Service{}
+	passengers := []domain.OrderPassenger{{Name: "张三", IDNumber: "11010519491231002X"}}
+	cases := []struct {
+		in   ReserveOrderInput
+		want error
+	}{
+		{ReserveOrderInput{OrderID: "o-1", PartitionKey: "G123", Passengers: passengers}, domain.ErrInvalidPartitionKey},
+		{ReserveOrderInput{
+			OrderID:    "o-1",
+			Passengers: passengers,
+			DepartAt:   "2026-02-11T12:00:00+08:00",
+			ArriveAt:   "2026-02-11T08:00:00+08:00",
+		}, domain.ErrInvalidTravelWindow},
+		{ReserveOrderInput{OrderID: "o-1", Passengers: passengers, DepartAt: "noon", ArriveAt: "2026-02-11T08:00:00+08:00"}, domain.ErrInvalidTravelWindow},
+	}
+	for _, tc := range cases {
+		_, err := svc.ReserveOrder(context.Background(), tc.in)
+		if !errors.Is(err, tc.want) || errors.Is(err, domain.ErrInvalidPassenger) {
+			t.Fatalf("expected %v for %+v, got: %v", tc.want, tc.in, err)
+		}
+	}
+}
+
+func TestReserveOrder_RejectsInvalidStationRange(t *testing.T) {
+	t.Parallel()
+
+	svc := &Service{}
+	for _, r := range [][2]int{{3, 3}, {4, 2}, {-1, 2}, {0, domain.MaxStationIndex + 1}} {
+		in := ReserveOrderInput{OrderID: "o-1", FromIndex: r[0], ToIndex: r[1]}
+		if _, err := svc.ReserveOrder(context.Background(), in); !errors.Is(err, domain.ErrInvalidStationRange) {
+			t.Fatalf("expected ErrInvalidStationRange for [%d, %d), got: %v", r[0], r[1], err)
+		}
+	}
+}
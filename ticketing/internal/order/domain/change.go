@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ChangeStatus is the business outcome of a ticket change (改签); step-level
+// progress lives in the CHANGE saga that drives it.
+type ChangeStatus string
+
+const (
+	ChangeStatusPending   ChangeStatus = "PENDING"
+	ChangeStatusCompleted ChangeStatus = "COMPLETED"
+	ChangeStatusFailed    ChangeStatus = "FAILED"
+)
+
+var (
+	ErrInvalidChange  = errors.New("invalid ticket change")
+	ErrChangeNotFound = errors.New("ticket change not found")
+)
+
+// Change moves an order's seats to NewPartitionKey. FarePaymentID is the
+// payment that collects a fare increase before the old seats are returned;
+// it is empty when the new fare is not higher.
+type Change struct {
+	ChangeID        string
+	OrderID         string
+	IdempotencyKey  string
+	OldPartitionKey string
+	OldHoldID       string
+	NewPartitionKey string
+	NewHoldID       string
+	Qty             int
+	Capacity        int
+	OldAmountCents  int64
+	NewAmountCents  int64
+	FarePaymentID   string
+	Status          ChangeStatus
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func NewChange(changeID string, idempotencyKey string, order *Order, newPartitionKey string, qty int, capacity int, newAmountCents int64) (*Change, error) {
+	if order.Status != StatusTicketed {
+		return nil, ErrInvalidStateTransfer
+	}
+	if newPartitionKey == "" || qty <= 0 {
+		return nil, ErrInvalidChange
+	}
+	if newAmountCents <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	return &Change{
+		ChangeID:        changeID,
+		OrderID:         order.OrderID,
+		IdempotencyKey:  idempotencyKey,
+		OldPartitionKey: order.PartitionKey,
+		OldHoldID:       order.HoldID,
+		NewPartitionKey: newPartitionKey,
+		NewHoldID:       changeID,
+		Qty:             qty,
+		Capacity:        capacity,
+		OldAmountCents:  order.AmountCents,
+		NewAmountCents:  newAmountCents,
+		Status:          ChangeStatusPending,
+	}, nil
+}
+
+func (c *Change) IsTerminal() bool {
+	return c.Status == ChangeStatusCompleted || c.Status == ChangeStatusFailed
+}
+
+// FareDiffCents is positive when the passenger owes more and negative when a
+// refund is due.
+func (c *Change) FareDiffCents() int64 {
+	return c.NewAmountCents - c.OldAmountCents
+}
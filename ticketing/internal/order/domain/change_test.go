@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewChange_CapturesOldItinerary(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewChange("chg-1", "idem-1", &Order{
+		OrderID:      "order-1",
+		Status:       StatusTicketed,
+		AmountCents:  1000,
+		PartitionKey: "G1|2026-02-11|2nd",
+		HoldID:       "order-1",
+	}, "G2|2026-02-11|2nd", 1, 500, 1200)
+	if err != nil {
+		t.Fatalf("NewChange failed: %v", err)
+	}
+	if c.OldPartitionKey != "G1|2026-02-11|2nd" || c.OldHoldID != "order-1" {
+		t.Fatalf("unexpected old itinerary: %s/%s", c.OldPartitionKey, c.OldHoldID)
+	}
+	if c.NewHoldID != "chg-1" {
+		t.Fatalf("expected new hold to be keyed by change id, got %s", c.NewHoldID)
+	}
+	if c.Status != ChangeStatusPending || c.IsTerminal() {
+		t.Fatalf("expected pending change, got %s", c.Status)
+	}
+	if c.FareDiffCents() != 200 {
+		t.Fatalf("expected fare diff 200, got %d", c.FareDiffCents())
+	}
+}
+
+func TestNewChange_RequiresTicketedOrder(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewChange("chg-2", "idem-2", &Order{OrderID: "order-2", Status: StatusPaid}, "G2|2026-02-11|2nd", 1, 500, 1000)
+	if !errors.Is(err, ErrInvalidStateTransfer) {
+		t.Fatalf("expected ErrInvalidStateTransfer, got: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var (
+	ErrInvalidFilter = errors.New("invalid order filter")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// OrderFilter selects orders for listing; zero values match everything.
+// Orders are returned newest first.
+type OrderFilter struct {
+	UserID      string
+	Status      Status
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	TrainNo     string
+	TravelDate  string
+	After       *OrderCursor
+	Limit       int
+}
+
+// OrderCursor is the keyset position of the last order on a page.
+type OrderCursor struct {
+	CreatedAt time.Time
+	OrderID   string
+}
+
+type OrderPage struct {
+	Orders     []*Order
+	NextCursor string
+}
+
+func (c OrderCursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.OrderID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeOrderCursor(s string) (*OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	ts, orderID, ok := strings.Cut(string(raw), "|")
+	if !ok || orderID == "" {
+		return nil, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &OrderCursor{CreatedAt: createdAt, OrderID: orderID}, nil
+}
+
+// ParsePartitionKey splits an inventory partition key of the form
+// "train|date|class" into the train number and travel date.
+func ParsePartitionKey(key string) (trainNo string, travelDate string) {
+	parts := strings.Split(key, "|")
+	if len(parts) < 2 {
+		return "", ""
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOrderCursor_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	in := OrderCursor{CreatedAt: time.Date(2026, 2, 11, 8, 30, 0, 0, time.UTC), OrderID: "order-1"}
+	out, err := DecodeOrderCursor(in.Encode())
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if !out.CreatedAt.Equal(in.CreatedAt) || out.OrderID != in.OrderID {
+		t.Fatalf("round trip mismatch: %+v vs %+v", out, in)
+	}
+
+	if _, err := DecodeOrderCursor("not-a-cursor"); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor, got: %v", err)
+	}
+}
+
+func TestParsePartitionKey(t *testing.T) {
+	t.Parallel()
+
+	train, date := ParsePartitionKey("G123|2026-02-11|2nd")
+	if train != "G123" || date != "2026-02-11" {
+		t.Fatalf("unexpected parse: %q %q", train, date)
+	}
+	if train, date := ParsePartitionKey("bad"); train != "" || date != "" {
+		t.Fatalf("expected empty parse, got %q %q", train, date)
+	}
+}
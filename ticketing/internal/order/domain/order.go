@@ -13,19 +13,35 @@ const (
 	StatusPaid      Status = "PAID"
 	StatusTicketed  Status = "TICKETED"
 	StatusCancelled Status = "CANCELLED"
+	// StatusPaymentMismatch holds an order whose payment did not cover the
+	// fare exactly; nothing is ticketed until someone resolves it.
+	StatusPaymentMismatch Status = "PAYMENT_MISMATCH"
 )
 
 var (
 	ErrInvalidAmount        = errors.New("invalid amount")
 	ErrInvalidStateTransfer = errors.New("invalid state transition")
 	ErrOrderNotFound        = errors.New("order not found")
+	ErrInvalidStationRange  = errors.New("invalid station range")
+	ErrInvalidPartitionKey  = errors.New("invalid partition key")
+	ErrInvalidTravelWindow  = errors.New("invalid travel window")
 )
 
+// MaxStationIndex is the highest station index a trip may end at; seats are
+// tracked over at most 64 station-to-station segments per train.
+const MaxStationIndex = 64
+
 type Order struct {
 	OrderID        string
 	IdempotencyKey string
+	UserID         string
 	Status         Status
 	AmountCents    int64
+	PartitionKey   string
+	HoldID         string
+	HoldQty        int
+	TrainNo        string
+	TravelDate     string
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
 }
@@ -42,34 +58,40 @@ func NewOrder(orderID string, idempotencyKey string, amountCents int64) (*Order,
 	}, nil
 }
 
-func (o *Order) Reserve() error {
-	if o.Status != StatusInit {
-		return ErrInvalidStateTransfer
+// Apply moves the order along the transition table.
+func (o *Order) Apply(event OrderEvent) error {
+	next, err := NextStatus(o.Status, event)
+	if err != nil {
+		return err
 	}
-	o.Status = StatusReserved
+	o.Status = next
 	return nil
 }
 
+func (o *Order) Reserve() error {
+	return o.Apply(EventReserve)
+}
+
 func (o *Order) MarkPaid() error {
-	if o.Status != StatusReserved {
-		return ErrInvalidStateTransfer
-	}
-	o.Status = StatusPaid
-	return nil
+	return o.Apply(EventPay)
 }
 
 func (o *Order) MarkTicketed() error {
-	if o.Status != StatusPaid {
-		return ErrInvalidStateTransfer
-	}
-	o.Status = StatusTicketed
-	return nil
+	return o.Apply(EventIssueTicket)
 }
 
 func (o *Order) Cancel() error {
-	if o.Status != StatusInit && o.Status != StatusReserved {
-		return ErrInvalidStateTransfer
+	return o.Apply(EventCancel)
+}
+
+// ValidateStationRange checks the indexes of the boarding and alighting
+// stations of a trip. Both zero means the trip does not say.
+func ValidateStationRange(fromIndex int, toIndex int) error {
+	if fromIndex == 0 && toIndex == 0 {
+		return nil
+	}
+	if fromIndex < 0 || toIndex <= fromIndex || toIndex > MaxStationIndex {
+		return ErrInvalidStationRange
 	}
-	o.Status = StatusCancelled
 	return nil
 }
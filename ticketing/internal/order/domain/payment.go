@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+type PaymentStatus string
+
+const (
+	PaymentStatusPending PaymentStatus = "PENDING"
+	PaymentStatusSuccess PaymentStatus = "SUCCESS"
+	PaymentStatusFailed  PaymentStatus = "FAILED"
+	PaymentStatusClosed  PaymentStatus = "CLOSED"
+	// PaymentStatusRefunding and PaymentStatusRefunded mark a successful
+	// payment the order did not need, such as a second payment of a PAID
+	// order, while and after it is given back.
+	PaymentStatusRefunding PaymentStatus = "REFUNDING"
+	PaymentStatusRefunded  PaymentStatus = "REFUNDED"
+)
+
+type RefundStatus string
+
+const (
+	RefundStatusPending   RefundStatus = "PENDING"
+	RefundStatusSucceeded RefundStatus = "SUCCEEDED"
+	// RefundStatusRejected needs an operator: the provider will not refund.
+	RefundStatusRejected RefundStatus = "REJECTED"
+)
+
+const DefaultCurrency = "CNY"
+
+var (
+	ErrPaymentNotFound = errors.New("payment not found")
+	ErrRefundNotFound  = errors.New("refund not found")
+)
+
+// Payment is one attempt to pay an order. PaymentID is the merchant-side
+// number handed to the provider; ProviderTxnID is only known once the
+// provider reports the outcome. AmountCents and Currency are what was asked
+// for, PaidAmountCents and PaidCurrency what the provider says was paid.
+type Payment struct {
+	PaymentID       string
+	OrderID         string
+	Provider        string
+	ProviderTxnID   string
+	Status          PaymentStatus
+	AmountCents     int64
+	Currency        string
+	PaidAmountCents int64
+	PaidCurrency    string
+	PrepayID        string
+	ExpiresAt       time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// IsOpen reports whether the customer can still complete the payment.
+func (p *Payment) IsOpen(now time.Time) bool {
+	return p.Status == PaymentStatusPending && now.Before(p.ExpiresAt)
+}
+
+// PaymentCovers reports whether a payment of amountCents in currency settles
+// the order exactly. Orders are priced in DefaultCurrency.
+func (o *Order) PaymentCovers(amountCents int64, currency string) bool {
+	return amountCents == o.AmountCents && strings.EqualFold(strings.TrimSpace(currency), DefaultCurrency)
+}
+
+// Refund gives a surplus payment back to the customer. RefundID doubles as
+// the provider's idempotency key, so resending a refund is safe.
+type Refund struct {
+	RefundID         string
+	PaymentID        string
+	OrderID          string
+	ProviderTxnID    string
+	AmountCents      int64
+	Currency         string
+	Reason           string
+	Status           RefundStatus
+	ProviderRefundID string
+	Attempts         int
+	LastError        string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
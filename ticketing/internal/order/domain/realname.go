@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ticketing/internal/common/idcard"
+)
+
+// Seat preferences a passenger may ask for. They are passed on to the seat
+// allocator, which meets them when it can.
+const (
+	SeatPreferenceWindow = "WINDOW"
+	SeatPreferenceAisle  = "AISLE"
+)
+
+var (
+	ErrInvalidPassenger   = errors.New("invalid passenger")
+	ErrDuplicateTrip      = errors.New("passenger already holds a ticket on an overlapping trip")
+	ErrDailyLimitExceeded = errors.New("daily booking limit reached for passenger")
+)
+
+// chinaTime is the zone of travel dates and of the booking day that daily
+// limits count against.
+var chinaTime = time.FixedZone("CST", 8*3600)
+
+// OrderPassenger is a traveller on an order, identified by the ID document
+// that real-name rules are enforced on.
+type OrderPassenger struct {
+	Name           string
+	IDType         string
+	IDNumber       string
+	SeatPreference string
+}
+
+func (p OrderPassenger) DocumentKey() string {
+	return p.IDType + ":" + p.IDNumber
+}
+
+// MaskedIDNumber keeps the first and last four characters, enough for the
+// customer to tell passengers apart in error messages.
+func (p OrderPassenger) MaskedIDNumber() string {
+	if len(p.IDNumber) <= 8 {
+		return strings.Repeat("*", len(p.IDNumber))
+	}
+	return p.IDNumber[:4] + strings.Repeat("*", len(p.IDNumber)-8) + p.IDNumber[len(p.IDNumber)-4:]
+}
+
+// TravelWindow is the half-open interval [DepartAt, ArriveAt) a passenger is
+// on the train.
+type TravelWindow struct {
+	DepartAt time.Time
+	ArriveAt time.Time
+}
+
+func (w TravelWindow) Overlaps(other TravelWindow) bool {
+	return w.DepartAt.Before(other.ArriveAt) && other.DepartAt.Before(w.ArriveAt)
+}
+
+// DefaultTravelWindow covers the whole travel date; it is used when the
+// caller does not know the train's timetable.
+func DefaultTravelWindow(travelDate string) (TravelWindow, error) {
+	day, err := time.ParseInLocation(time.DateOnly, travelDate, chinaTime)
+	if err != nil {
+		return TravelWindow{}, ErrInvalidPartitionKey
+	}
+	return TravelWindow{DepartAt: day.UTC(), ArriveAt: day.AddDate(0, 0, 1).UTC()}, nil
+}
+
+func NewTravelWindow(departAt time.Time, arriveAt time.Time) (TravelWindow, error) {
+	if !departAt.Before(arriveAt) {
+		return TravelWindow{}, ErrInvalidTravelWindow
+	}
+	return TravelWindow{DepartAt: departAt.UTC(), ArriveAt: arriveAt.UTC()}, nil
+}
+
+// BookingDate is the calendar day in China that t counts against for daily
+// booking limits.
+func BookingDate(t time.Time) string {
+	return t.In(chinaTime).Format(time.DateOnly)
+}
+
+// NormalizePassengers validates the passengers of one order and returns them
+// sorted by document, the order in which their locks are taken. The same
+// document may appear only once per order.
+func NormalizePassengers(in []OrderPassenger) ([]OrderPassenger, error) {
+	out := make([]OrderPassenger, 0, len(in))
+	seen := make(map[string]bool, len(in))
+	for _, p := range in {
+		var err error
+		p.Name = strings.TrimSpace(p.Name)
+		p.SeatPreference = strings.ToUpper(strings.TrimSpace(p.SeatPreference))
+		if p.Name == "" || utf8.RuneCountInString(p.Name) > 64 {
+			return nil, ErrInvalidPassenger
+		}
+		if p.IDType, p.IDNumber, err = idcard.NormalizeDocument(p.IDType, p.IDNumber); err != nil {
+			return nil, ErrInvalidPassenger
+		}
+		switch p.SeatPreference {
+		case "", SeatPreferenceWindow, SeatPreferenceAisle:
+		default:
+			return nil, ErrInvalidPassenger
+		}
+		if seen[p.DocumentKey()] {
+			return nil, ErrInvalidPassenger
+		}
+		seen[p.DocumentKey()] = true
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].DocumentKey() < out[j].DocumentKey() })
+	return out, nil
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"ticketing/internal/common/idcard"
+)
+
+func TestNormalizePassengers(t *testing.T) {
+	t.Parallel()
+
+	got, err := NormalizePassengers([]OrderPassenger{
+		{Name: " 李四 ", IDType: "passport", IDNumber: "e12345678", SeatPreference: " window"},
+		{Name: "张三", IDNumber: "11010519491231002x"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got[0].IDType != idcard.TypeIDCard || got[0].IDNumber != "11010519491231002X" || got[1].Name != "李四" || got[1].IDNumber != "E12345678" || got[1].SeatPreference != SeatPreferenceWindow {
+		t.Fatalf("unexpected passengers: %+v", got)
+	}
+
+	bad := [][]OrderPassenger{
+		{{Name: "张三", IDNumber: "110105194912310021"}},
+		{{Name: "", IDNumber: "11010519491231002X"}},
+		{{Name: "张三", IDType: "DRIVER_LICENSE", IDNumber: "X1"}},
+		{{Name: "张三", IDNumber: "11010519491231002X", SeatPreference: "MIDDLE"}},
+		{{Name: "张三", IDNumber: "11010519491231002X"}, {Name: "张三", IDNumber: "11010519491231002x"}},
+	}
+	for _, in := range bad {
+		if _, err := NormalizePassengers(in); !errors.Is(err, ErrInvalidPassenger) {
+			t.Fatalf("expected ErrInvalidPassenger for %+v, got: %v", in, err)
+		}
+	}
+}
+
+func TestTravelWindow_Overlaps(t *testing.T) {
+	t.Parallel()
+
+	day, err := DefaultTravelWindow("2026-02-11")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if day.DepartAt.Format(time.RFC3339) != "2026-02-10T16:00:00Z" {
+		t.Fatalf("unexpected window start: %s", day.DepartAt)
+	}
+	at := func(s string) time.Time {
+		v, _ := time.Parse(time.RFC3339, s)
+		return v
+	}
+	morning, _ := NewTravelWindow(at("2026-02-11T08:00:00+08:00"), at("2026-02-11T12:30:00+08:00"))
+	afternoon, _ := NewTravelWindow(at("2026-02-11T12:30:00+08:00"), at("2026-02-11T17:00:00+08:00"))
+	if morning.Overlaps(afternoon) {
+		t.Fatalf("back-to-back trips must not overlap")
+	}
+	if !morning.Overlaps(day) || !day.Overlaps(afternoon) {
+		t.Fatalf("trips within the day must overlap the whole-day window")
+	}
+	if _, err := NewTravelWindow(at("2026-02-11T12:00:00Z"), at("2026-02-11T12:00:00Z")); !errors.Is(err, ErrInvalidTravelWindow) {
+		t.Fatalf("expected ErrInvalidTravelWindow for empty window, got: %v", err)
+	}
+	if _, err := DefaultTravelWindow(""); !errors.Is(err, ErrInvalidPartitionKey) {
+		t.Fatalf("expected ErrInvalidPartitionKey without a travel date, got: %v", err)
+	}
+	if got := BookingDate(at("2026-02-10T17:00:00Z")); got != "2026-02-11" {
+		t.Fatalf("unexpected booking date: %s", got)
+	}
+}
@@ -0,0 +1,176 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type DiscrepancyKind string
+
+const (
+	// DiscrepancyMissing is a local payment the provider did not settle.
+	DiscrepancyMissing DiscrepancyKind = "MISSING"
+	// DiscrepancyExtra is a settled provider transaction with no local payment.
+	DiscrepancyExtra DiscrepancyKind = "EXTRA"
+	// DiscrepancyAmountMismatch is a transaction both sides know with a
+	// different amount or currency.
+	DiscrepancyAmountMismatch DiscrepancyKind = "AMOUNT_MISMATCH"
+)
+
+var (
+	ErrInvalidSettlementDate  = errors.New("settlement date must be YYYY-MM-DD")
+	ErrReconciliationNotFound = errors.New("reconciliation run not found")
+)
+
+// SettlementRecord is one line of a provider settlement file.
+type SettlementRecord struct {
+	Line          int
+	ProviderTxnID string
+	PaymentID     string
+	OrderID       string
+	AmountCents   int64
+	Currency      string
+	SettledAt     time.Time
+}
+
+// LedgerEntry is a payment order-service considers collected, with the
+// order it paid for.
+type LedgerEntry struct {
+	PaymentID     string
+	OrderID       string
+	ProviderTxnID string
+	AmountCents   int64
+	Currency      string
+	PaymentStatus PaymentStatus
+	OrderStatus   Status
+	PaidAt        time.Time
+}
+
+type Discrepancy struct {
+	Kind                DiscrepancyKind
+	ProviderTxnID       string
+	PaymentID           string
+	OrderID             string
+	LocalAmountCents    int64
+	LocalCurrency       string
+	ProviderAmountCents int64
+	ProviderCurrency    string
+	OrderStatus         Status
+	Detail              string
+}
+
+// ReconciliationRun is the outcome of matching one settlement file.
+type ReconciliationRun struct {
+	RunID           string
+	Provider        string
+	SettlementDate  string
+	SourceFile      string
+	ProviderRecords int
+	LocalRecords    int
+	Matched         int
+	Missing         int
+	Extra           int
+	Mismatched      int
+	CreatedAt       time.Time
+	Items           []Discrepancy
+}
+
+// SettlementDay returns the business day [start, end) in China Standard
+// Time, the day provider settlement files are cut on.
+func SettlementDay(date string) (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation("2006-01-02", date, chinaTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, ErrInvalidSettlementDate
+	}
+	return start, start.AddDate(0, 0, 1), nil
+}
+
+// Reconcile matches settlement records against the local ledger by provider
+// transaction ID and fills in the counters and discrepancies of run. A
+// transaction listed twice by the provider is reported as extra.
+func Reconcile(run *ReconciliationRun, records []SettlementRecord, ledger []LedgerEntry) {
+	local := make(map[string]LedgerEntry, len(ledger))
+	for _, e := range ledger {
+		local[e.ProviderTxnID] = e
+	}
+	run.ProviderRecords = len(records)
+	run.LocalRecords = len(ledger)
+
+	seen := make(map[string]bool, len(records))
+	for _, r := range records {
+		if seen[r.ProviderTxnID] {
+			run.add(Discrepancy{
+				Kind:                DiscrepancyExtra,
+				ProviderTxnID:       r.ProviderTxnID,
+				PaymentID:           r.PaymentID,
+				OrderID:             r.OrderID,
+				ProviderAmountCents: r.AmountCents,
+				ProviderCurrency:    r.Currency,
+				Detail:              fmt.Sprintf("line %d repeats a transaction already settled in this file", r.Line),
+			})
+			continue
+		}
+		seen[r.ProviderTxnID] = true
+
+		e, ok := local[r.ProviderTxnID]
+		if !ok {
+			run.add(Discrepancy{
+				Kind:                DiscrepancyExtra,
+				ProviderTxnID:       r.ProviderTxnID,
+				PaymentID:           r.PaymentID,
+				OrderID:             r.OrderID,
+				ProviderAmountCents: r.AmountCents,
+				ProviderCurrency:    r.Currency,
+				Detail:              fmt.Sprintf("line %d has no local payment", r.Line),
+			})
+			continue
+		}
+		if e.AmountCents != r.AmountCents || !strings.EqualFold(e.Currency, r.Currency) {
+			run.add(Discrepancy{
+				Kind:                DiscrepancyAmountMismatch,
+				ProviderTxnID:       r.ProviderTxnID,
+				PaymentID:           e.PaymentID,
+				OrderID:             e.OrderID,
+				LocalAmountCents:    e.AmountCents,
+				LocalCurrency:       e.Currency,
+				ProviderAmountCents: r.AmountCents,
+				ProviderCurrency:    r.Currency,
+				OrderStatus:         e.OrderStatus,
+				Detail: fmt.Sprintf("local %d %s, provider %d %s",
+					e.AmountCents, e.Currency, r.AmountCents, strings.ToUpper(r.Currency)),
+			})
+			continue
+		}
+		run.Matched++
+	}
+
+	for _, e := range ledger {
+		if seen[e.ProviderTxnID] {
+			continue
+		}
+		run.add(Discrepancy{
+			Kind:             DiscrepancyMissing,
+			ProviderTxnID:    e.ProviderTxnID,
+			PaymentID:        e.PaymentID,
+			OrderID:          e.OrderID,
+			LocalAmountCents: e.AmountCents,
+			LocalCurrency:    e.Currency,
+			OrderStatus:      e.OrderStatus,
+			Detail:           fmt.Sprintf("%s payment not in settlement file", e.PaymentStatus),
+		})
+	}
+}
+
+func (r *ReconciliationRun) add(d Discrepancy) {
+	switch d.Kind {
+	case DiscrepancyMissing:
+		r.Missing++
+	case DiscrepancyExtra:
+		r.Extra++
+	case DiscrepancyAmountMismatch:
+		r.Mismatched++
+	}
+	r.Items = append(r.Items, d)
+}
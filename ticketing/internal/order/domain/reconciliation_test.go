@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReconcile_ReportsMissingExtraAndMismatched(t *testing.T) {
+	t.Parallel()
+
+	records := []SettlementRecord{
+		{Line: 2, ProviderTxnID: "txn-ok", AmountCents: 18800, Currency: "cny"},
+		{Line: 3, ProviderTxnID: "txn-short", AmountCents: 18000, Currency: "CNY"},
+		{Line: 4, ProviderTxnID: "txn-unknown", AmountCents: 5000, Currency: "CNY"},
+		{Line: 5, ProviderTxnID: "txn-ok", AmountCents: 18800, Currency: "CNY"},
+	}
+	ledger := []LedgerEntry{
+		{PaymentID: "p-1", OrderID: "o-1", ProviderTxnID: "txn-ok", AmountCents: 18800, Currency: "CNY"},
+		{PaymentID: "p-2", OrderID: "o-2", ProviderTxnID: "txn-short", AmountCents: 18800, Currency: "CNY"},
+		{PaymentID: "p-3", OrderID: "o-3", ProviderTxnID: "txn-lost", AmountCents: 9900, Currency: "CNY", PaymentStatus: PaymentStatusSuccess},
+	}
+
+	run := &ReconciliationRun{}
+	Reconcile(run, records, ledger)
+
+	if run.ProviderRecords != 4 || run.LocalRecords != 3 {
+		t.Fatalf("unexpected record counts: %+v", run)
+	}
+	if run.Matched != 1 || run.Missing != 1 || run.Extra != 2 || run.Mismatched != 1 {
+		t.Fatalf("unexpected counters: matched=%d missing=%d extra=%d mismatched=%d",
+			run.Matched, run.Missing, run.Extra, run.Mismatched)
+	}
+	kinds := map[string]DiscrepancyKind{}
+	for _, d := range run.Items {
+		if d.Kind == DiscrepancyExtra && d.ProviderTxnID == "txn-ok" {
+			continue
+		}
+		kinds[d.ProviderTxnID] = d.Kind
+	}
+	want := map[string]DiscrepancyKind{
+		"txn-short":   DiscrepancyAmountMismatch,
+		"txn-unknown": DiscrepancyExtra,
+		"txn-lost":    DiscrepancyMissing,
+	}
+	for txn, kind := range want {
+		if kinds[txn] != kind {
+			t.Fatalf("%s: want %s, got %q", txn, kind, kinds[txn])
+		}
+	}
+}
+
+func TestSettlementDay_UsesChinaStandardTime(t *testing.T) {
+	t.Parallel()
+
+	start, end, err := SettlementDay("2026-02-11")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !start.Equal(time.Date(2026, 2, 10, 16, 0, 0, 0, time.UTC)) || end.Sub(start) != 24*time.Hour {
+		t.Fatalf("unexpected window: %s - %s", start, end)
+	}
+	if _, _, err := SettlementDay("11/02/2026"); !errors.Is(err, ErrInvalidSettlementDate) {
+		t.Fatalf("expected ErrInvalidSettlementDate, got %v", err)
+	}
+}
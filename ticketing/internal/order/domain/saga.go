@@ -0,0 +1,170 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+type SagaStatus string
+
+const (
+	SagaStatusRunning      SagaStatus = "RUNNING"
+	SagaStatusCompensating SagaStatus = "COMPENSATING"
+	SagaStatusCompleted    SagaStatus = "COMPLETED"
+	SagaStatusFailed       SagaStatus = "FAILED"
+)
+
+const (
+	SagaTypeReserve = "RESERVE"
+	SagaTypePay     = "PAY"
+	SagaTypeCancel  = "CANCEL"
+	SagaTypeChange  = "CHANGE"
+)
+
+const (
+	SagaPhaseForward    = "FORWARD"
+	SagaPhaseCompensate = "COMPENSATE"
+
+	SagaOutcomeDone   = "DONE"
+	SagaOutcomeFailed = "FAILED"
+)
+
+var (
+	ErrSagaNotFound   = errors.New("saga not found")
+	ErrSagaInProgress = errors.New("another workflow is in progress for this order")
+)
+
+// Saga is the persisted progress of one cross-service workflow on an order.
+// Step is the index of the step to run next while RUNNING, or of the step to
+// undo next while COMPENSATING.
+type Saga struct {
+	SagaID      string
+	SagaType    string
+	OrderID     string
+	Data        map[string]any
+	Step        int
+	Status      SagaStatus
+	Attempts    int
+	LastError   string
+	NextRetryAt time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// SagaStepLog is one append-only entry of a saga's step log.
+type SagaStepLog struct {
+	SagaID    string
+	Step      int
+	StepName  string
+	Phase     string
+	Outcome   string
+	Error     string
+	CreatedAt time.Time
+}
+
+func NewSaga(sagaID string, sagaType string, orderID string, data map[string]any) *Saga {
+	if data == nil {
+		data = map[string]any{}
+	}
+	return &Saga{
+		SagaID:   sagaID,
+		SagaType: sagaType,
+		OrderID:  orderID,
+		Data:     data,
+		Status:   SagaStatusRunning,
+	}
+}
+
+func (s *Saga) IsTerminal() bool {
+	return s.Status == SagaStatusCompleted || s.Status == SagaStatusFailed
+}
+
+// Forward records that the current step finished; the saga completes after
+// the last of stepCount steps.
+func (s *Saga) Forward(stepCount int) error {
+	if s.Status != SagaStatusRunning {
+		return ErrInvalidStateTransfer
+	}
+	s.Step++
+	s.Attempts = 0
+	s.LastError = ""
+	if s.Step >= stepCount {
+		s.Status = SagaStatusCompleted
+	}
+	return nil
+}
+
+// StartCompensation switches the saga to undo the current step and every
+// step before it. The cause stays in LastError for operators.
+func (s *Saga) StartCompensation() error {
+	if s.Status != SagaStatusRunning {
+		return ErrInvalidStateTransfer
+	}
+	s.Status = SagaStatusCompensating
+	s.Attempts = 0
+	return nil
+}
+
+// Backward records that the current step was undone; the saga fails once
+// the first step has been compensated.
+func (s *Saga) Backward() error {
+	if s.Status != SagaStatusCompensating {
+		return ErrInvalidStateTransfer
+	}
+	s.Attempts = 0
+	if s.Step <= 0 {
+		s.Step = 0
+		s.Status = SagaStatusFailed
+		return nil
+	}
+	s.Step--
+	return nil
+}
+
+// Abort fails a running saga without undoing anything, for a step past the
+// point of no return that can never succeed. The cause stays in LastError.
+func (s *Saga) Abort() error {
+	if s.Status != SagaStatusRunning {
+		return ErrInvalidStateTransfer
+	}
+	s.Status = SagaStatusFailed
+	s.Attempts = 0
+	return nil
+}
+
+func (s *Saga) String(key string) string {
+	v, _ := s.Data[key].(string)
+	return v
+}
+
+// Int64 reads a number that may have come back from JSON as float64.
+func (s *Saga) Int64(key string) int64 {
+	switch v := s.Data[key].(type) {
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	default:
+		return 0
+	}
+}
+
+func (s *Saga) Bool(key string) bool {
+	v, _ := s.Data[key].(bool)
+	return v
+}
+
+func (s *Saga) Int(key string) int {
+	switch v := s.Data[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	default:
+		return 0
+	}
+}
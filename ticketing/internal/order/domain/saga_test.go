@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSaga_ForwardCompletesAfterLastStep(t *testing.T) {
+	t.Parallel()
+
+	sg := NewSaga("saga-1", SagaTypeReserve, "order-1", nil)
+	for i := 0; i < 2; i++ {
+		if err := sg.Forward(3); err != nil {
+			t.Fatalf("forward %d failed: %v", i, err)
+		}
+	}
+	if sg.Status != SagaStatusRunning || sg.Step != 2 {
+		t.Fatalf("expected RUNNING at step 2, got %s at %d", sg.Status, sg.Step)
+	}
+	if err := sg.Forward(3); err != nil {
+		t.Fatalf("last forward failed: %v", err)
+	}
+	if sg.Status != SagaStatusCompleted || !sg.IsTerminal() {
+		t.Fatalf("expected COMPLETED, got %s", sg.Status)
+	}
+	if err := sg.Forward(3); !errors.Is(err, ErrInvalidStateTransfer) {
+		t.Fatalf("expected ErrInvalidStateTransfer past completion, got: %v", err)
+	}
+}
+
+func TestSaga_CompensationUndoesFailedAndEarlierSteps(t *testing.T) {
+	t.Parallel()
+
+	sg := NewSaga("saga-2", SagaTypeChange, "order-2", nil)
+	_ = sg.Forward(5)
+	sg.Attempts = 3
+	sg.LastError = "inventory down"
+	if err := sg.StartCompensation(); err != nil {
+		t.Fatalf("start compensation failed: %v", err)
+	}
+	if sg.Status != SagaStatusCompensating || sg.Step != 1 || sg.Attempts != 0 {
+		t.Fatalf("unexpected state: status=%s step=%d attempts=%d", sg.Status, sg.Step, sg.Attempts)
+	}
+	if sg.LastError != "inventory down" {
+		t.Fatalf("expected cause to be kept, got %q", sg.LastError)
+	}
+
+	if err := sg.Backward(); err != nil || sg.Step != 0 || sg.Status != SagaStatusCompensating {
+		t.Fatalf("expected to compensate step 0 next, got step=%d status=%s err=%v", sg.Step, sg.Status, err)
+	}
+	if err := sg.Backward(); err != nil || sg.Status != SagaStatusFailed {
+		t.Fatalf("expected FAILED after first step undone, got status=%s err=%v", sg.Status, err)
+	}
+}
+
+func TestSaga_DataAccessorsHandleJSONNumbers(t *testing.T) {
+	t.Parallel()
+
+	sg := NewSaga("saga-3", SagaTypePay, "order-3", map[string]any{
+		"hold_id": "h1",
+		"qty":     float64(2),
+	})
+	if sg.String("hold_id") != "h1" || sg.Int("qty") != 2 {
+		t.Fatalf("unexpected accessors: %q %d", sg.String("hold_id"), sg.Int("qty"))
+	}
+	if sg.String("missing") != "" || sg.Int("missing") != 0 {
+		t.Fatal("expected zero values for missing keys")
+	}
+}
+
+func TestSaga_AbortFailsWithoutCompensating(t *testing.T) {
+	t.Parallel()
+
+	sg := NewSaga("saga-4", SagaTypeCancel, "order-4", nil)
+	sg.Attempts = 1
+	sg.LastError = "invalid state transfer"
+	if err := sg.Abort(); err != nil {
+		t.Fatalf("abort failed: %v", err)
+	}
+	if sg.Status != SagaStatusFailed || sg.Step != 0 || sg.LastError == "" {
+		t.Fatalf("expected FAILED at step 0 with the cause kept, got %s at %d (%q)", sg.Status, sg.Step, sg.LastError)
+	}
+	if err := sg.Abort(); !errors.Is(err, ErrInvalidStateTransfer) {
+		t.Fatalf("expected ErrInvalidStateTransfer when aborting twice, got: %v", err)
+	}
+}
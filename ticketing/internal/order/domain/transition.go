@@ -0,0 +1,74 @@
+package domain
+
+import "time"
+
+// OrderEvent names a business event that moves an order between statuses.
+type OrderEvent string
+
+const (
+	EventCreate      OrderEvent = "CREATE"
+	EventReserve     OrderEvent = "RESERVE"
+	EventPay         OrderEvent = "PAY"
+	EventIssueTicket OrderEvent = "ISSUE_TICKET"
+	EventCancel      OrderEvent = "CANCEL"
+	EventChange      OrderEvent = "CHANGE"
+	// EventPaymentMismatch is a successful payment whose amount or currency
+	// differs from the order.
+	EventPaymentMismatch OrderEvent = "PAYMENT_MISMATCH"
+)
+
+const (
+	ActorCustomer        = "customer"
+	ActorPaymentProvider = "payment-provider"
+	ActorTicketWorker    = "ticket-worker"
+	ActorReconciler      = "payment-reconciler"
+)
+
+type Transition struct {
+	Event OrderEvent
+	From  Status
+	To    Status
+}
+
+// transitionTable is the single source of truth for the order lifecycle.
+// An empty From is the creation of the order.
+var transitionTable = []Transition{
+	{Event: EventCreate, From: "", To: StatusInit},
+	{Event: EventReserve, From: StatusInit, To: StatusReserved},
+	{Event: EventPay, From: StatusReserved, To: StatusPaid},
+	{Event: EventPaymentMismatch, From: StatusReserved, To: StatusPaymentMismatch},
+	{Event: EventIssueTicket, From: StatusPaid, To: StatusTicketed},
+	{Event: EventCancel, From: StatusInit, To: StatusCancelled},
+	{Event: EventCancel, From: StatusReserved, To: StatusCancelled},
+	{Event: EventCancel, From: StatusPaymentMismatch, To: StatusCancelled},
+	{Event: EventChange, From: StatusTicketed, To: StatusTicketed},
+}
+
+// NextStatus returns the status reached by applying event in status from.
+func NextStatus(from Status, event OrderEvent) (Status, error) {
+	for _, t := range transitionTable {
+		if t.Event == event && t.From == from {
+			return t.To, nil
+		}
+	}
+	return "", ErrInvalidStateTransfer
+}
+
+// Transitions returns a copy of the transition table.
+func Transitions() []Transition {
+	out := make([]Transition, len(transitionTable))
+	copy(out, transitionTable)
+	return out
+}
+
+// StatusTransition is one row of an order's status history.
+type StatusTransition struct {
+	OrderID    string
+	Event      OrderEvent
+	FromStatus Status
+	ToStatus   Status
+	Actor      string
+	Reason     string
+	TraceID    string
+	CreatedAt  time.Time
+}
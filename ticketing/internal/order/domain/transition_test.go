@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNextStatus_FollowsTransitionTable(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		from  Status
+		event OrderEvent
+		want  Status
+	}{
+		{"", EventCreate, StatusInit},
+		{StatusInit, EventReserve, StatusReserved},
+		{StatusReserved, EventPay, StatusPaid},
+		{StatusPaid, EventIssueTicket, StatusTicketed},
+		{StatusInit, EventCancel, StatusCancelled},
+		{StatusReserved, EventCancel, StatusCancelled},
+		{StatusTicketed, EventChange, StatusTicketed},
+		{StatusReserved, EventPaymentMismatch, StatusPaymentMismatch},
+		{StatusPaymentMismatch, EventCancel, StatusCancelled},
+	}
+	for _, tc := range cases {
+		got, err := NextStatus(tc.from, tc.event)
+		if err != nil || got != tc.want {
+			t.Fatalf("%s on %q: want %s, got %s (err=%v)", tc.event, tc.from, tc.want, got, err)
+		}
+	}
+}
+
+func TestNextStatus_RejectsUnlistedTransitions(t *testing.T) {
+	t.Parallel()
+
+	rejected := []struct {
+		from  Status
+		event OrderEvent
+	}{
+		{StatusInit, EventPay},
+		{StatusPaid, EventCancel},
+		{StatusCancelled, EventReserve},
+		{StatusReserved, EventChange},
+		{StatusPaymentMismatch, EventIssueTicket},
+		{StatusPaymentMismatch, EventPay},
+	}
+	for _, tc := range rejected {
+		if _, err := NextStatus(tc.from, tc.event); !errors.Is(err, ErrInvalidStateTransfer) {
+			t.Fatalf("%s on %s: expected ErrInvalidStateTransfer, got %v", tc.event, tc.from, err)
+		}
+	}
+
+	o := &Order{Status: StatusPaid}
+	if err := o.Cancel(); !errors.Is(err, ErrInvalidStateTransfer) || o.Status != StatusPaid {
+		t.Fatalf("expected paid order to stay PAID, got %s (err=%v)", o.Status, err)
+	}
+}
+
+func TestOrderPaymentCovers(t *testing.T) {
+	t.Parallel()
+
+	o := &Order{AmountCents: 18800}
+	if !o.PaymentCovers(18800, "cny") {
+		t.Fatal("exact payment should cover the order")
+	}
+	for _, tc := range []struct {
+		amount   int64
+		currency string
+	}{
+		{18700, "CNY"},
+		{18900, "CNY"},
+		{18800, "USD"},
+		{18800, ""},
+	} {
+		if o.PaymentCovers(tc.amount, tc.currency) {
+			t.Fatalf("payment of %d %q should not cover 18800 CNY", tc.amount, tc.currency)
+		}
+	}
+}
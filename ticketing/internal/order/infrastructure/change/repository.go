@@ -0,0 +1,85 @@
+package change
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"ticketing/internal/order/domain"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+const selectColumns = `SELECT change_id, order_id, idempotency_key, old_partition_key, old_hold_id,
+		        new_partition_key, new_hold_id, qty, capacity, old_amount_cents, new_amount_cents,
+		        fare_payment_id, status, created_at, updated_at
+		 FROM ticket_changes`
+
+func (r *Repository) InsertTx(ctx context.Context, tx *sql.Tx, c *domain.Change) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO ticket_changes(
+		   change_id, order_id, idempotency_key, old_partition_key, old_hold_id,
+		   new_partition_key, new_hold_id, qty, capacity, old_amount_cents, new_amount_cents,
+		   fare_payment_id, status)
+		 VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.ChangeID, c.OrderID, c.IdempotencyKey, c.OldPartitionKey, c.OldHoldID,
+		c.NewPartitionKey, c.NewHoldID, c.Qty, c.Capacity, c.OldAmountCents, c.NewAmountCents,
+		c.FarePaymentID, string(c.Status),
+	)
+	return err
+}
+
+func (r *Repository) FindByID(ctx context.Context, changeID string) (*domain.Change, error) {
+	return scanChange(r.db.QueryRowContext(ctx, selectColumns+` WHERE change_id=?`, changeID))
+}
+
+func (r *Repository) FindByIdempotencyKey(ctx context.Context, key string) (*domain.Change, error) {
+	return scanChange(r.db.QueryRowContext(ctx, selectColumns+` WHERE idempotency_key=?`, key))
+}
+
+func (r *Repository) UpdateStatusTx(ctx context.Context, tx *sql.Tx, changeID string, status domain.ChangeStatus) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`UPDATE ticket_changes SET status=?, updated_at=CURRENT_TIMESTAMP WHERE change_id=?`,
+		string(status), changeID,
+	)
+	return err
+}
+
+func scanChange(row interface {
+	Scan(dest ...any) error
+}) (*domain.Change, error) {
+	c := &domain.Change{}
+	var status string
+	if err := row.Scan(
+		&c.ChangeID,
+		&c.OrderID,
+		&c.IdempotencyKey,
+		&c.OldPartitionKey,
+		&c.OldHoldID,
+		&c.NewPartitionKey,
+		&c.NewHoldID,
+		&c.Qty,
+		&c.Capacity,
+		&c.OldAmountCents,
+		&c.NewAmountCents,
+		&c.FarePaymentID,
+		&status,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrChangeNotFound
+		}
+		return nil, err
+	}
+	c.Status = domain.ChangeStatus(status)
+	return c, nil
+}
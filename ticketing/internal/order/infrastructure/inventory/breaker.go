@@ -0,0 +1,101 @@
+package inventory
+
+import (
+	"sync"
+	"time"
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerHalfOpen
+	breakerOpen
+)
+
+type breakerResult int
+
+const (
+	breakerSuccess breakerResult = iota
+	breakerFailure
+	// breakerIgnore ends an attempt that tells nothing about the service,
+	// such as one the caller cancelled.
+	breakerIgnore
+)
+
+// breaker opens after a run of consecutive failures and fails calls fast
+// until openFor has passed. It then lets a single probe through: success
+// closes the circuit, failure opens it again.
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	openFor   time.Duration
+	now       func() time.Time
+
+	state    breakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker(threshold int, openFor time.Duration) *breaker {
+	return &breaker{threshold: threshold, openFor: openFor, now: time.Now}
+}
+
+// allow reports whether a call may go out and whether it is the half-open
+// probe. The caller hands probe back to record.
+func (b *breaker) allow() (ok, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.openFor {
+			return false, false
+		}
+		b.state = breakerHalfOpen
+		b.probing = true
+		return true, true
+	case breakerHalfOpen:
+		if b.probing {
+			return false, false
+		}
+		b.probing = true
+		return true, true
+	}
+	return true, false
+}
+
+func (b *breaker) record(result breakerResult, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		// A call that started before the circuit opened.
+		return
+	case breakerHalfOpen:
+		if !probe {
+			// Likewise; only the probe decides the half-open circuit.
+			return
+		}
+		b.probing = false
+	}
+	switch result {
+	case breakerSuccess:
+		b.state = breakerClosed
+		b.failures = 0
+	case breakerFailure:
+		b.failures++
+		if b.state == breakerHalfOpen || b.failures >= b.threshold {
+			b.state = breakerOpen
+			b.openedAt = b.now()
+		}
+	}
+}
+
+func (b *breaker) current() breakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
@@ -1,22 +1,123 @@
 package inventory
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"strings"
+	"math/rand/v2"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	commonerrors "ticketing/internal/common/errors"
+)
+
+var (
+	ErrHoldNotFound      = errors.New("inventory hold not found")
+	ErrInsufficientStock = errors.New("inventory insufficient stock")
+	// ErrRequestRejected marks 4xx responses that will not succeed on retry.
+	ErrRequestRejected = errors.New("inventory request rejected")
+	// ErrUnavailable marks 5xx responses and transport failures.
+	ErrUnavailable = fmt.Errorf("inventory unavailable: %w", commonerrors.ErrDependencyUnavailable)
+	// ErrCircuitOpen is returned without calling inventory-service while the
+	// circuit breaker is open.
+	ErrCircuitOpen = fmt.Errorf("inventory circuit open: %w", commonerrors.ErrDependencyUnavailable)
+)
+
+// Error codes sent by inventory-service in the "code" field of error bodies.
+const (
+	codeInsufficientStock = "INSUFFICIENT_STOCK"
+	codeBackpressure      = "BACKPRESSURE"
+	codeHoldNotFound      = "HOLD_NOT_FOUND"
 )
 
-var ErrHoldNotFound = errors.New("inventory hold not found")
+// Error is a non-2xx response from inventory-service.
+type Error struct {
+	Path       string
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("inventory %s failed: status=%d code=%s err=%s", e.Path, e.StatusCode, e.Code, e.Message)
+}
+
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrHoldNotFound:
+		return e.Code == codeHoldNotFound
+	case ErrInsufficientStock:
+		return e.Code == codeInsufficientStock
+	case ErrRequestRejected:
+		return e.StatusCode >= 400 && e.StatusCode < 500
+	case ErrUnavailable, commonerrors.ErrDependencyUnavailable:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// retryable reports whether the same request may succeed later.
+func (e *Error) retryable() bool {
+	return e.StatusCode >= 500
+}
+
+// Config tunes the client. Zero fields take the defaults of DefaultConfig.
+type Config struct {
+	// Timeout bounds a single attempt.
+	Timeout time.Duration
+	// MaxAttempts bounds the attempts of idempotent calls.
+	MaxAttempts int
+	// BaseBackoff and MaxBackoff bound the jittered wait between attempts.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// BreakerFailures consecutive failures open the circuit for BreakerOpenFor.
+	BreakerFailures int
+	BreakerOpenFor  time.Duration
+}
 
-type Client struct {
-	baseURL    string
-	httpClient *http.Client
+func DefaultConfig() Config {
+	return Config{
+		Timeout:         3 * time.Second,
+		MaxAttempts:     3,
+		BaseBackoff:     100 * time.Millisecond,
+		MaxBackoff:      time.Second,
+		BreakerFailures: 5,
+		BreakerOpenFor:  10 * time.Second,
+	}
+}
+
+func (c Config) withDefaults() Config {
+	def := DefaultConfig()
+	if c.Timeout <= 0 {
+		c.Timeout = def.Timeout
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = def.MaxAttempts
+	}
+	if c.BaseBackoff <= 0 {
+		c.BaseBackoff = def.BaseBackoff
+	}
+	if c.MaxBackoff < c.BaseBackoff {
+		c.MaxBackoff = max(def.MaxBackoff, c.BaseBackoff)
+	}
+	if c.BreakerFailures <= 0 {
+		c.BreakerFailures = def.BreakerFailures
+	}
+	if c.BreakerOpenFor <= 0 {
+		c.BreakerOpenFor = def.BreakerOpenFor
+	}
+	return c
+}
+
+// API is the inventory-service surface order-service uses. Client speaks
+// JSON over HTTP and GRPCClient speaks inventory.v1 over gRPC; both share
+// retries, the circuit breaker and the metrics.
+type API interface {
+	TryHold(ctx context.Context, in TryHoldInput) error
+	ConfirmHold(ctx context.Context, in ConfirmInput) error
+	ReleaseHold(ctx context.Context, in ReleaseInput) error
+	ReturnConfirmed(ctx context.Context, in ReturnInput) error
 }
 
 type TryHoldInput struct {
@@ -36,66 +137,174 @@ type ReleaseInput struct {
 	HoldID       string `json:"hold_id"`
 }
 
-func NewClient(baseURL string) *Client {
-	return &Client{
-		baseURL: strings.TrimRight(baseURL, "/"),
-		httpClient: &http.Client{
-			Timeout: 3 * time.Second,
-		},
-	}
+type ReturnInput struct {
+	PartitionKey string `json:"partition_key"`
+	HoldID       string `json:"hold_id"`
 }
 
-func (c *Client) TryHold(ctx context.Context, in TryHoldInput) error {
-	return c.post(ctx, "/inventory/try-hold", in)
+// caller runs inventory calls through the retry policy and the circuit
+// breaker and records their outcome.
+type caller struct {
+	cfg     Config
+	breaker *breaker
+
+	requests *prometheus.CounterVec
+	retries  *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	circuit  prometheus.GaugeFunc
 }
 
-func (c *Client) ConfirmHold(ctx context.Context, in ConfirmInput) error {
-	return c.post(ctx, "/inventory/confirm-hold", in)
+func newCaller(cfg Config) *caller {
+	c := &caller{
+		cfg:     cfg,
+		breaker: newBreaker(cfg.BreakerFailures, cfg.BreakerOpenFor),
+		requests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "inventory_client_requests_total",
+				Help: "Inventory calls by operation and final outcome.",
+			},
+			[]string{"operation", "outcome"},
+		),
+		retries: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "inventory_client_retries_total",
+				Help: "Inventory call attempts beyond the first, by operation.",
+			},
+			[]string{"operation"},
+		),
+		duration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "inventory_client_request_duration_seconds",
+				Help:    "Inventory call duration in seconds, retries included.",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"operation", "outcome"},
+		),
+	}
+	c.circuit = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "inventory_client_circuit_state",
+			Help: "Inventory circuit breaker state: 0 closed, 1 half-open, 2 open.",
+		},
+		func() float64 { return float64(c.breaker.current()) },
+	)
+	return c
 }
 
-func (c *Client) ReleaseHold(ctx context.Context, in ReleaseInput) error {
-	return c.post(ctx, "/inventory/release-hold", in)
+// Collectors returns the client metrics for registration.
+func (c *caller) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{c.requests, c.retries, c.duration, c.circuit}
 }
 
-func (c *Client) post(ctx context.Context, path string, payload any) error {
-	raw, err := json.Marshal(payload)
-	if err != nil {
-		return err
+// call sends one logical call. Only idempotent calls are retried.
+func (c *caller) call(ctx context.Context, op string, idempotent bool, send func(ctx context.Context) error) error {
+	var err error
+	start := time.Now()
+	attempts := 1
+	if idempotent {
+		attempts = c.cfg.MaxAttempts
+	}
+	for attempt := 1; ; attempt++ {
+		err = c.attempt(ctx, send)
+		if err == nil || attempt >= attempts || !shouldRetry(ctx, err) {
+			break
+		}
+		if waitErr := sleepContext(ctx, c.backoff(attempt)); waitErr != nil {
+			err = errors.Join(err, waitErr)
+			break
+		}
+		c.retries.WithLabelValues(op).Inc()
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(raw))
-	if err != nil {
-		return err
+	outcome := classify(ctx, err)
+	c.requests.WithLabelValues(op, outcome).Inc()
+	c.duration.WithLabelValues(op, outcome).Observe(time.Since(start).Seconds())
+	return err
+}
+
+// attempt sends one request through the circuit breaker.
+func (c *caller) attempt(ctx context.Context, send func(ctx context.Context) error) error {
+	ok, probe := c.breaker.allow()
+	if !ok {
+		return ErrCircuitOpen
+	}
+	err := send(ctx)
+	switch {
+	case err == nil:
+		c.breaker.record(breakerSuccess, probe)
+	case ctx.Err() != nil:
+		// The caller gave up; that says nothing about inventory-service.
+		c.breaker.record(breakerIgnore, probe)
+	case isServiceFailure(err):
+		c.breaker.record(breakerFailure, probe)
+	default:
+		c.breaker.record(breakerSuccess, probe)
 	}
-	req.Header.Set("Content-Type", "application/json")
+	return err
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return err
+// backoff returns a full-jitter wait before the attempt after attempt.
+func (c *caller) backoff(attempt int) time.Duration {
+	ceiling := c.cfg.BaseBackoff << min(attempt-1, 16)
+	if ceiling <= 0 || ceiling > c.cfg.MaxBackoff {
+		ceiling = c.cfg.MaxBackoff
 	}
-	defer resp.Body.Close()
+	return time.Duration(rand.Int64N(int64(ceiling))) + 1
+}
 
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
 		return nil
 	}
+}
 
-	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
-	msg := extractErrorMessage(body)
-	if resp.StatusCode == http.StatusNotFound && strings.Contains(strings.ToLower(msg), "hold not found") {
-		return ErrHoldNotFound
+func shouldRetry(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrCircuitOpen) {
+		return false
 	}
-	if msg == "" {
-		msg = string(body)
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.retryable()
 	}
-	return fmt.Errorf("inventory %s failed: status=%d err=%s", path, resp.StatusCode, strings.TrimSpace(msg))
+	return errors.Is(err, ErrUnavailable)
 }
 
-func extractErrorMessage(body []byte) string {
-	var payload map[string]any
-	if err := json.Unmarshal(body, &payload); err != nil {
-		return ""
+// isServiceFailure reports whether err counts against the circuit breaker.
+// Backpressure comes from a healthy service shedding load.
+func isServiceFailure(err error) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.retryable() && apiErr.Code != codeBackpressure
 	}
-	msg, _ := payload["error"].(string)
-	return msg
+	return errors.Is(err, ErrUnavailable)
 }
 
+func classify(ctx context.Context, err error) string {
+	var apiErr *Error
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit_open"
+	case ctx.Err() != nil:
+		return "canceled"
+	case errors.As(err, &apiErr):
+		switch {
+		case apiErr.Code == codeHoldNotFound:
+			return "hold_not_found"
+		case apiErr.Code == codeInsufficientStock:
+			return "insufficient_stock"
+		case apiErr.Code == codeBackpressure:
+			return "backpressure"
+		case apiErr.StatusCode < 500:
+			return "rejected"
+		}
+		return "server_error"
+	}
+	return "transport_error"
+}
@@ -0,0 +1,225 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	commonerrors "ticketing/internal/common/errors"
+)
+
+func testConfig() Config {
+	return Config{
+		Timeout:         time.Second,
+		MaxAttempts:     3,
+		BaseBackoff:     time.Millisecond,
+		MaxBackoff:      2 * time.Millisecond,
+		BreakerFailures: 3,
+		BreakerOpenFor:  time.Hour,
+	}
+}
+
+func respond(status int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+func TestClient_MapsErrorCodes(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		status int
+		body   string
+		is     []error
+		not    []error
+	}{
+		{"hold not found", 404, `{"error":"hold not found","code":"HOLD_NOT_FOUND"}`, []error{ErrHoldNotFound, ErrRequestRejected}, []error{ErrUnavailable}},
+		{"legacy 404", 404, `{"error":"gone"}`, []error{ErrHoldNotFound}, nil},
+		{"insufficient stock", 400, `{"error":"insufficient stock","code":"INSUFFICIENT_STOCK"}`, []error{ErrInsufficientStock, ErrRequestRejected}, []error{ErrHoldNotFound}},
+		{"invalid", 400, `{"error":"invalid json","code":"INVALID_REQUEST"}`, []error{ErrRequestRejected}, []error{ErrHoldNotFound, ErrInsufficientStock}},
+		{"backpressure", 503, `{"error":"wal backpressure","code":"BACKPRESSURE"}`, []error{ErrUnavailable, commonerrors.ErrDependencyUnavailable}, []error{ErrRequestRejected}},
+	}
+	for _, tc := range cases {
+		srv := httptest.NewServer(respond(tc.status, tc.body))
+		err := NewClient(srv.URL, testConfig()).TryHold(context.Background(), TryHoldInput{PartitionKey: "p", HoldID: "h", Qty: 1})
+		srv.Close()
+		for _, target := range tc.is {
+			if !errors.Is(err, target) {
+				t.Fatalf("%s: expected %v, got %v", tc.name, target, err)
+			}
+		}
+		for _, target := range tc.not {
+			if errors.Is(err, target) {
+				t.Fatalf("%s: did not expect %v, got %v", tc.name, target, err)
+			}
+		}
+	}
+}
+
+func TestClient_RetriesIdempotentCalls(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			respond(500, `{"error":"boom","code":"INTERNAL"}`)(w, r)
+			return
+		}
+		respond(200, `{}`)(w, r)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, testConfig())
+	if err := client.ReleaseHold(context.Background(), ReleaseInput{PartitionKey: "p", HoldID: "h"}); err != nil {
+		t.Fatalf("expected release to succeed after retries, got %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestClient_DoesNotRetryTryHoldOrRejections(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	status := atomic.Int32{}
+	status.Store(500)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		respond(int(status.Load()), `{"error":"x"}`)(w, r)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, testConfig())
+	if err := client.TryHold(context.Background(), TryHoldInput{PartitionKey: "p", HoldID: "h", Qty: 1}); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable, got %v", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("expected try-hold to be sent once, got %d", got)
+	}
+
+	calls.Store(0)
+	status.Store(400)
+	if err := client.ConfirmHold(context.Background(), ConfirmInput{PartitionKey: "p", HoldID: "h"}); !errors.Is(err, ErrRequestRejected) {
+		t.Fatalf("expected ErrRequestRejected, got %v", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("expected a rejected confirm to be sent once, got %d", got)
+	}
+}
+
+func TestClient_CircuitOpensAndFailsFast(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		respond(502, `bad gateway`)(w, r)
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	cfg.MaxAttempts = 1
+	client := NewClient(srv.URL, cfg)
+	for i := 0; i < cfg.BreakerFailures; i++ {
+		if err := client.ReleaseHold(context.Background(), ReleaseInput{PartitionKey: "p", HoldID: "h"}); !errors.Is(err, ErrUnavailable) {
+			t.Fatalf("expected ErrUnavailable, got %v", err)
+		}
+	}
+	err := client.ReleaseHold(context.Background(), ReleaseInput{PartitionKey: "p", HoldID: "h"})
+	if !errors.Is(err, ErrCircuitOpen) || !errors.Is(err, commonerrors.ErrDependencyUnavailable) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if got := calls.Load(); int(got) != cfg.BreakerFailures {
+		t.Fatalf("expected %d calls to reach the server, got %d", cfg.BreakerFailures, got)
+	}
+}
+
+func TestClient_BackpressureDoesNotOpenCircuit(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(respond(503, `{"error":"wal backpressure","code":"BACKPRESSURE"}`))
+	defer srv.Close()
+
+	cfg := testConfig()
+	cfg.MaxAttempts = 1
+	client := NewClient(srv.URL, cfg)
+	for i := 0; i < cfg.BreakerFailures+1; i++ {
+		if err := client.ReleaseHold(context.Background(), ReleaseInput{PartitionKey: "p", HoldID: "h"}); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("backpressure must not open the circuit")
+		}
+	}
+}
+
+func TestBreaker_HalfOpenProbe(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	b := newBreaker(2, 10*time.Second)
+	b.now = func() time.Time { return now }
+
+	b.record(breakerFailure, false)
+	b.record(breakerFailure, false)
+	if ok, _ := b.allow(); ok {
+		t.Fatalf("expected open circuit to reject calls")
+	}
+
+	now = now.Add(10 * time.Second)
+	if ok, probe := b.allow(); !ok || !probe {
+		t.Fatalf("expected a probe after the open period")
+	}
+	if ok, _ := b.allow(); ok {
+		t.Fatalf("expected a single probe while half-open")
+	}
+	b.record(breakerFailure, true)
+	if ok, _ := b.allow(); b.current() != breakerOpen || ok {
+		t.Fatalf("expected a failed probe to reopen the circuit")
+	}
+
+	now = now.Add(10 * time.Second)
+	if ok, probe := b.allow(); !ok || !probe {
+		t.Fatalf("expected a probe after the open period")
+	}
+	b.record(breakerSuccess, true)
+	if ok, probe := b.allow(); b.current() != breakerClosed || !ok || probe {
+		t.Fatalf("expected a successful probe to close the circuit")
+	}
+}
+
+func TestBreaker_OnlyTheProbeEndsHalfOpen(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	b := newBreaker(1, 10*time.Second)
+	b.now = func() time.Time { return now }
+
+	// A call admitted while closed is still in flight when the circuit opens.
+	if ok, probe := b.allow(); !ok || probe {
+		t.Fatalf("expected a closed circuit to admit a plain call")
+	}
+	b.record(breakerFailure, false)
+
+	now = now.Add(10 * time.Second)
+	if ok, probe := b.allow(); !ok || !probe {
+		t.Fatalf("expected a probe after the open period")
+	}
+	b.record(breakerSuccess, false)
+	if b.current() != breakerHalfOpen {
+		t.Fatalf("expected a late plain call to leave the circuit half-open, got %v", b.current())
+	}
+	if ok, _ := b.allow(); ok {
+		t.Fatalf("expected a late plain call not to let a second probe through")
+	}
+	b.record(breakerSuccess, true)
+	if b.current() != breakerClosed {
+		t.Fatalf("expected the probe to close the circuit, got %v", b.current())
+	}
+}
@@ -0,0 +1,129 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	inventoryv1 "ticketing/proto/inventory/v1"
+)
+
+// errorDomain is the ErrorInfo domain inventory-service sets on its errors.
+const errorDomain = "inventory.v1"
+
+// GRPCClient calls inventory.v1.InventoryService.
+type GRPCClient struct {
+	*caller
+	conn   *grpc.ClientConn
+	client inventoryv1.InventoryServiceClient
+}
+
+func NewGRPCClient(addr string, cfg Config) (*GRPCClient, error) {
+	cfg = cfg.withDefaults()
+	conn, err := grpc.NewClient(
+		addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &GRPCClient{
+		caller: newCaller(cfg),
+		conn:   conn,
+		client: inventoryv1.NewInventoryServiceClient(conn),
+	}, nil
+}
+
+// TryHold is not retried, for the same reason as Client.TryHold.
+func (c *GRPCClient) TryHold(ctx context.Context, in TryHoldInput) error {
+	return c.call(ctx, "try_hold", false, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+		defer cancel()
+		_, err := c.client.TryHold(ctx, &inventoryv1.TryHoldRequest{
+			PartitionKey: in.PartitionKey,
+			HoldId:       in.HoldID,
+			Qty:          int32(in.Qty),
+			Capacity:     int32(in.Capacity),
+		})
+		return grpcError(inventoryv1.InventoryService_TryHold_FullMethodName, err)
+	})
+}
+
+func (c *GRPCClient) ConfirmHold(ctx context.Context, in ConfirmInput) error {
+	return c.call(ctx, "confirm_hold", true, c.send(inventoryv1.InventoryService_ConfirmHold_FullMethodName, c.client.ConfirmHold, in.PartitionKey, in.HoldID))
+}
+
+func (c *GRPCClient) ReleaseHold(ctx context.Context, in ReleaseInput) error {
+	return c.call(ctx, "release_hold", true, c.send(inventoryv1.InventoryService_ReleaseHold_FullMethodName, c.client.ReleaseHold, in.PartitionKey, in.HoldID))
+}
+
+func (c *GRPCClient) ReturnConfirmed(ctx context.Context, in ReturnInput) error {
+	return c.call(ctx, "return_confirmed", true, c.send(inventoryv1.InventoryService_ReturnConfirmed_FullMethodName, c.client.ReturnConfirmed, in.PartitionKey, in.HoldID))
+}
+
+func (c *GRPCClient) Close() error {
+	return c.conn.Close()
+}
+
+type holdRPC func(ctx context.Context, in *inventoryv1.HoldRequest, opts ...grpc.CallOption) (*inventoryv1.HoldResponse, error)
+
+// send returns an attempt that invokes a hold RPC with the per-attempt
+// timeout.
+func (c *GRPCClient) send(method string, rpc holdRPC, partitionKey string, holdID string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+		defer cancel()
+		_, err := rpc(ctx, &inventoryv1.HoldRequest{PartitionKey: partitionKey, HoldId: holdID})
+		return grpcError(method, err)
+	}
+}
+
+// grpcError turns a failed RPC into the errors Client returns. Statuses
+// without an inventory ErrorInfo come from the transport, not the service.
+func grpcError(method string, err error) error {
+	if err == nil {
+		return nil
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return fmt.Errorf("inventory %s: %w: %w", method, ErrUnavailable, err)
+	}
+	apiErr := &Error{Path: method, StatusCode: httpStatus(st.Code()), Message: st.Message()}
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok && info.GetDomain() == errorDomain {
+			apiErr.Code = info.GetReason()
+		}
+	}
+	if apiErr.Code == "" {
+		switch st.Code() {
+		case codes.NotFound:
+			apiErr.Code = codeHoldNotFound
+		case codes.InvalidArgument, codes.FailedPrecondition:
+		default:
+			return fmt.Errorf("inventory %s: %w: %w", method, ErrUnavailable, err)
+		}
+	}
+	return apiErr
+}
+
+// httpStatus gives a gRPC code the status the HTTP API answers with, so
+// Error classifies both transports alike.
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unavailable, codes.ResourceExhausted:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
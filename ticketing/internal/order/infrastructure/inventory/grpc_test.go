@@ -0,0 +1,103 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	inventoryv1 "ticketing/proto/inventory/v1"
+)
+
+type fakeInventoryServer struct {
+	inventoryv1.UnimplementedInventoryServiceServer
+	releases atomic.Int32
+	release  func(n int32) error
+}
+
+func (f *fakeInventoryServer) TryHold(ctx context.Context, req *inventoryv1.TryHoldRequest) (*inventoryv1.HoldResponse, error) {
+	return nil, codedStatus(codes.FailedPrecondition, inventoryv1.ErrorCode_INSUFFICIENT_STOCK)
+}
+
+func (f *fakeInventoryServer) ReleaseHold(ctx context.Context, req *inventoryv1.HoldRequest) (*inventoryv1.HoldResponse, error) {
+	if err := f.release(f.releases.Add(1)); err != nil {
+		return nil, err
+	}
+	return &inventoryv1.HoldResponse{State: &inventoryv1.PartitionState{PartitionKey: req.GetPartitionKey()}}, nil
+}
+
+func codedStatus(c codes.Code, code inventoryv1.ErrorCode) error {
+	st, err := status.New(c, code.String()).WithDetails(&errdetails.ErrorInfo{Reason: code.String(), Domain: errorDomain})
+	if err != nil {
+		panic(err)
+	}
+	return st.Err()
+}
+
+func startFakeInventory(t *testing.T, fake *fakeInventoryServer) *GRPCClient {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	srv := grpc.NewServer()
+	inventoryv1.RegisterInventoryServiceServer(srv, fake)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	client, err := NewGRPCClient(lis.Addr().String(), testConfig())
+	if err != nil {
+		t.Fatalf("client init failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestGRPCClient_MapsErrorInfo(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeInventoryServer{release: func(int32) error {
+		return codedStatus(codes.NotFound, inventoryv1.ErrorCode_HOLD_NOT_FOUND)
+	}}
+	client := startFakeInventory(t, fake)
+
+	err := client.TryHold(context.Background(), TryHoldInput{PartitionKey: "p", HoldID: "h", Qty: 1})
+	if !errors.Is(err, ErrInsufficientStock) || !errors.Is(err, ErrRequestRejected) {
+		t.Fatalf("expected insufficient stock, got %v", err)
+	}
+	err = client.ReleaseHold(context.Background(), ReleaseInput{PartitionKey: "p", HoldID: "h"})
+	if !errors.Is(err, ErrHoldNotFound) {
+		t.Fatalf("expected ErrHoldNotFound, got %v", err)
+	}
+	if got := fake.releases.Load(); got != 1 {
+		t.Fatalf("expected a missing hold not to be retried, got %d calls", got)
+	}
+	if err := client.ConfirmHold(context.Background(), ConfirmInput{PartitionKey: "p", HoldID: "h"}); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected an unimplemented RPC to surface as unavailable, got %v", err)
+	}
+}
+
+func TestGRPCClient_RetriesBackpressure(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeInventoryServer{release: func(n int32) error {
+		if n < 3 {
+			return codedStatus(codes.Unavailable, inventoryv1.ErrorCode_BACKPRESSURE)
+		}
+		return nil
+	}}
+	client := startFakeInventory(t, fake)
+
+	if err := client.ReleaseHold(context.Background(), ReleaseInput{PartitionKey: "p", HoldID: "h"}); err != nil {
+		t.Fatalf("expected release to succeed after retries, got %v", err)
+	}
+	if got := fake.releases.Load(); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+}
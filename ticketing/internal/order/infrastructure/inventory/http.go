@@ -0,0 +1,95 @@
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Client calls the JSON HTTP API of inventory-service.
+type Client struct {
+	*caller
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewClient(baseURL string, cfg Config) *Client {
+	cfg = cfg.withDefaults()
+	return &Client{
+		caller:  newCaller(cfg),
+		baseURL: strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{
+			Timeout: cfg.Timeout,
+		},
+	}
+}
+
+// TryHold is not retried: a hold the caller gave up on would keep seats
+// until its TTL. The reserve saga retries it under the same hold ID.
+func (c *Client) TryHold(ctx context.Context, in TryHoldInput) error {
+	return c.call(ctx, "try_hold", false, c.send("/inventory/try-hold", in))
+}
+
+func (c *Client) ConfirmHold(ctx context.Context, in ConfirmInput) error {
+	return c.call(ctx, "confirm_hold", true, c.send("/inventory/confirm-hold", in))
+}
+
+func (c *Client) ReleaseHold(ctx context.Context, in ReleaseInput) error {
+	return c.call(ctx, "release_hold", true, c.send("/inventory/release-hold", in))
+}
+
+func (c *Client) ReturnConfirmed(ctx context.Context, in ReturnInput) error {
+	return c.call(ctx, "return_confirmed", true, c.send("/inventory/return-confirmed", in))
+}
+
+// send returns an attempt that posts payload to path.
+func (c *Client) send(path string, payload any) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		return c.post(ctx, path, raw)
+	}
+}
+
+func (c *Client) post(ctx context.Context, path string, raw []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("inventory %s: %w: %w", path, ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	apiErr := &Error{Path: path, StatusCode: resp.StatusCode}
+	var payload struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil {
+		apiErr.Code = payload.Code
+		apiErr.Message = strings.TrimSpace(payload.Error)
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	// inventory-service builds without error codes only answer 404 on the
+	// hold endpoints for a missing hold.
+	if apiErr.Code == "" && resp.StatusCode == http.StatusNotFound {
+		apiErr.Code = codeHoldNotFound
+	}
+	return apiErr
+}
@@ -4,7 +4,28 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"strings"
 	"time"
+
+	"github.com/google/uuid"
+)
+
+// Outbox statuses. RETRY rows failed to publish and wait for next_retry_at;
+// DEAD rows failed too often and are left for an operator to replay.
+const (
+	StatusPending   = "PENDING"
+	StatusRetry     = "RETRY"
+	StatusPublished = "PUBLISHED"
+	StatusDead      = "DEAD"
+)
+
+var (
+	// ErrClaimLost means the claim on an event expired and another relay
+	// took it over before the outcome was recorded.
+	ErrClaimLost = errors.New("outbox claim lost")
+	// ErrNotDead means there is no dead event to replay under the ID.
+	ErrNotDead = errors.New("outbox event not found or not dead")
 )
 
 type Event struct {
@@ -14,6 +35,10 @@ type Event struct {
 	EventType   string
 	Payload     map[string]any
 	Status      string
+	RetryCount  int
+	NextRetryAt time.Time
+	ClaimToken  string
+	LastError   string
 	CreatedAt   time.Time
 }
 
@@ -32,18 +57,150 @@ func (r *Repository) InsertTx(ctx context.Context, tx *sql.Tx, eventID string, a
 	}
 	_, err = tx.ExecContext(
 		ctx,
-		`INSERT INTO outbox(event_id, aggregate_id, event_type, payload, status) VALUES(?, ?, ?, ?, 'PENDING')`,
+		`INSERT INTO outbox(event_id, aggregate_id, event_type, payload, status, retry_count, next_retry_at)
+		 VALUES(?, ?, ?, ?, 'PENDING', 0, CURRENT_TIMESTAMP)`,
 		eventID, aggregateID, eventType, raw,
 	)
 	return err
 }
 
-func (r *Repository) ListPending(ctx context.Context, limit int) ([]Event, error) {
+// ClaimPending claims up to limit due events for lease. Only the oldest
+// unpublished event of each aggregate is eligible, so an aggregate's events
+// are published in order even across relays; a dead event keeps blocking
+// the ones after it until it is replayed. Rows locked by another relay's
+// claim are skipped.
+func (r *Repository) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]Event, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT o.id, o.event_id, o.aggregate_id, o.event_type, o.payload, o.status, o.retry_count, o.next_retry_at, o.created_at
+		 FROM outbox o
+		 WHERE o.status IN ('PENDING', 'RETRY')
+		   AND o.next_retry_at <= CURRENT_TIMESTAMP
+		   AND NOT EXISTS (
+		     SELECT 1 FROM outbox prev
+		     WHERE prev.aggregate_id = o.aggregate_id
+		       AND prev.status IN ('PENDING', 'RETRY', 'DEAD')
+		       AND prev.id < o.id
+		   )
+		 ORDER BY o.id ASC
+		 LIMIT ?
+		 FOR UPDATE SKIP LOCKED`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Event, 0)
+	for rows.Next() {
+		var (
+			ev  Event
+			raw []byte
+		)
+		if err := rows.Scan(
+			&ev.ID,
+			&ev.EventID,
+			&ev.AggregateID,
+			&ev.EventType,
+			&raw,
+			&ev.Status,
+			&ev.RetryCount,
+			&ev.NextRetryAt,
+			&ev.CreatedAt,
+		); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &ev.Payload); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		out = append(out, ev)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return out, nil
+	}
+
+	token := uuid.NewString()
+	args := make([]any, 0, len(out)+2)
+	args = append(args, token, time.Now().Add(lease))
+	for i := range out {
+		out[i].ClaimToken = token
+		args = append(args, out[i].ID)
+	}
+	if _, err := tx.ExecContext(
+		ctx,
+		`UPDATE outbox SET claim_token=?, next_retry_at=?
+		 WHERE id IN (?`+strings.Repeat(", ?", len(out)-1)+`)`,
+		args...,
+	); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *Repository) MarkPublished(ctx context.Context, ev Event) error {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE outbox
+		 SET status='PUBLISHED',
+		     published_at=CURRENT_TIMESTAMP,
+		     claim_token=NULL
+		 WHERE id=? AND claim_token=?`,
+		ev.ID, ev.ClaimToken,
+	)
+	return claimed(res, err)
+}
+
+func (r *Repository) MarkRetry(ctx context.Context, ev Event, retryCount int, nextRetryAt time.Time, lastError string) error {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE outbox
+		 SET status='RETRY',
+		     retry_count=?,
+		     next_retry_at=?,
+		     last_error=?,
+		     claim_token=NULL
+		 WHERE id=? AND claim_token=?`,
+		retryCount, nextRetryAt, lastError, ev.ID, ev.ClaimToken,
+	)
+	return claimed(res, err)
+}
+
+func (r *Repository) MarkDead(ctx context.Context, ev Event, retryCount int, lastError string) error {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE outbox
+		 SET status='DEAD',
+		     retry_count=?,
+		     last_error=?,
+		     claim_token=NULL
+		 WHERE id=? AND claim_token=?`,
+		retryCount, lastError, ev.ID, ev.ClaimToken,
+	)
+	return claimed(res, err)
+}
+
+// ListDead returns up to limit dead events, oldest first. Each one holds
+// back the later events of its aggregate.
+func (r *Repository) ListDead(ctx context.Context, limit int) ([]Event, error) {
 	rows, err := r.db.QueryContext(
 		ctx,
-		`SELECT id, event_id, aggregate_id, event_type, payload, status, created_at
+		`SELECT id, event_id, aggregate_id, event_type, payload, status, retry_count, COALESCE(last_error, ''), created_at
 		 FROM outbox
-		 WHERE status='PENDING'
+		 WHERE status='DEAD'
 		 ORDER BY id ASC
 		 LIMIT ?`,
 		limit,
@@ -59,7 +216,7 @@ func (r *Repository) ListPending(ctx context.Context, limit int) ([]Event, error
 			ev  Event
 			raw []byte
 		)
-		if err := rows.Scan(&ev.ID, &ev.EventID, &ev.AggregateID, &ev.EventType, &raw, &ev.Status, &ev.CreatedAt); err != nil {
+		if err := rows.Scan(&ev.ID, &ev.EventID, &ev.AggregateID, &ev.EventType, &raw, &ev.Status, &ev.RetryCount, &ev.LastError, &ev.CreatedAt); err != nil {
 			return nil, err
 		}
 		if err := json.Unmarshal(raw, &ev.Payload); err != nil {
@@ -70,11 +227,42 @@ func (r *Repository) ListPending(ctx context.Context, limit int) ([]Event, error
 	return out, rows.Err()
 }
 
-func (r *Repository) MarkPublished(ctx context.Context, id int64) error {
-	_, err := r.db.ExecContext(
+// Replay puts a dead event back in line with a fresh retry budget. It is
+// published before the events of its aggregate that queued up behind it.
+func (r *Repository) Replay(ctx context.Context, eventID string) error {
+	res, err := r.db.ExecContext(
 		ctx,
-		`UPDATE outbox SET status='PUBLISHED', published_at=CURRENT_TIMESTAMP WHERE id=?`,
-		id,
+		`UPDATE outbox
+		 SET status='RETRY',
+		     retry_count=0,
+		     next_retry_at=CURRENT_TIMESTAMP,
+		     claim_token=NULL
+		 WHERE event_id=? AND status='DEAD'`,
+		eventID,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotDead
+	}
+	return nil
+}
+
+func claimed(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrClaimLost
+	}
+	return nil
 }
@@ -0,0 +1,26 @@
+package payment
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type RedisNonceStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisNonceStore(client *redis.Client, prefix string) *RedisNonceStore {
+	return &RedisNonceStore{client: client, prefix: prefix}
+}
+
+func (s *RedisNonceStore) Seen(ctx context.Context, nonce string) (bool, error) {
+	n, err := s.client.Exists(ctx, s.prefix+nonce).Result()
+	return n > 0, err
+}
+
+func (s *RedisNonceStore) Remember(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, s.prefix+nonce, 1, ttl).Result()
+}
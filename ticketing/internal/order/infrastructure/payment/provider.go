@@ -0,0 +1,92 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ticketing/internal/order/domain"
+)
+
+var (
+	ErrTradeNotFound = errors.New("payment trade not found")
+	// ErrRefundRejected marks refunds the provider will never accept, such as
+	// refunding a trade that was not paid.
+	ErrRefundRejected = errors.New("refund rejected by provider")
+)
+
+// Provider is the payment gateway as seen by order-service. Implementations
+// must be idempotent on PaymentID and RefundID.
+type Provider interface {
+	Name() string
+	// CreatePayment registers the payment with the provider and returns
+	// what the client needs to pay (prepay).
+	CreatePayment(ctx context.Context, in CreateInput) (*Intent, error)
+	// QueryPayment reports the provider's view of the payment; it returns
+	// ErrTradeNotFound when the provider never saw it.
+	QueryPayment(ctx context.Context, paymentID string) (*Trade, error)
+	Refund(ctx context.Context, in RefundInput) (*Refund, error)
+	// VerifyCallback authenticates an asynchronous notification and
+	// rejects replays of one already accepted.
+	VerifyCallback(ctx context.Context, n Notification) error
+	// AcceptCallback marks a verified notification as accepted once it has
+	// been handled. Until then the provider may deliver it again.
+	AcceptCallback(ctx context.Context, n Notification) error
+}
+
+type CreateInput struct {
+	PaymentID   string
+	OrderID     string
+	AmountCents int64
+	Currency    string
+	Subject     string
+	ExpiresAt   time.Time
+}
+
+type Intent struct {
+	PaymentID string
+	PrepayID  string
+	ExpiresAt time.Time
+}
+
+type Trade struct {
+	PaymentID     string
+	OrderID       string
+	ProviderTxnID string
+	Status        domain.PaymentStatus
+	AmountCents   int64
+	Currency      string
+	PaidAt        time.Time
+}
+
+type RefundInput struct {
+	RefundID      string
+	PaymentID     string
+	ProviderTxnID string
+	AmountCents   int64
+	Currency      string
+	Reason        string
+}
+
+type Refund struct {
+	RefundID         string
+	ProviderRefundID string
+	Status           string
+}
+
+// Notification is the payload of a payment callback as received over HTTP.
+// AmountCents and Currency are what the customer actually paid. KeyID names
+// the key Signature was made with; Timestamp (Unix seconds) and Nonce are
+// signed along with the payload.
+type Notification struct {
+	OrderID       string
+	PaymentID     string
+	ProviderTxnID string
+	Status        string
+	AmountCents   int64
+	Currency      string
+	KeyID         string
+	Timestamp     int64
+	Nonce         string
+	Signature     string
+}
@@ -0,0 +1,319 @@
+package payment
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid payment signature")
+	// The errors below all wrap ErrInvalidSignature so callers can treat any
+	// rejected notification alike.
+	ErrUnknownKey           = fmt.Errorf("%w: unknown key id", ErrInvalidSignature)
+	ErrStaleNotification    = fmt.Errorf("%w: timestamp outside freshness window", ErrInvalidSignature)
+	ErrReplayedNotification = fmt.Errorf("%w: nonce already used", ErrInvalidSignature)
+)
+
+// DefaultKeyID names the key configured through the single shared secret.
+const DefaultKeyID = "default"
+
+// CanonicalPayload is the byte string every scheme signs.
+func CanonicalPayload(n Notification) []byte {
+	return []byte(strings.Join([]string{
+		n.OrderID,
+		n.PaymentID,
+		n.ProviderTxnID,
+		strings.ToUpper(strings.TrimSpace(n.Status)),
+		strconv.FormatInt(n.AmountCents, 10),
+		strings.ToUpper(strings.TrimSpace(n.Currency)),
+		strconv.FormatInt(n.Timestamp, 10),
+		n.Nonce,
+	}, "|"))
+}
+
+// KeyVerifier checks a signature over a payload with one public or shared
+// key.
+type KeyVerifier interface {
+	Verify(payload []byte, signature string) error
+}
+
+// Signer produces the signature a provider attaches to a notification.
+type Signer interface {
+	KeyID() string
+	Sign(payload []byte) (string, error)
+}
+
+// SignNotification fills in KeyID and Signature of n, which must already
+// carry its Timestamp and Nonce.
+func SignNotification(signer Signer, n Notification) (Notification, error) {
+	n.KeyID = signer.KeyID()
+	sig, err := signer.Sign(CanonicalPayload(n))
+	if err != nil {
+		return n, err
+	}
+	n.Signature = sig
+	return n, nil
+}
+
+// HMACKey is a shared HMAC-SHA256 secret, hex encoded; both sides use it to
+// sign and to verify.
+type HMACKey struct {
+	id     string
+	secret []byte
+}
+
+func NewHMACKey(keyID string, secret string) *HMACKey {
+	return &HMACKey{id: keyID, secret: []byte(secret)}
+}
+
+func (k *HMACKey) KeyID() string {
+	return k.id
+}
+
+func (k *HMACKey) Sign(payload []byte) (string, error) {
+	mac := hmac.New(sha256.New, k.secret)
+	_, _ = mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func (k *HMACKey) Verify(payload []byte, signature string) error {
+	expected, _ := k.Sign(payload)
+	if !hmac.Equal([]byte(strings.ToLower(strings.TrimSpace(signature))), []byte(expected)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// SHA256withRSA (PKCS #1 v1.5), base64 encoded, as used by Alipay RSA2 and
+// WeChat Pay v3.
+type rsaVerifier struct {
+	pub *rsa.PublicKey
+}
+
+func (v *rsaVerifier) Verify(payload []byte, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	digest := sha256.Sum256(payload)
+	if rsa.VerifyPKCS1v15(v.pub, crypto.SHA256, digest[:], sig) != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+type rsaSigner struct {
+	id   string
+	priv *rsa.PrivateKey
+}
+
+func NewRSASigner(keyID string, priv *rsa.PrivateKey) Signer {
+	return &rsaSigner{id: keyID, priv: priv}
+}
+
+func (s *rsaSigner) KeyID() string {
+	return s.id
+}
+
+func (s *rsaSigner) Sign(payload []byte) (string, error) {
+	digest := sha256.Sum256(payload)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.priv, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// Ed25519, base64 encoded.
+type ed25519Verifier struct {
+	pub ed25519.PublicKey
+}
+
+func (v *ed25519Verifier) Verify(payload []byte, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil || !ed25519.Verify(v.pub, payload, sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+type ed25519Signer struct {
+	id   string
+	priv ed25519.PrivateKey
+}
+
+func NewEd25519Signer(keyID string, priv ed25519.PrivateKey) Signer {
+	return &ed25519Signer{id: keyID, priv: priv}
+}
+
+func (s *ed25519Signer) KeyID() string {
+	return s.id
+}
+
+func (s *ed25519Signer) Sign(payload []byte) (string, error) {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(s.priv, payload)), nil
+}
+
+// ParsePublicKey reads a PEM encoded PKIX public key and returns the
+// verifier for its algorithm (RSA or Ed25519).
+func ParsePublicKey(pemBytes []byte) (KeyVerifier, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		return &rsaVerifier{pub: key}, nil
+	case ed25519.PublicKey:
+		return &ed25519Verifier{pub: key}, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+// ParseKeySpecs parses a comma separated list of id:scheme:value entries,
+// where scheme is hmac (value is the secret) or rsa / ed25519 (value is the
+// path of a PEM public key file).
+func ParseKeySpecs(spec string) (map[string]KeyVerifier, error) {
+	keys := make(map[string]KeyVerifier)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid key spec %q", entry)
+		}
+		keyID, scheme, value := parts[0], strings.ToLower(parts[1]), parts[2]
+		if _, dup := keys[keyID]; dup {
+			return nil, fmt.Errorf("duplicate key id %q", keyID)
+		}
+		switch scheme {
+		case "hmac":
+			keys[keyID] = NewHMACKey(keyID, value)
+		case "rsa", "ed25519":
+			raw, err := os.ReadFile(value)
+			if err != nil {
+				return nil, fmt.Errorf("read key %s: %w", keyID, err)
+			}
+			v, err := ParsePublicKey(raw)
+			if err != nil {
+				return nil, fmt.Errorf("parse key %s: %w", keyID, err)
+			}
+			keys[keyID] = v
+		default:
+			return nil, fmt.Errorf("unknown signature scheme %q for key %s", scheme, keyID)
+		}
+	}
+	return keys, nil
+}
+
+// NonceStore remembers nonces of accepted notifications.
+type NonceStore interface {
+	// Seen reports whether nonce is remembered.
+	Seen(ctx context.Context, nonce string) (bool, error)
+	// Remember stores nonce for ttl and reports false when it was already
+	// there.
+	Remember(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
+// CallbackVerifier accepts a notification when it is signed by one of the
+// active keys, its timestamp lies within the freshness window and its nonce
+// has not been accepted during that window. Several keys may be active at
+// once, which is how keys are rotated.
+//
+// A nonce counts as accepted only once Accept is called after the
+// notification has been handled, so a provider retrying a notification
+// whose handling failed is not turned away as a replay. Duplicates that
+// arrive while the first is still being handled both pass; handling
+// callbacks is idempotent.
+type CallbackVerifier struct {
+	keys   map[string]KeyVerifier
+	window time.Duration
+	nonces NonceStore
+	now    func() time.Time
+}
+
+// NewCallbackVerifier returns a verifier for keys. With no keys every
+// notification is accepted, which keeps local setups without keys working.
+// A nil nonces disables replay detection.
+func NewCallbackVerifier(keys map[string]KeyVerifier, window time.Duration, nonces NonceStore) *CallbackVerifier {
+	if window <= 0 {
+		window = 5 * time.Minute
+	}
+	return &CallbackVerifier{keys: keys, window: window, nonces: nonces, now: time.Now}
+}
+
+func (v *CallbackVerifier) Verify(ctx context.Context, n Notification) error {
+	if v == nil || len(v.keys) == 0 {
+		return nil
+	}
+	keyID := v.keyID(n)
+	key, ok := v.keys[keyID]
+	if !ok {
+		return ErrUnknownKey
+	}
+	if err := key.Verify(CanonicalPayload(n), n.Signature); err != nil {
+		return err
+	}
+	age := v.now().Sub(time.Unix(n.Timestamp, 0))
+	if age > v.window || age < -v.window {
+		return ErrStaleNotification
+	}
+	if n.Nonce == "" {
+		return fmt.Errorf("%w: missing nonce", ErrInvalidSignature)
+	}
+	if v.nonces == nil {
+		return nil
+	}
+	seen, err := v.nonces.Seen(ctx, keyID+":"+n.Nonce)
+	if err != nil {
+		return err
+	}
+	if seen {
+		return ErrReplayedNotification
+	}
+	return nil
+}
+
+// Accept remembers the nonce of a verified notification once it has been
+// handled, so replays of it are rejected from then on.
+func (v *CallbackVerifier) Accept(ctx context.Context, n Notification) error {
+	if v == nil || len(v.keys) == 0 || v.nonces == nil || n.Nonce == "" {
+		return nil
+	}
+	// Anything older than the window is already rejected by timestamp, so
+	// nonces only need to outlive it on both sides.
+	_, err := v.nonces.Remember(ctx, v.keyID(n)+":"+n.Nonce, 2*v.window)
+	return err
+}
+
+// keyID is the key n names, or the only key when n names none.
+func (v *CallbackVerifier) keyID(n Notification) string {
+	if n.KeyID == "" && len(v.keys) == 1 {
+		for id := range v.keys {
+			return id
+		}
+	}
+	return n.KeyID
+}
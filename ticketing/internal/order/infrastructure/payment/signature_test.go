@@ -0,0 +1,163 @@
+package payment
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryNonceStore struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func newMemoryNonceStore() *memoryNonceStore {
+	return &memoryNonceStore{seen: make(map[string]bool)}
+}
+
+func (m *memoryNonceStore) Seen(_ context.Context, nonce string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.seen[nonce], nil
+}
+
+func (m *memoryNonceStore) Remember(_ context.Context, nonce string, _ time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.seen[nonce] {
+		return false, nil
+	}
+	m.seen[nonce] = true
+	return true, nil
+}
+
+func publicKeyPEM(t *testing.T, pub any) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestCallbackVerifier_SchemesAndRotation(t *testing.T) {
+	t.Parallel()
+
+	rsaPriv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ed25519 key: %v", err)
+	}
+	rsaVerifier, err := ParsePublicKey(publicKeyPEM(t, &rsaPriv.PublicKey))
+	if err != nil {
+		t.Fatalf("parse rsa key: %v", err)
+	}
+	edVerifier, err := ParsePublicKey(publicKeyPEM(t, edPub))
+	if err != nil {
+		t.Fatalf("parse ed25519 key: %v", err)
+	}
+
+	oldKey := NewHMACKey("2025", "old-secret")
+	newKey := NewHMACKey("2026", "new-secret")
+	verifier := NewCallbackVerifier(map[string]KeyVerifier{
+		"2025": oldKey,
+		"2026": newKey,
+		"rsa":  rsaVerifier,
+		"ed":   edVerifier,
+	}, time.Minute, newMemoryNonceStore())
+
+	base := Notification{OrderID: "o-1", PaymentID: "p-1", ProviderTxnID: "t-1", Status: "SUCCESS", Timestamp: time.Now().Unix()}
+	signers := []Signer{oldKey, newKey, NewRSASigner("rsa", rsaPriv), NewEd25519Signer("ed", edPriv)}
+	for i, signer := range signers {
+		n := base
+		n.Nonce = "nonce-" + signer.KeyID()
+		signed, err := SignNotification(signer, n)
+		if err != nil {
+			t.Fatalf("sign with %s: %v", signer.KeyID(), err)
+		}
+		if err := verifier.Verify(context.Background(), signed); err != nil {
+			t.Fatalf("signer %d (%s) rejected: %v", i, signer.KeyID(), err)
+		}
+		if err := verifier.Accept(context.Background(), signed); err != nil {
+			t.Fatalf("accept %s: %v", signer.KeyID(), err)
+		}
+		if err := verifier.Verify(context.Background(), signed); !errors.Is(err, ErrReplayedNotification) {
+			t.Fatalf("expected ErrReplayedNotification for %s, got: %v", signer.KeyID(), err)
+		}
+		signed.Nonce = "tampered"
+		if err := verifier.Verify(context.Background(), signed); !errors.Is(err, ErrInvalidSignature) {
+			t.Fatalf("expected ErrInvalidSignature for tampered %s, got: %v", signer.KeyID(), err)
+		}
+	}
+
+	n := base
+	n.Nonce = "unknown-key"
+	signed, _ := SignNotification(NewHMACKey("retired", "old-secret"), n)
+	if err := verifier.Verify(context.Background(), signed); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey, got: %v", err)
+	}
+
+	n.Nonce = "stale"
+	n.Timestamp = time.Now().Add(-2 * time.Minute).Unix()
+	signed, _ = SignNotification(newKey, n)
+	if err := verifier.Verify(context.Background(), signed); !errors.Is(err, ErrStaleNotification) {
+		t.Fatalf("expected ErrStaleNotification, got: %v", err)
+	}
+}
+
+func TestCallbackVerifier_RetryBeforeAcceptIsNotAReplay(t *testing.T) {
+	t.Parallel()
+
+	key := NewHMACKey("2026", "secret")
+	verifier := NewCallbackVerifier(map[string]KeyVerifier{"2026": key}, time.Minute, newMemoryNonceStore())
+	signed, err := SignNotification(key, Notification{OrderID: "o-1", Status: "SUCCESS", Timestamp: time.Now().Unix(), Nonce: "n-1"})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	// Handling the first delivery failed, so it was never accepted.
+	if err := verifier.Verify(context.Background(), signed); err != nil {
+		t.Fatalf("first delivery rejected: %v", err)
+	}
+	if err := verifier.Verify(context.Background(), signed); err != nil {
+		t.Fatalf("expected the provider's retry to pass, got: %v", err)
+	}
+	if err := verifier.Accept(context.Background(), signed); err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	if err := verifier.Verify(context.Background(), signed); !errors.Is(err, ErrReplayedNotification) {
+		t.Fatalf("expected ErrReplayedNotification once accepted, got: %v", err)
+	}
+}
+
+func TestParseKeySpecs(t *testing.T) {
+	t.Parallel()
+
+	keys, err := ParseKeySpecs("2025:hmac:old-secret, 2026:hmac:new:secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 keys, got %d", len(keys))
+	}
+	n := Notification{OrderID: "o-1", Status: "SUCCESS"}
+	signed, _ := SignNotification(NewHMACKey("2026", "new:secret"), n)
+	if err := keys["2026"].Verify(CanonicalPayload(signed), signed.Signature); err != nil {
+		t.Fatalf("secret containing ':' not preserved: %v", err)
+	}
+	for _, spec := range []string{"k1:hmac", "k1:dsa:x", "k1:hmac:a,k1:hmac:b", "k1:rsa:/does/not/exist.pem"} {
+		if _, err := ParseKeySpecs(spec); err == nil {
+			t.Fatalf("expected error for spec %q", spec)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ticketing/internal/order/domain"
+)
+
+type SimulatedConfig struct {
+	// CallbackURL receives the signed notification; empty disables
+	// callbacks so only QueryPayment reveals the outcome.
+	CallbackURL   string
+	CallbackDelay time.Duration
+	// Signer signs outgoing notifications; nil sends them unsigned.
+	Signer   Signer
+	Verifier *CallbackVerifier
+}
+
+// Simulated is an in-process provider for local runs and tests. Every
+// payment succeeds after CallbackDelay and is then reported to CallbackURL,
+// like a customer paying right away. State is kept in memory only.
+type Simulated struct {
+	logger     *slog.Logger
+	cfg        SimulatedConfig
+	httpClient *http.Client
+
+	mu      sync.Mutex
+	trades  map[string]*Trade
+	refunds map[string]*Refund
+	now     func() time.Time
+}
+
+func NewSimulated(logger *slog.Logger, cfg SimulatedConfig) *Simulated {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Simulated{
+		logger:     logger,
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: 3 * time.Second},
+		trades:     make(map[string]*Trade),
+		refunds:    make(map[string]*Refund),
+		now:        time.Now,
+	}
+}
+
+func (s *Simulated) Name() string {
+	return "simulated"
+}
+
+func (s *Simulated) CreatePayment(_ context.Context, in CreateInput) (*Intent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.trades[in.PaymentID]; !ok {
+		s.trades[in.PaymentID] = &Trade{
+			PaymentID:   in.PaymentID,
+			OrderID:     in.OrderID,
+			Status:      domain.PaymentStatusPending,
+			AmountCents: in.AmountCents,
+			Currency:    in.Currency,
+		}
+		go s.settle(in.PaymentID)
+	}
+	return &Intent{
+		PaymentID: in.PaymentID,
+		PrepayID:  "sim-prepay-" + in.PaymentID,
+		ExpiresAt: in.ExpiresAt,
+	}, nil
+}
+
+func (s *Simulated) QueryPayment(_ context.Context, paymentID string) (*Trade, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.trades[paymentID]
+	if !ok {
+		return nil, ErrTradeNotFound
+	}
+	out := *t
+	return &out, nil
+}
+
+func (s *Simulated) Refund(_ context.Context, in RefundInput) (*Refund, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, ok := s.refunds[in.RefundID]; ok {
+		out := *r
+		return &out, nil
+	}
+	t, ok := s.trades[in.PaymentID]
+	if !ok {
+		return nil, ErrTradeNotFound
+	}
+	if t.Status != domain.PaymentStatusSuccess || in.AmountCents <= 0 || in.AmountCents > t.AmountCents {
+		return nil, ErrRefundRejected
+	}
+	r := &Refund{RefundID: in.RefundID, ProviderRefundID: "sim-refund-" + uuid.NewString(), Status: "SUCCESS"}
+	s.refunds[in.RefundID] = r
+	out := *r
+	return &out, nil
+}
+
+func (s *Simulated) VerifyCallback(ctx context.Context, n Notification) error {
+	return s.cfg.Verifier.Verify(ctx, n)
+}
+
+func (s *Simulated) AcceptCallback(ctx context.Context, n Notification) error {
+	return s.cfg.Verifier.Accept(ctx, n)
+}
+
+func (s *Simulated) settle(paymentID string) {
+	time.Sleep(s.cfg.CallbackDelay)
+
+	s.mu.Lock()
+	t := s.trades[paymentID]
+	t.Status = domain.PaymentStatusSuccess
+	t.ProviderTxnID = "sim-" + uuid.NewString()
+	t.PaidAt = s.now()
+	n := Notification{
+		OrderID:       t.OrderID,
+		PaymentID:     t.PaymentID,
+		ProviderTxnID: t.ProviderTxnID,
+		Status:        string(t.Status),
+		AmountCents:   t.AmountCents,
+		Currency:      t.Currency,
+	}
+	s.mu.Unlock()
+
+	if s.cfg.CallbackURL == "" {
+		return
+	}
+	if err := s.notify(n); err != nil {
+		// The reconciler picks the payment up through QueryPayment.
+		s.logger.Warn("simulated payment callback failed", "payment_id", paymentID, "error", err)
+	}
+}
+
+func (s *Simulated) notify(n Notification) error {
+	n.Timestamp = s.now().Unix()
+	n.Nonce = uuid.NewString()
+	if s.cfg.Signer != nil {
+		signed, err := SignNotification(s.cfg.Signer, n)
+		if err != nil {
+			return err
+		}
+		n = signed
+	}
+	body, err := json.Marshal(map[string]any{
+		"order_id":        n.OrderID,
+		"payment_id":      n.PaymentID,
+		"provider_txn_id": n.ProviderTxnID,
+		"status":          n.Status,
+		"amount_cents":    n.AmountCents,
+		"currency":        n.Currency,
+		"key_id":          n.KeyID,
+		"timestamp":       n.Timestamp,
+		"nonce":           n.Nonce,
+		"signature":       n.Signature,
+	})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.CallbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("callback status=%d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ticketing/internal/order/domain"
+)
+
+func TestSimulated_PaysAndCallsBack(t *testing.T) {
+	t.Parallel()
+
+	received := make(chan Notification, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			OrderID       string `json:"order_id"`
+			PaymentID     string `json:"payment_id"`
+			ProviderTxnID string `json:"provider_txn_id"`
+			Status        string `json:"status"`
+			AmountCents   int64  `json:"amount_cents"`
+			Currency      string `json:"currency"`
+			KeyID         string `json:"key_id"`
+			Timestamp     int64  `json:"timestamp"`
+			Nonce         string `json:"nonce"`
+			Signature     string `json:"signature"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		received <- Notification(body)
+	}))
+	defer server.Close()
+
+	key := NewHMACKey("k1", "unit-test-key")
+	provider := NewSimulated(nil, SimulatedConfig{
+		CallbackURL:   server.URL,
+		CallbackDelay: 10 * time.Millisecond,
+		Signer:        key,
+		Verifier:      NewCallbackVerifier(map[string]KeyVerifier{"k1": key}, time.Minute, newMemoryNonceStore()),
+	})
+	if _, err := provider.CreatePayment(context.Background(), CreateInput{PaymentID: "pay-1", OrderID: "order-1", AmountCents: 18800, Currency: "CNY"}); err != nil {
+		t.Fatalf("create payment failed: %v", err)
+	}
+
+	select {
+	case n := <-received:
+		if n.PaymentID != "pay-1" || n.Status != string(domain.PaymentStatusSuccess) || n.AmountCents != 18800 || n.Currency != "CNY" {
+			t.Fatalf("unexpected notification: %+v", n)
+		}
+		if err := provider.VerifyCallback(context.Background(), n); err != nil {
+			t.Fatalf("callback signature rejected: %v", err)
+		}
+		underpaid := n
+		underpaid.AmountCents = 1
+		if err := provider.VerifyCallback(context.Background(), underpaid); !errors.Is(err, ErrInvalidSignature) {
+			t.Fatalf("expected ErrInvalidSignature for altered amount, got: %v", err)
+		}
+		n.Status = "FAILED"
+		if err := provider.VerifyCallback(context.Background(), n); !errors.Is(err, ErrInvalidSignature) {
+			t.Fatalf("expected ErrInvalidSignature for altered status, got: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("callback not received")
+	}
+
+	trade, err := provider.QueryPayment(context.Background(), "pay-1")
+	if err != nil || trade.Status != domain.PaymentStatusSuccess || trade.ProviderTxnID == "" {
+		t.Fatalf("unexpected trade: %+v, err=%v", trade, err)
+	}
+	if _, err := provider.QueryPayment(context.Background(), "pay-unknown"); !errors.Is(err, ErrTradeNotFound) {
+		t.Fatalf("expected ErrTradeNotFound, got: %v", err)
+	}
+	refund, err := provider.Refund(context.Background(), RefundInput{RefundID: "refund-1", PaymentID: "pay-1", AmountCents: 18800})
+	if err != nil || refund.Status != "SUCCESS" {
+		t.Fatalf("unexpected refund: %+v, err=%v", refund, err)
+	}
+	if _, err := provider.Refund(context.Background(), RefundInput{RefundID: "refund-2", PaymentID: "pay-1", AmountCents: 99999}); !errors.Is(err, ErrRefundRejected) {
+		t.Fatalf("expected ErrRefundRejected, got: %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package reconciliation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"ticketing/internal/order/domain"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) DB() *sql.DB {
+	return r.db
+}
+
+// ledgerSelect reads collected payments. Payments recorded before the paid
+// amount was stored fall back to the amount asked for.
+const ledgerSelect = `SELECT p.payment_id, p.order_id, COALESCE(p.provider_txn_id, ''),
+		        COALESCE(p.paid_amount_cents, NULLIF(p.amount_cents, 0), o.amount_cents),
+		        COALESCE(NULLIF(p.paid_currency, ''), p.currency), p.status, o.status, p.paid_at
+		 FROM payments p JOIN orders o ON o.order_id = p.order_id
+		 WHERE p.status IN ('SUCCESS', 'REFUNDING', 'REFUNDED')`
+
+// ListLedger returns the payments of provider paid in [from, to). Payments
+// recorded without a provider name are included.
+func (r *Repository) ListLedger(ctx context.Context, provider string, from time.Time, to time.Time) ([]domain.LedgerEntry, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		ledgerSelect+` AND p.paid_at >= ? AND p.paid_at < ? AND p.provider IN (?, '')
+		 ORDER BY p.paid_at ASC, p.id ASC`,
+		from, to, provider,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanLedger(rows)
+}
+
+// FindLedgerByTxnIDs returns the collected payments carrying any of the
+// provider transaction IDs, whatever day they were paid on.
+func (r *Repository) FindLedgerByTxnIDs(ctx context.Context, txnIDs []string) ([]domain.LedgerEntry, error) {
+	if len(txnIDs) == 0 {
+		return nil, nil
+	}
+	args := make([]any, len(txnIDs))
+	for i, id := range txnIDs {
+		args[i] = id
+	}
+	rows, err := r.db.QueryContext(
+		ctx,
+		ledgerSelect+` AND p.provider_txn_id IN (?`+strings.Repeat(", ?", len(txnIDs)-1)+`)`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanLedger(rows)
+}
+
+func (r *Repository) InsertRunTx(ctx context.Context, tx *sql.Tx, run *domain.ReconciliationRun) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO reconciliation_runs(
+		   run_id, provider, settlement_date, source_file, provider_records, local_records,
+		   matched, missing, extra, mismatched)
+		 VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		run.RunID, run.Provider, run.SettlementDate, run.SourceFile, run.ProviderRecords, run.LocalRecords,
+		run.Matched, run.Missing, run.Extra, run.Mismatched,
+	)
+	return err
+}
+
+func (r *Repository) InsertItemTx(ctx context.Context, tx *sql.Tx, runID string, d domain.Discrepancy) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO reconciliation_items(
+		   run_id, kind, provider_txn_id, payment_id, order_id, local_amount_cents, local_currency,
+		   provider_amount_cents, provider_currency, order_status, detail)
+		 VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		runID, string(d.Kind), d.ProviderTxnID, d.PaymentID, d.OrderID, d.LocalAmountCents, d.LocalCurrency,
+		d.ProviderAmountCents, d.ProviderCurrency, string(d.OrderStatus), d.Detail,
+	)
+	return err
+}
+
+const runColumns = `run_id, provider, DATE_FORMAT(settlement_date, '%Y-%m-%d'), source_file, provider_records,
+		        local_records, matched, missing, extra, mismatched, created_at`
+
+// ListRuns returns the newest runs first, optionally only those of one
+// settlement date.
+func (r *Repository) ListRuns(ctx context.Context, settlementDate string, limit int) ([]*domain.ReconciliationRun, error) {
+	query := `SELECT ` + runColumns + ` FROM reconciliation_runs`
+	args := []any{}
+	if settlementDate != "" {
+		query += ` WHERE settlement_date=?`
+		args = append(args, settlementDate)
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*domain.ReconciliationRun, 0, limit)
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, run)
+	}
+	return out, rows.Err()
+}
+
+// FindRun returns a run with its discrepancies, optionally only those of
+// one kind.
+func (r *Repository) FindRun(ctx context.Context, runID string, kind domain.DiscrepancyKind) (*domain.ReconciliationRun, error) {
+	run, err := scanRun(r.db.QueryRowContext(ctx, `SELECT `+runColumns+` FROM reconciliation_runs WHERE run_id=?`, runID))
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT kind, provider_txn_id, payment_id, order_id, local_amount_cents, local_currency,
+		        provider_amount_cents, provider_currency, order_status, detail
+		 FROM reconciliation_items WHERE run_id=?`
+	args := []any{runID}
+	if kind != "" {
+		query += ` AND kind=?`
+		args = append(args, string(kind))
+	}
+	rows, err := r.db.QueryContext(ctx, query+` ORDER BY id ASC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	run.Items = []domain.Discrepancy{}
+	for rows.Next() {
+		var d domain.Discrepancy
+		var k, orderStatus string
+		if err := rows.Scan(
+			&k,
+			&d.ProviderTxnID,
+			&d.PaymentID,
+			&d.OrderID,
+			&d.LocalAmountCents,
+			&d.LocalCurrency,
+			&d.ProviderAmountCents,
+			&d.ProviderCurrency,
+			&orderStatus,
+			&d.Detail,
+		); err != nil {
+			return nil, err
+		}
+		d.Kind = domain.DiscrepancyKind(k)
+		d.OrderStatus = domain.Status(orderStatus)
+		run.Items = append(run.Items, d)
+	}
+	return run, rows.Err()
+}
+
+func scanLedger(rows *sql.Rows) ([]domain.LedgerEntry, error) {
+	defer rows.Close()
+
+	var out []domain.LedgerEntry
+	for rows.Next() {
+		var e domain.LedgerEntry
+		var paymentStatus, orderStatus string
+		var paidAt sql.NullTime
+		if err := rows.Scan(
+			&e.PaymentID,
+			&e.OrderID,
+			&e.ProviderTxnID,
+			&e.AmountCents,
+			&e.Currency,
+			&paymentStatus,
+			&orderStatus,
+			&paidAt,
+		); err != nil {
+			return nil, err
+		}
+		e.PaymentStatus = domain.PaymentStatus(paymentStatus)
+		e.OrderStatus = domain.Status(orderStatus)
+		e.PaidAt = paidAt.Time
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func scanRun(row interface {
+	Scan(dest ...any) error
+}) (*domain.ReconciliationRun, error) {
+	run := &domain.ReconciliationRun{}
+	if err := row.Scan(
+		&run.RunID,
+		&run.Provider,
+		&run.SettlementDate,
+		&run.SourceFile,
+		&run.ProviderRecords,
+		&run.LocalRecords,
+		&run.Matched,
+		&run.Missing,
+		&run.Extra,
+		&run.Mismatched,
+		&run.CreatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrReconciliationNotFound
+		}
+		return nil, err
+	}
+	return run, nil
+}
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 
@@ -19,6 +21,9 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
+const orderColumns = `order_id, idempotency_key, user_id, status, amount_cents, partition_key, hold_id,
+		        hold_qty, train_no, travel_date, created_at, updated_at`
+
 func (r *Repository) DB() *sql.DB {
 	return r.db
 }
@@ -26,7 +31,7 @@ func (r *Repository) DB() *sql.DB {
 func (r *Repository) FindByID(ctx context.Context, orderID string) (*domain.Order, error) {
 	row := r.db.QueryRowContext(
 		ctx,
-		`SELECT order_id, idempotency_key, status, amount_cents, created_at, updated_at
+		`SELECT `+orderColumns+`
 		 FROM orders WHERE order_id=?`,
 		orderID,
 	)
@@ -36,18 +41,30 @@ func (r *Repository) FindByID(ctx context.Context, orderID string) (*domain.Orde
 func (r *Repository) FindByIdempotencyKey(ctx context.Context, key string) (*domain.Order, error) {
 	row := r.db.QueryRowContext(
 		ctx,
-		`SELECT order_id, idempotency_key, status, amount_cents, created_at, updated_at
+		`SELECT `+orderColumns+`
 		 FROM orders WHERE idempotency_key=?`,
 		key,
 	)
 	return scanOrder(row)
 }
 
+// LockByIDTx reads the order with a row lock so concurrent flows on the same
+// order serialize on it until tx ends.
+func (r *Repository) LockByIDTx(ctx context.Context, tx *sql.Tx, orderID string) (*domain.Order, error) {
+	row := tx.QueryRowContext(
+		ctx,
+		`SELECT `+orderColumns+`
+		 FROM orders WHERE order_id=? FOR UPDATE`,
+		orderID,
+	)
+	return scanOrder(row)
+}
+
 func (r *Repository) InsertTx(ctx context.Context, tx *sql.Tx, order *domain.Order) error {
 	_, err := tx.ExecContext(
 		ctx,
-		`INSERT INTO orders(order_id, idempotency_key, status, amount_cents) VALUES(?, ?, ?, ?)`,
-		order.OrderID, order.IdempotencyKey, string(order.Status), order.AmountCents,
+		`INSERT INTO orders(order_id, idempotency_key, user_id, status, amount_cents) VALUES(?, ?, ?, ?, ?)`,
+		order.OrderID, order.IdempotencyKey, order.UserID, string(order.Status), order.AmountCents,
 	)
 	if err == nil {
 		return nil
@@ -59,37 +76,565 @@ func (r *Repository) InsertTx(ctx context.Context, tx *sql.Tx, order *domain.Ord
 	return err
 }
 
-func (r *Repository) UpdateStatusTx(ctx context.Context, tx *sql.Tx, orderID string, expected domain.Status, next domain.Status) (bool, error) {
+// TransitionTx moves the order from t.FromStatus to t.ToStatus and appends
+// the history row in the same transaction. It reports false when the order is
+// no longer in t.FromStatus.
+func (r *Repository) TransitionTx(ctx context.Context, tx *sql.Tx, t domain.StatusTransition) (bool, error) {
+	if t.FromStatus == t.ToStatus {
+		// MySQL reports no affected rows for an UPDATE that changes nothing,
+		// so a self-transition only checks the current status.
+		order, err := r.LockByIDTx(ctx, tx, t.OrderID)
+		if err != nil {
+			return false, err
+		}
+		if order.Status != t.FromStatus {
+			return false, nil
+		}
+	} else {
+		res, err := tx.ExecContext(
+			ctx,
+			`UPDATE orders SET status=?, updated_at=CURRENT_TIMESTAMP WHERE order_id=? AND status=?`,
+			string(t.ToStatus), t.OrderID, string(t.FromStatus),
+		)
+		if err != nil {
+			return false, err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return false, err
+		}
+		if affected == 0 {
+			return false, nil
+		}
+	}
+	return true, r.InsertHistoryTx(ctx, tx, t)
+}
+
+func (r *Repository) InsertHistoryTx(ctx context.Context, tx *sql.Tx, t domain.StatusTransition) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO order_status_history(order_id, event, from_status, to_status, actor, reason, trace_id)
+		 VALUES(?, ?, ?, ?, ?, ?, ?)`,
+		t.OrderID, string(t.Event), string(t.FromStatus), string(t.ToStatus), t.Actor, t.Reason, t.TraceID,
+	)
+	return err
+}
+
+func (r *Repository) ListHistory(ctx context.Context, orderID string) ([]domain.StatusTransition, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT order_id, event, from_status, to_status, actor, reason, trace_id, created_at
+		 FROM order_status_history WHERE order_id=? ORDER BY id ASC`,
+		orderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]domain.StatusTransition, 0)
+	for rows.Next() {
+		var t domain.StatusTransition
+		var event, from, to string
+		if err := rows.Scan(&t.OrderID, &event, &from, &to, &t.Actor, &t.Reason, &t.TraceID, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		t.Event = domain.OrderEvent(event)
+		t.FromStatus = domain.Status(from)
+		t.ToStatus = domain.Status(to)
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// UpdateHoldTx stores where the order's inventory hold lives, together with
+// the train and travel date parsed from the partition key for listing.
+func (r *Repository) UpdateHoldTx(ctx context.Context, tx *sql.Tx, orderID string, partitionKey string, holdID string) error {
+	trainNo, travelDate := domain.ParsePartitionKey(partitionKey)
+	_, err := tx.ExecContext(
+		ctx,
+		`UPDATE orders SET partition_key=?, hold_id=?, train_no=?, travel_date=?, updated_at=CURRENT_TIMESTAMP WHERE order_id=?`,
+		partitionKey, holdID, trainNo, travelDate, orderID,
+	)
+	return err
+}
+
+// UpdateItineraryTx records how many seats the order holds, between which
+// stations and whether they should be together, for ticket-worker to
+// allocate seats from.
+func (r *Repository) UpdateItineraryTx(ctx context.Context, tx *sql.Tx, orderID string, qty int, fromIndex int, toIndex int, keepTogether bool) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`UPDATE orders SET hold_qty=?, from_index=?, to_index=?, keep_together=?, updated_at=CURRENT_TIMESTAMP WHERE order_id=?`,
+		qty, fromIndex, toIndex, keepTogether, orderID,
+	)
+	return err
+}
+
+func (r *Repository) UpdateAmountTx(ctx context.Context, tx *sql.Tx, orderID string, amountCents int64) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`UPDATE orders SET amount_cents=?, updated_at=CURRENT_TIMESTAMP WHERE order_id=?`,
+		amountCents, orderID,
+	)
+	return err
+}
+
+func (r *Repository) InsertPaymentTx(ctx context.Context, tx *sql.Tx, paymentID string, orderID string, providerTxnID string, status string, paidAmountCents int64, paidCurrency string) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO payments(payment_id, order_id, provider_txn_id, status, paid_amount_cents, paid_currency, paid_at)
+		 VALUES(?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`,
+		paymentID, orderID, providerTxnID, status, paidAmountCents, paidCurrency,
+	)
+	return err
+}
+
+const paymentColumns = `payment_id, order_id, provider, COALESCE(provider_txn_id, ''), status, amount_cents, currency,
+		        COALESCE(paid_amount_cents, 0), COALESCE(paid_currency, ''), prepay_id, expires_at, created_at, updated_at`
+
+// InsertPaymentIntentTx records a payment the customer is about to make;
+// the provider transaction ID stays NULL until the outcome is known.
+func (r *Repository) InsertPaymentIntentTx(ctx context.Context, tx *sql.Tx, p *domain.Payment) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO payments(payment_id, order_id, provider, provider_txn_id, status, amount_cents, currency, expires_at)
+		 VALUES(?, ?, ?, NULL, ?, ?, ?, ?)`,
+		p.PaymentID, p.OrderID, p.Provider, string(p.Status), p.AmountCents, p.Currency, p.ExpiresAt,
+	)
+	return err
+}
+
+// FindOpenPaymentTx returns the newest PENDING payment of the order that has
+// not expired at now.
+func (r *Repository) FindOpenPaymentTx(ctx context.Context, tx *sql.Tx, orderID string, now time.Time) (*domain.Payment, error) {
+	row := tx.QueryRowContext(
+		ctx,
+		`SELECT `+paymentColumns+`
+		 FROM payments WHERE order_id=? AND status=? AND expires_at > ?
+		 ORDER BY created_at DESC, id DESC LIMIT 1`,
+		orderID, string(domain.PaymentStatusPending), now,
+	)
+	return scanPayment(row)
+}
+
+func (r *Repository) FindPayment(ctx context.Context, paymentID string) (*domain.Payment, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		`SELECT `+paymentColumns+`
+		 FROM payments WHERE payment_id=?`,
+		paymentID,
+	)
+	return scanPayment(row)
+}
+
+// FindPaidPaymentTx returns the successful payment of the order that a
+// refund of amountCents is best taken from: the newest one that covers it,
+// or else the newest one.
+func (r *Repository) FindPaidPaymentTx(ctx context.Context, tx *sql.Tx, orderID string, amountCents int64) (*domain.Payment, error) {
+	row := tx.QueryRowContext(
+		ctx,
+		`SELECT `+paymentColumns+`
+		 FROM payments WHERE order_id=? AND status=?
+		 ORDER BY COALESCE(paid_amount_cents, 0) >= ? DESC, created_at DESC, id DESC LIMIT 1`,
+		orderID, string(domain.PaymentStatusSuccess), amountCents,
+	)
+	return scanPayment(row)
+}
+
+func (r *Repository) UpdatePaymentPrepay(ctx context.Context, paymentID string, prepayID string, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE payments SET prepay_id=?, expires_at=?, updated_at=CURRENT_TIMESTAMP WHERE payment_id=?`,
+		prepayID, expiresAt, paymentID,
+	)
+	return err
+}
+
+// UpdatePaymentStatus moves a payment from one status to another and reports
+// false when it was no longer in from.
+func (r *Repository) UpdatePaymentStatus(ctx context.Context, paymentID string, from domain.PaymentStatus, to domain.PaymentStatus) (bool, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE payments SET status=?, updated_at=CURRENT_TIMESTAMP WHERE payment_id=? AND status=?`,
+		string(to), paymentID, string(from),
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// CompletePaymentTx stores the provider outcome and the amount actually paid
+// on a PENDING payment of the order. It reports false when there is no such
+// payment, e.g. a callback for a payment that was not started through
+// order-service.
+func (r *Repository) CompletePaymentTx(ctx context.Context, tx *sql.Tx, paymentID string, orderID string, providerTxnID string, status domain.PaymentStatus, paidAmountCents int64, paidCurrency string) (bool, error) {
 	res, err := tx.ExecContext(
 		ctx,
-		`UPDATE orders SET status=?, updated_at=CURRENT_TIMESTAMP WHERE order_id=? AND status=?`,
-		string(next), orderID, string(expected),
+		`UPDATE payments SET provider_txn_id=?, status=?, paid_amount_cents=?, paid_currency=?, paid_at=CURRENT_TIMESTAMP, updated_at=CURRENT_TIMESTAMP
+		 WHERE payment_id=? AND order_id=? AND status=?`,
+		providerTxnID, string(status), paidAmountCents, paidCurrency, paymentID, orderID, string(domain.PaymentStatusPending),
 	)
 	if err != nil {
 		return false, err
 	}
 	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// FailPaymentTx stores a FAILED or CLOSED outcome on a PENDING payment of the
+// order and reports false when there is no such payment. The provider
+// transaction ID is optional: a payment closed before it was paid has none.
+func (r *Repository) FailPaymentTx(ctx context.Context, tx *sql.Tx, paymentID string, orderID string, providerTxnID string, status domain.PaymentStatus) (bool, error) {
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE payments SET provider_txn_id=COALESCE(NULLIF(?, ''), provider_txn_id), status=?, updated_at=CURRENT_TIMESTAMP
+		 WHERE payment_id=? AND order_id=? AND status=?`,
+		providerTxnID, string(status), paymentID, orderID, string(domain.PaymentStatusPending),
+	)
 	if err != nil {
 		return false, err
 	}
-	return affected > 0, nil
+	affected, err := res.RowsAffected()
+	return affected > 0, err
 }
 
-func (r *Repository) InsertPaymentTx(ctx context.Context, tx *sql.Tx, paymentID string, orderID string, providerTxnID string, status string) error {
+// InsertFailedPaymentTx records a failed attempt that was not started
+// through order-service.
+func (r *Repository) InsertFailedPaymentTx(ctx context.Context, tx *sql.Tx, paymentID string, orderID string, providerTxnID string, status domain.PaymentStatus) error {
 	_, err := tx.ExecContext(
 		ctx,
-		`INSERT INTO payments(payment_id, order_id, provider_txn_id, status) VALUES(?, ?, ?, ?)`,
-		paymentID, orderID, providerTxnID, status,
+		`INSERT INTO payments(payment_id, order_id, provider_txn_id, status) VALUES(?, ?, NULLIF(?, ''), ?)`,
+		paymentID, orderID, providerTxnID, string(status),
 	)
 	return err
 }
 
+// PaymentTxnRecordedTx reports whether a provider transaction is already
+// stored on any payment.
+func (r *Repository) PaymentTxnRecordedTx(ctx context.Context, tx *sql.Tx, providerTxnID string) (bool, error) {
+	var n int
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*) FROM payments WHERE provider_txn_id=?`,
+		providerTxnID,
+	).Scan(&n)
+	return n > 0, err
+}
+
+func (r *Repository) UpdatePaymentStatusTx(ctx context.Context, tx *sql.Tx, paymentID string, from domain.PaymentStatus, to domain.PaymentStatus) (bool, error) {
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE payments SET status=?, updated_at=CURRENT_TIMESTAMP WHERE payment_id=? AND status=?`,
+		string(to), paymentID, string(from),
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+func (r *Repository) InsertRefundTx(ctx context.Context, tx *sql.Tx, rf *domain.Refund) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO payment_refunds(refund_id, payment_id, order_id, provider_txn_id, amount_cents, currency, reason, status)
+		 VALUES(?, ?, ?, ?, ?, ?, ?, ?)`,
+		rf.RefundID, rf.PaymentID, rf.OrderID, rf.ProviderTxnID, rf.AmountCents, rf.Currency, rf.Reason, string(rf.Status),
+	)
+	return err
+}
+
+const refundColumns = `refund_id, payment_id, order_id, provider_txn_id, amount_cents, currency, reason, status,
+		        provider_refund_id, attempts, last_error, created_at, updated_at`
+
+func (r *Repository) FindRefund(ctx context.Context, refundID string) (*domain.Refund, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		`SELECT `+refundColumns+`
+		 FROM payment_refunds WHERE refund_id=?`,
+		refundID,
+	)
+	return scanRefund(row)
+}
+
+// ListPendingRefunds returns refunds not yet accepted by the provider, least
+// recently tried first.
+func (r *Repository) ListPendingRefunds(ctx context.Context, limit int) ([]*domain.Refund, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+refundColumns+`
+		 FROM payment_refunds WHERE status=?
+		 ORDER BY updated_at ASC LIMIT ?`,
+		string(domain.RefundStatusPending), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*domain.Refund, 0, limit)
+	for rows.Next() {
+		rf, err := scanRefund(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rf)
+	}
+	return out, rows.Err()
+}
+
+// FinishRefundTx moves a PENDING refund to its final status and reports
+// false when it was already finished.
+func (r *Repository) FinishRefundTx(ctx context.Context, tx *sql.Tx, refundID string, status domain.RefundStatus, providerRefundID string, lastError string) (bool, error) {
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE payment_refunds SET status=?, provider_refund_id=?, last_error=?, attempts=attempts+1, updated_at=CURRENT_TIMESTAMP
+		 WHERE refund_id=? AND status=?`,
+		string(status), providerRefundID, lastError, refundID, string(domain.RefundStatusPending),
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// RecordRefundAttempt keeps a refund PENDING after a failed attempt so the
+// next pass retries it.
+func (r *Repository) RecordRefundAttempt(ctx context.Context, refundID string, lastError string) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE payment_refunds SET attempts=attempts+1, last_error=?, updated_at=CURRENT_TIMESTAMP
+		 WHERE refund_id=? AND status=?`,
+		lastError, refundID, string(domain.RefundStatusPending),
+	)
+	return err
+}
+
+// ListStalePendingPayments returns payments still PENDING since before
+// createdBefore, oldest first, whatever state their order is in: a late
+// success of a superseded payment still has to be found and refunded.
+func (r *Repository) ListStalePendingPayments(ctx context.Context, createdBefore time.Time, limit int) ([]*domain.Payment, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT p.payment_id, p.order_id, p.provider, COALESCE(p.provider_txn_id, ''), p.status, p.amount_cents, p.currency,
+		        COALESCE(p.paid_amount_cents, 0), COALESCE(p.paid_currency, ''), p.prepay_id, p.expires_at, p.created_at, p.updated_at
+		 FROM payments p
+		 WHERE p.status=? AND p.created_at < ?
+		 ORDER BY p.created_at ASC LIMIT ?`,
+		string(domain.PaymentStatusPending), createdBefore, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*domain.Payment, 0, limit)
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// LockPassengerDocumentsTx takes a row lock per ID document until tx ends.
+// Callers pass the documents sorted so that concurrent reservations lock them
+// in the same order.
+func (r *Repository) LockPassengerDocumentsTx(ctx context.Context, tx *sql.Tx, passengers []domain.OrderPassenger) error {
+	for _, p := range passengers {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT IGNORE INTO passenger_documents(id_type, id_number) VALUES(?, ?)`,
+			p.IDType, p.IDNumber,
+		); err != nil {
+			return err
+		}
+		var locked string
+		if err := tx.QueryRowContext(
+			ctx,
+			`SELECT id_number FROM passenger_documents WHERE id_type=? AND id_number=? FOR UPDATE`,
+			p.IDType, p.IDNumber,
+		).Scan(&locked); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CountOverlappingTripsTx counts other RESERVED, PAID or TICKETED orders of
+// the document whose travel window overlaps w, and INIT orders whose
+// reservation is still in flight, so two concurrent reservations cannot both
+// pass.
+func (r *Repository) CountOverlappingTripsTx(ctx context.Context, tx *sql.Tx, p domain.OrderPassenger, w domain.TravelWindow, excludeOrderID string) (int, error) {
+	var n int
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*) FROM order_passengers op
+		 JOIN orders o ON o.order_id = op.order_id
+		 WHERE op.id_type=? AND op.id_number=? AND op.depart_at < ? AND op.arrive_at > ?
+		   AND op.order_id <> ?
+		   AND (o.status IN (?, ?, ?)
+		     OR (o.status = ? AND EXISTS (
+		       SELECT 1 FROM order_sagas s
+		       WHERE s.order_id = o.order_id AND s.saga_type = ? AND s.status NOT IN (?, ?))))`,
+		p.IDType, p.IDNumber, w.ArriveAt, w.DepartAt, excludeOrderID,
+		string(domain.StatusReserved), string(domain.StatusPaid), string(domain.StatusTicketed),
+		string(domain.StatusInit), domain.SagaTypeReserve,
+		string(domain.SagaStatusCompleted), string(domain.SagaStatusFailed),
+	).Scan(&n)
+	return n, err
+}
+
+// CountBookingsTx counts the orders the document was booked on during
+// bookingDate, cancelled ones included.
+func (r *Repository) CountBookingsTx(ctx context.Context, tx *sql.Tx, p domain.OrderPassenger, bookingDate string) (int, error) {
+	var n int
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*) FROM order_passengers WHERE id_type=? AND id_number=? AND booking_date=?`,
+		p.IDType, p.IDNumber, bookingDate,
+	).Scan(&n)
+	return n, err
+}
+
+func (r *Repository) InsertPassengersTx(ctx context.Context, tx *sql.Tx, orderID string, passengers []domain.OrderPassenger, w domain.TravelWindow, bookingDate string) error {
+	for _, p := range passengers {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO order_passengers(order_id, id_type, id_number, name, seat_preference, depart_at, arrive_at, booking_date)
+			 VALUES(?, ?, ?, ?, ?, ?, ?, ?)`,
+			orderID, p.IDType, p.IDNumber, p.Name, p.SeatPreference, w.DepartAt, w.ArriveAt, bookingDate,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) ListPassengersTx(ctx context.Context, tx *sql.Tx, orderID string) ([]domain.OrderPassenger, error) {
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT name, id_type, id_number, seat_preference FROM order_passengers
+		 WHERE order_id=? ORDER BY id_type ASC, id_number ASC`,
+		orderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]domain.OrderPassenger, 0)
+	for rows.Next() {
+		var p domain.OrderPassenger
+		if err := rows.Scan(&p.Name, &p.IDType, &p.IDNumber, &p.SeatPreference); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// UpdatePassengerWindowTx moves the order's passengers onto a new trip after
+// a ticket change.
+func (r *Repository) UpdatePassengerWindowTx(ctx context.Context, tx *sql.Tx, orderID string, w domain.TravelWindow) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`UPDATE order_passengers SET depart_at=?, arrive_at=? WHERE order_id=?`,
+		w.DepartAt, w.ArriveAt, orderID,
+	)
+	return err
+}
+
+// DeletePassengersTx drops the passengers of a reservation that never
+// happened, so they neither block other trips nor count as a booking.
+func (r *Repository) DeletePassengersTx(ctx context.Context, tx *sql.Tx, orderID string) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM order_passengers WHERE order_id=?`, orderID)
+	return err
+}
+
+// ListOrders returns up to f.Limit orders matching f, newest first, starting
+// after f.After. Each filter combination is served by one of the
+// idx_orders_* indexes ending in (created_at, order_id).
+func (r *Repository) ListOrders(ctx context.Context, f domain.OrderFilter) ([]*domain.Order, error) {
+	where := make([]string, 0, 8)
+	args := make([]any, 0, 10)
+	if f.UserID != "" {
+		where = append(where, "user_id=?")
+		args = append(args, f.UserID)
+	}
+	if f.Status != "" {
+		where = append(where, "status=?")
+		args = append(args, string(f.Status))
+	}
+	if f.TrainNo != "" {
+		where = append(where, "train_no=?")
+		args = append(args, f.TrainNo)
+	}
+	if f.TravelDate != "" {
+		where = append(where, "travel_date=?")
+		args = append(args, f.TravelDate)
+	}
+	if !f.CreatedFrom.IsZero() {
+		where = append(where, "created_at>=?")
+		args = append(args, f.CreatedFrom)
+	}
+	if !f.CreatedTo.IsZero() {
+		where = append(where, "created_at<?")
+		args = append(args, f.CreatedTo)
+	}
+	if f.After != nil {
+		where = append(where, "(created_at<? OR (created_at=? AND order_id<?))")
+		args = append(args, f.After.CreatedAt, f.After.CreatedAt, f.After.OrderID)
+	}
+	query := `SELECT ` + orderColumns + ` FROM orders`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += ` ORDER BY created_at DESC, order_id DESC LIMIT ?`
+	args = append(args, f.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*domain.Order, 0, f.Limit)
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
+
 func scanOrder(row interface {
 	Scan(dest ...any) error
 }) (*domain.Order, error) {
 	o := &domain.Order{}
 	var status string
-	if err := row.Scan(&o.OrderID, &o.IdempotencyKey, &status, &o.AmountCents, &o.CreatedAt, &o.UpdatedAt); err != nil {
+	if err := row.Scan(
+		&o.OrderID,
+		&o.IdempotencyKey,
+		&o.UserID,
+		&status,
+		&o.AmountCents,
+		&o.PartitionKey,
+		&o.HoldID,
+		&o.HoldQty,
+		&o.TrainNo,
+		&o.TravelDate,
+		&o.CreatedAt,
+		&o.UpdatedAt,
+	); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, domain.ErrOrderNotFound
 		}
@@ -98,3 +643,63 @@ func scanOrder(row interface {
 	o.Status = domain.Status(status)
 	return o, nil
 }
+
+func scanPayment(row interface {
+	Scan(dest ...any) error
+}) (*domain.Payment, error) {
+	p := &domain.Payment{}
+	var status string
+	var expiresAt sql.NullTime
+	if err := row.Scan(
+		&p.PaymentID,
+		&p.OrderID,
+		&p.Provider,
+		&p.ProviderTxnID,
+		&status,
+		&p.AmountCents,
+		&p.Currency,
+		&p.PaidAmountCents,
+		&p.PaidCurrency,
+		&p.PrepayID,
+		&expiresAt,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrPaymentNotFound
+		}
+		return nil, err
+	}
+	p.Status = domain.PaymentStatus(status)
+	p.ExpiresAt = expiresAt.Time
+	return p, nil
+}
+
+func scanRefund(row interface {
+	Scan(dest ...any) error
+}) (*domain.Refund, error) {
+	rf := &domain.Refund{}
+	var status string
+	if err := row.Scan(
+		&rf.RefundID,
+		&rf.PaymentID,
+		&rf.OrderID,
+		&rf.ProviderTxnID,
+		&rf.AmountCents,
+		&rf.Currency,
+		&rf.Reason,
+		&status,
+		&rf.ProviderRefundID,
+		&rf.Attempts,
+		&rf.LastError,
+		&rf.CreatedAt,
+		&rf.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrRefundNotFound
+		}
+		return nil, err
+	}
+	rf.Status = domain.RefundStatus(status)
+	return rf, nil
+}
@@ -0,0 +1,171 @@
+package saga
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"ticketing/internal/order/domain"
+)
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+const selectColumns = `SELECT saga_id, saga_type, order_id, data, step, status, attempts, last_error,
+		        next_retry_at, created_at, updated_at
+		 FROM order_sagas`
+
+func (r *Repository) InsertTx(ctx context.Context, tx *sql.Tx, sg *domain.Saga) error {
+	data, err := json.Marshal(sg.Data)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO order_sagas(saga_id, saga_type, order_id, data, step, status, next_retry_at)
+		 VALUES(?, ?, ?, ?, ?, ?, ?)`,
+		sg.SagaID, sg.SagaType, sg.OrderID, data, sg.Step, string(sg.Status), sg.NextRetryAt,
+	)
+	return err
+}
+
+func (r *Repository) FindByID(ctx context.Context, sagaID string) (*domain.Saga, error) {
+	return scanSaga(r.db.QueryRowContext(ctx, selectColumns+` WHERE saga_id=?`, sagaID))
+}
+
+// FindActiveByOrderTx returns the saga of the order that has not reached
+// COMPLETED or FAILED. Callers hold the order row lock, so at most one exists.
+func (r *Repository) FindActiveByOrderTx(ctx context.Context, tx *sql.Tx, orderID string) (*domain.Saga, error) {
+	return scanSaga(tx.QueryRowContext(
+		ctx,
+		selectColumns+` WHERE order_id=? AND status NOT IN ('COMPLETED', 'FAILED') LIMIT 1`,
+		orderID,
+	))
+}
+
+func (r *Repository) ListDue(ctx context.Context, limit int) ([]*domain.Saga, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		selectColumns+`
+		 WHERE status NOT IN ('COMPLETED', 'FAILED')
+		   AND next_retry_at <= CURRENT_TIMESTAMP
+		 ORDER BY next_retry_at ASC
+		 LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*domain.Saga, 0)
+	for rows.Next() {
+		sg, err := scanSaga(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sg)
+	}
+	return out, rows.Err()
+}
+
+// Claim leases a due saga to the caller until leaseUntil so that only one
+// process drives it at a time.
+func (r *Repository) Claim(ctx context.Context, sagaID string, leaseUntil time.Time) (bool, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE order_sagas SET next_retry_at=?
+		 WHERE saga_id=? AND status NOT IN ('COMPLETED', 'FAILED') AND next_retry_at <= CURRENT_TIMESTAMP`,
+		leaseUntil, sagaID,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *Repository) Save(ctx context.Context, sg *domain.Saga) error {
+	return save(ctx, r.db, sg)
+}
+
+func (r *Repository) SaveTx(ctx context.Context, tx *sql.Tx, sg *domain.Saga) error {
+	return save(ctx, tx, sg)
+}
+
+func save(ctx context.Context, db execer, sg *domain.Saga) error {
+	_, err := db.ExecContext(
+		ctx,
+		`UPDATE order_sagas
+		 SET step=?, status=?, attempts=?, last_error=?, next_retry_at=?, updated_at=CURRENT_TIMESTAMP
+		 WHERE saga_id=?`,
+		sg.Step, string(sg.Status), sg.Attempts, sg.LastError, sg.NextRetryAt, sg.SagaID,
+	)
+	return err
+}
+
+func (r *Repository) AppendStep(ctx context.Context, entry domain.SagaStepLog) error {
+	return appendStep(ctx, r.db, entry)
+}
+
+func (r *Repository) AppendStepTx(ctx context.Context, tx *sql.Tx, entry domain.SagaStepLog) error {
+	return appendStep(ctx, tx, entry)
+}
+
+func appendStep(ctx context.Context, db execer, entry domain.SagaStepLog) error {
+	_, err := db.ExecContext(
+		ctx,
+		`INSERT INTO order_saga_steps(saga_id, step, step_name, phase, outcome, error)
+		 VALUES(?, ?, ?, ?, ?, ?)`,
+		entry.SagaID, entry.Step, entry.StepName, entry.Phase, entry.Outcome, entry.Error,
+	)
+	return err
+}
+
+func scanSaga(row interface {
+	Scan(dest ...any) error
+}) (*domain.Saga, error) {
+	sg := &domain.Saga{}
+	var status string
+	var data []byte
+	if err := row.Scan(
+		&sg.SagaID,
+		&sg.SagaType,
+		&sg.OrderID,
+		&data,
+		&sg.Step,
+		&status,
+		&sg.Attempts,
+		&sg.LastError,
+		&sg.NextRetryAt,
+		&sg.CreatedAt,
+		&sg.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrSagaNotFound
+		}
+		return nil, err
+	}
+	sg.Status = domain.SagaStatus(status)
+	sg.Data = map[string]any{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &sg.Data); err != nil {
+			return nil, err
+		}
+	}
+	return sg, nil
+}
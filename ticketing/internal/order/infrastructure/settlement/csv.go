@@ -0,0 +1,107 @@
+package settlement
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"ticketing/internal/order/domain"
+)
+
+var ErrInvalidFile = errors.New("invalid settlement file")
+
+// ParseCSV reads a provider settlement file. The first row names the
+// columns; provider_txn_id and amount (yuan, at most two decimals) are
+// required, payment_id, order_id, currency and settled_at (RFC 3339) are
+// optional. Blank rows are skipped; Line is the row's line in the file.
+func ParseCSV(r io.Reader) ([]domain.SettlementRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: read header: %v", ErrInvalidFile, err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"provider_txn_id", "amount"} {
+		if _, ok := cols[required]; !ok {
+			return nil, fmt.Errorf("%w: missing column %q", ErrInvalidFile, required)
+		}
+	}
+
+	var out []domain.SettlementRecord
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			i, ok := cols[name]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+
+		rec := domain.SettlementRecord{
+			Line:          line,
+			ProviderTxnID: field("provider_txn_id"),
+			PaymentID:     field("payment_id"),
+			OrderID:       field("order_id"),
+			Currency:      strings.ToUpper(field("currency")),
+		}
+		if rec.ProviderTxnID == "" {
+			return nil, fmt.Errorf("%w: line %d: empty provider_txn_id", ErrInvalidFile, line)
+		}
+		if rec.Currency == "" {
+			rec.Currency = domain.DefaultCurrency
+		}
+		rec.AmountCents, err = parseYuan(field("amount"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidFile, line, err)
+		}
+		if raw := field("settled_at"); raw != "" {
+			rec.SettledAt, err = time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: settled_at %q is not RFC 3339", ErrInvalidFile, line, raw)
+			}
+		}
+		out = append(out, rec)
+	}
+}
+
+// parseYuan turns "188", "188.5" or "188.50" into cents without going
+// through floating point.
+func parseYuan(raw string) (int64, error) {
+	whole, frac, _ := strings.Cut(raw, ".")
+	if whole == "" || len(frac) > 2 {
+		return 0, fmt.Errorf("amount %q is not a yuan amount", raw)
+	}
+	yuan, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || yuan < 0 {
+		return 0, fmt.Errorf("amount %q is not a yuan amount", raw)
+	}
+	var cents int64
+	if frac != "" {
+		cents, err = strconv.ParseInt(frac+strings.Repeat("0", 2-len(frac)), 10, 64)
+		if err != nil || cents < 0 {
+			return 0, fmt.Errorf("amount %q is not a yuan amount", raw)
+		}
+	}
+	return yuan*100 + cents, nil
+}
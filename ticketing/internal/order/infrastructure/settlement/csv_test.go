@@ -0,0 +1,50 @@
+package settlement
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseCSV(t *testing.T) {
+	t.Parallel()
+
+	file := "\ufeffsettled_at,provider_txn_id,payment_id,amount,currency\n" +
+		"2026-02-11T09:30:00+08:00,txn-1,pay-1,188.00,cny\n" +
+		"\n" +
+		",txn-2,,188.5,\n" +
+		",txn-3,,12,USD\n"
+	records, err := ParseCSV(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+	if r := records[0]; r.ProviderTxnID != "txn-1" || r.PaymentID != "pay-1" || r.AmountCents != 18800 || r.Currency != "CNY" || r.SettledAt.IsZero() || r.Line != 2 {
+		t.Fatalf("unexpected first record: %+v", r)
+	}
+	if r := records[1]; r.AmountCents != 18850 || r.Currency != "CNY" || r.Line != 4 {
+		t.Fatalf("unexpected second record: %+v", r)
+	}
+	if r := records[2]; r.AmountCents != 1200 || r.Currency != "USD" {
+		t.Fatalf("unexpected third record: %+v", r)
+	}
+}
+
+func TestParseCSV_RejectsMalformedFiles(t *testing.T) {
+	t.Parallel()
+
+	bad := []string{
+		"payment_id,amount\npay-1,1.00\n",
+		"provider_txn_id,amount\ntxn-1,1.005\n",
+		"provider_txn_id,amount\ntxn-1,-1\n",
+		"provider_txn_id,amount\n,1.00\n",
+		"provider_txn_id,amount,settled_at\ntxn-1,1.00,yesterday\n",
+	}
+	for _, file := range bad {
+		if _, err := ParseCSV(strings.NewReader(file)); !errors.Is(err, ErrInvalidFile) {
+			t.Fatalf("expected ErrInvalidFile for %q, got %v", file, err)
+		}
+	}
+}
@@ -5,23 +5,70 @@ type CreateOrderRequest struct {
 	AmountCents    int64  `json:"amount_cents"`
 }
 
+type ListOrdersQuery struct {
+	UserID      string `form:"user_id"`
+	Status      string `form:"status"`
+	CreatedFrom string `form:"created_from"`
+	CreatedTo   string `form:"created_to"`
+	TrainNo     string `form:"train_no"`
+	TravelDate  string `form:"travel_date"`
+	Cursor      string `form:"cursor"`
+	Limit       int    `form:"limit"`
+}
+
+type ListReconciliationsQuery struct {
+	Date  string `form:"date"`
+	Limit int    `form:"limit"`
+}
+
+type ListDeadEventsQuery struct {
+	Limit int `form:"limit"`
+}
+
 type ReserveOrderRequest struct {
-	OrderID      string `json:"order_id"`
-	PartitionKey string `json:"partition_key"`
-	HoldID       string `json:"hold_id"`
-	Qty          int    `json:"qty"`
-	Capacity     int    `json:"capacity"`
+	OrderID      string             `json:"order_id"`
+	PartitionKey string             `json:"partition_key"`
+	HoldID       string             `json:"hold_id"`
+	Capacity     int                `json:"capacity"`
+	Passengers   []PassengerRequest `json:"passengers"`
+	DepartAt     string             `json:"depart_at"`
+	ArriveAt     string             `json:"arrive_at"`
+	FromIndex    int                `json:"from_index"`
+	ToIndex      int                `json:"to_index"`
+	KeepTogether bool               `json:"keep_together"`
+}
+
+type PassengerRequest struct {
+	Name           string `json:"name"`
+	IDType         string `json:"id_type"`
+	IDNumber       string `json:"id_number"`
+	SeatPreference string `json:"seat_preference"`
 }
 
 type PaymentCallbackRequest struct {
 	OrderID       string `json:"order_id"`
+	PaymentID     string `json:"payment_id"`
 	ProviderTxnID string `json:"provider_txn_id"`
 	Status        string `json:"status"`
+	AmountCents   int64  `json:"amount_cents"`
+	Currency      string `json:"currency"`
 	PartitionKey  string `json:"partition_key"`
 	HoldID        string `json:"hold_id"`
+	KeyID         string `json:"key_id"`
+	Timestamp     int64  `json:"timestamp"`
+	Nonce         string `json:"nonce"`
 	Signature     string `json:"signature"`
 }
 
+type ChangeTicketRequest struct {
+	OrderID         string `json:"order_id"`
+	IdempotencyKey  string `json:"idempotency_key"`
+	NewPartitionKey string `json:"new_partition_key"`
+	NewAmountCents  int64  `json:"new_amount_cents"`
+	Qty             int    `json:"qty"`
+	Capacity        int    `json:"capacity"`
+}
+
 type CancelOrderRequest struct {
 	OrderID      string `json:"order_id"`
 	PartitionKey string `json:"partition_key"`
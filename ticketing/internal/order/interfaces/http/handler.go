@@ -6,25 +6,34 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"ticketing/internal/common/tracing"
 	"ticketing/internal/order/application"
 	"ticketing/internal/order/domain"
 	"ticketing/internal/order/interfaces/dto"
 )
 
 type Handler struct {
-	service *application.Service
+	service     *application.Service
+	requireAuth gin.HandlerFunc
 }
 
-func NewHandler(service *application.Service) *Handler {
-	return &Handler{service: service}
+func NewHandler(service *application.Service, requireAuth gin.HandlerFunc) *Handler {
+	return &Handler{service: service, requireAuth: requireAuth}
 }
 
+// Register mounts the order routes. Everything a customer calls needs a
+// token; the payment callback is authenticated by its signature instead.
 func (h *Handler) Register(r *gin.Engine) {
-	r.POST("/orders", h.createOrder)
-	r.POST("/orders/reserve", h.reserveOrder)
-	r.POST("/orders/cancel", h.cancelOrder)
+	r.POST("/orders", h.requireAuth, h.createOrder)
+	r.GET("/orders", h.requireAuth, h.listOrders)
+	r.POST("/orders/reserve", h.requireAuth, h.reserveOrder)
+	r.POST("/orders/cancel", h.requireAuth, h.cancelOrder)
+	r.POST("/orders/change", h.requireAuth, h.changeTicket)
+	r.GET("/orders/change", h.requireAuth, h.getChange)
+	r.POST("/orders/:id/pay", h.requireAuth, h.payOrder)
 	r.POST("/payments/callback", h.paymentCallback)
-	r.GET("/orders/get", h.getOrder)
+	r.GET("/orders/get", h.requireAuth, h.getOrder)
+	r.GET("/orders/:id/history", h.requireAuth, h.getOrderHistory)
 }
 
 func (h *Handler) createOrder(c *gin.Context) {
@@ -35,6 +44,7 @@ func (h *Handler) createOrder(c *gin.Context) {
 	}
 	order, err := h.service.CreateOrder(c.Request.Context(), application.CreateOrderInput{
 		IdempotencyKey: req.IdempotencyKey,
+		UserID:         tracing.UserID(c.Request.Context()),
 		AmountCents:    req.AmountCents,
 	})
 	if err != nil {
@@ -42,28 +52,76 @@ func (h *Handler) createOrder(c *gin.Context) {
 		if errors.Is(err, domain.ErrInvalidAmount) {
 			status = http.StatusBadRequest
 		}
+		if errors.Is(err, application.ErrIdempotencyKeyConflict) {
+			status = http.StatusConflict
+		}
 		writeError(c, status, err.Error())
 		return
 	}
 	writeJSON(c, http.StatusOK, order)
 }
 
+func (h *Handler) listOrders(c *gin.Context) {
+	var q dto.ListOrdersQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid query")
+		return
+	}
+	page, err := h.service.ListOrders(c.Request.Context(), application.ListOrdersInput{
+		UserID:      q.UserID,
+		Status:      q.Status,
+		CreatedFrom: q.CreatedFrom,
+		CreatedTo:   q.CreatedTo,
+		TrainNo:     q.TrainNo,
+		TravelDate:  q.TravelDate,
+		Cursor:      q.Cursor,
+		Limit:       q.Limit,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrInvalidFilter) || errors.Is(err, domain.ErrInvalidCursor) {
+			status = http.StatusBadRequest
+		}
+		writeError(c, status, err.Error())
+		return
+	}
+	writeJSON(c, http.StatusOK, page)
+}
+
 func (h *Handler) reserveOrder(c *gin.Context) {
 	var req dto.ReserveOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		writeError(c, http.StatusBadRequest, "invalid json")
 		return
 	}
+	passengers := make([]domain.OrderPassenger, 0, len(req.Passengers))
+	for _, p := range req.Passengers {
+		passengers = append(passengers, domain.OrderPassenger{Name: p.Name, IDType: p.IDType, IDNumber: p.IDNumber, SeatPreference: p.SeatPreference})
+	}
 	order, err := h.service.ReserveOrder(c.Request.Context(), application.ReserveOrderInput{
 		OrderID:      req.OrderID,
 		PartitionKey: req.PartitionKey,
 		HoldID:       req.HoldID,
-		Qty:          req.Qty,
 		Capacity:     req.Capacity,
+		Passengers:   passengers,
+		DepartAt:     req.DepartAt,
+		ArriveAt:     req.ArriveAt,
+		FromIndex:    req.FromIndex,
+		ToIndex:      req.ToIndex,
+		KeepTogether: req.KeepTogether,
 	})
+	if errors.Is(err, application.ErrSagaPending) {
+		writeJSON(c, http.StatusAccepted, order)
+		return
+	}
 	if err != nil {
 		status := http.StatusInternalServerError
-		if errors.Is(err, domain.ErrInvalidStateTransfer) {
+		if errors.Is(err, domain.ErrInvalidPassenger) || errors.Is(err, domain.ErrInvalidStationRange) ||
+			errors.Is(err, domain.ErrInvalidPartitionKey) || errors.Is(err, domain.ErrInvalidTravelWindow) {
+			status = http.StatusBadRequest
+		}
+		if errors.Is(err, domain.ErrInvalidStateTransfer) || errors.Is(err, domain.ErrSagaInProgress) ||
+			errors.Is(err, domain.ErrDuplicateTrip) || errors.Is(err, domain.ErrDailyLimitExceeded) {
 			status = http.StatusConflict
 		}
 		if errors.Is(err, domain.ErrOrderNotFound) {
@@ -86,9 +144,13 @@ func (h *Handler) cancelOrder(c *gin.Context) {
 		PartitionKey: req.PartitionKey,
 		HoldID:       req.HoldID,
 	})
+	if errors.Is(err, application.ErrSagaPending) {
+		writeJSON(c, http.StatusAccepted, order)
+		return
+	}
 	if err != nil {
 		status := http.StatusInternalServerError
-		if errors.Is(err, domain.ErrInvalidStateTransfer) {
+		if errors.Is(err, domain.ErrInvalidStateTransfer) || errors.Is(err, domain.ErrSagaInProgress) {
 			status = http.StatusConflict
 		}
 		if errors.Is(err, domain.ErrOrderNotFound) {
@@ -100,6 +162,76 @@ func (h *Handler) cancelOrder(c *gin.Context) {
 	writeJSON(c, http.StatusOK, order)
 }
 
+func (h *Handler) changeTicket(c *gin.Context) {
+	var req dto.ChangeTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	change, err := h.service.ChangeTicket(c.Request.Context(), application.ChangeTicketInput{
+		OrderID:         req.OrderID,
+		IdempotencyKey:  req.IdempotencyKey,
+		NewPartitionKey: req.NewPartitionKey,
+		NewAmountCents:  req.NewAmountCents,
+		Qty:             req.Qty,
+		Capacity:        req.Capacity,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrInvalidChange) || errors.Is(err, domain.ErrInvalidAmount) {
+			status = http.StatusBadRequest
+		}
+		if errors.Is(err, domain.ErrInvalidStateTransfer) || errors.Is(err, domain.ErrSagaInProgress) ||
+			errors.Is(err, domain.ErrDuplicateTrip) {
+			status = http.StatusConflict
+		}
+		if errors.Is(err, domain.ErrOrderNotFound) || errors.Is(err, domain.ErrChangeNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(c, status, err.Error())
+		return
+	}
+	status := http.StatusOK
+	if !change.IsTerminal() {
+		status = http.StatusAccepted
+	}
+	writeJSON(c, status, change)
+}
+
+func (h *Handler) getChange(c *gin.Context) {
+	changeID := c.Query("change_id")
+	if changeID == "" {
+		writeError(c, http.StatusBadRequest, "change_id is required")
+		return
+	}
+	change, err := h.service.GetChange(c.Request.Context(), changeID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrChangeNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(c, status, err.Error())
+		return
+	}
+	writeJSON(c, http.StatusOK, change)
+}
+
+func (h *Handler) payOrder(c *gin.Context) {
+	intent, err := h.service.PayOrder(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrInvalidStateTransfer) {
+			status = http.StatusConflict
+		}
+		if errors.Is(err, domain.ErrOrderNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(c, status, err.Error())
+		return
+	}
+	writeJSON(c, http.StatusOK, intent)
+}
+
 func (h *Handler) paymentCallback(c *gin.Context) {
 	var req dto.PaymentCallbackRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -108,21 +240,31 @@ func (h *Handler) paymentCallback(c *gin.Context) {
 	}
 	order, err := h.service.PaymentCallback(c.Request.Context(), application.PaymentCallbackInput{
 		OrderID:       req.OrderID,
+		PaymentID:     req.PaymentID,
 		ProviderTxnID: req.ProviderTxnID,
 		Status:        req.Status,
+		AmountCents:   req.AmountCents,
+		Currency:      req.Currency,
 		PartitionKey:  req.PartitionKey,
 		HoldID:        req.HoldID,
+		KeyID:         req.KeyID,
+		Timestamp:     req.Timestamp,
+		Nonce:         req.Nonce,
 		Signature:     req.Signature,
 	})
+	if errors.Is(err, application.ErrSagaPending) {
+		writeJSON(c, http.StatusAccepted, order)
+		return
+	}
 	if err != nil {
 		status := http.StatusInternalServerError
 		if errors.Is(err, application.ErrInvalidSignature) {
 			status = http.StatusUnauthorized
 		}
-		if errors.Is(err, application.ErrInvalidPaymentStatus) {
+		if errors.Is(err, application.ErrInvalidPaymentStatus) || errors.Is(err, application.ErrInvalidPaymentAmount) {
 			status = http.StatusBadRequest
 		}
-		if errors.Is(err, domain.ErrInvalidStateTransfer) {
+		if errors.Is(err, domain.ErrInvalidStateTransfer) || errors.Is(err, domain.ErrSagaInProgress) {
 			status = http.StatusConflict
 		}
 		if errors.Is(err, domain.ErrOrderNotFound) {
@@ -152,6 +294,19 @@ func (h *Handler) getOrder(c *gin.Context) {
 	writeJSON(c, http.StatusOK, order)
 }
 
+func (h *Handler) getOrderHistory(c *gin.Context) {
+	history, err := h.service.GetOrderHistory(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrOrderNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(c, status, err.Error())
+		return
+	}
+	writeJSON(c, http.StatusOK, history)
+}
+
 func writeJSON(c *gin.Context, status int, body any) {
 	c.JSON(status, body)
 }
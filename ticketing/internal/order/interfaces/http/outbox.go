@@ -0,0 +1,59 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ticketing/internal/order/application"
+	"ticketing/internal/order/infrastructure/outbox"
+	"ticketing/internal/order/interfaces/dto"
+)
+
+// OutboxHandler lets support staff find dead-lettered outbox events and
+// replay them, which unblocks the later events of their orders.
+type OutboxHandler struct {
+	service *application.Service
+	guards  []gin.HandlerFunc
+}
+
+// NewOutboxHandler takes the middleware every route runs behind,
+// typically authentication followed by a role check.
+func NewOutboxHandler(service *application.Service, guards ...gin.HandlerFunc) *OutboxHandler {
+	return &OutboxHandler{service: service, guards: guards}
+}
+
+func (h *OutboxHandler) Register(r *gin.Engine) {
+	admin := r.Group("/admin", h.guards...)
+	admin.GET("/outbox/dead", h.listDead)
+	admin.POST("/outbox/:event_id/replay", h.replay)
+}
+
+func (h *OutboxHandler) listDead(c *gin.Context) {
+	var q dto.ListDeadEventsQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid query")
+		return
+	}
+	events, err := h.service.ListDeadOutboxEvents(c.Request.Context(), q.Limit)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(c, http.StatusOK, events)
+}
+
+func (h *OutboxHandler) replay(c *gin.Context) {
+	eventID := c.Param("event_id")
+	err := h.service.ReplayOutboxEvent(c.Request.Context(), eventID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, outbox.ErrNotDead) {
+			status = http.StatusNotFound
+		}
+		writeError(c, status, err.Error())
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"event_id": eventID, "status": outbox.StatusRetry})
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ticketing/internal/order/application"
+	"ticketing/internal/order/domain"
+	"ticketing/internal/order/interfaces/dto"
+)
+
+// ReconciliationHandler exposes payment reconciliation runs to finance and
+// support staff.
+type ReconciliationHandler struct {
+	reconciler *application.Reconciler
+	guards     []gin.HandlerFunc
+}
+
+// NewReconciliationHandler takes the middleware every route runs behind,
+// typically authentication followed by a role check.
+func NewReconciliationHandler(reconciler *application.Reconciler, guards ...gin.HandlerFunc) *ReconciliationHandler {
+	return &ReconciliationHandler{reconciler: reconciler, guards: guards}
+}
+
+func (h *ReconciliationHandler) Register(r *gin.Engine) {
+	admin := r.Group("/admin", h.guards...)
+	admin.GET("/reconciliations", h.listRuns)
+	admin.GET("/reconciliations/:id", h.getRun)
+}
+
+func (h *ReconciliationHandler) listRuns(c *gin.Context) {
+	var q dto.ListReconciliationsQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid query")
+		return
+	}
+	runs, err := h.reconciler.ListRuns(c.Request.Context(), q.Date, q.Limit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrInvalidSettlementDate) {
+			status = http.StatusBadRequest
+		}
+		writeError(c, status, err.Error())
+		return
+	}
+	writeJSON(c, http.StatusOK, runs)
+}
+
+func (h *ReconciliationHandler) getRun(c *gin.Context) {
+	run, err := h.reconciler.GetRun(c.Request.Context(), c.Param("id"), c.Query("kind"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrInvalidFilter) {
+			status = http.StatusBadRequest
+		}
+		if errors.Is(err, domain.ErrReconciliationNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(c, status, err.Error())
+		return
+	}
+	writeJSON(c, http.StatusOK, run)
+}
@@ -149,8 +149,13 @@ func (s *Service) handleOrderEvent(ctx context.Context, raw []byte) error {
 	case "OrderPaid":
 		status = "PAID"
 		providerTxnID = stringFromAny(ev.Payload["provider_txn_id"])
+	case "OrderPaymentMismatch":
+		status = "PAYMENT_MISMATCH"
 	case "OrderCancelled":
 		status = "CANCELLED"
+	case "OrderFareSettled":
+		status = "TICKETED"
+		amountCents = int64FromAny(ev.Payload["new_amount_cents"])
 	default:
 		return tx.Commit()
 	}
@@ -170,7 +175,9 @@ func (s *Service) handleTicketEvent(ctx context.Context, raw []byte) error {
 	if err := json.Unmarshal(raw, &ev); err != nil {
 		return err
 	}
-	if ev.EventType != "TicketIssued" {
+	switch ev.EventType {
+	case "TicketIssued", "TicketReissued", "TicketStatusChanged":
+	default:
 		return nil
 	}
 	tx, err := s.repo.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
@@ -184,11 +191,19 @@ func (s *Service) handleTicketEvent(ctx context.Context, raw []byte) error {
 		return err
 	}
 
-	seatNo := stringFromAny(ev.Payload["seat_no"])
-	if seatNo == "" {
-		seatNo = stringFromAny(mapFromAny(ev.Payload["payload"])["seat_no"])
+	if ev.EventType == "TicketStatusChanged" {
+		err = s.repo.UpdateTicketStatusTx(
+			ctx,
+			tx,
+			ev.AggregateID,
+			stringFromAny(ev.Payload["ticket_id"]),
+			stringFromAny(ev.Payload["passenger_id"]),
+			stringFromAny(ev.Payload["status"]),
+		)
+	} else {
+		err = s.markTicketedTx(ctx, tx, ev)
 	}
-	if err := s.repo.MarkTicketedTx(ctx, tx, ev.AggregateID, seatNo); err != nil {
+	if err != nil {
 		return err
 	}
 	if err := tx.Commit(); err != nil {
@@ -198,6 +213,46 @@ func (s *Service) handleTicketEvent(ctx context.Context, raw []byte) error {
 	return nil
 }
 
+func (s *Service) markTicketedTx(ctx context.Context, tx *sql.Tx, ev eventEnvelope) error {
+	seatNo := stringFromAny(ev.Payload["seat_no"])
+	if seatNo == "" {
+		seatNo = stringFromAny(mapFromAny(ev.Payload["payload"])["seat_no"])
+	}
+	if err := s.repo.MarkTicketedTx(ctx, tx, ev.AggregateID, seatNo); err != nil {
+		return err
+	}
+	return s.repo.UpsertOrderTicketsTx(ctx, tx, ev.AggregateID, ticketsFromPayload(ev.Payload))
+}
+
+// ticketsFromPayload reads the tickets of a TicketIssued or TicketReissued
+// payload. Events from before tickets carried a status are ISSUED.
+func ticketsFromPayload(payload map[string]any) []domain.TicketView {
+	raw, _ := payload["tickets"].([]any)
+	out := make([]domain.TicketView, 0, len(raw))
+	for _, item := range raw {
+		m := mapFromAny(item)
+		id := stringFromAny(m["ticket_id"])
+		if id == "" {
+			continue
+		}
+		status := stringFromAny(m["status"])
+		if status == "" {
+			status = "ISSUED"
+		}
+		out = append(out, domain.TicketView{
+			TicketID:      id,
+			PassengerID:   stringFromAny(m["passenger_id"]),
+			PassengerName: stringFromAny(m["passenger_name"]),
+			TrainID:       stringFromAny(m["train_id"]),
+			TravelDate:    stringFromAny(m["travel_date"]),
+			CoachNo:       int(int64FromAny(m["coach_no"])),
+			SeatNo:        stringFromAny(m["seat_no"]),
+			Status:        status,
+		})
+	}
+	return out
+}
+
 func int64FromAny(v any) int64 {
 	switch x := v.(type) {
 	case int:
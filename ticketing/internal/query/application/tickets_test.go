@@ -0,0 +1,40 @@
+package application
+
+import (
+	"encoding/json"
+	"testing"
+
+	"ticketing/internal/query/domain"
+)
+
+func TestTicketsFromPayload(t *testing.T) {
+	t.Parallel()
+
+	var payload map[string]any
+	raw := `{"tickets":[
+		{"ticket_id":"t-1","passenger_id":"p-1","passenger_name":"李雷","train_id":"G123","travel_date":"2026-02-11","coach_no":3,"seat_no":"12F","status":"ISSUED"},
+		{"ticket_id":"t-2","passenger_id":"p-2","coach_no":3,"seat_no":"12D"},
+		{"passenger_id":"p-3"}
+	]}`
+	if err := json.Unmarshal([]byte(raw), &payload); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	got := ticketsFromPayload(payload)
+	want := []domain.TicketView{
+		{TicketID: "t-1", PassengerID: "p-1", PassengerName: "李雷", TrainID: "G123", TravelDate: "2026-02-11", CoachNo: 3, SeatNo: "12F", Status: "ISSUED"},
+		{TicketID: "t-2", PassengerID: "p-2", CoachNo: 3, SeatNo: "12D", Status: "ISSUED"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d tickets, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ticket %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+
+	if got := ticketsFromPayload(map[string]any{"seat_no": "01A"}); len(got) != 0 {
+		t.Fatalf("expected no tickets from a payload without tickets, got %+v", got)
+	}
+}
@@ -10,10 +10,23 @@ var (
 )
 
 type OrderView struct {
-	OrderID       string    `json:"order_id"`
-	Status        string    `json:"status"`
-	AmountCents   int64     `json:"amount_cents"`
-	ProviderTxnID string    `json:"provider_txn_id,omitempty"`
-	SeatNo        string    `json:"seat_no,omitempty"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	OrderID       string       `json:"order_id"`
+	Status        string       `json:"status"`
+	AmountCents   int64        `json:"amount_cents"`
+	ProviderTxnID string       `json:"provider_txn_id,omitempty"`
+	SeatNo        string       `json:"seat_no,omitempty"`
+	Tickets       []TicketView `json:"tickets,omitempty"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+// TicketView is one ticket of an order as last reported on ticket.events.
+type TicketView struct {
+	TicketID      string `json:"ticket_id"`
+	PassengerID   string `json:"passenger_id,omitempty"`
+	PassengerName string `json:"passenger_name,omitempty"`
+	TrainID       string `json:"train_id,omitempty"`
+	TravelDate    string `json:"travel_date,omitempty"`
+	CoachNo       int    `json:"coach_no,omitempty"`
+	SeatNo        string `json:"seat_no,omitempty"`
+	Status        string `json:"status"`
 }
@@ -38,9 +38,74 @@ func (r *Repository) GetOrderView(ctx context.Context, orderID string) (*domain.
 		}
 		return nil, err
 	}
+	tickets, err := r.listOrderTickets(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	v.Tickets = tickets
 	return &v, nil
 }
 
+func (r *Repository) listOrderTickets(ctx context.Context, orderID string) ([]domain.TicketView, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT ticket_id, passenger_id, passenger_name, train_id, travel_date, coach_no, seat_no, status
+		 FROM query_order_tickets
+		 WHERE order_id=?
+		 ORDER BY coach_no, seat_no, ticket_id`,
+		orderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []domain.TicketView
+	for rows.Next() {
+		var t domain.TicketView
+		if err := rows.Scan(&t.TicketID, &t.PassengerID, &t.PassengerName, &t.TrainID, &t.TravelDate, &t.CoachNo, &t.SeatNo, &t.Status); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// UpsertOrderTicketsTx records issued tickets. A ticket already projected
+// keeps its status: only TicketStatusChanged moves it.
+func (r *Repository) UpsertOrderTicketsTx(ctx context.Context, tx *sql.Tx, orderID string, tickets []domain.TicketView) error {
+	for _, t := range tickets {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO query_order_tickets(ticket_id, order_id, passenger_id, passenger_name, train_id, travel_date, coach_no, seat_no, status)
+			 VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)
+			 ON DUPLICATE KEY UPDATE
+			   passenger_id=VALUES(passenger_id),
+			   passenger_name=VALUES(passenger_name),
+			   train_id=VALUES(train_id),
+			   travel_date=VALUES(travel_date),
+			   coach_no=VALUES(coach_no),
+			   seat_no=VALUES(seat_no)`,
+			t.TicketID, orderID, t.PassengerID, t.PassengerName, t.TrainID, t.TravelDate, t.CoachNo, t.SeatNo, t.Status,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) UpdateTicketStatusTx(ctx context.Context, tx *sql.Tx, orderID string, ticketID string, passengerID string, status string) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO query_order_tickets(ticket_id, order_id, passenger_id, status)
+		 VALUES(?, ?, ?, ?)
+		 ON DUPLICATE KEY UPDATE status=VALUES(status)`,
+		ticketID, orderID, passengerID, status,
+	)
+	return err
+}
+
 func (r *Repository) UpsertOrderViewTx(ctx context.Context, tx *sql.Tx, v domain.OrderView) error {
 	_, err := tx.ExecContext(
 		ctx,
@@ -0,0 +1,135 @@
+package application
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	segmentkafka "github.com/segmentio/kafka-go"
+)
+
+// laneBuffer is how many fetched messages may wait for each lane before
+// fetching blocks.
+const laneBuffer = 16
+
+// consumeLoop fetches from c and processes messages on w.concurrency
+// lanes. Messages with the same key, i.e. the events of one order, always
+// go to the same lane and are processed in the order they were fetched.
+// A partition's offset is only committed up to the last message before
+// which every fetched message of the partition has been processed, so a
+// slow event never has its offset committed by a faster one behind it.
+func (w *Worker) consumeLoop(ctx context.Context, c manualCommitConsumer, source string) {
+	lanes := make([]chan segmentkafka.Message, max(w.concurrency, 1))
+	tracker := newOffsetTracker()
+	var wg sync.WaitGroup
+	for i := range lanes {
+		lanes[i] = make(chan segmentkafka.Message, laneBuffer)
+		wg.Add(1)
+		go func(msgs <-chan segmentkafka.Message) {
+			defer wg.Done()
+			for msg := range msgs {
+				// Once ctx is done nothing more is processed or committed;
+				// the rest of the lane is redelivered after a restart.
+				if ctx.Err() != nil || !w.process(ctx, msg, source) {
+					continue
+				}
+				w.commitProcessed(ctx, c, tracker, msg, source)
+			}
+		}(lanes[i])
+	}
+	defer func() {
+		for _, lane := range lanes {
+			close(lane)
+		}
+		wg.Wait()
+	}()
+
+	for {
+		msg, err := c.Fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			w.logger.Error("fetch order event failed", "source", source, "error", err)
+			time.Sleep(200 * time.Millisecond)
+			continue
+		}
+		tracker.track(msg)
+		select {
+		case lanes[laneFor(msg.Key, len(lanes))] <- msg:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *Worker) commitProcessed(ctx context.Context, c manualCommitConsumer, tracker *offsetTracker, msg segmentkafka.Message, source string) {
+	// Commits are made under the tracker lock so they reach the consumer in
+	// offset order.
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	upTo, ok := tracker.completeLocked(msg)
+	if !ok {
+		return
+	}
+	commitCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if err := c.Commit(commitCtx, upTo); err != nil && ctx.Err() == nil {
+		w.logger.Error("commit order event failed", "source", source, "partition", upTo.Partition, "offset", upTo.Offset, "error", err)
+	}
+}
+
+func laneFor(key []byte, lanes int) int {
+	h := fnv.New32a()
+	_, _ = h.Write(key)
+	return int(h.Sum32() % uint32(lanes))
+}
+
+// offsetTracker follows the fetched messages of each partition until they
+// are processed.
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[int]*partitionOffsets
+}
+
+type partitionOffsets struct {
+	// pending is in fetch order, i.e. offset order.
+	pending []segmentkafka.Message
+	done    map[int64]bool
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: make(map[int]*partitionOffsets)}
+}
+
+func (t *offsetTracker) track(msg segmentkafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p, ok := t.partitions[msg.Partition]
+	if !ok {
+		p = &partitionOffsets{done: make(map[int64]bool)}
+		t.partitions[msg.Partition] = p
+	}
+	p.pending = append(p.pending, msg)
+}
+
+// completeLocked marks msg processed and returns the last message of the
+// partition that can now be committed, if any.
+func (t *offsetTracker) completeLocked(msg segmentkafka.Message) (segmentkafka.Message, bool) {
+	p, ok := t.partitions[msg.Partition]
+	if !ok {
+		return segmentkafka.Message{}, false
+	}
+	p.done[msg.Offset] = true
+	var (
+		upTo      segmentkafka.Message
+		committed bool
+	)
+	for len(p.pending) > 0 && p.done[p.pending[0].Offset] {
+		upTo, committed = p.pending[0], true
+		delete(p.done, upTo.Offset)
+		p.pending = p.pending[1:]
+	}
+	return upTo, committed
+}
@@ -0,0 +1,326 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	segmentkafka "github.com/segmentio/kafka-go"
+
+	"ticketing/internal/ticket/domain"
+	"ticketing/internal/ticket/infrastructure/parking"
+)
+
+const (
+	orderEventsTopic      = "order.events"
+	orderEventsRetryTopic = "order.events.retry"
+	orderEventsDLQTopic   = "order.events.dlq"
+
+	sourceMain  = "main"
+	sourceRetry = "retry"
+
+	// Headers of messages on the retry and dead-letter topics.
+	headerRetryCount    = "x-retry-count"
+	headerNotBefore     = "x-not-before"
+	headerError         = "x-error"
+	headerOriginalTopic = "x-original-topic"
+)
+
+// errMalformedEvent marks events that cannot be decoded; retrying them is
+// pointless.
+var errMalformedEvent = errors.New("malformed order event")
+
+// RetryPolicy bounds how hard the worker tries an event before giving up
+// on it. Each delivery is tried Attempts times in process, Backoff apart
+// and doubling. An event that still fails goes to order.events.retry,
+// where it waits Delay before being tried again, up to TopicAttempts
+// times; after that it is dead-lettered to order.events.dlq.
+type RetryPolicy struct {
+	Attempts      int
+	Backoff       time.Duration
+	TopicAttempts int
+	Delay         time.Duration
+}
+
+type workerMetrics struct {
+	messages *prometheus.CounterVec
+	retries  *prometheus.CounterVec
+	forwards *prometheus.CounterVec
+}
+
+func newWorkerMetrics() *workerMetrics {
+	return &workerMetrics{
+		messages: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "ticket_worker_messages_total",
+				Help: "Order events by source topic and outcome: handled, retry_topic, dead_letter or parked.",
+			},
+			[]string{"source", "outcome"},
+		),
+		retries: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "ticket_worker_handle_retries_total",
+				Help: "In-process handling attempts beyond the first, by source topic.",
+			},
+			[]string{"source"},
+		),
+		forwards: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "ticket_worker_forward_failures_total",
+				Help: "Failed publishes to the retry or dead-letter topic, by topic.",
+			},
+			[]string{"topic"},
+		),
+	}
+}
+
+// Collectors returns the worker metrics for registration.
+func (w *Worker) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{w.metrics.messages, w.metrics.retries, w.metrics.forwards}
+}
+
+// Outcomes of processing an order event, as counted by
+// ticket_worker_messages_total.
+const (
+	outcomeHandled    = "handled"
+	outcomeRetryTopic = "retry_topic"
+	outcomeDeadLetter = "dead_letter"
+	outcomeParked     = "parked"
+)
+
+// process handles msg or hands it to the retry or dead-letter topic. It
+// returns false when ctx ended before either happened, in which case the
+// offset must not be committed so the message is delivered again after a
+// restart.
+//
+// Events of one key stay in order while one of them waits on the retry
+// topic: the key is held before the event is sent there, later events of
+// the key arriving
+// on the main topic are parked, and once the held event has been handled
+// or dead-lettered the parked events are handled in order before the key
+// is released.
+func (w *Worker) process(ctx context.Context, msg segmentkafka.Message, source string) bool {
+	key := string(msg.Key)
+	ordered := w.parking != nil && key != ""
+	if ordered && source == sourceMain {
+		var parked bool
+		if !w.withStoreRetries(ctx, "park order event", func() (err error) {
+			parked, err = w.parking.Park(ctx, key, msg.Value)
+			return err
+		}) {
+			return false
+		}
+		if parked {
+			w.logger.Info("order event parked behind a retried one", "key", key)
+			w.metrics.messages.WithLabelValues(source, outcomeParked).Inc()
+			return true
+		}
+	}
+
+	outcome, ok := w.handleOrForward(ctx, msg, source, ordered && source == sourceMain)
+	if !ok || !ordered {
+		return ok
+	}
+	if outcome != outcomeRetryTopic && source == sourceRetry {
+		return w.drainParked(ctx, key)
+	}
+	return true
+}
+
+// drainParked handles the events parked under key in order and releases
+// the key. A parked event that fails goes to the retry topic like any
+// other and keeps the key held. It returns false when ctx ended first; the
+// held event is then redelivered and draining resumes.
+func (w *Worker) drainParked(ctx context.Context, key string) bool {
+	for {
+		var (
+			e     parking.Event
+			found bool
+		)
+		if !w.withStoreRetries(ctx, "read parked order event", func() (err error) {
+			e, found, err = w.parking.Next(ctx, key)
+			return err
+		}) {
+			return false
+		}
+		if !found {
+			var released bool
+			if !w.withStoreRetries(ctx, "release order key", func() (err error) {
+				released, err = w.parking.Release(ctx, key)
+				return err
+			}) {
+				return false
+			}
+			if released {
+				return true
+			}
+			continue
+		}
+
+		outcome, ok := w.handleOrForward(ctx, segmentkafka.Message{Key: []byte(key), Value: e.Payload}, sourceMain, false)
+		if !ok {
+			return false
+		}
+		if !w.withStoreRetries(ctx, "remove parked order event", func() error {
+			return w.parking.Remove(ctx, e.ID)
+		}) {
+			return false
+		}
+		if outcome == outcomeRetryTopic {
+			return true
+		}
+	}
+}
+
+// handleOrForward handles msg or forwards it to the retry or dead-letter
+// topic and reports which. It returns false when ctx ended before either
+// happened. With hold set, the key of msg is held before msg goes to the
+// retry topic: were it held after, the retry consumer could handle msg and
+// find nothing to release first, leaving the hold behind for good.
+func (w *Worker) handleOrForward(ctx context.Context, msg segmentkafka.Message, source string, hold bool) (string, bool) {
+	retries := 0
+	if source == sourceRetry {
+		retries, _ = strconv.Atoi(header(msg, headerRetryCount))
+		if notBefore, err := time.Parse(time.RFC3339Nano, header(msg, headerNotBefore)); err == nil {
+			if !sleepContext(ctx, time.Until(notBefore)) {
+				return "", false
+			}
+		}
+	}
+
+	err := w.handleWithRetries(ctx, msg.Value, source)
+	if err == nil {
+		w.metrics.messages.WithLabelValues(source, outcomeHandled).Inc()
+		return outcomeHandled, true
+	}
+	if ctx.Err() != nil {
+		return "", false
+	}
+
+	headers := []segmentkafka.Header{
+		{Key: headerOriginalTopic, Value: []byte(orderEventsTopic)},
+		{Key: headerError, Value: []byte(truncateError(err, 240))},
+	}
+	if isPermanent(err) || retries >= w.retry.TopicAttempts {
+		w.logger.Error("order event dead-lettered", "source", source, "retries", retries, "error", err, "key", string(msg.Key))
+		headers = append(headers, segmentkafka.Header{Key: headerRetryCount, Value: []byte(strconv.Itoa(retries))})
+		if w.forward(ctx, orderEventsDLQTopic, msg, headers) != nil {
+			return "", false
+		}
+		w.metrics.messages.WithLabelValues(source, outcomeDeadLetter).Inc()
+		return outcomeDeadLetter, true
+	}
+
+	w.logger.Warn("order event sent to retry topic", "source", source, "retries", retries+1, "error", err, "key", string(msg.Key))
+	headers = append(headers,
+		segmentkafka.Header{Key: headerRetryCount, Value: []byte(strconv.Itoa(retries + 1))},
+		segmentkafka.Header{Key: headerNotBefore, Value: []byte(time.Now().Add(w.retry.Delay).UTC().Format(time.RFC3339Nano))},
+	)
+	key := string(msg.Key)
+	if hold && !w.withStoreRetries(ctx, "hold order key", func() error {
+		return w.parking.Hold(ctx, key)
+	}) {
+		return "", false
+	}
+	if w.forward(ctx, orderEventsRetryTopic, msg, headers) != nil {
+		if hold {
+			w.releaseAbandonedHold(ctx, key)
+		}
+		return "", false
+	}
+	w.metrics.messages.WithLabelValues(source, outcomeRetryTopic).Inc()
+	return outcomeRetryTopic, true
+}
+
+// releaseAbandonedHold lifts the hold taken for an event that never reached
+// the retry topic, so the event is not parked behind itself when it is
+// delivered again. ctx has ended by then.
+func (w *Worker) releaseAbandonedHold(ctx context.Context, key string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*time.Second)
+	defer cancel()
+	if _, err := w.parking.Release(ctx, key); err != nil {
+		w.logger.Error("release abandoned order key failed", "key", key, "error", err)
+	}
+}
+
+// withStoreRetries runs fn until it succeeds and reports false when ctx
+// ends first.
+func (w *Worker) withStoreRetries(ctx context.Context, what string, fn func() error) bool {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return true
+		}
+		w.logger.Error(what+" failed", "attempt", attempt, "error", err)
+		if !sleepContext(ctx, retryBackoff(attempt)) {
+			return false
+		}
+	}
+}
+
+func (w *Worker) handleWithRetries(ctx context.Context, raw []byte, source string) error {
+	backoff := w.retry.Backoff
+	for attempt := 1; ; attempt++ {
+		err := w.handleMessage(ctx, raw)
+		if err == nil || isPermanent(err) || attempt >= w.retry.Attempts {
+			return err
+		}
+		w.metrics.retries.WithLabelValues(source).Inc()
+		w.logger.Warn("handle order event failed, retrying", "source", source, "attempt", attempt, "error", err)
+		if !sleepContext(ctx, backoff) {
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// forward publishes msg to topic, retrying until it succeeds: committing
+// the offset without it would lose the event. It fails only when ctx ends.
+func (w *Worker) forward(ctx context.Context, topic string, msg segmentkafka.Message, headers []segmentkafka.Header) error {
+	for attempt := 1; ; attempt++ {
+		pubCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		err := w.producer.PublishWithHeaders(pubCtx, topic, msg.Key, msg.Value, headers)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		w.metrics.forwards.WithLabelValues(topic).Inc()
+		w.logger.Error("forward order event failed", "topic", topic, "attempt", attempt, "error", err)
+		if !sleepContext(ctx, retryBackoff(attempt)) {
+			return ctx.Err()
+		}
+	}
+}
+
+// isPermanent reports errors that no retry can fix.
+func isPermanent(err error) bool {
+	return errors.Is(err, errMalformedEvent) ||
+		errors.Is(err, domain.ErrUnknownCoachType) ||
+		errors.Is(err, domain.ErrInvalidSegment)
+}
+
+func header(msg segmentkafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// sleepContext waits for d and reports whether ctx is still live.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
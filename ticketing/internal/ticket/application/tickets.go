@@ -0,0 +1,257 @@
+package application
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ticketing/internal/common/auth"
+	"ticketing/internal/common/tracing"
+	orderdomain "ticketing/internal/order/domain"
+	"ticketing/internal/ticket/domain"
+)
+
+type eTicketIssuer interface {
+	Issue(t domain.Ticket) (domain.ETicket, error)
+}
+
+type eTicketVerifier interface {
+	Verify(token string) (domain.ETicketClaims, error)
+}
+
+// TicketService hands e-tickets to the owners of their orders and moves
+// tickets through their lifecycle: gate scans check them in and use them
+// up, support staff void and refund them. Every transition is recorded
+// and published to ticket.events as TicketStatusChanged.
+type TicketService struct {
+	repo     ticketRepository
+	outbox   ticketOutboxStore
+	verifier eTicketVerifier
+	now      func() time.Time
+}
+
+func NewTicketService(repo ticketRepository, outboxStore ticketOutboxStore, verifier eTicketVerifier) *TicketService {
+	return &TicketService{repo: repo, outbox: outboxStore, verifier: verifier, now: time.Now}
+}
+
+// Get returns the e-ticket of ticketID. Only the owner of the order and
+// support staff may fetch it; anyone else is told it does not exist.
+func (s *TicketService) Get(ctx context.Context, ticketID string) (domain.ETicket, error) {
+	doc, owner, err := s.repo.FindETicket(ctx, ticketID)
+	if err != nil {
+		return domain.ETicket{}, err
+	}
+	if caller := tracing.UserID(ctx); caller != owner && tracing.UserRole(ctx) != auth.RoleSupport {
+		return domain.ETicket{}, domain.ErrTicketNotFound
+	}
+	return doc, nil
+}
+
+// ScanResult is a scanned ticket as it stands after the scan. On
+// ErrTicketUsed it carries when the ticket was used.
+type ScanResult struct {
+	Claims      domain.ETicketClaims
+	Status      domain.TicketStatus
+	CheckedInAt time.Time
+	UsedAt      time.Time
+}
+
+// ScanInput is a scan at the gate of station StationIndex for TrainID on
+// TravelDate.
+type ScanInput struct {
+	Token        string
+	TrainID      string
+	TravelDate   string
+	StationIndex int
+}
+
+// CheckIn checks the ticket of token in. Scanning a checked-in ticket again
+// is not an error and changes nothing. Tickets for another train, date or
+// boarding station are rejected with ErrTicketMismatch.
+func (s *TicketService) CheckIn(ctx context.Context, in ScanInput) (ScanResult, error) {
+	claims, err := s.verifier.Verify(in.Token)
+	res := ScanResult{Claims: claims}
+	if err != nil {
+		return res, err
+	}
+
+	tx, err := s.repo.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return res, err
+	}
+	defer tx.Rollback()
+
+	t, err := s.findScannable(ctx, tx, claims.TicketID, &res)
+	if err != nil {
+		return res, err
+	}
+	if err := t.CheckInAt(in.TrainID, in.TravelDate, in.StationIndex); err != nil {
+		return res, err
+	}
+	if t.Status == domain.TicketStatusCheckedIn {
+		return res, nil
+	}
+	station := in.StationIndex
+	c, err := s.transitionTx(ctx, tx, t, domain.TicketEventCheckIn, callerActor(ctx, domain.TicketActorGate), "checked in at the gate", &station)
+	if err != nil {
+		return res, err
+	}
+	res.Status, res.CheckedInAt = c.ToStatus, c.At
+	return res, tx.Commit()
+}
+
+// Verify validates token and uses its ticket up. A ticket passes once:
+// later scans return ErrTicketUsed. Genuine tokens of tickets that have
+// been voided, refunded or replaced, or whose order is no longer TICKETED,
+// return ErrTicketNotValid. Like CheckIn, it rejects tickets for another
+// train, date or boarding station with ErrTicketMismatch.
+func (s *TicketService) Verify(ctx context.Context, in ScanInput) (ScanResult, error) {
+	claims, err := s.verifier.Verify(in.Token)
+	res := ScanResult{Claims: claims}
+	if err != nil {
+		return res, err
+	}
+
+	tx, err := s.repo.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return res, err
+	}
+	defer tx.Rollback()
+
+	t, err := s.findScannable(ctx, tx, claims.TicketID, &res)
+	if err != nil {
+		return res, err
+	}
+	if err := t.CheckInAt(in.TrainID, in.TravelDate, in.StationIndex); err != nil {
+		return res, err
+	}
+	station := in.StationIndex
+	c, err := s.transitionTx(ctx, tx, t, domain.TicketEventUse, callerActor(ctx, domain.TicketActorGate), "verified at the gate", &station)
+	if err != nil {
+		return res, err
+	}
+	res.Status, res.UsedAt = c.ToStatus, c.At
+	return res, tx.Commit()
+}
+
+// findScannable locks the ticket and fills res from it. It fails for
+// tickets a gate must not let through.
+func (s *TicketService) findScannable(ctx context.Context, tx *sql.Tx, ticketID string, res *ScanResult) (domain.Ticket, error) {
+	t, orderStatus, err := s.repo.FindTicketTx(ctx, tx, ticketID)
+	if errors.Is(err, domain.ErrTicketNotFound) {
+		return domain.Ticket{}, domain.ErrTicketNotValid
+	}
+	if err != nil {
+		return domain.Ticket{}, err
+	}
+	res.Status, res.CheckedInAt, res.UsedAt = t.Status, t.CheckedInAt, t.UsedAt
+	switch {
+	case t.Status == domain.TicketStatusUsed:
+		return domain.Ticket{}, domain.ErrTicketUsed
+	case t.Status == domain.TicketStatusVoided, t.Status == domain.TicketStatusRefunded, orderdomain.Status(orderStatus) != orderdomain.StatusTicketed:
+		return domain.Ticket{}, domain.ErrTicketNotValid
+	}
+	return t, nil
+}
+
+// UpdateStatus applies a void or refund on behalf of support staff.
+// Applying it to a ticket already in the resulting status changes nothing.
+func (s *TicketService) UpdateStatus(ctx context.Context, ticketID string, event domain.TicketEvent, reason string) (domain.Ticket, error) {
+	if event != domain.TicketEventVoid && event != domain.TicketEventRefund {
+		return domain.Ticket{}, domain.ErrInvalidTicketTransition
+	}
+	tx, err := s.repo.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return domain.Ticket{}, err
+	}
+	defer tx.Rollback()
+
+	t, _, err := s.repo.FindTicketTx(ctx, tx, ticketID)
+	if err != nil {
+		return domain.Ticket{}, err
+	}
+	if (event == domain.TicketEventVoid && t.Status == domain.TicketStatusVoided) ||
+		(event == domain.TicketEventRefund && t.Status == domain.TicketStatusRefunded) {
+		return t, nil
+	}
+	c, err := s.transitionTx(ctx, tx, t, event, callerActor(ctx, domain.TicketActorSupport), reason, nil)
+	if err != nil {
+		return domain.Ticket{}, err
+	}
+	t.Status = c.ToStatus
+	return t, tx.Commit()
+}
+
+func (s *TicketService) transitionTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	t domain.Ticket,
+	event domain.TicketEvent,
+	actor string,
+	reason string,
+	station *int,
+) (domain.TicketStatusChange, error) {
+	to, err := domain.NextTicketStatus(t.Status, event)
+	if err != nil {
+		return domain.TicketStatusChange{}, err
+	}
+	c := domain.TicketStatusChange{
+		TicketID:     t.TicketID,
+		OrderID:      t.OrderID,
+		PassengerID:  t.PassengerID,
+		Event:        event,
+		FromStatus:   t.Status,
+		ToStatus:     to,
+		Actor:        actor,
+		Reason:       reason,
+		StationIndex: station,
+		At:           s.now().UTC().Truncate(time.Second),
+	}
+	if err := s.repo.TransitionTicketTx(ctx, tx, c); err != nil {
+		return domain.TicketStatusChange{}, err
+	}
+	return c, insertStatusChangedTx(ctx, tx, s.outbox, c)
+}
+
+// insertStatusChangedTx queues the TicketStatusChanged event of c. It is
+// keyed by order, like TicketIssued, so consumers see an order's ticket
+// events in order.
+func insertStatusChangedTx(ctx context.Context, tx *sql.Tx, store ticketOutboxStore, c domain.TicketStatusChange) error {
+	eventID, payload := buildTicketStatusChangedEvent(c)
+	return store.InsertTx(ctx, tx, eventID, c.OrderID, "TicketStatusChanged", payload)
+}
+
+func buildTicketStatusChangedEvent(c domain.TicketStatusChange) (string, map[string]any) {
+	eventID := uuid.NewString()
+	body := map[string]any{
+		"order_id":     c.OrderID,
+		"ticket_id":    c.TicketID,
+		"passenger_id": c.PassengerID,
+		"event":        string(c.Event),
+		"from_status":  string(c.FromStatus),
+		"status":       string(c.ToStatus),
+		"actor":        c.Actor,
+		"reason":       c.Reason,
+		"changed_at":   c.At.UTC().Format(time.RFC3339),
+	}
+	if c.StationIndex != nil {
+		body["station_index"] = *c.StationIndex
+	}
+	return eventID, map[string]any{
+		"event_id":     eventID,
+		"aggregate_id": c.OrderID,
+		"event_type":   "TicketStatusChanged",
+		"occurred_at":  time.Now().UTC().Format(time.RFC3339Nano),
+		"payload":      body,
+	}
+}
+
+func callerActor(ctx context.Context, fallback string) string {
+	if caller := tracing.UserID(ctx); caller != "" {
+		return "user:" + caller
+	}
+	return fallback
+}
@@ -0,0 +1,337 @@
+package application
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"ticketing/internal/common/sqltest"
+	"ticketing/internal/ticket/domain"
+	grpcclient "ticketing/internal/ticket/infrastructure/grpc_client"
+)
+
+type fakeTicketStore struct {
+	ticketRepository
+	db          *sql.DB
+	tickets     map[string]domain.Ticket
+	orderStatus string
+	changes     []domain.TicketStatusChange
+	voided      []domain.TicketStatusChange
+}
+
+func newFakeTicketStore(tickets ...domain.Ticket) *fakeTicketStore {
+	f := &fakeTicketStore{db: sqltest.NewDB(), tickets: map[string]domain.Ticket{}, orderStatus: "TICKETED"}
+	for _, t := range tickets {
+		f.tickets[t.TicketID] = t
+	}
+	return f
+}
+
+func (f *fakeTicketStore) DB() *sql.DB { return f.db }
+
+func (f *fakeTicketStore) FindTicketTx(_ context.Context, _ *sql.Tx, ticketID string) (domain.Ticket, string, error) {
+	t, ok := f.tickets[ticketID]
+	if !ok {
+		return domain.Ticket{}, "", domain.ErrTicketNotFound
+	}
+	return t, f.orderStatus, nil
+}
+
+func (f *fakeTicketStore) TransitionTicketTx(_ context.Context, _ *sql.Tx, c domain.TicketStatusChange) error {
+	t := f.tickets[c.TicketID]
+	t.Status = c.ToStatus
+	switch c.ToStatus {
+	case domain.TicketStatusCheckedIn:
+		t.CheckedInAt = c.At
+	case domain.TicketStatusUsed:
+		t.UsedAt = c.At
+	}
+	f.tickets[c.TicketID] = t
+	f.changes = append(f.changes, c)
+	return nil
+}
+
+func (f *fakeTicketStore) ReissueTicketsTx(context.Context, *sql.Tx, string, string, []domain.Ticket, time.Time) ([]domain.TicketStatusChange, bool, error) {
+	return f.voided, true, nil
+}
+
+func (f *fakeTicketStore) InsertETicketsTx(context.Context, *sql.Tx, []domain.ETicket) error {
+	return nil
+}
+
+type fakeVerifier map[string]domain.Ticket
+
+func (f fakeVerifier) Verify(token string) (domain.ETicketClaims, error) {
+	t, ok := f[token]
+	if !ok {
+		return domain.ETicketClaims{}, domain.ErrInvalidETicket
+	}
+	return domain.ETicketClaims{Ticket: t}, nil
+}
+
+type fakeIssuer struct{}
+
+func (fakeIssuer) Issue(t domain.Ticket) (domain.ETicket, error) {
+	return domain.ETicket{TicketID: t.TicketID, OrderID: t.OrderID, Status: t.Status, Token: "token-" + t.TicketID}, nil
+}
+
+func testTicket(ticketID string, status domain.TicketStatus) domain.Ticket {
+	return domain.Ticket{
+		TicketID:    ticketID,
+		OrderID:     "order-1",
+		PassengerID: "ID_CARD:110101199001011234",
+		TrainID:     "G123",
+		TravelDate:  "2026-02-11",
+		FromIndex:   2,
+		ToIndex:     5,
+		Status:      status,
+	}
+}
+
+func newTicketTestService(t domain.Ticket) (*TicketService, *fakeTicketStore, *fakeOutboxStore) {
+	repo := newFakeTicketStore(t)
+	store := &fakeOutboxStore{}
+	now := time.Date(2026, 2, 11, 8, 0, 0, 0, time.UTC)
+	svc := NewTicketService(repo, store, fakeVerifier{"token": t})
+	svc.now = func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+	return svc, repo, store
+}
+
+func gateScan() ScanInput {
+	return ScanInput{Token: "token", TrainID: "G123", TravelDate: "2026-02-11", StationIndex: 2}
+}
+
+func TestCheckIn_RescanOfCheckedInTicketChangesNothing(t *testing.T) {
+	t.Parallel()
+
+	svc, repo, store := newTicketTestService(testTicket("ticket-1", domain.TicketStatusIssued))
+
+	first, err := svc.CheckIn(context.Background(), gateScan())
+	if err != nil {
+		t.Fatalf("check-in failed: %v", err)
+	}
+	if first.Status != domain.TicketStatusCheckedIn || first.CheckedInAt.IsZero() {
+		t.Fatalf("expected CHECKED_IN with a check-in time, got %s at %v", first.Status, first.CheckedInAt)
+	}
+	again, err := svc.CheckIn(context.Background(), gateScan())
+	if err != nil {
+		t.Fatalf("expected the rescan to succeed, got: %v", err)
+	}
+	if again.Status != domain.TicketStatusCheckedIn || !again.CheckedInAt.Equal(first.CheckedInAt) {
+		t.Fatalf("expected the first check-in to be reported, got %s at %v", again.Status, again.CheckedInAt)
+	}
+	if len(repo.changes) != 1 || len(store.inserted) != 1 {
+		t.Fatalf("expected one transition and one event, got %d and %d", len(repo.changes), len(store.inserted))
+	}
+	ev := store.inserted[0]
+	body, _ := ev.payload["payload"].(map[string]any)
+	if ev.eventType != "TicketStatusChanged" || body["status"] != string(domain.TicketStatusCheckedIn) || body["station_index"] != 2 {
+		t.Fatalf("unexpected event %s: %v", ev.eventType, body)
+	}
+}
+
+func TestCheckIn_RejectsMismatchBeforeIdempotentReturn(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]func(in *ScanInput){
+		"train":   func(in *ScanInput) { in.TrainID = "G125" },
+		"date":    func(in *ScanInput) { in.TravelDate = "2026-02-12" },
+		"station": func(in *ScanInput) { in.StationIndex = 3 },
+	}
+	for name, mutate := range cases {
+		checkedIn := testTicket("ticket-2", domain.TicketStatusCheckedIn)
+		svc, repo, _ := newTicketTestService(checkedIn)
+		in := gateScan()
+		mutate(&in)
+
+		if _, err := svc.CheckIn(context.Background(), in); !errors.Is(err, domain.ErrTicketMismatch) {
+			t.Fatalf("%s: expected ErrTicketMismatch, got: %v", name, err)
+		}
+		if len(repo.changes) != 0 {
+			t.Fatalf("%s: expected no transition, got %d", name, len(repo.changes))
+		}
+	}
+}
+
+func TestScan_RejectsFinishedTickets(t *testing.T) {
+	t.Parallel()
+
+	used := testTicket("ticket-3", domain.TicketStatusUsed)
+	used.UsedAt = time.Date(2026, 2, 11, 7, 30, 0, 0, time.UTC)
+	cases := []struct {
+		ticket domain.Ticket
+		want   error
+	}{
+		{ticket: used, want: domain.ErrTicketUsed},
+		{ticket: testTicket("ticket-4", domain.TicketStatusVoided), want: domain.ErrTicketNotValid},
+		{ticket: testTicket("ticket-5", domain.TicketStatusRefunded), want: domain.ErrTicketNotValid},
+	}
+	for _, tc := range cases {
+		svc, repo, _ := newTicketTestService(tc.ticket)
+
+		res, err := svc.CheckIn(context.Background(), gateScan())
+		if !errors.Is(err, tc.want) {
+			t.Fatalf("%s check-in: expected %v, got: %v", tc.ticket.Status, tc.want, err)
+		}
+		if res.Status != tc.ticket.Status || !res.UsedAt.Equal(tc.ticket.UsedAt) {
+			t.Fatalf("%s check-in: expected the stored ticket to be reported, got %s used at %v", tc.ticket.Status, res.Status, res.UsedAt)
+		}
+		if _, err := svc.Verify(context.Background(), gateScan()); !errors.Is(err, tc.want) {
+			t.Fatalf("%s verify: expected %v, got: %v", tc.ticket.Status, tc.want, err)
+		}
+		if len(repo.changes) != 0 {
+			t.Fatalf("%s: expected no transition, got %d", tc.ticket.Status, len(repo.changes))
+		}
+	}
+}
+
+func TestUpdateStatus_VoidAndRefundAreIdempotent(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		event domain.TicketEvent
+		want  domain.TicketStatus
+	}{
+		{event: domain.TicketEventVoid, want: domain.TicketStatusVoided},
+		{event: domain.TicketEventRefund, want: domain.TicketStatusRefunded},
+	} {
+		svc, repo, store := newTicketTestService(testTicket("ticket-6", domain.TicketStatusIssued))
+		for i := 0; i < 2; i++ {
+			got, err := svc.UpdateStatus(context.Background(), "ticket-6", tc.event, "requested by passenger")
+			if err != nil {
+				t.Fatalf("%s attempt %d failed: %v", tc.event, i, err)
+			}
+			if got.Status != tc.want {
+				t.Fatalf("%s attempt %d: expected %s, got %s", tc.event, i, tc.want, got.Status)
+			}
+		}
+		if len(repo.changes) != 1 || len(store.inserted) != 1 {
+			t.Fatalf("%s: expected one transition and one event, got %d and %d", tc.event, len(repo.changes), len(store.inserted))
+		}
+	}
+}
+
+func TestUpdateStatus_RejectsRefundOfCheckedInTicket(t *testing.T) {
+	t.Parallel()
+
+	svc, repo, _ := newTicketTestService(testTicket("ticket-7", domain.TicketStatusCheckedIn))
+
+	_, err := svc.UpdateStatus(context.Background(), "ticket-7", domain.TicketEventRefund, "too late")
+	if !errors.Is(err, domain.ErrInvalidTicketTransition) {
+		t.Fatalf("expected ErrInvalidTicketTransition, got: %v", err)
+	}
+	if _, err := svc.UpdateStatus(context.Background(), "ticket-7", domain.TicketEventUse, "not for support"); !errors.Is(err, domain.ErrInvalidTicketTransition) {
+		t.Fatalf("expected ErrInvalidTicketTransition for USE, got: %v", err)
+	}
+	if len(repo.changes) != 0 {
+		t.Fatalf("expected no transition, got %d", len(repo.changes))
+	}
+}
+
+func TestStoreReissuedTickets_PublishesVoidedTickets(t *testing.T) {
+	t.Parallel()
+
+	repo := newFakeTicketStore()
+	for _, id := range []string{"old-1", "old-2"} {
+		repo.voided = append(repo.voided, domain.TicketStatusChange{
+			TicketID:   id,
+			OrderID:    "order-1",
+			Event:      domain.TicketEventVoid,
+			FromStatus: domain.TicketStatusIssued,
+			ToStatus:   domain.TicketStatusVoided,
+			Actor:      domain.TicketActorTicketWorker,
+		})
+	}
+	store := &fakeOutboxStore{}
+	w := &Worker{
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		repo:     repo,
+		outbox:   store,
+		etickets: fakeIssuer{},
+	}
+	alloc := seatAllocation{
+		in:         grpcclient.AllocateSeatInput{OrderID: "order-1", TrainID: "G125", TravelDate: "2026-02-12", FromIndex: 0, ToIndex: 3},
+		passengers: []domain.Passenger{{ID: "ID_CARD:110101199001011234", Name: "Zhang San"}},
+		seats:      grpcclient.AllocateSeatResult{SeatNos: []string{"05A"}},
+	}
+
+	if err := w.storeReissuedTickets(context.Background(), "order-1", "change-1", alloc); err != nil {
+		t.Fatalf("store reissued tickets failed: %v", err)
+	}
+	var types []string
+	for _, ev := range store.inserted {
+		types = append(types, ev.eventType)
+	}
+	want := []string{"TicketStatusChanged", "TicketStatusChanged", "TicketReissued"}
+	if !slices.Equal(types, want) {
+		t.Fatalf("expected events %v, got %v", want, types)
+	}
+	for i, ev := range store.inserted[:2] {
+		body, _ := ev.payload["payload"].(map[string]any)
+		if body["ticket_id"] != repo.voided[i].TicketID || body["status"] != string(domain.TicketStatusVoided) {
+			t.Fatalf("unexpected status change event: %v", body)
+		}
+	}
+}
+
+func TestVerify_TicketPassesOnce(t *testing.T) {
+	t.Parallel()
+
+	svc, repo, store := newTicketTestService(testTicket("ticket-8", domain.TicketStatusIssued))
+
+	first, err := svc.Verify(context.Background(), gateScan())
+	if err != nil {
+		t.Fatalf("first scan failed: %v", err)
+	}
+	if first.Status != domain.TicketStatusUsed || first.UsedAt.IsZero() || first.Claims.TicketID != "ticket-8" {
+		t.Fatalf("expected ticket-8 USED with a use time, got %s (%s) at %v", first.Claims.TicketID, first.Status, first.UsedAt)
+	}
+	second, err := svc.Verify(context.Background(), gateScan())
+	if !errors.Is(err, domain.ErrTicketUsed) {
+		t.Fatalf("expected ErrTicketUsed on the second scan, got: %v", err)
+	}
+	if !second.UsedAt.Equal(first.UsedAt) {
+		t.Fatalf("expected the first use time %v, got %v", first.UsedAt, second.UsedAt)
+	}
+	if len(repo.changes) != 1 || len(store.inserted) != 1 {
+		t.Fatalf("expected one transition and one event, got %d and %d", len(repo.changes), len(store.inserted))
+	}
+}
+
+func TestVerify_RejectsTicketsOfOrdersNoLongerTicketed(t *testing.T) {
+	t.Parallel()
+
+	svc, repo, _ := newTicketTestService(testTicket("ticket-9", domain.TicketStatusIssued))
+	repo.orderStatus = "CANCELLED"
+
+	if _, err := svc.Verify(context.Background(), gateScan()); !errors.Is(err, domain.ErrTicketNotValid) {
+		t.Fatalf("expected ErrTicketNotValid, got: %v", err)
+	}
+	if len(repo.changes) != 0 {
+		t.Fatalf("expected no transition, got %d", len(repo.changes))
+	}
+}
+
+func TestVerify_RejectsUnknownTickets(t *testing.T) {
+	t.Parallel()
+
+	svc, repo, _ := newTicketTestService(testTicket("ticket-10", domain.TicketStatusIssued))
+	delete(repo.tickets, "ticket-10")
+
+	if _, err := svc.Verify(context.Background(), gateScan()); !errors.Is(err, domain.ErrTicketNotValid) {
+		t.Fatalf("expected ErrTicketNotValid for a replaced ticket, got: %v", err)
+	}
+	in := gateScan()
+	in.Token = "forged"
+	if _, err := svc.Verify(context.Background(), in); !errors.Is(err, domain.ErrInvalidETicket) {
+		t.Fatalf("expected ErrInvalidETicket for a bad token, got: %v", err)
+	}
+}
@@ -4,21 +4,29 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	segmentkafka "github.com/segmentio/kafka-go"
 
 	commonkafka "ticketing/internal/common/kafka"
 	"ticketing/internal/ticket/domain"
 	grpcclient "ticketing/internal/ticket/infrastructure/grpc_client"
 	"ticketing/internal/ticket/infrastructure/outbox"
-	"ticketing/internal/ticket/infrastructure/repository"
+	"ticketing/internal/ticket/infrastructure/parking"
 )
 
 type eventProducer interface {
 	Publish(ctx context.Context, topic string, key []byte, value []byte) error
+	PublishWithHeaders(ctx context.Context, topic string, key []byte, value []byte, headers []segmentkafka.Header) error
+}
+
+type manualCommitConsumer interface {
+	Fetch(ctx context.Context) (segmentkafka.Message, error)
+	Commit(ctx context.Context, msg segmentkafka.Message) error
 }
 
 type ticketOutboxStore interface {
@@ -28,13 +36,56 @@ type ticketOutboxStore interface {
 	MarkRetry(ctx context.Context, id int64, retryCount int, nextRetryAt time.Time, lastError string) error
 }
 
+// eventParking keeps the events of a key in order while one of them waits
+// on the retry topic.
+type eventParking interface {
+	Hold(ctx context.Context, key string) error
+	Park(ctx context.Context, key string, payload []byte) (bool, error)
+	Next(ctx context.Context, key string) (parking.Event, bool, error)
+	Remove(ctx context.Context, id int64) error
+	Release(ctx context.Context, key string) (bool, error)
+}
+
+type ticketRepository interface {
+	DB() *sql.DB
+	IsOrderPaid(ctx context.Context, orderID string) (bool, error)
+	IsOrderPaidTx(ctx context.Context, tx *sql.Tx, orderID string) (bool, error)
+	FindItinerary(ctx context.Context, orderID string) (domain.Itinerary, error)
+	InsertTicketsTx(ctx context.Context, tx *sql.Tx, tickets []domain.Ticket) (bool, error)
+	ReissueTicketsTx(ctx context.Context, tx *sql.Tx, orderID string, changeID string, tickets []domain.Ticket, at time.Time) ([]domain.TicketStatusChange, bool, error)
+	InsertETicketsTx(ctx context.Context, tx *sql.Tx, docs []domain.ETicket) error
+	IsChangeApplied(ctx context.Context, orderID string, changeID string) (bool, error)
+	MarkOrderTicketedTx(ctx context.Context, tx *sql.Tx, orderID string, traceID string) error
+	FindETicket(ctx context.Context, ticketID string) (domain.ETicket, string, error)
+	FindTicketTx(ctx context.Context, tx *sql.Tx, ticketID string) (domain.Ticket, string, error)
+	TransitionTicketTx(ctx context.Context, tx *sql.Tx, c domain.TicketStatusChange) error
+}
+
 type Worker struct {
 	logger        *slog.Logger
-	consumer      *commonkafka.Consumer
+	consumer      manualCommitConsumer
+	retryConsumer manualCommitConsumer
 	producer      eventProducer
-	repo          *repository.Repository
+	parking       eventParking
+	repo          ticketRepository
 	outbox        ticketOutboxStore
 	seatAllocator grpcclient.SeatAllocatorClient
+	etickets      eTicketIssuer
+	itinerary     ItineraryDefaults
+	retry         RetryPolicy
+	concurrency   int
+	metrics       *workerMetrics
+}
+
+// ItineraryDefaults stands in for what an order does not record: the
+// train, date and class of an order without a partition key and the
+// station range of an order reserved without one.
+type ItineraryDefaults struct {
+	TrainID    string
+	TravelDate string
+	CoachType  string
+	FromIndex  int
+	ToIndex    int
 }
 
 type orderEventEnvelope struct {
@@ -48,57 +99,86 @@ type orderEventEnvelope struct {
 func NewWorker(
 	logger *slog.Logger,
 	consumer *commonkafka.Consumer,
+	retryConsumer *commonkafka.Consumer,
 	producer eventProducer,
-	repo *repository.Repository,
+	parking eventParking,
+	repo ticketRepository,
 	outboxStore ticketOutboxStore,
 	seatAllocator grpcclient.SeatAllocatorClient,
+	etickets eTicketIssuer,
+	itinerary ItineraryDefaults,
+	retry RetryPolicy,
+	concurrency int,
 ) *Worker {
 	return &Worker{
 		logger:        logger,
 		consumer:      consumer,
+		retryConsumer: retryConsumer,
 		producer:      producer,
+		parking:       parking,
 		repo:          repo,
 		outbox:        outboxStore,
 		seatAllocator: seatAllocator,
+		etickets:      etickets,
+		itinerary:     itinerary,
+		retry:         retry,
+		concurrency:   concurrency,
+		metrics:       newWorkerMetrics(),
 	}
 }
 
+// Start consumes order.events and the retry topic until ctx is done, each
+// on concurrency lanes. Offsets are committed only once an event has been
+// handled or moved to the retry or dead-letter topic, so none is lost when
+// the worker fails.
 func (w *Worker) Start(ctx context.Context) error {
 	go w.startOutboxPublisher(ctx)
-
-	for {
-		select {
-		case <-ctx.Done():
-			return nil
-		default:
-		}
-
-		msg, err := w.consumer.Read(ctx)
-		if err != nil {
-			if ctx.Err() != nil {
-				return nil
-			}
-			w.logger.Error("read kafka message failed", "error", err)
-			time.Sleep(200 * time.Millisecond)
-			continue
-		}
-		if err := w.handleMessage(ctx, msg.Value); err != nil {
-			w.logger.Error("handle order event failed", "error", err)
-		}
-	}
+	go w.consumeLoop(ctx, w.retryConsumer, sourceRetry)
+	w.consumeLoop(ctx, w.consumer, sourceMain)
+	return nil
 }
 
 func (w *Worker) handleMessage(ctx context.Context, raw []byte) error {
 	var ev orderEventEnvelope
 	if err := json.Unmarshal(raw, &ev); err != nil {
-		return err
+		return fmt.Errorf("%w: %v", errMalformedEvent, err)
 	}
-	if ev.EventType != "OrderPaid" {
+	switch ev.EventType {
+	case "OrderPaid":
+		return w.issueTicket(ctx, ev.AggregateID, stringFromAny(ev.Payload["trace_id"]))
+	case "OrderChanged":
+		return w.reissueTicket(ctx, ev.AggregateID, stringFromAny(ev.Payload["change_id"]), stringFromAny(ev.Payload["old_partition_key"]))
+	case "OrderCancelled":
+		return w.releaseSeats(ctx, ev.AggregateID, stringFromAny(ev.Payload["partition_key"]))
+	default:
 		return nil
 	}
+}
+
+// issueTicket allocates seats only for an order that still waits for its
+// ticket, so a redelivered OrderPaid does not reach the allocator. Should
+// the ticket transaction fail, the seats are released again unless the
+// tickets turn out to be stored; they would otherwise stay taken with no
+// ticket pointing at them.
+func (w *Worker) issueTicket(ctx context.Context, orderID string, traceID string) error {
+	paid, err := w.repo.IsOrderPaid(ctx, orderID)
+	if err != nil || !paid {
+		return err
+	}
+	alloc, err := w.allocateSeats(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if err := w.storeTickets(ctx, orderID, traceID, alloc); err != nil {
+		w.releaseOrphanedSeats(ctx, alloc.in, "", err)
+		return err
+	}
+	return nil
+}
 
-	orderID := ev.AggregateID
-	seat, err := w.seatAllocator.AllocateSeat(ctx, orderID)
+func (w *Worker) storeTickets(ctx context.Context, orderID string, traceID string, alloc seatAllocation) error {
+	tickets := alloc.tickets()
+	docs, err := w.issueETickets(tickets)
 	if err != nil {
 		return err
 	}
@@ -109,6 +189,8 @@ func (w *Worker) handleMessage(ctx context.Context, raw []byte) error {
 	}
 	defer tx.Rollback()
 
+	// Another delivery may have issued the tickets since the check above;
+	// allocation is idempotent by order, so their seats are these seats.
 	ok, err := w.repo.IsOrderPaidTx(ctx, tx, orderID)
 	if err != nil {
 		return err
@@ -117,30 +199,219 @@ func (w *Worker) handleMessage(ctx context.Context, raw []byte) error {
 		return nil
 	}
 
-	inserted, err := w.repo.InsertTicketTx(ctx, tx, domain.Ticket{
-		TicketID:      uuid.NewString(),
-		OrderID:       orderID,
-		PassengerName: seat,
-	})
+	inserted, err := w.repo.InsertTicketsTx(ctx, tx, tickets)
+	if err != nil || !inserted {
+		return err
+	}
+	if err := w.repo.InsertETicketsTx(ctx, tx, docs); err != nil {
+		return err
+	}
+	if err := w.repo.MarkOrderTicketedTx(ctx, tx, orderID, traceID); err != nil {
+		return err
+	}
+	eventID, payload := buildTicketIssuedEvent(orderID, alloc.seats, tickets)
+	if err := w.outbox.InsertTx(ctx, tx, eventID, orderID, "TicketIssued", payload); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// reissueTicket gives back the seats of the old itinerary before allocating
+// the new one, so a change within the same train can reuse them. A change
+// that has already been applied is skipped, as releasing again would free
+// the reissued seats.
+func (w *Worker) reissueTicket(ctx context.Context, orderID string, changeID string, oldPartitionKey string) error {
+	if changeID == "" {
+		return nil
+	}
+	applied, err := w.repo.IsChangeApplied(ctx, orderID, changeID)
+	if err != nil || applied {
+		return err
+	}
+	if err := w.releaseSeats(ctx, orderID, oldPartitionKey); err != nil {
+		return err
+	}
+	alloc, err := w.allocateSeats(ctx, orderID)
 	if err != nil {
 		return err
 	}
-	if inserted {
-		if err := w.repo.MarkOrderTicketedTx(ctx, tx, orderID); err != nil {
-			return err
-		}
-		eventID, payload := buildTicketIssuedEvent(orderID, seat)
-		if err := w.outbox.InsertTx(ctx, tx, eventID, orderID, "TicketIssued", payload); err != nil {
+	if err := w.storeReissuedTickets(ctx, orderID, changeID, alloc); err != nil {
+		w.releaseOrphanedSeats(ctx, alloc.in, changeID, err)
+		return err
+	}
+	return nil
+}
+
+func (w *Worker) storeReissuedTickets(ctx context.Context, orderID string, changeID string, alloc seatAllocation) error {
+	tickets := alloc.tickets()
+	docs, err := w.issueETickets(tickets)
+	if err != nil {
+		return err
+	}
+
+	tx, err := w.repo.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	voided, reissued, err := w.repo.ReissueTicketsTx(ctx, tx, orderID, changeID, tickets, time.Now().UTC().Truncate(time.Second))
+	if err != nil || !reissued {
+		return err
+	}
+	for _, c := range voided {
+		if err := insertStatusChangedTx(ctx, tx, w.outbox, c); err != nil {
 			return err
 		}
 	}
-	if err := tx.Commit(); err != nil {
+	if err := w.repo.InsertETicketsTx(ctx, tx, docs); err != nil {
+		return err
+	}
+	eventID, payload := buildTicketReissuedEvent(orderID, alloc.seats, tickets, changeID)
+	if err := w.outbox.InsertTx(ctx, tx, eventID, orderID, "TicketReissued", payload); err != nil {
 		return err
 	}
+	return tx.Commit()
+}
+
+// issueETickets signs and renders the e-ticket of every ticket. It runs
+// before the ticket transaction so rendering does not hold the order lock.
+func (w *Worker) issueETickets(tickets []domain.Ticket) ([]domain.ETicket, error) {
+	docs := make([]domain.ETicket, 0, len(tickets))
+	for _, t := range tickets {
+		doc, err := w.etickets.Issue(t)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// releaseSeats frees the seats the order holds on the route of
+// partitionKey. Cancelled orders normally hold none, as seats are only
+// allocated once an order is paid.
+func (w *Worker) releaseSeats(ctx context.Context, orderID string, partitionKey string) error {
+	released, err := w.seatAllocator.ReleaseSeat(ctx, w.itinerary.releaseRequest(orderID, partitionKey))
+	if err != nil {
+		return fmt.Errorf("release seats of order %s: %w", orderID, err)
+	}
+	if len(released) > 0 {
+		w.logger.Info("seats released", "order_id", orderID, "seat_nos", released)
+	}
 	return nil
 }
 
-func buildTicketIssuedEvent(orderID string, seat string) (string, map[string]any) {
+// releaseOrphanedSeats gives back seats allocated for the tickets of
+// changeID (empty for the first issue) when they were not stored. As
+// allocation is idempotent by order, the seats may belong to live tickets
+// after all: a concurrent delivery may have stored them, or a failed commit
+// may have gone through. It therefore checks afresh that no such tickets
+// exist and keeps the seats when it cannot tell. It runs even when ctx is
+// done, as the worker may be failing because it is shutting down.
+func (w *Worker) releaseOrphanedSeats(ctx context.Context, in grpcclient.AllocateSeatInput, changeID string, cause error) {
+	releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), grpcclient.DefaultTimeout())
+	defer cancel()
+	stored, err := w.repo.IsChangeApplied(releaseCtx, in.OrderID, changeID)
+	if err != nil {
+		w.logger.Error("check for stored tickets failed, keeping seats", "error", err, "order_id", in.OrderID, "cause", cause)
+		return
+	}
+	if stored {
+		w.logger.Warn("tickets stored despite the error, keeping seats", "order_id", in.OrderID, "change_id", changeID, "cause", cause)
+		return
+	}
+	released, err := w.seatAllocator.ReleaseSeat(releaseCtx, grpcclient.ReleaseSeatInput{
+		OrderID:    in.OrderID,
+		TrainID:    in.TrainID,
+		TravelDate: in.TravelDate,
+		CoachType:  in.CoachType,
+	})
+	if err != nil {
+		w.logger.Error("release orphaned seats failed", "error", err, "order_id", in.OrderID, "cause", cause)
+		return
+	}
+	w.logger.Warn("orphaned seats released", "order_id", in.OrderID, "seat_nos", released, "cause", cause)
+}
+
+// seatAllocation is the outcome of allocateSeats: the request made, the
+// passengers it was made for and the seats they got.
+type seatAllocation struct {
+	in         grpcclient.AllocateSeatInput
+	passengers []domain.Passenger
+	seats      grpcclient.AllocateSeatResult
+}
+
+// tickets gives every passenger a ticket for their seat.
+func (a seatAllocation) tickets() []domain.Ticket {
+	route := domain.TicketRoute{
+		TrainID:    a.in.TrainID,
+		TravelDate: a.in.TravelDate,
+		FromIndex:  a.in.FromIndex,
+		ToIndex:    a.in.ToIndex,
+	}
+	return domain.NewTickets(a.in.OrderID, route, a.passengers, a.seats.SeatNos, uuid.NewString)
+}
+
+// allocateSeats allocates the seats of the order's current itinerary. The
+// request is returned even when allocation fails.
+func (w *Worker) allocateSeats(ctx context.Context, orderID string) (seatAllocation, error) {
+	it, err := w.repo.FindItinerary(ctx, orderID)
+	if err != nil {
+		return seatAllocation{}, err
+	}
+	alloc := seatAllocation{in: w.itinerary.seatRequest(orderID, it), passengers: it.Passengers}
+	seats, err := w.seatAllocator.AllocateSeat(ctx, alloc.in)
+	if err != nil {
+		return alloc, err
+	}
+	if len(seats.SeatNos) == 0 {
+		return alloc, fmt.Errorf("seat allocator returned no seats for order %s", orderID)
+	}
+	alloc.seats = seats
+	return alloc, nil
+}
+
+func (d ItineraryDefaults) seatRequest(orderID string, it domain.Itinerary) grpcclient.AllocateSeatInput {
+	trainID, travelDate, coachType := d.route(it.PartitionKey)
+	in := grpcclient.AllocateSeatInput{
+		OrderID:      orderID,
+		TrainID:      trainID,
+		TravelDate:   travelDate,
+		CoachType:    coachType,
+		FromIndex:    it.FromIndex,
+		ToIndex:      it.ToIndex,
+		Qty:          it.Qty,
+		Preferences:  it.Preferences(),
+		KeepTogether: it.KeepTogether,
+	}
+	if in.FromIndex == 0 && in.ToIndex == 0 {
+		in.FromIndex, in.ToIndex = d.FromIndex, d.ToIndex
+	}
+	if in.Qty <= 0 {
+		in.Qty = 1
+	}
+	return in
+}
+
+func (d ItineraryDefaults) releaseRequest(orderID string, partitionKey string) grpcclient.ReleaseSeatInput {
+	trainID, travelDate, coachType := d.route(partitionKey)
+	return grpcclient.ReleaseSeatInput{OrderID: orderID, TrainID: trainID, TravelDate: travelDate, CoachType: coachType}
+}
+
+// route splits a train|date|class partition key, falling back to the
+// defaults for keys of any other shape.
+func (d ItineraryDefaults) route(partitionKey string) (string, string, string) {
+	if parts := strings.Split(partitionKey, "|"); len(parts) == 3 {
+		return parts[0], parts[1], parts[2]
+	}
+	return d.TrainID, d.TravelDate, d.CoachType
+}
+
+// buildTicketIssuedEvent lists the ticket of every passenger under
+// tickets. It keeps seat_no, the first seat, and seat_nos for consumers
+// that predate tickets.
+func buildTicketIssuedEvent(orderID string, seats grpcclient.AllocateSeatResult, tickets []domain.Ticket) (string, map[string]any) {
 	eventID := uuid.NewString()
 	return eventID, map[string]any{
 		"event_id":     eventID,
@@ -148,12 +419,59 @@ func buildTicketIssuedEvent(orderID string, seat string) (string, map[string]any
 		"event_type":   "TicketIssued",
 		"occurred_at":  time.Now().UTC().Format(time.RFC3339Nano),
 		"payload": map[string]any{
-			"order_id": orderID,
-			"seat_no":  seat,
+			"order_id":                  orderID,
+			"seat_no":                   seats.SeatNos[0],
+			"seat_nos":                  seats.SeatNos,
+			"seat_preferences_honoured": seats.PreferenceHonoured,
+			"seats_kept_together":       seats.KeptTogether,
+			"tickets":                   ticketPayloads(tickets),
+		},
+	}
+}
+
+func buildTicketReissuedEvent(orderID string, seats grpcclient.AllocateSeatResult, tickets []domain.Ticket, changeID string) (string, map[string]any) {
+	eventID := uuid.NewString()
+	return eventID, map[string]any{
+		"event_id":     eventID,
+		"aggregate_id": orderID,
+		"event_type":   "TicketReissued",
+		"occurred_at":  time.Now().UTC().Format(time.RFC3339Nano),
+		"payload": map[string]any{
+			"order_id":                  orderID,
+			"change_id":                 changeID,
+			"seat_no":                   seats.SeatNos[0],
+			"seat_nos":                  seats.SeatNos,
+			"seat_preferences_honoured": seats.PreferenceHonoured,
+			"seats_kept_together":       seats.KeptTogether,
+			"tickets":                   ticketPayloads(tickets),
 		},
 	}
 }
 
+func ticketPayloads(tickets []domain.Ticket) []map[string]any {
+	out := make([]map[string]any, 0, len(tickets))
+	for _, t := range tickets {
+		out = append(out, map[string]any{
+			"ticket_id":      t.TicketID,
+			"passenger_id":   t.PassengerID,
+			"passenger_name": t.PassengerName,
+			"train_id":       t.TrainID,
+			"travel_date":    t.TravelDate,
+			"coach_no":       t.CoachNo,
+			"seat_no":        t.SeatNo,
+			"from_index":     t.FromIndex,
+			"to_index":       t.ToIndex,
+			"status":         string(t.Status),
+		})
+	}
+	return out
+}
+
+func stringFromAny(v any) string {
+	s, _ := v.(string)
+	return s
+}
+
 func (w *Worker) startOutboxPublisher(ctx context.Context) {
 	ticker := time.NewTicker(500 * time.Millisecond)
 	defer ticker.Stop()
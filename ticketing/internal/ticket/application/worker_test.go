@@ -4,17 +4,34 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"reflect"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
+	segmentkafka "github.com/segmentio/kafka-go"
+
+	"ticketing/internal/ticket/domain"
+	grpcclient "ticketing/internal/ticket/infrastructure/grpc_client"
 	"ticketing/internal/ticket/infrastructure/outbox"
+	"ticketing/internal/ticket/infrastructure/parking"
 )
 
+type publishedMessage struct {
+	topic   string
+	headers map[string]string
+}
+
 type fakeProducer struct {
 	publishErr   error
 	publishCalls int
+	published    []publishedMessage
+	// onPublish, if set, sees every publish before it succeeds or fails.
+	onPublish func(topic string)
 }
 
 func (f *fakeProducer) Publish(_ context.Context, _ string, _ []byte, _ []byte) error {
@@ -22,6 +39,22 @@ func (f *fakeProducer) Publish(_ context.Context, _ string, _ []byte, _ []byte)
 	return f.publishErr
 }
 
+func (f *fakeProducer) PublishWithHeaders(_ context.Context, topic string, _ []byte, _ []byte, headers []segmentkafka.Header) error {
+	f.publishCalls++
+	if f.onPublish != nil {
+		f.onPublish(topic)
+	}
+	if f.publishErr != nil {
+		return f.publishErr
+	}
+	m := publishedMessage{topic: topic, headers: map[string]string{}}
+	for _, h := range headers {
+		m.headers[h.Key] = string(h.Value)
+	}
+	f.published = append(f.published, m)
+	return nil
+}
+
 type retryMark struct {
 	id         int64
 	retryCount int
@@ -33,9 +66,16 @@ type fakeOutboxStore struct {
 	events          []outbox.Event
 	markedPublished []int64
 	markedRetry     []retryMark
+	inserted        []insertedEvent
 }
 
-func (f *fakeOutboxStore) InsertTx(_ context.Context, _ *sql.Tx, _ string, _ string, _ string, _ map[string]any) error {
+type insertedEvent struct {
+	eventType string
+	payload   map[string]any
+}
+
+func (f *fakeOutboxStore) InsertTx(_ context.Context, _ *sql.Tx, _ string, _ string, eventType string, payload map[string]any) error {
+	f.inserted = append(f.inserted, insertedEvent{eventType: eventType, payload: payload})
 	return nil
 }
 
@@ -139,3 +179,542 @@ func TestPublishOutboxBatch_MarkRetryOnPublishFailure(t *testing.T) {
 		t.Fatal("expected last error to be recorded")
 	}
 }
+
+func TestItineraryDefaults_SeatRequest(t *testing.T) {
+	t.Parallel()
+
+	defaults := ItineraryDefaults{TrainID: "G123", TravelDate: "2026-02-11", CoachType: "2nd", FromIndex: 1, ToIndex: 3}
+
+	passengers := []domain.Passenger{
+		{ID: "ID_CARD:110101199003074258", Name: "Li Lei", SeatPreference: domain.SeatPreferenceWindow},
+		{ID: "PASSPORT:E12345678", Name: "Han Meimei"},
+	}
+	prefs := []domain.SeatPreference{domain.SeatPreferenceWindow, domain.SeatPreferenceNone}
+	got := defaults.seatRequest("order-1", domain.Itinerary{PartitionKey: "D5|2026-03-01|1st", Qty: 2, FromIndex: 4, ToIndex: 9, KeepTogether: true, Passengers: passengers})
+	want := grpcclient.AllocateSeatInput{OrderID: "order-1", TrainID: "D5", TravelDate: "2026-03-01", CoachType: "1st", FromIndex: 4, ToIndex: 9, Qty: 2, Preferences: prefs, KeepTogether: true}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected the order's itinerary %+v, got %+v", want, got)
+	}
+
+	got = defaults.seatRequest("order-2", domain.Itinerary{})
+	want = grpcclient.AllocateSeatInput{OrderID: "order-2", TrainID: "G123", TravelDate: "2026-02-11", CoachType: "2nd", FromIndex: 1, ToIndex: 3, Qty: 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected the defaults %+v, got %+v", want, got)
+	}
+}
+
+type fakeSeatAllocator struct {
+	grpcclient.SeatAllocatorClient
+	mu         sync.Mutex
+	released   []grpcclient.ReleaseSeatInput
+	releaseErr error
+	calls      int
+}
+
+func (f *fakeSeatAllocator) ReleaseSeat(ctx context.Context, in grpcclient.ReleaseSeatInput) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f.releaseErr != nil {
+		return nil, f.releaseErr
+	}
+	f.released = append(f.released, in)
+	return []string{"01-01A"}, nil
+}
+
+func TestHandleMessage_OrderCancelledReleasesSeats(t *testing.T) {
+	t.Parallel()
+
+	seats := &fakeSeatAllocator{}
+	worker := &Worker{
+		logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+		seatAllocator: seats,
+		itinerary:     ItineraryDefaults{TrainID: "G123", TravelDate: "2026-02-11", CoachType: "2nd"},
+	}
+
+	for _, raw := range []string{
+		`{"aggregate_id":"order-1","event_type":"OrderCancelled","payload":{"partition_key":"D5|2026-03-01|1st"}}`,
+		`{"aggregate_id":"order-2","event_type":"OrderCancelled","payload":{"partition_key":"legacy-key"}}`,
+	} {
+		if err := worker.handleMessage(context.Background(), []byte(raw)); err != nil {
+			t.Fatalf("handle %s: %v", raw, err)
+		}
+	}
+	want := []grpcclient.ReleaseSeatInput{
+		{OrderID: "order-1", TrainID: "D5", TravelDate: "2026-03-01", CoachType: "1st"},
+		{OrderID: "order-2", TrainID: "G123", TravelDate: "2026-02-11", CoachType: "2nd"},
+	}
+	if !reflect.DeepEqual(seats.released, want) {
+		t.Fatalf("expected releases %+v, got %+v", want, seats.released)
+	}
+}
+
+// fakeTicketRepo answers IsChangeApplied from stored, keyed by order and
+// change; every other method panics through the nil embedded interface.
+type fakeTicketRepo struct {
+	ticketRepository
+	stored     map[string]bool
+	appliedErr error
+}
+
+func (f *fakeTicketRepo) IsChangeApplied(_ context.Context, orderID string, changeID string) (bool, error) {
+	if f.appliedErr != nil {
+		return false, f.appliedErr
+	}
+	return f.stored[orderID+"/"+changeID], nil
+}
+
+func TestReleaseOrphanedSeats_SurvivesCancelledContext(t *testing.T) {
+	t.Parallel()
+
+	seats := &fakeSeatAllocator{}
+	worker := &Worker{
+		logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+		repo:          &fakeTicketRepo{},
+		seatAllocator: seats,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	in := grpcclient.AllocateSeatInput{OrderID: "order-1", TrainID: "D5", TravelDate: "2026-03-01", CoachType: "1st", FromIndex: 1, ToIndex: 4, Qty: 2}
+	worker.releaseOrphanedSeats(ctx, in, "", errors.New("db down"))
+
+	want := []grpcclient.ReleaseSeatInput{{OrderID: "order-1", TrainID: "D5", TravelDate: "2026-03-01", CoachType: "1st"}}
+	if !reflect.DeepEqual(seats.released, want) {
+		t.Fatalf("expected releases %+v, got %+v", want, seats.released)
+	}
+}
+
+func TestReleaseOrphanedSeats_KeepsSeatsOfStoredTickets(t *testing.T) {
+	t.Parallel()
+
+	in := grpcclient.AllocateSeatInput{OrderID: "order-1", TrainID: "D5", TravelDate: "2026-03-01", CoachType: "1st"}
+	for name, tc := range map[string]struct {
+		repo     *fakeTicketRepo
+		changeID string
+	}{
+		"issued by a concurrent delivery": {repo: &fakeTicketRepo{stored: map[string]bool{"order-1/": true}}},
+		"reissue committed despite error": {repo: &fakeTicketRepo{stored: map[string]bool{"order-1/change-1": true}}, changeID: "change-1"},
+		"check failed":                    {repo: &fakeTicketRepo{appliedErr: errors.New("db down")}},
+	} {
+		seats := &fakeSeatAllocator{}
+		worker := &Worker{
+			logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+			repo:          tc.repo,
+			seatAllocator: seats,
+		}
+		worker.releaseOrphanedSeats(context.Background(), in, tc.changeID, errors.New("commit failed"))
+		if seats.calls != 0 {
+			t.Fatalf("%s: expected the seats to be kept, got %d release calls", name, seats.calls)
+		}
+	}
+}
+
+type fakeConsumer struct {
+	mu        sync.Mutex
+	messages  []segmentkafka.Message
+	committed []segmentkafka.Message
+}
+
+func (f *fakeConsumer) Fetch(ctx context.Context) (segmentkafka.Message, error) {
+	f.mu.Lock()
+	if len(f.messages) == 0 {
+		f.mu.Unlock()
+		<-ctx.Done()
+		return segmentkafka.Message{}, ctx.Err()
+	}
+	msg := f.messages[0]
+	f.messages = f.messages[1:]
+	f.mu.Unlock()
+	return msg, nil
+}
+
+func (f *fakeConsumer) Commit(_ context.Context, msg segmentkafka.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.committed = append(f.committed, msg)
+	return nil
+}
+
+func (f *fakeConsumer) commits() []segmentkafka.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]segmentkafka.Message(nil), f.committed...)
+}
+
+func testRetryWorker(prod *fakeProducer, seats *fakeSeatAllocator) *Worker {
+	return &Worker{
+		logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+		producer:      prod,
+		seatAllocator: seats,
+		itinerary:     ItineraryDefaults{TrainID: "G123", TravelDate: "2026-02-11", CoachType: "2nd"},
+		retry:         RetryPolicy{Attempts: 2, Backoff: time.Millisecond, TopicAttempts: 2, Delay: time.Minute},
+		metrics:       newWorkerMetrics(),
+	}
+}
+
+var cancelledEvent = []byte(`{"aggregate_id":"order-1","event_type":"OrderCancelled","payload":{}}`)
+
+func TestProcess_SendsFailingEventToRetryTopic(t *testing.T) {
+	t.Parallel()
+
+	prod := &fakeProducer{}
+	seats := &fakeSeatAllocator{releaseErr: errors.New("allocator down")}
+	worker := testRetryWorker(prod, seats)
+	if !worker.process(context.Background(), segmentkafka.Message{Key: []byte("order-1"), Value: cancelledEvent}, sourceMain) {
+		t.Fatal("expected the event to be forwarded")
+	}
+	if seats.calls != 2 {
+		t.Fatalf("expected 2 in-process attempts, got %d", seats.calls)
+	}
+	if len(prod.published) != 1 || prod.published[0].topic != orderEventsRetryTopic {
+		t.Fatalf("expected the event on the retry topic, got %+v", prod.published)
+	}
+	h := prod.published[0].headers
+	notBefore, err := time.Parse(time.RFC3339Nano, h[headerNotBefore])
+	if h[headerRetryCount] != "1" || h[headerError] == "" || err != nil || time.Until(notBefore) < 30*time.Second {
+		t.Fatalf("unexpected retry headers %+v", h)
+	}
+}
+
+func TestProcess_DeadLettersAfterRetryTopicAttempts(t *testing.T) {
+	t.Parallel()
+
+	prod := &fakeProducer{}
+	seats := &fakeSeatAllocator{releaseErr: errors.New("allocator down")}
+	worker := testRetryWorker(prod, seats)
+	msg := segmentkafka.Message{
+		Value: cancelledEvent,
+		Headers: []segmentkafka.Header{
+			{Key: headerRetryCount, Value: []byte("2")},
+			{Key: headerNotBefore, Value: []byte(time.Now().Add(-time.Second).Format(time.RFC3339Nano))},
+		},
+	}
+
+	if !worker.process(context.Background(), msg, sourceRetry) {
+		t.Fatal("expected the event to be dead-lettered")
+	}
+	if len(prod.published) != 1 || prod.published[0].topic != orderEventsDLQTopic {
+		t.Fatalf("expected the event to be dead-lettered, got %+v", prod.published)
+	}
+	if got := prod.published[0].headers[headerError]; got != "release seats of order order-1: allocator down" {
+		t.Fatalf("expected the failure reason on the dead letter, got %q", got)
+	}
+}
+
+func TestProcess_DeadLettersMalformedEventsAtOnce(t *testing.T) {
+	t.Parallel()
+
+	prod := &fakeProducer{}
+	worker := testRetryWorker(prod, &fakeSeatAllocator{})
+	worker.process(context.Background(), segmentkafka.Message{Value: []byte("{not json")}, sourceMain)
+	if len(prod.published) != 1 || prod.published[0].topic != orderEventsDLQTopic {
+		t.Fatalf("expected a malformed event to skip the retry topic, got %+v", prod.published)
+	}
+}
+
+func TestProcess_KeepsOffsetWhenForwardingFails(t *testing.T) {
+	t.Parallel()
+
+	prod := &fakeProducer{publishErr: errors.New("kafka down")}
+	worker := testRetryWorker(prod, &fakeSeatAllocator{releaseErr: errors.New("allocator down")})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if worker.process(ctx, segmentkafka.Message{Value: cancelledEvent}, sourceMain) {
+		t.Fatal("expected an event that was neither handled nor forwarded to keep its offset")
+	}
+}
+
+type fakeParking struct {
+	mu     sync.Mutex
+	held   map[string]bool
+	parked []parking.Event
+	nextID int64
+}
+
+func newFakeParking() *fakeParking {
+	return &fakeParking{held: make(map[string]bool)}
+}
+
+func (f *fakeParking) Hold(_ context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.held[key] = true
+	return nil
+}
+
+func (f *fakeParking) Park(_ context.Context, key string, payload []byte) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.held[key] {
+		return false, nil
+	}
+	f.nextID++
+	f.parked = append(f.parked, parking.Event{ID: f.nextID, Key: key, Payload: payload})
+	return true, nil
+}
+
+func (f *fakeParking) Next(_ context.Context, key string) (parking.Event, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, e := range f.parked {
+		if e.Key == key {
+			return e, true, nil
+		}
+	}
+	return parking.Event{}, false, nil
+}
+
+func (f *fakeParking) Remove(_ context.Context, id int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, e := range f.parked {
+		if e.ID == id {
+			f.parked = append(f.parked[:i], f.parked[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeParking) Release(_ context.Context, key string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, e := range f.parked {
+		if e.Key == key {
+			return false, nil
+		}
+	}
+	delete(f.held, key)
+	return true, nil
+}
+
+func cancelledEventOn(partitionKey string) []byte {
+	return []byte(`{"aggregate_id":"order-1","event_type":"OrderCancelled","payload":{"partition_key":"` + partitionKey + `"}}`)
+}
+
+func retryMessage(key string, value []byte, retries int) segmentkafka.Message {
+	return segmentkafka.Message{
+		Key:   []byte(key),
+		Value: value,
+		Headers: []segmentkafka.Header{
+			{Key: headerRetryCount, Value: []byte(strconv.Itoa(retries))},
+			{Key: headerNotBefore, Value: []byte(time.Now().Add(-time.Second).Format(time.RFC3339Nano))},
+		},
+	}
+}
+
+func TestProcess_ParksEventsBehindARetriedOne(t *testing.T) {
+	t.Parallel()
+
+	prod := &fakeProducer{}
+	seats := &fakeSeatAllocator{releaseErr: errors.New("allocator down")}
+	parked := newFakeParking()
+	worker := testRetryWorker(prod, seats)
+	worker.parking = parked
+
+	first, second, third := cancelledEventOn("G1|2026-02-11|2nd"), cancelledEventOn("G2|2026-02-11|2nd"), cancelledEventOn("G3|2026-02-11|2nd")
+	if !worker.process(context.Background(), segmentkafka.Message{Key: []byte("order-1"), Value: first}, sourceMain) {
+		t.Fatal("expected the first event to be forwarded")
+	}
+	if len(prod.published) != 1 || prod.published[0].topic != orderEventsRetryTopic || !parked.held["order-1"] {
+		t.Fatalf("expected the first event on the retry topic and the order held, got %+v", prod.published)
+	}
+
+	seats.releaseErr = nil
+	calls := seats.calls
+	if !worker.process(context.Background(), segmentkafka.Message{Key: []byte("order-1"), Value: second}, sourceMain) {
+		t.Fatal("expected the second event to be parked")
+	}
+	if seats.calls != calls || len(prod.published) != 1 || len(parked.parked) != 1 {
+		t.Fatalf("expected the second event parked rather than handled, got %d calls and %+v", seats.calls-calls, parked.parked)
+	}
+
+	if !worker.process(context.Background(), retryMessage("order-1", first, 1), sourceRetry) {
+		t.Fatal("expected the retried event to be handled")
+	}
+	var trains []string
+	for _, r := range seats.released {
+		trains = append(trains, r.TrainID)
+	}
+	if !reflect.DeepEqual(trains, []string{"G1", "G2"}) {
+		t.Fatalf("expected the retried event before the parked one, got %v", trains)
+	}
+	if len(parked.parked) != 0 || parked.held["order-1"] {
+		t.Fatalf("expected the order released, got held=%v parked=%+v", parked.held, parked.parked)
+	}
+
+	if !worker.process(context.Background(), segmentkafka.Message{Key: []byte("order-1"), Value: third}, sourceMain) {
+		t.Fatal("expected the third event to be handled")
+	}
+	if n := len(seats.released); n != 3 || seats.released[2].TrainID != "G3" {
+		t.Fatalf("expected the third event handled directly, got %+v", seats.released)
+	}
+}
+
+func TestProcess_ParkedEventThatFailsBecomesTheHeldOne(t *testing.T) {
+	t.Parallel()
+
+	prod := &fakeProducer{}
+	seats := &fakeSeatAllocator{releaseErr: errors.New("allocator down")}
+	parked := newFakeParking()
+	worker := testRetryWorker(prod, seats)
+	worker.parking = parked
+
+	second := cancelledEventOn("G2|2026-02-11|2nd")
+	_ = parked.Hold(context.Background(), "order-1")
+	_, _ = parked.Park(context.Background(), "order-1", second)
+
+	// The held event runs out of attempts; the parked one fails too.
+	if !worker.process(context.Background(), retryMessage("order-1", cancelledEventOn("G1|2026-02-11|2nd"), 2), sourceRetry) {
+		t.Fatal("expected the held event to be dead-lettered")
+	}
+	if len(prod.published) != 2 || prod.published[0].topic != orderEventsDLQTopic || prod.published[1].topic != orderEventsRetryTopic {
+		t.Fatalf("expected the held event dead-lettered and the parked one retried, got %+v", prod.published)
+	}
+	if h := prod.published[1].headers; h[headerRetryCount] != "1" {
+		t.Fatalf("expected the parked event to start its retries, got %+v", h)
+	}
+	if len(parked.parked) != 0 || !parked.held["order-1"] {
+		t.Fatalf("expected the order to stay held for the retried event, got held=%v parked=%+v", parked.held, parked.parked)
+	}
+}
+
+func TestProcess_HoldsTheKeyBeforeForwardingToTheRetryTopic(t *testing.T) {
+	t.Parallel()
+
+	prod := &fakeProducer{}
+	parked := newFakeParking()
+	worker := testRetryWorker(prod, &fakeSeatAllocator{releaseErr: errors.New("allocator down")})
+	worker.parking = parked
+	var heldOnPublish []bool
+	prod.onPublish = func(topic string) {
+		parked.mu.Lock()
+		defer parked.mu.Unlock()
+		heldOnPublish = append(heldOnPublish, parked.held["order-1"])
+	}
+
+	if !worker.process(context.Background(), segmentkafka.Message{Key: []byte("order-1"), Value: cancelledEvent}, sourceMain) {
+		t.Fatal("expected the event to be forwarded")
+	}
+	if !reflect.DeepEqual(heldOnPublish, []bool{true}) {
+		t.Fatalf("expected the order held by the time the event reached the retry topic, got %v", heldOnPublish)
+	}
+}
+
+func TestProcess_ReleasesTheHoldWhenForwardingIsAbandoned(t *testing.T) {
+	t.Parallel()
+
+	prod := &fakeProducer{publishErr: errors.New("kafka down")}
+	parked := newFakeParking()
+	worker := testRetryWorker(prod, &fakeSeatAllocator{releaseErr: errors.New("allocator down")})
+	worker.parking = parked
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if worker.process(ctx, segmentkafka.Message{Key: []byte("order-1"), Value: cancelledEvent}, sourceMain) {
+		t.Fatal("expected an event that was neither handled nor forwarded to keep its offset")
+	}
+	if parked.held["order-1"] {
+		t.Fatal("expected the hold of an event that never reached the retry topic to be lifted")
+	}
+
+	// Redelivered, the event is handled rather than parked behind itself.
+	prod.publishErr = nil
+	if !worker.process(context.Background(), segmentkafka.Message{Key: []byte("order-1"), Value: cancelledEvent}, sourceMain) {
+		t.Fatal("expected the redelivered event to be forwarded")
+	}
+	if len(parked.parked) != 0 || len(prod.published) != 1 || prod.published[0].topic != orderEventsRetryTopic {
+		t.Fatalf("expected the redelivered event on the retry topic, got parked=%+v published=%+v", parked.parked, prod.published)
+	}
+}
+
+func TestOffsetTracker_CommitsContiguousPrefix(t *testing.T) {
+	t.Parallel()
+
+	tracker := newOffsetTracker()
+	for _, m := range []segmentkafka.Message{{Partition: 0, Offset: 10}, {Partition: 0, Offset: 11}, {Partition: 1, Offset: 5}, {Partition: 0, Offset: 12}} {
+		tracker.track(m)
+	}
+
+	steps := []struct {
+		done   segmentkafka.Message
+		commit int64
+	}{
+		{segmentkafka.Message{Partition: 0, Offset: 11}, -1},
+		{segmentkafka.Message{Partition: 1, Offset: 5}, 5},
+		{segmentkafka.Message{Partition: 0, Offset: 10}, 11},
+		{segmentkafka.Message{Partition: 0, Offset: 12}, 12},
+	}
+	for _, step := range steps {
+		upTo, ok := tracker.completeLocked(step.done)
+		if (step.commit < 0 && ok) || (step.commit >= 0 && (!ok || upTo.Offset != step.commit)) {
+			t.Fatalf("completing %d/%d: expected commit %d, got %d (%v)", step.done.Partition, step.done.Offset, step.commit, upTo.Offset, ok)
+		}
+	}
+}
+
+func TestConsumeLoop_KeepsEachOrderInSequence(t *testing.T) {
+	t.Parallel()
+
+	var msgs []segmentkafka.Message
+	for i := 0; i < 40; i++ {
+		order := fmt.Sprintf("order-%d", i%5)
+		raw := fmt.Sprintf(`{"aggregate_id":%q,"event_type":"OrderCancelled","payload":{"partition_key":"T%02d|2026-02-11|2nd"}}`, order, i)
+		msgs = append(msgs, segmentkafka.Message{Partition: i % 2, Offset: int64(i / 2), Key: []byte(order), Value: []byte(raw)})
+	}
+	seats := &fakeSeatAllocator{}
+	worker := testRetryWorker(&fakeProducer{}, seats)
+	worker.concurrency = 4
+	consumer := &fakeConsumer{messages: msgs}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		worker.consumeLoop(ctx, consumer, sourceMain)
+		close(done)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for lastCommit(consumer.commits(), 0) != 19 || lastCommit(consumer.commits(), 1) != 19 {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for commits, got %+v", consumer.commits())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	last := map[string]string{}
+	seats.mu.Lock()
+	defer seats.mu.Unlock()
+	if len(seats.released) != len(msgs) {
+		t.Fatalf("expected every event to be handled, got %d", len(seats.released))
+	}
+	for _, r := range seats.released {
+		if r.TrainID <= last[r.OrderID] {
+			t.Fatalf("events of %s handled out of order: %s after %s", r.OrderID, r.TrainID, last[r.OrderID])
+		}
+		last[r.OrderID] = r.TrainID
+	}
+	prev := map[int]int64{0: -1, 1: -1}
+	for _, c := range consumer.commits() {
+		if c.Offset <= prev[c.Partition] {
+			t.Fatalf("partition %d committed out of order: %d after %d", c.Partition, c.Offset, prev[c.Partition])
+		}
+		prev[c.Partition] = c.Offset
+	}
+}
+
+func lastCommit(commits []segmentkafka.Message, partition int) int64 {
+	last := int64(-1)
+	for _, c := range commits {
+		if c.Partition == partition {
+			last = c.Offset
+		}
+	}
+	return last
+}
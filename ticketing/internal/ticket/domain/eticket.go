@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidETicket = errors.New("invalid e-ticket")
+	ErrETicketExpired = errors.New("e-ticket expired")
+	ErrTicketNotFound = errors.New("ticket not found")
+	ErrTicketUsed     = errors.New("ticket already used")
+	// ErrTicketNotValid is a genuine ticket that no longer entitles its
+	// holder to travel, e.g. one whose order is no longer TICKETED.
+	ErrTicketNotValid = errors.New("ticket is no longer valid")
+)
+
+// chinaTime is the zone travel dates are in.
+var chinaTime = time.FixedZone("CST", 8*3600)
+
+// ETicketClaims is what an e-ticket token vouches for: the ticket and the
+// window it may be presented in.
+type ETicketClaims struct {
+	Ticket
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// ETicket is the document a passenger presents: the signed token and the
+// QR code and PDF itinerary rendered from it.
+type ETicket struct {
+	TicketID  string
+	OrderID   string
+	Status    TicketStatus
+	Token     string
+	QRPNG     []byte
+	PDF       []byte
+	ExpiresAt time.Time
+}
+
+// ETicketValidUntil is the end of the travel date in China. Tickets
+// without a parseable travel date stay valid for two days from issuedAt.
+func ETicketValidUntil(travelDate string, issuedAt time.Time) time.Time {
+	day, err := time.ParseInLocation(time.DateOnly, travelDate, chinaTime)
+	if err != nil {
+		return issuedAt.Add(48 * time.Hour).UTC()
+	}
+	return day.AddDate(0, 0, 1).UTC()
+}
@@ -0,0 +1,391 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxSegments is the number of station-to-station segments a seat's
+// occupancy mask covers, matching bitset<64> in the C++ seat-allocator.
+const MaxSegments = 64
+
+var (
+	ErrNoSeatAvailable  = errors.New("no seat available for the requested segments")
+	ErrInvalidSegment   = errors.New("invalid station segment range")
+	ErrUnknownCoachType = errors.New("unknown coach type")
+)
+
+// CoachLayout describes the seats of one coach: Rows rows, each with a seat
+// per letter in Letters. Letters follow the usual CRH lettering, where A
+// and F are window seats and C and D are on the aisle.
+type CoachLayout struct {
+	CoachType string
+	Rows      int
+	Letters   string
+}
+
+var coachLayouts = map[string]CoachLayout{
+	"2nd":      {CoachType: "2nd", Rows: 18, Letters: "ABCDF"},
+	"1st":      {CoachType: "1st", Rows: 14, Letters: "ACDF"},
+	"business": {CoachType: "business", Rows: 8, Letters: "ACF"},
+}
+
+func LayoutFor(coachType string) (CoachLayout, error) {
+	layout, ok := coachLayouts[strings.ToLower(coachType)]
+	if !ok {
+		return CoachLayout{}, fmt.Errorf("%w: %q", ErrUnknownCoachType, coachType)
+	}
+	return layout, nil
+}
+
+func (l CoachLayout) SeatsPerCoach() int {
+	return l.Rows * len(l.Letters)
+}
+
+// SeatPreference is where a passenger would like to sit.
+type SeatPreference string
+
+const (
+	SeatPreferenceNone   SeatPreference = ""
+	SeatPreferenceWindow SeatPreference = "WINDOW"
+	SeatPreferenceAisle  SeatPreference = "AISLE"
+)
+
+// Satisfies reports whether a seat lettered letter meets p: A and F are
+// window seats, C and D aisle seats. Every seat meets no preference.
+func (p SeatPreference) Satisfies(letter byte) bool {
+	switch p {
+	case SeatPreferenceWindow:
+		return letter == 'A' || letter == 'F'
+	case SeatPreferenceAisle:
+		return letter == 'C' || letter == 'D'
+	}
+	return true
+}
+
+// SeatPosition identifies a seat within a train: coach and row are 1-based.
+type SeatPosition struct {
+	Coach  int
+	Row    int
+	Letter byte
+}
+
+// SeatNo is the seat within its coach, e.g. "12F".
+func (p SeatPosition) SeatNo() string {
+	return fmt.Sprintf("%02d%c", p.Row, p.Letter)
+}
+
+// Label is the seat number printed on the ticket, e.g. "03-12F".
+func (p SeatPosition) Label() string {
+	return fmt.Sprintf("%02d-%s", p.Coach, p.SeatNo())
+}
+
+// ParseSeatLabel splits a seat label from an allocator into coach and
+// seat: "03-12F" is seat 12F of coach 3 and the C++ allocator's "1-23"
+// seat 23 of coach 1. Labels of any other shape are kept whole as the
+// seat, with coach 0.
+func ParseSeatLabel(label string) (int, string) {
+	coach, seat, ok := strings.Cut(label, "-")
+	if !ok || seat == "" || strings.Contains(seat, "-") {
+		return 0, label
+	}
+	n, err := strconv.Atoi(coach)
+	if err != nil || n <= 0 {
+		return 0, label
+	}
+	return n, seat
+}
+
+// SegmentMask returns the occupancy bits for travelling from station index
+// from to station index to, i.e. segments [from, to).
+func SegmentMask(from int, to int) (uint64, error) {
+	if from < 0 || to <= from || to > MaxSegments {
+		return 0, fmt.Errorf("%w: [%d, %d)", ErrInvalidSegment, from, to)
+	}
+	if to-from == MaxSegments {
+		return ^uint64(0), nil
+	}
+	return ((uint64(1) << uint(to-from)) - 1) << uint(from), nil
+}
+
+// SeatRouteKey identifies the seat inventory of one coach class of one
+// train on one day.
+func SeatRouteKey(trainID string, travelDate string, coachType string) string {
+	return trainID + "|" + travelDate + "|" + strings.ToLower(coachType)
+}
+
+// SeatAllocation is a seat held by an order for a range of segments.
+type SeatAllocation struct {
+	AllocationID string
+	RouteKey     string
+	OrderID      string
+	Seat         SeatPosition
+	FromIndex    int
+	ToIndex      int
+	SegmentMask  uint64
+	CreatedAt    time.Time
+}
+
+// SeatState is a seat of a route and the segments it is occupied for.
+type SeatState struct {
+	Seat     SeatPosition
+	Occupied uint64
+}
+
+// SeatAssignment is a seat handed to one passenger of a group.
+type SeatAssignment struct {
+	Seat               SeatPosition
+	PreferenceHonoured bool
+}
+
+// GroupAllocation is the outcome of SeatMap.AllocateGroup: one seat per
+// passenger, in passenger order. KeptTogether is set when the seats are
+// adjacent in one coach, whether or not that was asked for.
+type GroupAllocation struct {
+	Seats        []SeatAssignment
+	KeptTogether bool
+}
+
+// SeatMap tracks which segments each seat of a route is occupied for.
+// Seats are ordered coach by coach, row by row, letter by letter, and
+// Allocate hands out the first seat that is free for the whole range, so
+// the same sequence of requests always yields the same seats.
+type SeatMap struct {
+	layout   CoachLayout
+	coaches  int
+	occupied []uint64
+}
+
+func NewSeatMap(layout CoachLayout, coaches int) *SeatMap {
+	return &SeatMap{
+		layout:   layout,
+		coaches:  coaches,
+		occupied: make([]uint64, coaches*layout.SeatsPerCoach()),
+	}
+}
+
+// Occupy marks seat as taken for mask, e.g. when rebuilding the map from
+// stored allocations. It fails if the seat does not exist in the layout or
+// overlaps an allocation already on the map.
+func (m *SeatMap) Occupy(seat SeatPosition, mask uint64) error {
+	idx, ok := m.index(seat)
+	if !ok {
+		return fmt.Errorf("seat %s is not in the %s layout", seat.Label(), m.layout.CoachType)
+	}
+	if m.occupied[idx]&mask != 0 {
+		return fmt.Errorf("seat %s is already occupied on the requested segments", seat.Label())
+	}
+	m.occupied[idx] |= mask
+	return nil
+}
+
+// Release frees seat for mask, e.g. when the order holding it is cancelled.
+func (m *SeatMap) Release(seat SeatPosition, mask uint64) error {
+	idx, ok := m.index(seat)
+	if !ok {
+		return fmt.Errorf("seat %s is not in the %s layout", seat.Label(), m.layout.CoachType)
+	}
+	m.occupied[idx] &^= mask
+	return nil
+}
+
+// Seats returns every seat of the map in seat order, free ones included.
+func (m *SeatMap) Seats() []SeatState {
+	out := make([]SeatState, 0, len(m.occupied))
+	for idx, bits := range m.occupied {
+		out = append(out, SeatState{Seat: m.position(idx), Occupied: bits})
+	}
+	return out
+}
+
+// Allocate occupies the first seat that is free for every segment in mask.
+func (m *SeatMap) Allocate(mask uint64) (SeatPosition, error) {
+	for idx, bits := range m.occupied {
+		if bits&mask == 0 {
+			m.occupied[idx] |= mask
+			return m.position(idx), nil
+		}
+	}
+	return SeatPosition{}, ErrNoSeatAvailable
+}
+
+// AllocateGroup occupies one seat for every passenger in prefs, all of them
+// or none. With keepTogether it looks for adjacent free seats in one coach
+// first, spanning as few rows as possible, then for free seats in a single
+// coach, and only then spreads the group over the train. Whatever the seats,
+// they go to the passengers so that as many preferences as possible are met.
+func (m *SeatMap) AllocateGroup(mask uint64, prefs []SeatPreference, keepTogether bool) (GroupAllocation, error) {
+	if len(prefs) == 0 {
+		prefs = []SeatPreference{SeatPreferenceNone}
+	}
+	free := make([]int, 0, len(m.occupied))
+	for idx, bits := range m.occupied {
+		if bits&mask == 0 {
+			free = append(free, idx)
+		}
+	}
+	if len(free) < len(prefs) {
+		return GroupAllocation{}, ErrNoSeatAvailable
+	}
+
+	var picked []int
+	if keepTogether && len(prefs) > 1 {
+		if picked = m.bestRun(mask, prefs); picked == nil {
+			picked = m.bestCoach(free, prefs)
+		}
+	}
+	if picked == nil {
+		picked, _ = m.assign(free, prefs)
+	}
+
+	out := GroupAllocation{Seats: make([]SeatAssignment, 0, len(picked)), KeptTogether: m.adjacent(picked)}
+	for i, idx := range picked {
+		m.occupied[idx] |= mask
+		seat := m.position(idx)
+		out.Seats = append(out.Seats, SeatAssignment{Seat: seat, PreferenceHonoured: prefs[i].Satisfies(seat.Letter)})
+	}
+	return out, nil
+}
+
+// bestRun returns the run of len(prefs) consecutive free seats within one
+// coach that meets the most preferences, then spans the fewest rows.
+func (m *SeatMap) bestRun(mask uint64, prefs []SeatPreference) []int {
+	var (
+		best      []int
+		bestScore = -1
+		bestRows  int
+	)
+	perCoach := m.layout.SeatsPerCoach()
+	perRow := len(m.layout.Letters)
+	run := make([]int, len(prefs))
+	for coach := 0; coach < m.coaches; coach++ {
+		first := coach * perCoach
+		for start := first; start+len(prefs) <= first+perCoach; start++ {
+			free := true
+			for i := range run {
+				run[i] = start + i
+				if m.occupied[run[i]]&mask != 0 {
+					free = false
+					break
+				}
+			}
+			if !free {
+				continue
+			}
+			order, score := m.assign(run, prefs)
+			rows := (run[len(run)-1]-first)/perRow - (start-first)/perRow + 1
+			if score > bestScore || (score == bestScore && rows < bestRows) {
+				best, bestScore, bestRows = order, score, rows
+			}
+		}
+	}
+	return best
+}
+
+// Adjacent reports whether seats form one run of consecutive seats within
+// a coach of the map.
+func (m *SeatMap) Adjacent(seats []SeatPosition) bool {
+	if len(seats) == 0 {
+		return false
+	}
+	idx := make([]int, 0, len(seats))
+	for _, seat := range seats {
+		i, ok := m.index(seat)
+		if !ok {
+			return false
+		}
+		idx = append(idx, i)
+	}
+	return m.adjacent(idx)
+}
+
+// adjacent reports whether seats form one run of consecutive seats within
+// a coach.
+func (m *SeatMap) adjacent(seats []int) bool {
+	lo, hi := seats[0], seats[0]
+	for _, idx := range seats {
+		lo, hi = min(lo, idx), max(hi, idx)
+	}
+	perCoach := m.layout.SeatsPerCoach()
+	return hi-lo == len(seats)-1 && lo/perCoach == hi/perCoach
+}
+
+// bestCoach returns seats from the single coach that meets the most
+// preferences, or nil when no coach has room for the whole group.
+func (m *SeatMap) bestCoach(free []int, prefs []SeatPreference) []int {
+	var (
+		best      []int
+		bestScore = -1
+	)
+	perCoach := m.layout.SeatsPerCoach()
+	for start := 0; start < len(free); {
+		end := start
+		for end < len(free) && free[end]/perCoach == free[start]/perCoach {
+			end++
+		}
+		if end-start >= len(prefs) {
+			if order, score := m.assign(free[start:end], prefs); score > bestScore {
+				best, bestScore = order, score
+			}
+		}
+		start = end
+	}
+	return best
+}
+
+// assign picks a seat from candidates, which are in seat order, for every
+// passenger. Passengers with a preference choose first, each taking the
+// first seat that suits them; everyone else takes the first seat left. It
+// returns the seats in passenger order and how many preferences were met.
+func (m *SeatMap) assign(candidates []int, prefs []SeatPreference) ([]int, int) {
+	order := make([]int, len(prefs))
+	taken := make([]bool, len(candidates))
+	done := make([]bool, len(prefs))
+	score := 0
+	for i, pref := range prefs {
+		if pref == SeatPreferenceNone {
+			continue
+		}
+		for c, idx := range candidates {
+			if !taken[c] && pref.Satisfies(m.position(idx).Letter) {
+				order[i], taken[c], done[i] = idx, true, true
+				score++
+				break
+			}
+		}
+	}
+	next := 0
+	for i := range prefs {
+		if done[i] {
+			continue
+		}
+		for taken[next] {
+			next++
+		}
+		order[i], taken[next] = candidates[next], true
+	}
+	return order, score
+}
+
+func (m *SeatMap) index(seat SeatPosition) (int, bool) {
+	letter := strings.IndexByte(m.layout.Letters, seat.Letter)
+	if seat.Coach < 1 || seat.Coach > m.coaches || seat.Row < 1 || seat.Row > m.layout.Rows || letter < 0 {
+		return 0, false
+	}
+	perRow := len(m.layout.Letters)
+	return (seat.Coach-1)*m.layout.SeatsPerCoach() + (seat.Row-1)*perRow + letter, true
+}
+
+func (m *SeatMap) position(idx int) SeatPosition {
+	perCoach := m.layout.SeatsPerCoach()
+	perRow := len(m.layout.Letters)
+	inCoach := idx % perCoach
+	return SeatPosition{
+		Coach:  idx/perCoach + 1,
+		Row:    inCoach/perRow + 1,
+		Letter: m.layout.Letters[inCoach%perRow],
+	}
+}
@@ -0,0 +1,214 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSegmentMask(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		from, to int
+		want     uint64
+	}{
+		{0, 1, 0b1},
+		{1, 3, 0b110},
+		{62, 64, 0b11 << 62},
+		{0, 64, ^uint64(0)},
+	}
+	for _, tc := range cases {
+		got, err := SegmentMask(tc.from, tc.to)
+		if err != nil || got != tc.want {
+			t.Fatalf("SegmentMask(%d, %d) = %b, %v; want %b", tc.from, tc.to, got, err, tc.want)
+		}
+	}
+	for _, bad := range [][2]int{{-1, 2}, {3, 3}, {4, 2}, {0, 65}} {
+		if _, err := SegmentMask(bad[0], bad[1]); !errors.Is(err, ErrInvalidSegment) {
+			t.Fatalf("SegmentMask(%d, %d): expected ErrInvalidSegment, got %v", bad[0], bad[1], err)
+		}
+	}
+}
+
+func TestParseSeatLabel(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		label string
+		coach int
+		seat  string
+	}{
+		{"03-12F", 3, "12F"},
+		{"1-23", 1, "23"},
+		{"CARRIAGE-1-abcd1234", 0, "CARRIAGE-1-abcd1234"},
+		{"12F", 0, "12F"},
+		{"00-01A", 0, "00-01A"},
+	}
+	for _, tc := range cases {
+		coach, seat := ParseSeatLabel(tc.label)
+		if coach != tc.coach || seat != tc.seat {
+			t.Fatalf("ParseSeatLabel(%q) = %d, %q; want %d, %q", tc.label, coach, seat, tc.coach, tc.seat)
+		}
+	}
+}
+
+func TestSeatMap_AllocatesFirstFreeSeat(t *testing.T) {
+	t.Parallel()
+
+	layout, err := LayoutFor("business")
+	if err != nil {
+		t.Fatalf("layout: %v", err)
+	}
+	m := NewSeatMap(layout, 2)
+	whole, _ := SegmentMask(0, 4)
+	first, _ := SegmentMask(0, 2)
+	second, _ := SegmentMask(2, 4)
+
+	want := []string{"01-01A", "01-01C", "01-01F", "01-02A"}
+	for _, label := range want {
+		seat, err := m.Allocate(whole)
+		if err != nil || seat.Label() != label {
+			t.Fatalf("expected %s, got %s (%v)", label, seat.Label(), err)
+		}
+	}
+
+	seat, _ := m.Allocate(first)
+	if seat.Label() != "01-02C" {
+		t.Fatalf("expected 01-02C, got %s", seat.Label())
+	}
+	seat, _ = m.Allocate(second)
+	if seat.Label() != "01-02C" {
+		t.Fatalf("expected the later segments of 01-02C to be reused, got %s", seat.Label())
+	}
+
+	if err := m.Occupy(SeatPosition{Coach: 1, Row: 2, Letter: 'C'}, first); err == nil {
+		t.Fatalf("expected an overlapping occupation to fail")
+	}
+	if err := m.Occupy(SeatPosition{Coach: 3, Row: 1, Letter: 'A'}, first); err == nil {
+		t.Fatalf("expected a seat outside the layout to be rejected")
+	}
+}
+
+func TestSeatMap_Exhausted(t *testing.T) {
+	t.Parallel()
+
+	layout, _ := LayoutFor("business")
+	m := NewSeatMap(layout, 1)
+	mask, _ := SegmentMask(5, 6)
+	for i := 0; i < layout.SeatsPerCoach(); i++ {
+		if _, err := m.Allocate(mask); err != nil {
+			t.Fatalf("allocation %d failed: %v", i, err)
+		}
+	}
+	if _, err := m.Allocate(mask); !errors.Is(err, ErrNoSeatAvailable) {
+		t.Fatalf("expected ErrNoSeatAvailable, got %v", err)
+	}
+	if seat, err := m.Allocate(mask << 1); err != nil || seat.Label() != "01-01A" {
+		t.Fatalf("expected a free later segment to fit, got %s (%v)", seat.Label(), err)
+	}
+}
+
+func labels(g GroupAllocation) []string {
+	out := make([]string, 0, len(g.Seats))
+	for _, s := range g.Seats {
+		out = append(out, s.Seat.Label())
+	}
+	return out
+}
+
+func TestSeatMap_AllocateGroupKeepsFamiliesTogether(t *testing.T) {
+	t.Parallel()
+
+	layout, _ := LayoutFor("2nd")
+	m := NewSeatMap(layout, 2)
+	mask, _ := SegmentMask(0, 3)
+	// Leave single free seats scattered over the first rows of coach 1.
+	for _, seat := range []SeatPosition{{1, 1, 'A'}, {1, 1, 'C'}, {1, 1, 'F'}, {1, 2, 'B'}, {1, 2, 'D'}} {
+		if err := m.Occupy(seat, mask); err != nil {
+			t.Fatalf("occupy: %v", err)
+		}
+	}
+
+	g, err := m.AllocateGroup(mask, []SeatPreference{SeatPreferenceWindow, SeatPreferenceNone, SeatPreferenceNone}, true)
+	if err != nil {
+		t.Fatalf("allocate: %v", err)
+	}
+	if got := fmt.Sprint(labels(g)); got != "[01-03A 01-03B 01-03C]" || !g.KeptTogether {
+		t.Fatalf("expected a row for the family, got %s (together=%v)", got, g.KeptTogether)
+	}
+	for _, s := range g.Seats {
+		if !s.PreferenceHonoured {
+			t.Fatalf("expected every preference to be met, got %+v", g.Seats)
+		}
+	}
+
+	g, _ = m.AllocateGroup(mask, []SeatPreference{SeatPreferenceNone, SeatPreferenceNone}, false)
+	if got := fmt.Sprint(labels(g)); got != "[01-01B 01-01D]" || g.KeptTogether {
+		t.Fatalf("expected the first free seats without keep-together, got %s (together=%v)", got, g.KeptTogether)
+	}
+}
+
+func TestSeatMap_AllocateGroupFallsBack(t *testing.T) {
+	t.Parallel()
+
+	layout, _ := LayoutFor("business")
+	m := NewSeatMap(layout, 2)
+	mask, _ := SegmentMask(0, 1)
+	// Coach 1 keeps only 02C, 04C and 06C free; coach 2 only 01A and 08F.
+	for idx := range m.occupied {
+		seat := m.position(idx)
+		free := (seat.Coach == 1 && seat.Letter == 'C' && seat.Row%2 == 0 && seat.Row <= 6) ||
+			(seat.Coach == 2 && (seat == SeatPosition{2, 1, 'A'} || seat == SeatPosition{2, 8, 'F'}))
+		if !free {
+			m.occupied[idx] = mask
+		}
+	}
+
+	g, err := m.AllocateGroup(mask, []SeatPreference{SeatPreferenceWindow, SeatPreferenceNone, SeatPreferenceNone}, true)
+	if err != nil {
+		t.Fatalf("allocate: %v", err)
+	}
+	if got := fmt.Sprint(labels(g)); got != "[01-02C 01-04C 01-06C]" || g.KeptTogether {
+		t.Fatalf("expected one coach without adjacent seats, got %s (together=%v)", got, g.KeptTogether)
+	}
+	if g.Seats[0].PreferenceHonoured || !g.Seats[1].PreferenceHonoured {
+		t.Fatalf("expected only the window preference to be missed, got %+v", g.Seats)
+	}
+
+	g, err = m.AllocateGroup(mask, []SeatPreference{SeatPreferenceAisle, SeatPreferenceWindow}, true)
+	if err != nil {
+		t.Fatalf("allocate: %v", err)
+	}
+	if got := fmt.Sprint(labels(g)); got != "[02-08F 02-01A]" || g.KeptTogether || g.Seats[0].PreferenceHonoured || !g.Seats[1].PreferenceHonoured {
+		t.Fatalf("expected the window seat to go to the window passenger, got %s (%+v)", got, g.Seats)
+	}
+
+	if _, err := m.AllocateGroup(mask, []SeatPreference{SeatPreferenceNone}, true); !errors.Is(err, ErrNoSeatAvailable) {
+		t.Fatalf("expected ErrNoSeatAvailable, got %v", err)
+	}
+}
+
+func TestSeatMap_ReleaseFreesSegments(t *testing.T) {
+	t.Parallel()
+
+	layout, _ := LayoutFor("business")
+	m := NewSeatMap(layout, 1)
+	whole, _ := SegmentMask(0, 4)
+	first, _ := SegmentMask(0, 2)
+	seat, _ := m.Allocate(whole)
+	if err := m.Release(seat, first); err != nil {
+		t.Fatalf("release failed: %v", err)
+	}
+	if got, _ := m.Allocate(first); got != seat {
+		t.Fatalf("expected the released segments of %s to be reused, got %s", seat.Label(), got.Label())
+	}
+
+	seats := m.Seats()
+	if len(seats) != layout.SeatsPerCoach() || seats[0].Seat != seat || seats[0].Occupied != whole || seats[1].Occupied != 0 {
+		t.Fatalf("unexpected seat states %+v", seats[:2])
+	}
+	if err := m.Release(SeatPosition{Coach: 2, Row: 1, Letter: 'A'}, whole); err == nil {
+		t.Fatalf("expected a seat outside the layout to be rejected")
+	}
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+type TicketStatus string
+
+const (
+	TicketStatusIssued    TicketStatus = "ISSUED"
+	TicketStatusCheckedIn TicketStatus = "CHECKED_IN"
+	TicketStatusUsed      TicketStatus = "USED"
+	TicketStatusVoided    TicketStatus = "VOIDED"
+	TicketStatusRefunded  TicketStatus = "REFUNDED"
+)
+
+// TicketEvent names what moves a ticket between statuses.
+type TicketEvent string
+
+const (
+	// TicketEventCheckIn is the scan at the entrance gate of the boarding
+	// station.
+	TicketEventCheckIn TicketEvent = "CHECK_IN"
+	// TicketEventUse is the verification that uses the ticket up.
+	TicketEventUse    TicketEvent = "USE"
+	TicketEventVoid   TicketEvent = "VOID"
+	TicketEventRefund TicketEvent = "REFUND"
+)
+
+const (
+	TicketActorGate         = "gate"
+	TicketActorTicketWorker = "ticket-worker"
+	TicketActorSupport      = "support"
+)
+
+var (
+	ErrInvalidTicketTransition = errors.New("invalid ticket status transition")
+	ErrTicketMismatch          = errors.New("ticket is for a different train, date or station")
+)
+
+type TicketTransition struct {
+	Event TicketEvent
+	From  TicketStatus
+	To    TicketStatus
+}
+
+// ticketTransitions is the single source of truth for the ticket
+// lifecycle. USED, VOIDED and REFUNDED are final.
+var ticketTransitions = []TicketTransition{
+	{Event: TicketEventCheckIn, From: TicketStatusIssued, To: TicketStatusCheckedIn},
+	{Event: TicketEventUse, From: TicketStatusIssued, To: TicketStatusUsed},
+	{Event: TicketEventUse, From: TicketStatusCheckedIn, To: TicketStatusUsed},
+	{Event: TicketEventVoid, From: TicketStatusIssued, To: TicketStatusVoided},
+	{Event: TicketEventVoid, From: TicketStatusCheckedIn, To: TicketStatusVoided},
+	{Event: TicketEventRefund, From: TicketStatusIssued, To: TicketStatusRefunded},
+}
+
+// NextTicketStatus returns the status reached by applying event in status
+// from.
+func NextTicketStatus(from TicketStatus, event TicketEvent) (TicketStatus, error) {
+	for _, t := range ticketTransitions {
+		if t.Event == event && t.From == from {
+			return t.To, nil
+		}
+	}
+	return "", ErrInvalidTicketTransition
+}
+
+// TicketStatusChange is one row of a ticket's status history.
+// StationIndex is set for gate scans.
+type TicketStatusChange struct {
+	TicketID     string
+	OrderID      string
+	PassengerID  string
+	Event        TicketEvent
+	FromStatus   TicketStatus
+	ToStatus     TicketStatus
+	Actor        string
+	Reason       string
+	StationIndex *int
+	At           time.Time
+}
+
+// CheckInAt reports whether t may be checked in or used on trainID on
+// travelDate at station index station: only at the station the passenger
+// boards.
+func (t Ticket) CheckInAt(trainID string, travelDate string, station int) error {
+	if t.TrainID != trainID || t.TravelDate != travelDate || t.FromIndex != station {
+		return ErrTicketMismatch
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNextTicketStatus(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		from  TicketStatus
+		event TicketEvent
+		want  TicketStatus
+	}{
+		{TicketStatusIssued, TicketEventCheckIn, TicketStatusCheckedIn},
+		{TicketStatusIssued, TicketEventUse, TicketStatusUsed},
+		{TicketStatusCheckedIn, TicketEventUse, TicketStatusUsed},
+		{TicketStatusCheckedIn, TicketEventVoid, TicketStatusVoided},
+		{TicketStatusIssued, TicketEventRefund, TicketStatusRefunded},
+	}
+	for _, tc := range cases {
+		got, err := NextTicketStatus(tc.from, tc.event)
+		if err != nil || got != tc.want {
+			t.Fatalf("%s on %s: expected %s, got %s (%v)", tc.event, tc.from, tc.want, got, err)
+		}
+	}
+
+	for _, tc := range []struct {
+		from  TicketStatus
+		event TicketEvent
+	}{
+		{TicketStatusCheckedIn, TicketEventCheckIn},
+		{TicketStatusCheckedIn, TicketEventRefund},
+		{TicketStatusUsed, TicketEventVoid},
+		{TicketStatusVoided, TicketEventUse},
+		{TicketStatusRefunded, TicketEventCheckIn},
+	} {
+		if _, err := NextTicketStatus(tc.from, tc.event); !errors.Is(err, ErrInvalidTicketTransition) {
+			t.Fatalf("%s on %s: expected ErrInvalidTicketTransition, got %v", tc.event, tc.from, err)
+		}
+	}
+}
+
+func TestTicket_CheckInAt(t *testing.T) {
+	t.Parallel()
+
+	ticket := Ticket{TrainID: "G123", TravelDate: "2026-02-11", FromIndex: 1, ToIndex: 4}
+	if err := ticket.CheckInAt("G123", "2026-02-11", 1); err != nil {
+		t.Fatalf("expected check-in at the boarding station, got %v", err)
+	}
+	for name, in := range map[string]struct {
+		train, date string
+		station     int
+	}{
+		"other train":   {"G125", "2026-02-11", 1},
+		"other date":    {"G123", "2026-02-12", 1},
+		"other station": {"G123", "2026-02-11", 2},
+	} {
+		if err := ticket.CheckInAt(in.train, in.date, in.station); !errors.Is(err, ErrTicketMismatch) {
+			t.Fatalf("%s: expected ErrTicketMismatch, got %v", name, err)
+		}
+	}
+}
@@ -1,15 +1,102 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"strconv"
+	"time"
+)
 
 var (
 	ErrOrderNotPaid = errors.New("order is not in PAID status")
 )
 
+// Ticket is one passenger's ticket: the seat SeatNo in coach CoachNo of
+// TrainID on TravelDate between station indexes [FromIndex, ToIndex).
+// PassengerID is the passenger's document key, unique within an order.
+// CheckedInAt and UsedAt are zero until those happen.
 type Ticket struct {
 	TicketID      string
 	OrderID       string
+	PassengerID   string
 	PassengerName string
+	TrainID       string
+	TravelDate    string
+	CoachNo       int
+	SeatNo        string
+	FromIndex     int
+	ToIndex       int
+	Status        TicketStatus
+	CheckedInAt   time.Time
+	UsedAt        time.Time
 }
 
+// Passenger is a traveller on an order. ID is the document key
+// "ID_TYPE:ID_NUMBER" order-service records the passenger under.
+type Passenger struct {
+	ID             string
+	Name           string
+	SeatPreference SeatPreference
+}
 
+// Itinerary is what an order holds seats for: Qty seats on the inventory
+// partition "train|date|class" between station indexes [FromIndex, ToIndex).
+// Orders reserved before these were recorded have zero values and no
+// Passengers.
+type Itinerary struct {
+	PartitionKey string
+	Qty          int
+	FromIndex    int
+	ToIndex      int
+	KeepTogether bool
+	Passengers   []Passenger
+}
+
+// Preferences returns the passengers' seat wishes in passenger order.
+func (it Itinerary) Preferences() []SeatPreference {
+	if len(it.Passengers) == 0 {
+		return nil
+	}
+	prefs := make([]SeatPreference, len(it.Passengers))
+	for i, p := range it.Passengers {
+		prefs[i] = p.SeatPreference
+	}
+	return prefs
+}
+
+// TicketRoute is where the tickets of one order travel.
+type TicketRoute struct {
+	TrainID    string
+	TravelDate string
+	FromIndex  int
+	ToIndex    int
+}
+
+// NewTickets gives each allocated seat, in passenger order, its own ticket.
+// Seats beyond the recorded passengers, as on orders reserved before
+// passengers were recorded, go to placeholder passengers "UNNAMED:n" so
+// every ticket of the order keeps a distinct PassengerID. newID supplies
+// the ticket IDs.
+func NewTickets(orderID string, route TicketRoute, passengers []Passenger, seatLabels []string, newID func() string) []Ticket {
+	tickets := make([]Ticket, 0, len(seatLabels))
+	for i, label := range seatLabels {
+		p := Passenger{ID: "UNNAMED:" + strconv.Itoa(i+1)}
+		if i < len(passengers) {
+			p = passengers[i]
+		}
+		coach, seat := ParseSeatLabel(label)
+		tickets = append(tickets, Ticket{
+			TicketID:      newID(),
+			OrderID:       orderID,
+			PassengerID:   p.ID,
+			PassengerName: p.Name,
+			TrainID:       route.TrainID,
+			TravelDate:    route.TravelDate,
+			CoachNo:       coach,
+			SeatNo:        seat,
+			FromIndex:     route.FromIndex,
+			ToIndex:       route.ToIndex,
+			Status:        TicketStatusIssued,
+		})
+	}
+	return tickets
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestNewTickets_OnePerPassenger(t *testing.T) {
+	t.Parallel()
+
+	n := 0
+	newID := func() string {
+		n++
+		return "ticket-" + strconv.Itoa(n)
+	}
+	route := TicketRoute{TrainID: "G123", TravelDate: "2026-02-11", FromIndex: 1, ToIndex: 4}
+	passengers := []Passenger{
+		{ID: "ID_CARD:110101199003074258", Name: "Li Lei"},
+		{ID: "PASSPORT:E12345678", Name: "Han Meimei"},
+	}
+
+	got := NewTickets("order-1", route, passengers, []string{"03-12A", "03-12B", "04-01F"}, newID)
+	want := []Ticket{
+		{TicketID: "ticket-1", OrderID: "order-1", PassengerID: "ID_CARD:110101199003074258", PassengerName: "Li Lei", TrainID: "G123", TravelDate: "2026-02-11", CoachNo: 3, SeatNo: "12A", FromIndex: 1, ToIndex: 4, Status: TicketStatusIssued},
+		{TicketID: "ticket-2", OrderID: "order-1", PassengerID: "PASSPORT:E12345678", PassengerName: "Han Meimei", TrainID: "G123", TravelDate: "2026-02-11", CoachNo: 3, SeatNo: "12B", FromIndex: 1, ToIndex: 4, Status: TicketStatusIssued},
+		{TicketID: "ticket-3", OrderID: "order-1", PassengerID: "UNNAMED:3", TrainID: "G123", TravelDate: "2026-02-11", CoachNo: 4, SeatNo: "01F", FromIndex: 1, ToIndex: 4, Status: TicketStatusIssued},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
@@ -0,0 +1,109 @@
+package eticket
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	qrcode "github.com/skip2/go-qrcode"
+
+	"ticketing/internal/ticket/domain"
+)
+
+const qrSize = 320
+
+// Issuer turns a ticket into its e-ticket: the signed token, a QR code of
+// it and a one-page PDF itinerary carrying the QR code.
+type Issuer struct {
+	signer   *Signer
+	fontPath string
+	now      func() time.Time
+}
+
+// NewIssuer renders PDF text in fontPath, a TrueType font, when it is set.
+// Without it the built-in Helvetica is used, which has no CJK glyphs:
+// characters outside Windows-1252 are printed as dots.
+func NewIssuer(signer *Signer, fontPath string) *Issuer {
+	return &Issuer{signer: signer, fontPath: fontPath, now: time.Now}
+}
+
+func (i *Issuer) Issue(t domain.Ticket) (domain.ETicket, error) {
+	issuedAt := i.now().UTC().Truncate(time.Second)
+	claims := domain.ETicketClaims{
+		Ticket:    t,
+		IssuedAt:  issuedAt,
+		ExpiresAt: domain.ETicketValidUntil(t.TravelDate, issuedAt),
+	}
+	token, err := i.signer.Sign(claims)
+	if err != nil {
+		return domain.ETicket{}, fmt.Errorf("sign e-ticket %s: %w", t.TicketID, err)
+	}
+	qr, err := qrcode.Encode(token, qrcode.Medium, qrSize)
+	if err != nil {
+		return domain.ETicket{}, fmt.Errorf("render qr code of e-ticket %s: %w", t.TicketID, err)
+	}
+	pdf, err := i.renderPDF(claims, qr)
+	if err != nil {
+		return domain.ETicket{}, fmt.Errorf("render pdf of e-ticket %s: %w", t.TicketID, err)
+	}
+	return domain.ETicket{
+		TicketID:  t.TicketID,
+		OrderID:   t.OrderID,
+		Token:     token,
+		QRPNG:     qr,
+		PDF:       pdf,
+		ExpiresAt: claims.ExpiresAt,
+	}, nil
+}
+
+func (i *Issuer) renderPDF(c domain.ETicketClaims, qr []byte) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A5", "")
+	family, tr := "Helvetica", pdf.UnicodeTranslatorFromDescriptor("")
+	if i.fontPath != "" {
+		pdf.AddUTF8Font("eticket", "", i.fontPath)
+		family, tr = "eticket", func(s string) string { return s }
+	}
+	pdf.SetTitle("E-ticket "+c.TicketID, true)
+	pdf.AddPage()
+
+	pdf.SetFont(family, "", 18)
+	pdf.CellFormat(0, 10, "E-ticket", "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	seat := c.SeatNo
+	if c.CoachNo > 0 {
+		seat = fmt.Sprintf("Coach %02d, seat %s", c.CoachNo, c.SeatNo)
+	}
+	rows := [][2]string{
+		{"Passenger", c.PassengerName},
+		{"Train", c.TrainID},
+		{"Date", c.TravelDate},
+		{"Stations", fmt.Sprintf("%d - %d", c.FromIndex, c.ToIndex)},
+		{"Seat", seat},
+		{"Ticket", c.TicketID},
+		{"Order", c.OrderID},
+		{"Valid until", c.ExpiresAt.Format(time.RFC3339)},
+	}
+	for _, row := range rows {
+		pdf.SetFont(family, "", 9)
+		pdf.CellFormat(28, 7, row[0], "", 0, "L", false, 0, "")
+		pdf.SetFont(family, "", 11)
+		pdf.CellFormat(0, 7, tr(row[1]), "", 1, "L", false, 0, "")
+	}
+
+	const qrMM = 60.0
+	pageW, _ := pdf.GetPageSize()
+	opts := gofpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader("qr", opts, bytes.NewReader(qr))
+	pdf.ImageOptions("qr", (pageW-qrMM)/2, pdf.GetY()+6, qrMM, qrMM, false, opts, 0, "")
+	pdf.SetY(pdf.GetY() + qrMM + 10)
+	pdf.SetFont(family, "", 9)
+	pdf.CellFormat(0, 5, "Present this QR code at the gate. Each ticket admits one passenger once.", "", 1, "C", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
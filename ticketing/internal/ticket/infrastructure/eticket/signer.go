@@ -0,0 +1,115 @@
+package eticket
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"ticketing/internal/ticket/domain"
+)
+
+// tokenPrefix versions the token format: "ET1.<claims>.<signature>", both
+// parts base64url without padding, the signature covering "ET1.<claims>".
+const tokenPrefix = "ET1"
+
+// tokenClaims uses short keys to keep the QR code small.
+type tokenClaims struct {
+	TicketID      string `json:"tid"`
+	OrderID       string `json:"oid"`
+	PassengerID   string `json:"pid"`
+	PassengerName string `json:"pn"`
+	TrainID       string `json:"tr"`
+	TravelDate    string `json:"td"`
+	CoachNo       int    `json:"cn"`
+	SeatNo        string `json:"sn"`
+	FromIndex     int    `json:"fi"`
+	ToIndex       int    `json:"ti"`
+	IssuedAt      int64  `json:"iat"`
+	ExpiresAt     int64  `json:"exp"`
+}
+
+// Signer signs e-ticket tokens with an Ed25519 key and verifies them. Gate
+// tooling that only holds PublicKey can verify tokens offline.
+type Signer struct {
+	key ed25519.PrivateKey
+	now func() time.Time
+}
+
+// NewSigner takes the 32-byte Ed25519 seed of the signing key.
+func NewSigner(seed []byte) (*Signer, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("e-ticket signing key must be a %d-byte Ed25519 seed, got %d bytes", ed25519.SeedSize, len(seed))
+	}
+	return &Signer{key: ed25519.NewKeyFromSeed(seed), now: time.Now}, nil
+}
+
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+func (s *Signer) Sign(c domain.ETicketClaims) (string, error) {
+	body, err := json.Marshal(tokenClaims{
+		TicketID:      c.TicketID,
+		OrderID:       c.OrderID,
+		PassengerID:   c.PassengerID,
+		PassengerName: c.PassengerName,
+		TrainID:       c.TrainID,
+		TravelDate:    c.TravelDate,
+		CoachNo:       c.CoachNo,
+		SeatNo:        c.SeatNo,
+		FromIndex:     c.FromIndex,
+		ToIndex:       c.ToIndex,
+		IssuedAt:      c.IssuedAt.Unix(),
+		ExpiresAt:     c.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	signingInput := tokenPrefix + "." + base64.RawURLEncoding.EncodeToString(body)
+	sig := ed25519.Sign(s.key, []byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// Verify checks the signature and validity of token and returns what it
+// vouches for. It says nothing about whether the ticket has been used.
+func (s *Signer) Verify(token string) (domain.ETicketClaims, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 || parts[0] != tokenPrefix {
+		return domain.ETicketClaims{}, domain.ErrInvalidETicket
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !ed25519.Verify(s.PublicKey(), []byte(parts[0]+"."+parts[1]), sig) {
+		return domain.ETicketClaims{}, domain.ErrInvalidETicket
+	}
+	body, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return domain.ETicketClaims{}, domain.ErrInvalidETicket
+	}
+	var c tokenClaims
+	if err := json.Unmarshal(body, &c); err != nil || c.TicketID == "" {
+		return domain.ETicketClaims{}, domain.ErrInvalidETicket
+	}
+	claims := domain.ETicketClaims{
+		Ticket: domain.Ticket{
+			TicketID:      c.TicketID,
+			OrderID:       c.OrderID,
+			PassengerID:   c.PassengerID,
+			PassengerName: c.PassengerName,
+			TrainID:       c.TrainID,
+			TravelDate:    c.TravelDate,
+			CoachNo:       c.CoachNo,
+			SeatNo:        c.SeatNo,
+			FromIndex:     c.FromIndex,
+			ToIndex:       c.ToIndex,
+		},
+		IssuedAt:  time.Unix(c.IssuedAt, 0).UTC(),
+		ExpiresAt: time.Unix(c.ExpiresAt, 0).UTC(),
+	}
+	if !s.now().Before(claims.ExpiresAt) {
+		return claims, domain.ErrETicketExpired
+	}
+	return claims, nil
+}
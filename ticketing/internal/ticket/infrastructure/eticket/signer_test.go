@@ -0,0 +1,119 @@
+package eticket
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"ticketing/internal/ticket/domain"
+)
+
+func testSigner(t *testing.T, seedByte byte) *Signer {
+	t.Helper()
+	s, err := NewSigner(bytes.Repeat([]byte{seedByte}, 32))
+	if err != nil {
+		t.Fatalf("new signer: %v", err)
+	}
+	return s
+}
+
+func testTicket() domain.Ticket {
+	return domain.Ticket{
+		TicketID:      "ticket-1",
+		OrderID:       "order-1",
+		PassengerID:   "ID_CARD:110101199003074258",
+		PassengerName: "李雷",
+		TrainID:       "G123",
+		TravelDate:    "2026-02-11",
+		CoachNo:       3,
+		SeatNo:        "12F",
+		FromIndex:     1,
+		ToIndex:       4,
+	}
+}
+
+func TestSigner_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	s := testSigner(t, 1)
+	s.now = func() time.Time { return time.Date(2026, 2, 10, 8, 0, 0, 0, time.UTC) }
+	want := domain.ETicketClaims{
+		Ticket:    testTicket(),
+		IssuedAt:  time.Date(2026, 2, 9, 8, 0, 0, 0, time.UTC),
+		ExpiresAt: domain.ETicketValidUntil("2026-02-11", time.Time{}),
+	}
+	token, err := s.Sign(want)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	got, err := s.Verify(token)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+
+	s.now = func() time.Time { return want.ExpiresAt }
+	if _, err := s.Verify(token); !errors.Is(err, domain.ErrETicketExpired) {
+		t.Fatalf("expected ErrETicketExpired at the end of the travel date, got %v", err)
+	}
+}
+
+func TestSigner_RejectsForgedTokens(t *testing.T) {
+	t.Parallel()
+
+	s := testSigner(t, 1)
+	claims := domain.ETicketClaims{Ticket: testTicket(), IssuedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+	token, err := s.Sign(claims)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	other, err := testSigner(t, 2).Sign(claims)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	claims.SeatNo = "01A"
+	moved, err := testSigner(t, 2).Sign(claims)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	parts := strings.Split(token, ".")
+	swapped := parts[0] + "." + strings.Split(moved, ".")[1] + "." + parts[2]
+
+	for name, bad := range map[string]string{
+		"other key":      other,
+		"swapped claims": swapped,
+		"garbage":        "not-a-token",
+		"wrong version":  "ET0" + strings.TrimPrefix(token, "ET1"),
+	} {
+		if _, err := s.Verify(bad); !errors.Is(err, domain.ErrInvalidETicket) {
+			t.Fatalf("%s: expected ErrInvalidETicket, got %v", name, err)
+		}
+	}
+}
+
+func TestIssuer_RendersDocuments(t *testing.T) {
+	t.Parallel()
+
+	issuer := NewIssuer(testSigner(t, 1), "")
+	doc, err := issuer.Issue(testTicket())
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if doc.TicketID != "ticket-1" || doc.OrderID != "order-1" {
+		t.Fatalf("unexpected e-ticket %+v", doc)
+	}
+	if _, err := issuer.signer.Verify(doc.Token); err != nil && !errors.Is(err, domain.ErrETicketExpired) {
+		t.Fatalf("expected a token the signer accepts, got %v", err)
+	}
+	if !bytes.HasPrefix(doc.QRPNG, []byte("\x89PNG\r\n\x1a\n")) {
+		t.Fatal("expected the QR code to be a PNG")
+	}
+	if !bytes.HasPrefix(doc.PDF, []byte("%PDF-")) {
+		t.Fatal("expected a PDF itinerary")
+	}
+}
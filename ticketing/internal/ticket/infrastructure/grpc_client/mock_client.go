@@ -4,10 +4,66 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"ticketing/internal/ticket/domain"
 )
 
+// AllocateSeatInput is the itinerary of one order: Qty seats on TrainID,
+// TravelDate and CoachType between station indexes [FromIndex, ToIndex).
+// Preferences holds the wish of each passenger in seat order; passengers
+// beyond its end have none. KeepTogether asks for adjacent seats.
+type AllocateSeatInput struct {
+	OrderID      string
+	TrainID      string
+	TravelDate   string
+	CoachType    string
+	FromIndex    int
+	ToIndex      int
+	Qty          int
+	Preferences  []domain.SeatPreference
+	KeepTogether bool
+}
+
+// AllocateSeatResult lists the seats in passenger order, whether each
+// passenger's preference was met, and whether the group sits together.
+type AllocateSeatResult struct {
+	SeatNos            []string
+	PreferenceHonoured []bool
+	KeptTogether       bool
+}
+
+// ReleaseSeatInput names an order and the route whose seats it gives back.
+type ReleaseSeatInput struct {
+	OrderID    string
+	TrainID    string
+	TravelDate string
+	CoachType  string
+}
+
+// SeatMapInput names the route of a seat map.
+type SeatMapInput struct {
+	TrainID    string
+	TravelDate string
+	CoachType  string
+}
+
+// SeatState is a seat and its occupancy: bit i of OccupiedSegments is set
+// when the seat is taken between station i and i+1.
+type SeatState struct {
+	SeatNo           string
+	OccupiedSegments uint64
+}
+
+// SeatAllocatorClient allocates all Qty seats of an order or none of them.
+// Preferences and KeepTogether are best effort. Allocation is idempotent by
+// OrderID: an order that already holds seats on the route gets them back,
+// so retrying AllocateSeat never takes a second set. ReleaseSeat frees every
+// seat the order holds on the route and returns them; releasing an order
+// without seats is not an error. GetSeatMap lists every seat of the route.
 type SeatAllocatorClient interface {
-	AllocateSeat(ctx context.Context, orderID string) (string, error)
+	AllocateSeat(ctx context.Context, in AllocateSeatInput) (AllocateSeatResult, error)
+	ReleaseSeat(ctx context.Context, in ReleaseSeatInput) ([]string, error)
+	GetSeatMap(ctx context.Context, in SeatMapInput) ([]SeatState, error)
 }
 
 type MockSeatAllocator struct{}
@@ -16,17 +72,35 @@ func NewMockSeatAllocator() *MockSeatAllocator {
 	return &MockSeatAllocator{}
 }
 
-func (m *MockSeatAllocator) AllocateSeat(_ context.Context, orderID string) (string, error) {
+func (m *MockSeatAllocator) AllocateSeat(_ context.Context, in AllocateSeatInput) (AllocateSeatResult, error) {
 	// Stage 4 mock: deterministic seat generation for integration.
-	key := orderID
+	key := in.OrderID
 	if len(key) > 8 {
 		key = key[:8]
 	}
-	return fmt.Sprintf("CARRIAGE-1-%s", key), nil
+	res := AllocateSeatResult{KeptTogether: true}
+	for i := 1; i <= in.Qty || i == 1; i++ {
+		seat := fmt.Sprintf("CARRIAGE-1-%s", key)
+		if i > 1 {
+			seat = fmt.Sprintf("%s-%d", seat, i)
+		}
+		res.SeatNos = append(res.SeatNos, seat)
+		// Mock seats have no letter, so only "no preference" is met.
+		res.PreferenceHonoured = append(res.PreferenceHonoured, i > len(in.Preferences) || in.Preferences[i-1] == domain.SeatPreferenceNone)
+	}
+	return res, nil
 }
 
-func DefaultTimeout() time.Duration {
-	return 2 * time.Second
+// ReleaseSeat has nothing to free: mock seats are derived from the order ID
+// and never stored.
+func (m *MockSeatAllocator) ReleaseSeat(_ context.Context, _ ReleaseSeatInput) ([]string, error) {
+	return nil, nil
 }
 
+func (m *MockSeatAllocator) GetSeatMap(_ context.Context, _ SeatMapInput) ([]SeatState, error) {
+	return nil, nil
+}
 
+func DefaultTimeout() time.Duration {
+	return 2 * time.Second
+}
@@ -6,27 +6,16 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 
+	"ticketing/internal/ticket/domain"
 	seatallocatorv1 "ticketing/proto/seatallocator/v1"
 )
 
 type GRPCSeatAllocator struct {
-	conn       *grpc.ClientConn
-	client     seatallocatorv1.SeatAllocatorClient
-	trainID    string
-	travelDate string
-	coachType  string
-	fromIndex  int
-	toIndex    int
+	conn   *grpc.ClientConn
+	client seatallocatorv1.SeatAllocatorClient
 }
 
-func NewGRPCSeatAllocator(
-	addr string,
-	trainID string,
-	travelDate string,
-	coachType string,
-	fromIndex int,
-	toIndex int,
-) (*GRPCSeatAllocator, error) {
+func NewGRPCSeatAllocator(addr string) (*GRPCSeatAllocator, error) {
 	conn, err := grpc.NewClient(
 		addr,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
@@ -35,29 +24,83 @@ func NewGRPCSeatAllocator(
 		return nil, err
 	}
 	return &GRPCSeatAllocator{
-		conn:       conn,
-		client:     seatallocatorv1.NewSeatAllocatorClient(conn),
-		trainID:    trainID,
-		travelDate: travelDate,
-		coachType:  coachType,
-		fromIndex:  fromIndex,
-		toIndex:    toIndex,
+		conn:   conn,
+		client: seatallocatorv1.NewSeatAllocatorClient(conn),
 	}, nil
 }
 
-func (c *GRPCSeatAllocator) AllocateSeat(ctx context.Context, orderID string) (string, error) {
+func (c *GRPCSeatAllocator) AllocateSeat(ctx context.Context, in AllocateSeatInput) (AllocateSeatResult, error) {
+	prefs := make([]seatallocatorv1.SeatPreference, 0, len(in.Preferences))
+	for _, p := range in.Preferences {
+		prefs = append(prefs, seatPreferenceToProto(p))
+	}
 	resp, err := c.client.AllocateSeat(ctx, &seatallocatorv1.AllocateSeatRequest{
-		OrderId:    orderID,
-		TrainId:    c.trainID,
-		TravelDate: c.travelDate,
-		CoachType:  c.coachType,
-		FromIndex:  uint32(c.fromIndex),
-		ToIndex:    uint32(c.toIndex),
+		OrderId:      in.OrderID,
+		TrainId:      in.TrainID,
+		TravelDate:   in.TravelDate,
+		CoachType:    in.CoachType,
+		FromIndex:    uint32(in.FromIndex),
+		ToIndex:      uint32(in.ToIndex),
+		Qty:          uint32(in.Qty),
+		Preferences:  prefs,
+		KeepTogether: in.KeepTogether,
+	})
+	if err != nil {
+		return AllocateSeatResult{}, err
+	}
+	res := AllocateSeatResult{
+		SeatNos:            resp.GetSeatNos(),
+		PreferenceHonoured: resp.GetPreferenceHonoured(),
+		KeptTogether:       resp.GetKeptTogether(),
+	}
+	if len(res.SeatNos) == 0 {
+		// Allocators that predate qty answer with a single seat_no.
+		res.SeatNos = []string{resp.GetSeatNo()}
+	}
+	// Allocators that predate preferences honour none of them.
+	for i := len(res.PreferenceHonoured); i < len(res.SeatNos); i++ {
+		res.PreferenceHonoured = append(res.PreferenceHonoured, i >= len(in.Preferences) || in.Preferences[i] == domain.SeatPreferenceNone)
+	}
+	return res, nil
+}
+
+func (c *GRPCSeatAllocator) ReleaseSeat(ctx context.Context, in ReleaseSeatInput) ([]string, error) {
+	resp, err := c.client.ReleaseSeat(ctx, &seatallocatorv1.ReleaseSeatRequest{
+		OrderId:    in.OrderID,
+		TrainId:    in.TrainID,
+		TravelDate: in.TravelDate,
+		CoachType:  in.CoachType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetSeatNos(), nil
+}
+
+func (c *GRPCSeatAllocator) GetSeatMap(ctx context.Context, in SeatMapInput) ([]SeatState, error) {
+	resp, err := c.client.GetSeatMap(ctx, &seatallocatorv1.GetSeatMapRequest{
+		TrainId:    in.TrainID,
+		TravelDate: in.TravelDate,
+		CoachType:  in.CoachType,
 	})
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	out := make([]SeatState, 0, len(resp.GetSeats()))
+	for _, seat := range resp.GetSeats() {
+		out = append(out, SeatState{SeatNo: seat.GetSeatNo(), OccupiedSegments: seat.GetOccupiedSegments()})
+	}
+	return out, nil
+}
+
+func seatPreferenceToProto(p domain.SeatPreference) seatallocatorv1.SeatPreference {
+	switch p {
+	case domain.SeatPreferenceWindow:
+		return seatallocatorv1.SeatPreference_SEAT_PREFERENCE_WINDOW
+	case domain.SeatPreferenceAisle:
+		return seatallocatorv1.SeatPreference_SEAT_PREFERENCE_AISLE
 	}
-	return resp.GetSeatNo(), nil
+	return seatallocatorv1.SeatPreference_SEAT_PREFERENCE_UNSPECIFIED
 }
 
 func (c *GRPCSeatAllocator) Close() error {
@@ -0,0 +1,128 @@
+package parking
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// Event is an order event parked behind a held one.
+type Event struct {
+	ID      int64
+	Key     string
+	Payload []byte
+}
+
+// Repository holds event keys while an event of theirs waits on the retry
+// topic and parks their later events. Park and Release lock the hold row,
+// so an event is never parked under a hold that is being released.
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Hold marks key as having an event on the retry topic. Holding a held key
+// is a no-op.
+func (r *Repository) Hold(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO order_event_holds(event_key) VALUES(?)
+		 ON DUPLICATE KEY UPDATE event_key=event_key`,
+		key,
+	)
+	return err
+}
+
+// Park stores payload behind the held event of key. It returns false,
+// storing nothing, when key is not held.
+func (r *Repository) Park(ctx context.Context, key string, payload []byte) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	held, err := lockHoldTx(ctx, tx, key)
+	if err != nil || !held {
+		return false, err
+	}
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO order_event_parking(event_key, payload) VALUES(?, ?)`,
+		key, payload,
+	); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// Next returns the oldest parked event of key.
+func (r *Repository) Next(ctx context.Context, key string) (Event, bool, error) {
+	e := Event{Key: key}
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT id, payload FROM order_event_parking WHERE event_key=? ORDER BY id ASC LIMIT 1`,
+		key,
+	).Scan(&e.ID, &e.Payload)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Event{}, false, nil
+	}
+	if err != nil {
+		return Event{}, false, err
+	}
+	return e, true, nil
+}
+
+func (r *Repository) Remove(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM order_event_parking WHERE id=?`, id)
+	return err
+}
+
+// Release lifts the hold of key. It returns false, keeping the hold, when
+// events are still parked under it.
+func (r *Repository) Release(ctx context.Context, key string) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	held, err := lockHoldTx(ctx, tx, key)
+	if err != nil {
+		return false, err
+	}
+	if !held {
+		return true, nil
+	}
+	var parked int
+	if err := tx.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*) FROM order_event_parking WHERE event_key=?`,
+		key,
+	).Scan(&parked); err != nil {
+		return false, err
+	}
+	if parked > 0 {
+		return false, nil
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM order_event_holds WHERE event_key=?`, key); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func lockHoldTx(ctx context.Context, tx *sql.Tx, key string) (bool, error) {
+	var k string
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT event_key FROM order_event_holds WHERE event_key=? FOR UPDATE`,
+		key,
+	).Scan(&k)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
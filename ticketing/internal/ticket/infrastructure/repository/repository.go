@@ -4,9 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 
+	orderdomain "ticketing/internal/order/domain"
 	"ticketing/internal/ticket/domain"
 )
 
@@ -22,8 +24,19 @@ func (r *Repository) DB() *sql.DB {
 	return r.db
 }
 
+// IsOrderPaid reports whether the order is PAID, i.e. still waiting for its
+// ticket; a TICKETED order already has one.
+func (r *Repository) IsOrderPaid(ctx context.Context, orderID string) (bool, error) {
+	return scanPaid(r.db.QueryRowContext(ctx, `SELECT status FROM orders WHERE order_id=?`, orderID))
+}
+
+// IsOrderPaidTx is IsOrderPaid holding the order row until tx ends, so
+// two deliveries of the same event cannot both issue the ticket.
 func (r *Repository) IsOrderPaidTx(ctx context.Context, tx *sql.Tx, orderID string) (bool, error) {
-	row := tx.QueryRowContext(ctx, `SELECT status FROM orders WHERE order_id=?`, orderID)
+	return scanPaid(tx.QueryRowContext(ctx, `SELECT status FROM orders WHERE order_id=? FOR UPDATE`, orderID))
+}
+
+func scanPaid(row *sql.Row) (bool, error) {
 	var status string
 	if err := row.Scan(&status); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -31,32 +44,280 @@ func (r *Repository) IsOrderPaidTx(ctx context.Context, tx *sql.Tx, orderID stri
 		}
 		return false, err
 	}
-	return status == "PAID", nil
+	return orderdomain.Status(status) == orderdomain.StatusPaid, nil
 }
 
-func (r *Repository) InsertTicketTx(ctx context.Context, tx *sql.Tx, ticket domain.Ticket) (bool, error) {
-	_, err := tx.ExecContext(
+// FindItinerary returns the itinerary recorded on the order, or a zero
+// Itinerary when the order does not exist. Passengers are in the order
+// order-service lists them in.
+func (r *Repository) FindItinerary(ctx context.Context, orderID string) (domain.Itinerary, error) {
+	var it domain.Itinerary
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT partition_key, hold_qty, from_index, to_index, keep_together FROM orders WHERE order_id=?`,
+		orderID,
+	).Scan(&it.PartitionKey, &it.Qty, &it.FromIndex, &it.ToIndex, &it.KeepTogether)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Itinerary{}, nil
+	}
+	if err != nil {
+		return domain.Itinerary{}, err
+	}
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id_type, id_number, name, seat_preference FROM order_passengers
+		 WHERE order_id=? ORDER BY id_type ASC, id_number ASC`,
+		orderID,
+	)
+	if err != nil {
+		return domain.Itinerary{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var idType, idNumber, name, pref string
+		if err := rows.Scan(&idType, &idNumber, &name, &pref); err != nil {
+			return domain.Itinerary{}, err
+		}
+		it.Passengers = append(it.Passengers, domain.Passenger{
+			ID:             idType + ":" + idNumber,
+			Name:           name,
+			SeatPreference: domain.SeatPreference(pref),
+		})
+	}
+	return it, rows.Err()
+}
+
+// InsertTicketsTx stores the tickets of one order. It returns false when
+// the order already has a ticket for one of the passengers, in which case
+// tx must not be committed.
+func (r *Repository) InsertTicketsTx(ctx context.Context, tx *sql.Tx, tickets []domain.Ticket) (bool, error) {
+	for _, t := range tickets {
+		if err := insertTicketTx(ctx, tx, t, ""); err != nil {
+			var me *mysql.MySQLError
+			if errors.As(err, &me) && me.Number == 1062 {
+				return false, nil
+			}
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// ReissueTicketsTx voids the order's live tickets and stores their
+// replacements for a ticket change. It returns the voids, or false when the
+// change has already been applied.
+func (r *Repository) ReissueTicketsTx(ctx context.Context, tx *sql.Tx, orderID string, changeID string, tickets []domain.Ticket, at time.Time) ([]domain.TicketStatusChange, bool, error) {
+	rows, err := tx.QueryContext(
 		ctx,
-		`INSERT INTO tickets(ticket_id, order_id, passenger_name) VALUES(?, ?, ?)`,
-		ticket.TicketID, ticket.OrderID, ticket.PassengerName,
+		`SELECT ticket_id, passenger_id, status FROM tickets
+		 WHERE order_id=? AND change_id<>? AND status IN ('ISSUED', 'CHECKED_IN')
+		 FOR UPDATE`,
+		orderID, changeID,
 	)
-	if err == nil {
-		return true, nil
+	if err != nil {
+		return nil, false, err
+	}
+	var voids []domain.TicketStatusChange
+	for rows.Next() {
+		c := domain.TicketStatusChange{
+			OrderID:  orderID,
+			Event:    domain.TicketEventVoid,
+			ToStatus: domain.TicketStatusVoided,
+			Actor:    domain.TicketActorTicketWorker,
+			Reason:   "replaced by ticket change " + changeID,
+			At:       at,
+		}
+		if err := rows.Scan(&c.TicketID, &c.PassengerID, &c.FromStatus); err != nil {
+			rows.Close()
+			return nil, false, err
+		}
+		voids = append(voids, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(voids) == 0 {
+		return nil, false, err
+	}
+
+	for _, c := range voids {
+		if err := r.TransitionTicketTx(ctx, tx, c); err != nil {
+			return nil, false, err
+		}
 	}
-	var me *mysql.MySQLError
-	if errors.As(err, &me) && me.Number == 1062 {
-		return false, nil
+	for _, t := range tickets {
+		if err := insertTicketTx(ctx, tx, t, changeID); err != nil {
+			return nil, false, err
+		}
 	}
-	return false, err
+	return voids, true, nil
 }
 
-func (r *Repository) MarkOrderTicketedTx(ctx context.Context, tx *sql.Tx, orderID string) error {
+func insertTicketTx(ctx context.Context, tx *sql.Tx, t domain.Ticket, changeID string) error {
 	_, err := tx.ExecContext(
 		ctx,
-		`UPDATE orders SET status='TICKETED', updated_at=CURRENT_TIMESTAMP WHERE order_id=? AND status='PAID'`,
-		orderID,
+		`INSERT INTO tickets(ticket_id, order_id, passenger_id, passenger_name, train_id, travel_date,
+		   coach_no, seat_no, from_index, to_index, change_id)
+		 VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.TicketID, t.OrderID, t.PassengerID, t.PassengerName, t.TrainID, t.TravelDate,
+		t.CoachNo, t.SeatNo, t.FromIndex, t.ToIndex, changeID,
+	)
+	return err
+}
+
+// InsertETicketsTx stores the e-tickets of newly stored tickets.
+func (r *Repository) InsertETicketsTx(ctx context.Context, tx *sql.Tx, docs []domain.ETicket) error {
+	for _, d := range docs {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO ticket_documents(ticket_id, order_id, token, qr_png, pdf, expires_at) VALUES(?, ?, ?, ?, ?, ?)`,
+			d.TicketID, d.OrderID, d.Token, d.QRPNG, d.PDF, d.ExpiresAt.UTC(),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindETicket returns the e-ticket of a ticket and the ticket's status,
+// along with the user who owns its order. E-tickets of voided tickets are
+// kept.
+func (r *Repository) FindETicket(ctx context.Context, ticketID string) (domain.ETicket, string, error) {
+	var d domain.ETicket
+	var userID string
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT d.ticket_id, d.order_id, t.status, d.token, d.qr_png, d.pdf, d.expires_at, o.user_id
+		 FROM ticket_documents d
+		 JOIN tickets t ON t.ticket_id = d.ticket_id
+		 JOIN orders o ON o.order_id = d.order_id
+		 WHERE d.ticket_id=?`,
+		ticketID,
+	).Scan(&d.TicketID, &d.OrderID, &d.Status, &d.Token, &d.QRPNG, &d.PDF, &d.ExpiresAt, &userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ETicket{}, "", domain.ErrTicketNotFound
+	}
+	if err != nil {
+		return domain.ETicket{}, "", err
+	}
+	return d, userID, nil
+}
+
+// FindTicketTx locks the ticket and returns it along with the status of
+// its order.
+func (r *Repository) FindTicketTx(ctx context.Context, tx *sql.Tx, ticketID string) (domain.Ticket, string, error) {
+	var (
+		t           domain.Ticket
+		checkedInAt sql.NullTime
+		usedAt      sql.NullTime
+		orderStatus string
+	)
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT t.ticket_id, t.order_id, t.passenger_id, t.passenger_name, t.train_id, t.travel_date,
+		   t.coach_no, t.seat_no, t.from_index, t.to_index, t.status, t.checked_in_at, t.used_at, o.status
+		 FROM tickets t JOIN orders o ON o.order_id = t.order_id
+		 WHERE t.ticket_id=? FOR UPDATE`,
+		ticketID,
+	).Scan(
+		&t.TicketID, &t.OrderID, &t.PassengerID, &t.PassengerName, &t.TrainID, &t.TravelDate,
+		&t.CoachNo, &t.SeatNo, &t.FromIndex, &t.ToIndex, &t.Status, &checkedInAt, &usedAt, &orderStatus,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Ticket{}, "", domain.ErrTicketNotFound
+	}
+	if err != nil {
+		return domain.Ticket{}, "", err
+	}
+	t.CheckedInAt = checkedInAt.Time
+	t.UsedAt = usedAt.Time
+	return t, orderStatus, nil
+}
+
+// TransitionTicketTx moves a ticket from c.FromStatus to c.ToStatus and
+// records the change in ticket_status_history. It fails with
+// ErrInvalidTicketTransition when the ticket is no longer in c.FromStatus.
+func (r *Repository) TransitionTicketTx(ctx context.Context, tx *sql.Tx, c domain.TicketStatusChange) error {
+	var checkedInAt, usedAt any
+	switch c.ToStatus {
+	case domain.TicketStatusCheckedIn:
+		checkedInAt = c.At.UTC()
+	case domain.TicketStatusUsed:
+		usedAt = c.At.UTC()
+	}
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE tickets SET status=?, checked_in_at=COALESCE(?, checked_in_at), used_at=COALESCE(?, used_at)
+		 WHERE ticket_id=? AND status=?`,
+		c.ToStatus, checkedInAt, usedAt, c.TicketID, c.FromStatus,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrInvalidTicketTransition
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO ticket_status_history(ticket_id, order_id, event, from_status, to_status, actor, reason, station_index, created_at)
+		 VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.TicketID, c.OrderID, c.Event, c.FromStatus, c.ToStatus, c.Actor, c.Reason, c.StationIndex, c.At.UTC(),
 	)
 	return err
 }
 
+// IsChangeApplied reports whether the order's tickets already reflect the
+// change. The first issue of an order has an empty changeID.
+func (r *Repository) IsChangeApplied(ctx context.Context, orderID string, changeID string) (bool, error) {
+	var n int
+	if err := r.db.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*) FROM tickets WHERE order_id=? AND change_id=?`,
+		orderID, changeID,
+	).Scan(&n); err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
 
+// MarkOrderTicketedTx applies ISSUE_TICKET to a PAID order and records the
+// transition in order_status_history. The target status comes from the
+// order transition table.
+func (r *Repository) MarkOrderTicketedTx(ctx context.Context, tx *sql.Tx, orderID string, traceID string) error {
+	from := orderdomain.StatusPaid
+	to, err := orderdomain.NextStatus(from, orderdomain.EventIssueTicket)
+	if err != nil {
+		return err
+	}
+	t := orderdomain.StatusTransition{
+		OrderID:    orderID,
+		Event:      orderdomain.EventIssueTicket,
+		FromStatus: from,
+		ToStatus:   to,
+		Actor:      orderdomain.ActorTicketWorker,
+		Reason:     "ticket issued",
+		TraceID:    traceID,
+	}
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE orders SET status=?, updated_at=CURRENT_TIMESTAMP WHERE order_id=? AND status=?`,
+		string(t.ToStatus), t.OrderID, string(t.FromStatus),
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil || affected == 0 {
+		return err
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO order_status_history(order_id, event, from_status, to_status, actor, reason, trace_id)
+		 VALUES(?, ?, ?, ?, ?, ?, ?)`,
+		t.OrderID, string(t.Event), string(t.FromStatus), string(t.ToStatus), t.Actor, t.Reason, t.TraceID,
+	)
+	return err
+}
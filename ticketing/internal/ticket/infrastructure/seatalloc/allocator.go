@@ -0,0 +1,237 @@
+// Package seatalloc assigns seats in-process, as a Go counterpart of the C++
+// seat-allocator service.
+package seatalloc
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"ticketing/internal/ticket/domain"
+	grpcclient "ticketing/internal/ticket/infrastructure/grpc_client"
+)
+
+type store interface {
+	WithRouteLock(ctx context.Context, routeKey string, fn func(ctx context.Context, tx *sql.Tx, version int64) error) error
+	ListActiveTx(ctx context.Context, tx *sql.Tx, routeKey string) ([]domain.SeatAllocation, error)
+	InsertTx(ctx context.Context, tx *sql.Tx, a domain.SeatAllocation) error
+	ListOrderTx(ctx context.Context, tx *sql.Tx, routeKey string, orderID string) ([]domain.SeatAllocation, error)
+	ReleaseTx(ctx context.Context, tx *sql.Tx, routeKey string, orderID string) ([]domain.SeatAllocation, error)
+	ListActive(ctx context.Context, routeKey string) ([]domain.SeatAllocation, error)
+}
+
+// Allocator implements grpc_client.SeatAllocatorClient on top of MySQL.
+// Each route's seat map is cached together with the route version it was
+// built at and rebuilt from seat_allocations whenever another allocator
+// has changed the route since. Every train has the same number of coaches.
+//
+// Requests for one route are serialised by that route's lock, so routes do
+// not wait on each other; WithRouteLock keeps allocators in other processes
+// out.
+type Allocator struct {
+	store   store
+	coaches int
+	newID   func() string
+
+	mu     sync.Mutex
+	routes map[string]*routeState
+}
+
+// routeState is the cached seat map of a route. mu is held for the whole
+// route transaction; seats is nil until the map is (re)built.
+type routeState struct {
+	mu      sync.Mutex
+	version int64
+	seats   *domain.SeatMap
+}
+
+func NewAllocator(s store, coaches int) (*Allocator, error) {
+	if coaches <= 0 {
+		return nil, fmt.Errorf("seat allocator needs at least one coach, got %d", coaches)
+	}
+	return &Allocator{
+		store:   s,
+		coaches: coaches,
+		newID:   uuid.NewString,
+		routes:  make(map[string]*routeState),
+	}, nil
+}
+
+// AllocateSeat is idempotent by order: an order that already holds seats on
+// the route gets those seats back, whatever the request asks for.
+func (a *Allocator) AllocateSeat(ctx context.Context, in grpcclient.AllocateSeatInput) (grpcclient.AllocateSeatResult, error) {
+	layout, err := domain.LayoutFor(in.CoachType)
+	if err != nil {
+		return grpcclient.AllocateSeatResult{}, err
+	}
+	mask, err := domain.SegmentMask(in.FromIndex, in.ToIndex)
+	if err != nil {
+		return grpcclient.AllocateSeatResult{}, err
+	}
+	prefs := make([]domain.SeatPreference, max(in.Qty, 1))
+	copy(prefs, in.Preferences)
+	key := domain.SeatRouteKey(in.TrainID, in.TravelDate, in.CoachType)
+
+	state := a.route(key)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	var res grpcclient.AllocateSeatResult
+	err = a.store.WithRouteLock(ctx, key, func(ctx context.Context, tx *sql.Tx, version int64) error {
+		if err := a.loadLocked(ctx, tx, state, key, layout, version); err != nil {
+			return err
+		}
+		held, err := a.store.ListOrderTx(ctx, tx, key, in.OrderID)
+		if err != nil {
+			return err
+		}
+		if len(held) > 0 {
+			res = heldSeats(state.seats, held, prefs)
+			return nil
+		}
+		group, err := state.seats.AllocateGroup(mask, prefs, in.KeepTogether)
+		if err != nil {
+			return err
+		}
+		res = grpcclient.AllocateSeatResult{KeptTogether: group.KeptTogether}
+		for _, assigned := range group.Seats {
+			if err := a.store.InsertTx(ctx, tx, domain.SeatAllocation{
+				AllocationID: a.newID(),
+				RouteKey:     key,
+				OrderID:      in.OrderID,
+				Seat:         assigned.Seat,
+				FromIndex:    in.FromIndex,
+				ToIndex:      in.ToIndex,
+				SegmentMask:  mask,
+			}); err != nil {
+				return err
+			}
+			res.SeatNos = append(res.SeatNos, assigned.Seat.Label())
+			res.PreferenceHonoured = append(res.PreferenceHonoured, assigned.PreferenceHonoured)
+		}
+		return nil
+	})
+	if err != nil {
+		// The cached map may hold seats that were never stored.
+		state.seats = nil
+		return grpcclient.AllocateSeatResult{}, err
+	}
+	state.version++
+	return res, nil
+}
+
+// heldSeats reports seats an order already holds as if just allocated.
+func heldSeats(seats *domain.SeatMap, held []domain.SeatAllocation, prefs []domain.SeatPreference) grpcclient.AllocateSeatResult {
+	positions := make([]domain.SeatPosition, 0, len(held))
+	res := grpcclient.AllocateSeatResult{}
+	for i, alloc := range held {
+		positions = append(positions, alloc.Seat)
+		res.SeatNos = append(res.SeatNos, alloc.Seat.Label())
+		res.PreferenceHonoured = append(res.PreferenceHonoured, i >= len(prefs) || prefs[i].Satisfies(alloc.Seat.Letter))
+	}
+	res.KeptTogether = seats.Adjacent(positions)
+	return res
+}
+
+func (a *Allocator) ReleaseSeat(ctx context.Context, in grpcclient.ReleaseSeatInput) ([]string, error) {
+	layout, err := domain.LayoutFor(in.CoachType)
+	if err != nil {
+		return nil, err
+	}
+	key := domain.SeatRouteKey(in.TrainID, in.TravelDate, in.CoachType)
+
+	state := a.route(key)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	var seatNos []string
+	err = a.store.WithRouteLock(ctx, key, func(ctx context.Context, tx *sql.Tx, version int64) error {
+		if err := a.loadLocked(ctx, tx, state, key, layout, version); err != nil {
+			return err
+		}
+		released, err := a.store.ReleaseTx(ctx, tx, key, in.OrderID)
+		if err != nil {
+			return err
+		}
+		for _, alloc := range released {
+			if err := state.seats.Release(alloc.Seat, alloc.SegmentMask); err != nil {
+				return fmt.Errorf("release allocation %s: %w", alloc.AllocationID, err)
+			}
+			seatNos = append(seatNos, alloc.Seat.Label())
+		}
+		return nil
+	})
+	if err != nil {
+		// The cached map may have freed seats that are still stored.
+		state.seats = nil
+		return nil, err
+	}
+	state.version++
+	return seatNos, nil
+}
+
+// GetSeatMap builds the seat map from the stored allocations rather than
+// the cache, which may be behind other allocators.
+func (a *Allocator) GetSeatMap(ctx context.Context, in grpcclient.SeatMapInput) ([]grpcclient.SeatState, error) {
+	layout, err := domain.LayoutFor(in.CoachType)
+	if err != nil {
+		return nil, err
+	}
+	key := domain.SeatRouteKey(in.TrainID, in.TravelDate, in.CoachType)
+	allocations, err := a.store.ListActive(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	seats, err := a.buildSeatMap(key, layout, allocations)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]grpcclient.SeatState, 0, a.coaches*layout.SeatsPerCoach())
+	for _, seat := range seats.Seats() {
+		out = append(out, grpcclient.SeatState{SeatNo: seat.Seat.Label(), OccupiedSegments: seat.Occupied})
+	}
+	return out, nil
+}
+
+// route returns the state of the route, creating it on first use.
+func (a *Allocator) route(key string) *routeState {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	state, ok := a.routes[key]
+	if !ok {
+		state = &routeState{}
+		a.routes[key] = state
+	}
+	return state
+}
+
+// loadLocked keeps the cached seat map of the route if it is current and
+// rebuilds it from the stored allocations otherwise. state.mu must be held.
+func (a *Allocator) loadLocked(ctx context.Context, tx *sql.Tx, state *routeState, key string, layout domain.CoachLayout, version int64) error {
+	if state.seats != nil && state.version == version {
+		return nil
+	}
+	allocations, err := a.store.ListActiveTx(ctx, tx, key)
+	if err != nil {
+		return err
+	}
+	seats, err := a.buildSeatMap(key, layout, allocations)
+	if err != nil {
+		return err
+	}
+	state.version, state.seats = version, seats
+	return nil
+}
+
+func (a *Allocator) buildSeatMap(key string, layout domain.CoachLayout, allocations []domain.SeatAllocation) (*domain.SeatMap, error) {
+	seats := domain.NewSeatMap(layout, a.coaches)
+	for _, alloc := range allocations {
+		if err := seats.Occupy(alloc.Seat, alloc.SegmentMask); err != nil {
+			return nil, fmt.Errorf("rebuild seat map for %s: allocation %s: %w", key, alloc.AllocationID, err)
+		}
+	}
+	return seats, nil
+}
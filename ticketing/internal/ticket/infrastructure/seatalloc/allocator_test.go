@@ -0,0 +1,346 @@
+package seatalloc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ticketing/internal/ticket/domain"
+	grpcclient "ticketing/internal/ticket/infrastructure/grpc_client"
+)
+
+// fakeStore keeps allocations in memory with the versioning contract of
+// Repository.WithRouteLock.
+type fakeStore struct {
+	versions    map[string]int64
+	allocations []domain.SeatAllocation
+	lists       int
+	failInsert  error
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{versions: make(map[string]int64)}
+}
+
+func (s *fakeStore) WithRouteLock(ctx context.Context, routeKey string, fn func(ctx context.Context, tx *sql.Tx, version int64) error) error {
+	saved := len(s.allocations)
+	if err := fn(ctx, nil, s.versions[routeKey]); err != nil {
+		s.allocations = s.allocations[:saved]
+		return err
+	}
+	s.versions[routeKey]++
+	return nil
+}
+
+func (s *fakeStore) ListActiveTx(ctx context.Context, tx *sql.Tx, routeKey string) ([]domain.SeatAllocation, error) {
+	s.lists++
+	out := make([]domain.SeatAllocation, 0)
+	for _, a := range s.allocations {
+		if a.RouteKey == routeKey {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeStore) InsertTx(ctx context.Context, tx *sql.Tx, a domain.SeatAllocation) error {
+	if s.failInsert != nil {
+		return s.failInsert
+	}
+	s.allocations = append(s.allocations, a)
+	return nil
+}
+
+func (s *fakeStore) ReleaseTx(ctx context.Context, tx *sql.Tx, routeKey string, orderID string) ([]domain.SeatAllocation, error) {
+	var kept, released []domain.SeatAllocation
+	for _, a := range s.allocations {
+		if a.RouteKey == routeKey && a.OrderID == orderID {
+			released = append(released, a)
+			continue
+		}
+		kept = append(kept, a)
+	}
+	s.allocations = kept
+	return released, nil
+}
+
+func (s *fakeStore) ListOrderTx(ctx context.Context, tx *sql.Tx, routeKey string, orderID string) ([]domain.SeatAllocation, error) {
+	out := make([]domain.SeatAllocation, 0)
+	for _, a := range s.allocations {
+		if a.RouteKey == routeKey && a.OrderID == orderID {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeStore) ListActive(ctx context.Context, routeKey string) ([]domain.SeatAllocation, error) {
+	return s.ListActiveTx(ctx, nil, routeKey)
+}
+
+func trip(orderID string, from, to, qty int) grpcclient.AllocateSeatInput {
+	return grpcclient.AllocateSeatInput{
+		OrderID:    orderID,
+		TrainID:    "G123",
+		TravelDate: "2026-02-11",
+		CoachType:  "business",
+		FromIndex:  from,
+		ToIndex:    to,
+		Qty:        qty,
+	}
+}
+
+func newTestAllocator(t *testing.T, s store) *Allocator {
+	t.Helper()
+	a, err := NewAllocator(s, 1)
+	if err != nil {
+		t.Fatalf("allocator init failed: %v", err)
+	}
+	n := 0
+	a.newID = func() string {
+		n++
+		return fmt.Sprintf("alloc-%d", n)
+	}
+	return a
+}
+
+func allocate(t *testing.T, a *Allocator, in grpcclient.AllocateSeatInput, want ...string) {
+	t.Helper()
+	got, err := a.AllocateSeat(context.Background(), in)
+	if err != nil || strings.Join(got.SeatNos, ",") != strings.Join(want, ",") {
+		t.Fatalf("%s: expected seats %v, got %v (%v)", in.OrderID, want, got, err)
+	}
+}
+
+func TestAllocator_PersistsAcrossRestart(t *testing.T) {
+	t.Parallel()
+
+	s := newFakeStore()
+	first := newTestAllocator(t, s)
+	allocate(t, first, trip("o1", 1, 3, 1), "01-01A")
+	allocate(t, first, trip("o2", 1, 3, 1), "01-01C")
+	if s.lists != 1 {
+		t.Fatalf("expected the seat map to be loaded once, got %d loads", s.lists)
+	}
+
+	restarted := newTestAllocator(t, s)
+	allocate(t, restarted, trip("o3", 1, 3, 1), "01-01F")
+	if got := s.allocations[2]; got.OrderID != "o3" || got.SegmentMask != 0b110 || got.Seat.Label() != "01-01F" {
+		t.Fatalf("unexpected stored allocation %+v", got)
+	}
+}
+
+func TestAllocator_ReloadsAfterOtherAllocator(t *testing.T) {
+	t.Parallel()
+
+	s := newFakeStore()
+	a := newTestAllocator(t, s)
+	b := newTestAllocator(t, s)
+
+	allocate(t, a, trip("o1", 0, 4, 1), "01-01A")
+	allocate(t, b, trip("o2", 2, 6, 1), "01-01C")
+	allocate(t, a, trip("o3", 0, 4, 1), "01-01F")
+	allocate(t, b, trip("o4", 4, 6, 1), "01-01A")
+}
+
+func TestAllocator_RoutesAreIndependent(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAllocator(t, newFakeStore())
+	allocate(t, a, trip("o1", 0, 4, 1), "01-01A")
+	other := trip("o2", 0, 4, 1)
+	other.TrainID = "G7"
+	allocate(t, a, other, "01-01A")
+	second := trip("o3", 0, 4, 1)
+	second.CoachType = "2nd"
+	allocate(t, a, second, "01-01A")
+}
+
+// blockingStore holds transactions on one route until release is closed.
+type blockingStore struct {
+	*fakeStore
+	routeKey string
+	entered  chan struct{}
+	release  chan struct{}
+}
+
+func (s *blockingStore) WithRouteLock(ctx context.Context, routeKey string, fn func(ctx context.Context, tx *sql.Tx, version int64) error) error {
+	if routeKey == s.routeKey {
+		close(s.entered)
+		<-s.release
+	}
+	return s.fakeStore.WithRouteLock(ctx, routeKey, fn)
+}
+
+func TestAllocator_RoutesDoNotWaitForEachOther(t *testing.T) {
+	t.Parallel()
+
+	blocked := trip("o1", 0, 4, 1)
+	s := &blockingStore{
+		fakeStore: newFakeStore(),
+		routeKey:  domain.SeatRouteKey(blocked.TrainID, blocked.TravelDate, blocked.CoachType),
+		entered:   make(chan struct{}),
+		release:   make(chan struct{}),
+	}
+	a := newTestAllocator(t, s)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := a.AllocateSeat(context.Background(), blocked)
+		done <- err
+	}()
+	<-s.entered
+
+	other := trip("o2", 0, 4, 1)
+	other.TrainID = "G7"
+	allocate(t, a, other, "01-01A")
+
+	close(s.release)
+	if err := <-done; err != nil {
+		t.Fatalf("expected the blocked route to allocate, got: %v", err)
+	}
+}
+
+func TestAllocator_AllocatesAllSeatsOrNone(t *testing.T) {
+	t.Parallel()
+
+	s := newFakeStore()
+	a := newTestAllocator(t, s)
+	allocate(t, a, trip("o1", 0, 1, 3), "01-01A", "01-01C", "01-01F")
+
+	if _, err := a.AllocateSeat(context.Background(), trip("o2", 0, 1, 22)); !errors.Is(err, domain.ErrNoSeatAvailable) {
+		t.Fatalf("expected ErrNoSeatAvailable, got %v", err)
+	}
+	if len(s.allocations) != 3 {
+		t.Fatalf("expected a failed allocation to store nothing, got %d allocations", len(s.allocations))
+	}
+	got, err := a.AllocateSeat(context.Background(), trip("o3", 0, 1, 21))
+	if err != nil || len(got.SeatNos) != 21 || got.SeatNos[0] != "01-02A" {
+		t.Fatalf("expected the seats of the failed order to be free, got %v (%v)", got, err)
+	}
+}
+
+func TestAllocator_ReportsPreferences(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAllocator(t, newFakeStore())
+	in := trip("o1", 0, 2, 3)
+	in.Preferences = []domain.SeatPreference{domain.SeatPreferenceAisle, domain.SeatPreferenceWindow}
+	in.KeepTogether = true
+	got, err := a.AllocateSeat(context.Background(), in)
+	if err != nil {
+		t.Fatalf("allocate failed: %v", err)
+	}
+	if strings.Join(got.SeatNos, ",") != "01-01C,01-01A,01-01F" || !got.KeptTogether {
+		t.Fatalf("unexpected seats %+v", got)
+	}
+	for i, ok := range got.PreferenceHonoured {
+		if !ok {
+			t.Fatalf("expected preference %d to be honoured, got %+v", i, got)
+		}
+	}
+}
+
+func TestAllocator_FailuresDoNotLeakSeats(t *testing.T) {
+	t.Parallel()
+
+	s := newFakeStore()
+	a := newTestAllocator(t, s)
+	s.failInsert = errors.New("db down")
+	if _, err := a.AllocateSeat(context.Background(), trip("o1", 0, 1, 1)); err == nil {
+		t.Fatalf("expected the insert failure to surface")
+	}
+	s.failInsert = nil
+	allocate(t, a, trip("o2", 0, 1, 1), "01-01A")
+}
+
+func TestAllocator_ValidatesInput(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewAllocator(newFakeStore(), 0); err == nil {
+		t.Fatalf("expected an allocator without coaches to be rejected")
+	}
+	a := newTestAllocator(t, newFakeStore())
+	sleeper := trip("o1", 0, 1, 1)
+	sleeper.CoachType = "sleeper"
+	if _, err := a.AllocateSeat(context.Background(), sleeper); !errors.Is(err, domain.ErrUnknownCoachType) {
+		t.Fatalf("expected ErrUnknownCoachType, got %v", err)
+	}
+	if _, err := a.AllocateSeat(context.Background(), trip("o1", 3, 3, 1)); !errors.Is(err, domain.ErrInvalidSegment) {
+		t.Fatalf("expected ErrInvalidSegment, got %v", err)
+	}
+}
+
+func TestAllocator_ReleaseFreesSeats(t *testing.T) {
+	t.Parallel()
+
+	s := newFakeStore()
+	a := newTestAllocator(t, s)
+	b := newTestAllocator(t, s)
+	allocate(t, a, trip("o1", 0, 4, 2), "01-01A", "01-01C")
+	allocate(t, a, trip("o2", 0, 4, 1), "01-01F")
+
+	release := grpcclient.ReleaseSeatInput{OrderID: "o1", TrainID: "G123", TravelDate: "2026-02-11", CoachType: "business"}
+	got, err := a.ReleaseSeat(context.Background(), release)
+	if err != nil || strings.Join(got, ",") != "01-01A,01-01C" {
+		t.Fatalf("expected o1's seats to be released, got %v (%v)", got, err)
+	}
+	if got, err := a.ReleaseSeat(context.Background(), release); err != nil || len(got) != 0 {
+		t.Fatalf("expected a second release to free nothing, got %v (%v)", got, err)
+	}
+
+	allocate(t, a, trip("o3", 0, 2, 1), "01-01A")
+	allocate(t, b, trip("o4", 0, 4, 1), "01-01C")
+}
+
+func TestAllocator_GetSeatMap(t *testing.T) {
+	t.Parallel()
+
+	s := newFakeStore()
+	a := newTestAllocator(t, s)
+	allocate(t, a, trip("o1", 1, 3, 1), "01-01A")
+	allocate(t, newTestAllocator(t, s), trip("o2", 0, 1, 1), "01-01A")
+
+	seats, err := a.GetSeatMap(context.Background(), grpcclient.SeatMapInput{TrainID: "G123", TravelDate: "2026-02-11", CoachType: "business"})
+	if err != nil {
+		t.Fatalf("seat map failed: %v", err)
+	}
+	if len(seats) != 24 {
+		t.Fatalf("expected every business seat of one coach, got %d", len(seats))
+	}
+	if seats[0] != (grpcclient.SeatState{SeatNo: "01-01A", OccupiedSegments: 0b111}) || seats[1] != (grpcclient.SeatState{SeatNo: "01-01C"}) {
+		t.Fatalf("unexpected seat states %+v", seats[:2])
+	}
+}
+
+func TestAllocator_IdempotentByOrder(t *testing.T) {
+	t.Parallel()
+
+	s := newFakeStore()
+	a := newTestAllocator(t, s)
+	allocate(t, a, trip("o1", 0, 2, 2), "01-01A", "01-01C")
+	allocate(t, a, trip("o2", 0, 2, 1), "01-01F")
+
+	again := trip("o1", 0, 2, 2)
+	again.Preferences = []domain.SeatPreference{domain.SeatPreferenceWindow, domain.SeatPreferenceWindow}
+	got, err := newTestAllocator(t, s).AllocateSeat(context.Background(), again)
+	if err != nil || strings.Join(got.SeatNos, ",") != "01-01A,01-01C" || !got.KeptTogether {
+		t.Fatalf("expected o1 to get its seats back, got %+v (%v)", got, err)
+	}
+	if !reflect.DeepEqual(got.PreferenceHonoured, []bool{true, false}) {
+		t.Fatalf("expected preferences to be judged against the held seats, got %v", got.PreferenceHonoured)
+	}
+	if len(s.allocations) != 3 {
+		t.Fatalf("expected a repeated allocation to store nothing, got %d allocations", len(s.allocations))
+	}
+
+	release := grpcclient.ReleaseSeatInput{OrderID: "o1", TrainID: "G123", TravelDate: "2026-02-11", CoachType: "business"}
+	if _, err := a.ReleaseSeat(context.Background(), release); err != nil {
+		t.Fatalf("release failed: %v", err)
+	}
+	allocate(t, a, trip("o1", 2, 4, 1), "01-01A")
+}
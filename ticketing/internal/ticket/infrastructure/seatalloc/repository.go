@@ -0,0 +1,170 @@
+package seatalloc
+
+import (
+	"context"
+	"database/sql"
+
+	"ticketing/internal/ticket/domain"
+)
+
+const allocationColumns = `allocation_id, route_key, order_id, coach_no, seat_row, seat_letter, from_index, to_index, segment_mask, created_at`
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// WithRouteLock runs fn in a transaction holding the route's seat_routes
+// row, passing the route version read under the lock. When fn succeeds the
+// version is bumped and the transaction committed, so the route's version
+// after a successful call is always version+1.
+func (r *Repository) WithRouteLock(ctx context.Context, routeKey string, fn func(ctx context.Context, tx *sql.Tx, version int64) error) error {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `INSERT IGNORE INTO seat_routes(route_key) VALUES(?)`, routeKey); err != nil {
+		return err
+	}
+	var version int64
+	if err := tx.QueryRowContext(
+		ctx,
+		`SELECT version FROM seat_routes WHERE route_key=? FOR UPDATE`,
+		routeKey,
+	).Scan(&version); err != nil {
+		return err
+	}
+	if err := fn(ctx, tx, version); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE seat_routes SET version=version+1 WHERE route_key=?`, routeKey); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *Repository) ListActiveTx(ctx context.Context, tx *sql.Tx, routeKey string) ([]domain.SeatAllocation, error) {
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT `+allocationColumns+`
+		 FROM seat_allocations
+		 WHERE route_key=? AND status='ACTIVE'
+		 ORDER BY id ASC`,
+		routeKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanAllocations(rows)
+}
+
+// ListOrderTx returns the order's active allocations on the route in the
+// order they were made, which is passenger order.
+func (r *Repository) ListOrderTx(ctx context.Context, tx *sql.Tx, routeKey string, orderID string) ([]domain.SeatAllocation, error) {
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT `+allocationColumns+`
+		 FROM seat_allocations
+		 WHERE route_key=? AND order_id=? AND status='ACTIVE'
+		 ORDER BY id ASC`,
+		routeKey, orderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanAllocations(rows)
+}
+
+// ListActive reads the route's allocations without taking the route lock,
+// for callers that only look at the seat map.
+func (r *Repository) ListActive(ctx context.Context, routeKey string) ([]domain.SeatAllocation, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+allocationColumns+`
+		 FROM seat_allocations
+		 WHERE route_key=? AND status='ACTIVE'
+		 ORDER BY id ASC`,
+		routeKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanAllocations(rows)
+}
+
+// ReleaseTx marks the order's active allocations on the route RELEASED and
+// returns them.
+func (r *Repository) ReleaseTx(ctx context.Context, tx *sql.Tx, routeKey string, orderID string) ([]domain.SeatAllocation, error) {
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT `+allocationColumns+`
+		 FROM seat_allocations
+		 WHERE route_key=? AND order_id=? AND status='ACTIVE'
+		 ORDER BY id ASC
+		 FOR UPDATE`,
+		routeKey, orderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	released, err := scanAllocations(rows)
+	if err != nil || len(released) == 0 {
+		return released, err
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE seat_allocations SET status='RELEASED', released_at=CURRENT_TIMESTAMP
+		 WHERE route_key=? AND order_id=? AND status='ACTIVE'`,
+		routeKey, orderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return released, nil
+}
+
+func (r *Repository) InsertTx(ctx context.Context, tx *sql.Tx, a domain.SeatAllocation) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO seat_allocations(allocation_id, route_key, order_id, coach_no, seat_row, seat_letter, from_index, to_index, segment_mask, status)
+		 VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, 'ACTIVE')`,
+		a.AllocationID, a.RouteKey, a.OrderID, a.Seat.Coach, a.Seat.Row, string(a.Seat.Letter), a.FromIndex, a.ToIndex, a.SegmentMask,
+	)
+	return err
+}
+
+func scanAllocations(rows *sql.Rows) ([]domain.SeatAllocation, error) {
+	defer rows.Close()
+
+	out := make([]domain.SeatAllocation, 0)
+	for rows.Next() {
+		var (
+			a      domain.SeatAllocation
+			letter string
+		)
+		if err := rows.Scan(
+			&a.AllocationID,
+			&a.RouteKey,
+			&a.OrderID,
+			&a.Seat.Coach,
+			&a.Seat.Row,
+			&letter,
+			&a.FromIndex,
+			&a.ToIndex,
+			&a.SegmentMask,
+			&a.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if letter != "" {
+			a.Seat.Letter = letter[0]
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,214 @@
+package http
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ticketing/internal/ticket/application"
+	"ticketing/internal/ticket/domain"
+)
+
+type Handler struct {
+	tickets        *application.TicketService
+	publicKey      ed25519.PublicKey
+	requireAuth    gin.HandlerFunc
+	requireVerify  gin.HandlerFunc
+	requireSupport gin.HandlerFunc
+}
+
+// NewHandler serves e-tickets and their lifecycle. requireVerify guards
+// gate scans, which only gate staff may make as they change the ticket;
+// requireSupport guards voids and refunds.
+func NewHandler(
+	tickets *application.TicketService,
+	publicKey ed25519.PublicKey,
+	requireAuth gin.HandlerFunc,
+	requireVerify gin.HandlerFunc,
+	requireSupport gin.HandlerFunc,
+) *Handler {
+	return &Handler{
+		tickets:        tickets,
+		publicKey:      publicKey,
+		requireAuth:    requireAuth,
+		requireVerify:  requireVerify,
+		requireSupport: requireSupport,
+	}
+}
+
+func (h *Handler) Register(r *gin.Engine) {
+	r.GET("/tickets/:ticket_id/eticket", h.requireAuth, h.getETicket)
+	r.POST("/tickets/verify", h.requireAuth, h.requireVerify, h.verifyETicket)
+	r.POST("/tickets/check-in", h.requireAuth, h.requireVerify, h.checkIn)
+	r.POST("/tickets/:ticket_id/void", h.requireAuth, h.requireSupport, h.updateStatus(domain.TicketEventVoid))
+	r.POST("/tickets/:ticket_id/refund", h.requireAuth, h.requireSupport, h.updateStatus(domain.TicketEventRefund))
+	r.GET("/eticket/public-key", h.getPublicKey)
+}
+
+// scanRequest is a gate scan: the token and where the gate stands.
+type scanRequest struct {
+	Token        string `json:"token"`
+	TrainID      string `json:"train_id"`
+	TravelDate   string `json:"travel_date"`
+	StationIndex *int   `json:"station_index"`
+}
+
+type updateStatusRequest struct {
+	Reason string `json:"reason"`
+}
+
+// getETicket returns the e-ticket as JSON, or with format=qr or format=pdf
+// as the QR code PNG or the PDF itinerary.
+func (h *Handler) getETicket(c *gin.Context) {
+	doc, err := h.tickets.Get(c.Request.Context(), c.Param("ticket_id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrTicketNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(c, status, err.Error())
+		return
+	}
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		writeJSON(c, http.StatusOK, gin.H{
+			"ticket_id":  doc.TicketID,
+			"order_id":   doc.OrderID,
+			"status":     doc.Status,
+			"token":      doc.Token,
+			"expires_at": doc.ExpiresAt.UTC().Format(time.RFC3339),
+		})
+	case "qr":
+		c.Data(http.StatusOK, "image/png", doc.QRPNG)
+	case "pdf":
+		c.Header("Content-Disposition", `inline; filename="eticket-`+doc.TicketID+`.pdf"`)
+		c.Data(http.StatusOK, "application/pdf", doc.PDF)
+	default:
+		writeError(c, http.StatusBadRequest, "format must be json, qr or pdf")
+	}
+}
+
+func (h *Handler) verifyETicket(c *gin.Context) {
+	in, ok := bindScan(c)
+	if !ok {
+		return
+	}
+	res, err := h.tickets.Verify(c.Request.Context(), in)
+	if err != nil {
+		writeScanError(c, res, err)
+		return
+	}
+	writeJSON(c, http.StatusOK, verifyBody(res, "VALID"))
+}
+
+// checkIn admits a passenger through the entrance gate. Scanning a ticket
+// that is already checked in succeeds again so gates can retry.
+func (h *Handler) checkIn(c *gin.Context) {
+	in, ok := bindScan(c)
+	if !ok {
+		return
+	}
+	res, err := h.tickets.CheckIn(c.Request.Context(), in)
+	if err != nil {
+		writeScanError(c, res, err)
+		return
+	}
+	writeJSON(c, http.StatusOK, verifyBody(res, "CHECKED_IN"))
+}
+
+func bindScan(c *gin.Context) (application.ScanInput, bool) {
+	var req scanRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Token == "" || req.TrainID == "" || req.TravelDate == "" || req.StationIndex == nil {
+		writeError(c, http.StatusBadRequest, "token, train_id, travel_date and station_index are required")
+		return application.ScanInput{}, false
+	}
+	return application.ScanInput{
+		Token:        req.Token,
+		TrainID:      req.TrainID,
+		TravelDate:   req.TravelDate,
+		StationIndex: *req.StationIndex,
+	}, true
+}
+
+func (h *Handler) updateStatus(event domain.TicketEvent) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req updateStatusRequest
+		if err := c.ShouldBindJSON(&req); err != nil || req.Reason == "" {
+			writeError(c, http.StatusBadRequest, "reason is required")
+			return
+		}
+		t, err := h.tickets.UpdateStatus(c.Request.Context(), c.Param("ticket_id"), event, req.Reason)
+		switch {
+		case err == nil:
+			writeJSON(c, http.StatusOK, gin.H{"ticket_id": t.TicketID, "order_id": t.OrderID, "status": t.Status})
+		case errors.Is(err, domain.ErrTicketNotFound):
+			writeError(c, http.StatusNotFound, err.Error())
+		case errors.Is(err, domain.ErrInvalidTicketTransition):
+			writeError(c, http.StatusConflict, err.Error())
+		default:
+			writeError(c, http.StatusInternalServerError, err.Error())
+		}
+	}
+}
+
+func writeScanError(c *gin.Context, res application.ScanResult, err error) {
+	switch {
+	case errors.Is(err, domain.ErrTicketMismatch):
+		writeJSON(c, http.StatusUnprocessableEntity, verifyBody(res, "MISMATCH"))
+	case errors.Is(err, domain.ErrTicketUsed):
+		writeJSON(c, http.StatusConflict, verifyBody(res, "ALREADY_USED"))
+	case errors.Is(err, domain.ErrTicketNotValid):
+		writeJSON(c, http.StatusConflict, verifyBody(res, "NOT_VALID"))
+	case errors.Is(err, domain.ErrETicketExpired):
+		writeJSON(c, http.StatusGone, verifyBody(res, "EXPIRED"))
+	case errors.Is(err, domain.ErrInvalidETicket):
+		writeError(c, http.StatusBadRequest, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// getPublicKey lets gate tooling check tokens offline.
+func (h *Handler) getPublicKey(c *gin.Context) {
+	writeJSON(c, http.StatusOK, gin.H{
+		"algorithm":  "Ed25519",
+		"public_key": base64.StdEncoding.EncodeToString(h.publicKey),
+	})
+}
+
+func verifyBody(res application.ScanResult, result string) gin.H {
+	body := gin.H{
+		"result":         result,
+		"status":         res.Status,
+		"ticket_id":      res.Claims.TicketID,
+		"order_id":       res.Claims.OrderID,
+		"passenger_id":   res.Claims.PassengerID,
+		"passenger_name": res.Claims.PassengerName,
+		"train_id":       res.Claims.TrainID,
+		"travel_date":    res.Claims.TravelDate,
+		"coach_no":       res.Claims.CoachNo,
+		"seat_no":        res.Claims.SeatNo,
+		"from_index":     res.Claims.FromIndex,
+		"to_index":       res.Claims.ToIndex,
+		"expires_at":     res.Claims.ExpiresAt.Format(time.RFC3339),
+	}
+	if !res.CheckedInAt.IsZero() {
+		body["checked_in_at"] = res.CheckedInAt.Format(time.RFC3339)
+	}
+	if !res.UsedAt.IsZero() {
+		body["used_at"] = res.UsedAt.Format(time.RFC3339)
+	}
+	return body
+}
+
+func writeJSON(c *gin.Context, status int, body any) {
+	c.JSON(status, body)
+}
+
+func writeError(c *gin.Context, status int, message string) {
+	writeJSON(c, status, map[string]string{"error": message})
+}
@@ -0,0 +1,118 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"ticketing/internal/common/auth"
+	"ticketing/internal/user/domain"
+	"ticketing/internal/user/infrastructure/repository"
+)
+
+type Service struct {
+	logger *slog.Logger
+	repo   *repository.Repository
+	tokens *auth.TokenManager
+}
+
+type RegisterInput struct {
+	Username string
+	Password string
+}
+
+type LoginInput struct {
+	Username string
+	Password string
+}
+
+type LoginResult struct {
+	Token     string
+	ExpiresAt time.Time
+	User      *domain.User
+}
+
+type AddPassengerInput struct {
+	UserID   string
+	Name     string
+	IDType   string
+	IDNumber string
+	Phone    string
+}
+
+func NewService(logger *slog.Logger, repo *repository.Repository, tokens *auth.TokenManager) *Service {
+	return &Service{logger: logger, repo: repo, tokens: tokens}
+}
+
+func (s *Service) Register(ctx context.Context, in RegisterInput) (*domain.User, error) {
+	username := strings.TrimSpace(in.Username)
+	if err := domain.ValidateUsername(username); err != nil {
+		return nil, err
+	}
+	if err := domain.ValidatePassword(in.Password); err != nil {
+		return nil, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(in.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("hash password failed: %w", err)
+	}
+	u := &domain.User{
+		UserID:       uuid.NewString(),
+		Username:     username,
+		PasswordHash: string(hash),
+		Role:         auth.RoleCustomer,
+	}
+	if err := s.repo.InsertUser(ctx, u); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(ctx, u.UserID)
+}
+
+// Login never tells an unknown username apart from a wrong password.
+func (s *Service) Login(ctx context.Context, in LoginInput) (*LoginResult, error) {
+	u, err := s.repo.FindByUsername(ctx, strings.TrimSpace(in.Username))
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, domain.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(in.Password)); err != nil {
+		return nil, domain.ErrInvalidCredentials
+	}
+	token, expiresAt, err := s.tokens.Issue(u.UserID, u.Role)
+	if err != nil {
+		return nil, fmt.Errorf("issue token failed: %w", err)
+	}
+	return &LoginResult{Token: token, ExpiresAt: expiresAt, User: u}, nil
+}
+
+func (s *Service) GetUser(ctx context.Context, userID string) (*domain.User, error) {
+	return s.repo.FindByID(ctx, userID)
+}
+
+func (s *Service) ListPassengers(ctx context.Context, userID string) ([]*domain.Passenger, error) {
+	return s.repo.ListPassengers(ctx, userID)
+}
+
+func (s *Service) AddPassenger(ctx context.Context, in AddPassengerInput) (*domain.Passenger, error) {
+	p, err := domain.NewPassenger(uuid.NewString(), in.UserID, in.Name, in.IDType, in.IDNumber, in.Phone)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.InsertPassenger(ctx, p); err != nil {
+		return nil, err
+	}
+	p.CreatedAt = time.Now().UTC()
+	return p, nil
+}
+
+func (s *Service) RemovePassenger(ctx context.Context, userID string, passengerID string) error {
+	return s.repo.DeletePassenger(ctx, userID, passengerID)
+}
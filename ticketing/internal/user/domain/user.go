@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ticketing/internal/common/idcard"
+)
+
+const MinPasswordLength = 8
+
+var (
+	ErrInvalidUsername    = errors.New("invalid username")
+	ErrWeakPassword       = errors.New("password too weak")
+	ErrUsernameTaken      = errors.New("username already taken")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidPassenger   = errors.New("invalid passenger")
+	ErrPassengerExists    = errors.New("passenger already exists")
+	ErrPassengerNotFound  = errors.New("passenger not found")
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{3,32}$`)
+
+type User struct {
+	UserID       string
+	Username     string
+	PasswordHash string `json:"-"`
+	Role         string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Passenger is an entry in a user's contact list; orders are booked for
+// passengers, not for the account holder.
+type Passenger struct {
+	PassengerID string
+	UserID      string
+	Name        string
+	IDType      string
+	IDNumber    string
+	Phone       string
+	CreatedAt   time.Time
+}
+
+func ValidateUsername(username string) error {
+	if !usernamePattern.MatchString(username) {
+		return ErrInvalidUsername
+	}
+	return nil
+}
+
+func ValidatePassword(password string) error {
+	if len(password) < MinPasswordLength || len(password) > 72 {
+		// bcrypt ignores everything past 72 bytes.
+		return ErrWeakPassword
+	}
+	return nil
+}
+
+func NewPassenger(passengerID string, userID string, name string, idType string, idNumber string, phone string) (*Passenger, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > 64 {
+		return nil, ErrInvalidPassenger
+	}
+	idType, idNumber, err := idcard.NormalizeDocument(idType, idNumber)
+	if err != nil {
+		return nil, ErrInvalidPassenger
+	}
+	return &Passenger{
+		PassengerID: passengerID,
+		UserID:      userID,
+		Name:        name,
+		IDType:      idType,
+		IDNumber:    idNumber,
+		Phone:       strings.TrimSpace(phone),
+	}, nil
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"ticketing/internal/common/idcard"
+)
+
+func TestValidateCredentials(t *testing.T) {
+	t.Parallel()
+
+	if err := ValidateUsername("alice_01"); err != nil {
+		t.Fatalf("expected valid username, got: %v", err)
+	}
+	for _, name := range []string{"", "ab", "has space", "名字"} {
+		if err := ValidateUsername(name); !errors.Is(err, ErrInvalidUsername) {
+			t.Fatalf("expected ErrInvalidUsername for %q, got: %v", name, err)
+		}
+	}
+	if err := ValidatePassword("short"); !errors.Is(err, ErrWeakPassword) {
+		t.Fatalf("expected ErrWeakPassword, got: %v", err)
+	}
+}
+
+func TestNewPassenger_NormalizesDocument(t *testing.T) {
+	t.Parallel()
+
+	p, err := NewPassenger("p-1", "u-1", " 张三 ", "", " 11010519491231002x ", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Name != "张三" || p.IDType != idcard.TypeIDCard || p.IDNumber != "11010519491231002X" {
+		t.Fatalf("unexpected passenger: %+v", p)
+	}
+	if _, err := NewPassenger("p-2", "u-1", "张三", "DRIVER_LICENSE", "x", ""); !errors.Is(err, ErrInvalidPassenger) {
+		t.Fatalf("expected ErrInvalidPassenger, got: %v", err)
+	}
+	if _, err := NewPassenger("p-3", "u-1", "张三", idcard.TypeIDCard, "110105194912310021", ""); !errors.Is(err, ErrInvalidPassenger) {
+		t.Fatalf("expected ErrInvalidPassenger for bad checksum, got: %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+
+	"ticketing/internal/user/domain"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) InsertUser(ctx context.Context, u *domain.User) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO users(user_id, username, password_hash, role) VALUES(?, ?, ?, ?)`,
+		u.UserID, u.Username, u.PasswordHash, u.Role,
+	)
+	if isDuplicate(err) {
+		return domain.ErrUsernameTaken
+	}
+	return err
+}
+
+func (r *Repository) FindByUsername(ctx context.Context, username string) (*domain.User, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		`SELECT user_id, username, password_hash, role, created_at, updated_at
+		 FROM users WHERE username=?`,
+		username,
+	)
+	return scanUser(row)
+}
+
+func (r *Repository) FindByID(ctx context.Context, userID string) (*domain.User, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		`SELECT user_id, username, password_hash, role, created_at, updated_at
+		 FROM users WHERE user_id=?`,
+		userID,
+	)
+	return scanUser(row)
+}
+
+func (r *Repository) InsertPassenger(ctx context.Context, p *domain.Passenger) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO passengers(passenger_id, user_id, name, id_type, id_number, phone) VALUES(?, ?, ?, ?, ?, ?)`,
+		p.PassengerID, p.UserID, p.Name, p.IDType, p.IDNumber, p.Phone,
+	)
+	if isDuplicate(err) {
+		return domain.ErrPassengerExists
+	}
+	return err
+}
+
+func (r *Repository) ListPassengers(ctx context.Context, userID string) ([]*domain.Passenger, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT passenger_id, user_id, name, id_type, id_number, phone, created_at
+		 FROM passengers WHERE user_id=? ORDER BY created_at ASC, passenger_id ASC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*domain.Passenger, 0)
+	for rows.Next() {
+		p := &domain.Passenger{}
+		if err := rows.Scan(&p.PassengerID, &p.UserID, &p.Name, &p.IDType, &p.IDNumber, &p.Phone, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// DeletePassenger only removes the passenger when it belongs to userID.
+func (r *Repository) DeletePassenger(ctx context.Context, userID string, passengerID string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM passengers WHERE passenger_id=? AND user_id=?`, passengerID, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrPassengerNotFound
+	}
+	return nil
+}
+
+func scanUser(row interface {
+	Scan(dest ...any) error
+}) (*domain.User, error) {
+	u := &domain.User{}
+	if err := row.Scan(&u.UserID, &u.Username, &u.PasswordHash, &u.Role, &u.CreatedAt, &u.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return u, nil
+}
+
+func isDuplicate(err error) bool {
+	var me *mysql.MySQLError
+	return errors.As(err, &me) && me.Number == 1062
+}
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ticketing/internal/common/tracing"
+	"ticketing/internal/user/application"
+	"ticketing/internal/user/domain"
+	"ticketing/internal/user/interfaces/dto"
+)
+
+type Handler struct {
+	service     *application.Service
+	requireAuth gin.HandlerFunc
+}
+
+func NewHandler(service *application.Service, requireAuth gin.HandlerFunc) *Handler {
+	return &Handler{service: service, requireAuth: requireAuth}
+}
+
+func (h *Handler) Register(r *gin.Engine) {
+	r.POST("/auth/register", h.register)
+	r.POST("/auth/login", h.login)
+	r.GET("/users/me", h.requireAuth, h.getMe)
+	r.GET("/users/me/passengers", h.requireAuth, h.listPassengers)
+	r.POST("/users/me/passengers", h.requireAuth, h.addPassenger)
+	r.DELETE("/users/me/passengers/:id", h.requireAuth, h.removePassenger)
+}
+
+func (h *Handler) register(c *gin.Context) {
+	var req dto.RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	user, err := h.service.Register(c.Request.Context(), application.RegisterInput{
+		Username: req.Username,
+		Password: req.Password,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrInvalidUsername) || errors.Is(err, domain.ErrWeakPassword) {
+			status = http.StatusBadRequest
+		}
+		if errors.Is(err, domain.ErrUsernameTaken) {
+			status = http.StatusConflict
+		}
+		writeError(c, status, err.Error())
+		return
+	}
+	writeJSON(c, http.StatusCreated, user)
+}
+
+func (h *Handler) login(c *gin.Context) {
+	var req dto.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	result, err := h.service.Login(c.Request.Context(), application.LoginInput{
+		Username: req.Username,
+		Password: req.Password,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrInvalidCredentials) {
+			status = http.StatusUnauthorized
+		}
+		writeError(c, status, err.Error())
+		return
+	}
+	writeJSON(c, http.StatusOK, result)
+}
+
+func (h *Handler) getMe(c *gin.Context) {
+	user, err := h.service.GetUser(c.Request.Context(), tracing.UserID(c.Request.Context()))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrUserNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(c, status, err.Error())
+		return
+	}
+	writeJSON(c, http.StatusOK, user)
+}
+
+func (h *Handler) listPassengers(c *gin.Context) {
+	passengers, err := h.service.ListPassengers(c.Request.Context(), tracing.UserID(c.Request.Context()))
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(c, http.StatusOK, passengers)
+}
+
+func (h *Handler) addPassenger(c *gin.Context) {
+	var req dto.AddPassengerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	passenger, err := h.service.AddPassenger(c.Request.Context(), application.AddPassengerInput{
+		UserID:   tracing.UserID(c.Request.Context()),
+		Name:     req.Name,
+		IDType:   req.IDType,
+		IDNumber: req.IDNumber,
+		Phone:    req.Phone,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrInvalidPassenger) {
+			status = http.StatusBadRequest
+		}
+		if errors.Is(err, domain.ErrPassengerExists) {
+			status = http.StatusConflict
+		}
+		writeError(c, status, err.Error())
+		return
+	}
+	writeJSON(c, http.StatusCreated, passenger)
+}
+
+func (h *Handler) removePassenger(c *gin.Context) {
+	err := h.service.RemovePassenger(c.Request.Context(), tracing.UserID(c.Request.Context()), c.Param("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrPassengerNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(c, status, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func writeJSON(c *gin.Context, status int, body any) {
+	c.JSON(status, body)
+}
+
+func writeError(c *gin.Context, status int, message string) {
+	writeJSON(c, status, map[string]any{"error": message})
+}
@@ -0,0 +1,47 @@
+SET @ddl := IF(
+  (SELECT COUNT(*) FROM information_schema.COLUMNS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'orders' AND COLUMN_NAME = 'partition_key') = 0,
+  'ALTER TABLE orders
+     ADD COLUMN partition_key VARCHAR(128) NOT NULL DEFAULT '''' AFTER amount_cents,
+     ADD COLUMN hold_id VARCHAR(64) NOT NULL DEFAULT '''' AFTER partition_key',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @ddl := IF(
+  (SELECT COUNT(*) FROM information_schema.COLUMNS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'tickets' AND COLUMN_NAME = 'change_id') = 0,
+  'ALTER TABLE tickets ADD COLUMN change_id VARCHAR(64) NOT NULL DEFAULT '''' AFTER passenger_name',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+CREATE TABLE IF NOT EXISTS ticket_changes (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  change_id VARCHAR(64) NOT NULL,
+  order_id VARCHAR(64) NOT NULL,
+  idempotency_key VARCHAR(128) NOT NULL,
+  old_partition_key VARCHAR(128) NOT NULL,
+  old_hold_id VARCHAR(64) NOT NULL,
+  new_partition_key VARCHAR(128) NOT NULL,
+  new_hold_id VARCHAR(64) NOT NULL,
+  qty INT NOT NULL,
+  capacity INT NOT NULL,
+  old_amount_cents BIGINT NOT NULL,
+  new_amount_cents BIGINT NOT NULL,
+  status VARCHAR(32) NOT NULL,
+  failed_step VARCHAR(32) NOT NULL DEFAULT '',
+  attempts INT NOT NULL DEFAULT 0,
+  last_error VARCHAR(255) NOT NULL DEFAULT '',
+  next_retry_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY uk_ticket_changes_change_id (change_id),
+  UNIQUE KEY uk_ticket_changes_idempotency_key (idempotency_key),
+  KEY idx_ticket_changes_order_id (order_id),
+  KEY idx_ticket_changes_status_next_retry (status, next_retry_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
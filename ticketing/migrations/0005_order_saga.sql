@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS order_sagas (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  saga_id VARCHAR(64) NOT NULL,
+  saga_type VARCHAR(32) NOT NULL,
+  order_id VARCHAR(64) NOT NULL,
+  data JSON NOT NULL,
+  step INT NOT NULL DEFAULT 0,
+  status VARCHAR(32) NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  last_error VARCHAR(255) NOT NULL DEFAULT '',
+  next_retry_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY uk_order_sagas_saga_id (saga_id),
+  KEY idx_order_sagas_order_id (order_id),
+  KEY idx_order_sagas_status_next_retry (status, next_retry_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS order_saga_steps (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  saga_id VARCHAR(64) NOT NULL,
+  step INT NOT NULL,
+  step_name VARCHAR(64) NOT NULL,
+  phase VARCHAR(16) NOT NULL,
+  outcome VARCHAR(16) NOT NULL,
+  error VARCHAR(255) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  KEY idx_order_saga_steps_saga_id (saga_id, id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Ticket changes are driven by a CHANGE saga now; the row only keeps the
+-- business outcome.
+SET @ddl := IF(
+  (SELECT COUNT(*) FROM information_schema.COLUMNS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'ticket_changes' AND COLUMN_NAME = 'next_retry_at') > 0,
+  'ALTER TABLE ticket_changes
+     DROP INDEX idx_ticket_changes_status_next_retry,
+     DROP COLUMN failed_step,
+     DROP COLUMN attempts,
+     DROP COLUMN last_error,
+     DROP COLUMN next_retry_at',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS order_status_history (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  order_id VARCHAR(64) NOT NULL,
+  event VARCHAR(32) NOT NULL,
+  from_status VARCHAR(32) NOT NULL,
+  to_status VARCHAR(32) NOT NULL,
+  actor VARCHAR(128) NOT NULL,
+  reason VARCHAR(255) NOT NULL DEFAULT '',
+  trace_id VARCHAR(64) NOT NULL DEFAULT '',
+  created_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  KEY idx_order_status_history_order_id (order_id, id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
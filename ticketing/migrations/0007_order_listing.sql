@@ -0,0 +1,22 @@
+SET @ddl := IF(
+  (SELECT COUNT(*) FROM information_schema.COLUMNS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'orders' AND COLUMN_NAME = 'user_id') = 0,
+  'ALTER TABLE orders
+     ADD COLUMN user_id VARCHAR(64) NOT NULL DEFAULT '''' AFTER idempotency_key,
+     ADD COLUMN train_no VARCHAR(16) NOT NULL DEFAULT '''' AFTER hold_id,
+     ADD COLUMN travel_date VARCHAR(10) NOT NULL DEFAULT '''' AFTER train_no,
+     ADD KEY idx_orders_user_created (user_id, created_at, order_id),
+     ADD KEY idx_orders_status_created (status, created_at, order_id),
+     ADD KEY idx_orders_train_date_created (train_no, travel_date, created_at, order_id),
+     ADD KEY idx_orders_created (created_at, order_id)',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+-- Backfill the itinerary of orders reserved before the columns existed.
+UPDATE orders
+SET train_no = SUBSTRING_INDEX(partition_key, '|', 1),
+    travel_date = SUBSTRING_INDEX(SUBSTRING_INDEX(partition_key, '|', 2), '|', -1)
+WHERE train_no = '' AND partition_key LIKE '%|%|%';
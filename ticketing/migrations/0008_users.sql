@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS users (
+  user_id VARCHAR(64) PRIMARY KEY,
+  username VARCHAR(64) NOT NULL,
+  password_hash VARCHAR(255) NOT NULL,
+  role VARCHAR(32) NOT NULL DEFAULT 'customer',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY uk_users_username (username)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS passengers (
+  passenger_id VARCHAR(64) PRIMARY KEY,
+  user_id VARCHAR(64) NOT NULL,
+  name VARCHAR(64) NOT NULL,
+  id_type VARCHAR(32) NOT NULL,
+  id_number VARCHAR(64) NOT NULL,
+  phone VARCHAR(32) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uk_passengers_user_document (user_id, id_type, id_number),
+  KEY idx_passengers_user_id (user_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
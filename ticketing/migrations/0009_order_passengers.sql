@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS order_passengers (
+  order_id VARCHAR(64) NOT NULL,
+  id_type VARCHAR(32) NOT NULL,
+  id_number VARCHAR(64) NOT NULL,
+  name VARCHAR(64) NOT NULL,
+  depart_at DATETIME NOT NULL,
+  arrive_at DATETIME NOT NULL,
+  booking_date DATE NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (order_id, id_type, id_number),
+  KEY idx_order_passengers_document_window (id_type, id_number, depart_at),
+  KEY idx_order_passengers_document_booking (id_type, id_number, booking_date)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- One row per ID document ever booked. Reservations lock these rows so the
+-- real-name checks of concurrent reservations run one after another.
+CREATE TABLE IF NOT EXISTS passenger_documents (
+  id_type VARCHAR(32) NOT NULL,
+  id_number VARCHAR(64) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id_type, id_number)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
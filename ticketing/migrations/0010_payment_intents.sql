@@ -0,0 +1,19 @@
+-- A payment row is now created when the customer starts paying, before the
+-- provider has assigned a transaction ID.
+ALTER TABLE payments MODIFY COLUMN provider_txn_id VARCHAR(128) NULL;
+
+SET @ddl := IF(
+  (SELECT COUNT(*) FROM information_schema.COLUMNS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'payments' AND COLUMN_NAME = 'amount_cents') = 0,
+  'ALTER TABLE payments
+     ADD COLUMN provider VARCHAR(32) NOT NULL DEFAULT '''' AFTER order_id,
+     ADD COLUMN amount_cents BIGINT NOT NULL DEFAULT 0 AFTER status,
+     ADD COLUMN currency VARCHAR(8) NOT NULL DEFAULT ''CNY'' AFTER amount_cents,
+     ADD COLUMN prepay_id VARCHAR(128) NOT NULL DEFAULT '''' AFTER currency,
+     ADD COLUMN expires_at TIMESTAMP NULL AFTER prepay_id,
+     ADD KEY idx_payments_status_created (status, created_at)',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,13 @@
+-- What the provider reports as actually paid, kept next to what was asked
+-- for so underpayments and overpayments can be audited.
+SET @ddl := IF(
+  (SELECT COUNT(*) FROM information_schema.COLUMNS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'payments' AND COLUMN_NAME = 'paid_amount_cents') = 0,
+  'ALTER TABLE payments
+     ADD COLUMN paid_amount_cents BIGINT NULL AFTER currency,
+     ADD COLUMN paid_currency VARCHAR(8) NULL AFTER paid_amount_cents',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,24 @@
+-- Refunds order-service owes the customer, e.g. for a second successful
+-- payment of an order that was already paid. A row is written in the same
+-- transaction that detects the surplus and is sent to the provider until it
+-- succeeds or is rejected.
+CREATE TABLE IF NOT EXISTS payment_refunds (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  refund_id VARCHAR(64) NOT NULL,
+  payment_id VARCHAR(64) NOT NULL,
+  order_id VARCHAR(64) NOT NULL,
+  provider_txn_id VARCHAR(128) NOT NULL DEFAULT '',
+  amount_cents BIGINT NOT NULL,
+  currency VARCHAR(8) NOT NULL,
+  reason VARCHAR(255) NOT NULL DEFAULT '',
+  status VARCHAR(32) NOT NULL,
+  provider_refund_id VARCHAR(128) NOT NULL DEFAULT '',
+  attempts INT NOT NULL DEFAULT 0,
+  last_error VARCHAR(512) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY uk_payment_refunds_refund_id (refund_id),
+  UNIQUE KEY uk_payment_refunds_payment_id (payment_id),
+  KEY idx_payment_refunds_status_updated (status, updated_at),
+  KEY idx_payment_refunds_order_id (order_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
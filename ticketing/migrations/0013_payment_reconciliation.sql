@@ -0,0 +1,51 @@
+-- When the provider reported a payment as paid; the daily reconciliation
+-- selects the local side of a settlement day by it.
+SET @ddl := IF(
+  (SELECT COUNT(*) FROM information_schema.COLUMNS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'payments' AND COLUMN_NAME = 'paid_at') = 0,
+  'ALTER TABLE payments
+     ADD COLUMN paid_at TIMESTAMP NULL AFTER expires_at,
+     ADD KEY idx_payments_paid_at (paid_at)',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+UPDATE payments SET paid_at = updated_at
+WHERE paid_at IS NULL AND status IN ('SUCCESS', 'REFUNDING', 'REFUNDED');
+
+CREATE TABLE IF NOT EXISTS reconciliation_runs (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  run_id VARCHAR(64) NOT NULL,
+  provider VARCHAR(32) NOT NULL,
+  settlement_date DATE NOT NULL,
+  source_file VARCHAR(255) NOT NULL DEFAULT '',
+  provider_records INT NOT NULL,
+  local_records INT NOT NULL,
+  matched INT NOT NULL,
+  missing INT NOT NULL,
+  extra INT NOT NULL,
+  mismatched INT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uk_reconciliation_runs_run_id (run_id),
+  KEY idx_reconciliation_runs_date (settlement_date, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS reconciliation_items (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  run_id VARCHAR(64) NOT NULL,
+  kind VARCHAR(32) NOT NULL,
+  provider_txn_id VARCHAR(128) NOT NULL DEFAULT '',
+  payment_id VARCHAR(64) NOT NULL DEFAULT '',
+  order_id VARCHAR(64) NOT NULL DEFAULT '',
+  local_amount_cents BIGINT NOT NULL DEFAULT 0,
+  local_currency VARCHAR(8) NOT NULL DEFAULT '',
+  provider_amount_cents BIGINT NOT NULL DEFAULT 0,
+  provider_currency VARCHAR(8) NOT NULL DEFAULT '',
+  order_status VARCHAR(32) NOT NULL DEFAULT '',
+  detail VARCHAR(255) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  KEY idx_reconciliation_items_run (run_id, kind),
+  KEY idx_reconciliation_items_txn (provider_txn_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,38 @@
+-- Bring the order outbox up to the ticket_outbox design: failed publishes
+-- are retried with backoff and dead-lettered after too many attempts, and
+-- relays claim rows with claim_token so several of them can run at once.
+SET @ddl := IF(
+  (SELECT COUNT(*) FROM information_schema.COLUMNS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'outbox' AND COLUMN_NAME = 'retry_count') = 0,
+  'ALTER TABLE outbox
+     ADD COLUMN retry_count INT NOT NULL DEFAULT 0 AFTER status,
+     ADD COLUMN next_retry_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP AFTER retry_count,
+     ADD COLUMN last_error VARCHAR(255) NULL AFTER next_retry_at,
+     ADD COLUMN claim_token VARCHAR(64) NULL AFTER last_error',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @ddl := IF(
+  (SELECT COUNT(*) FROM information_schema.STATISTICS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'outbox' AND INDEX_NAME = 'idx_outbox_status_next_retry') = 0,
+  'ALTER TABLE outbox ADD KEY idx_outbox_status_next_retry (status, next_retry_at, id)',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+-- Per-aggregate ordering looks for an earlier unpublished event of the
+-- same aggregate.
+SET @ddl := IF(
+  (SELECT COUNT(*) FROM information_schema.STATISTICS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'outbox' AND INDEX_NAME = 'idx_outbox_aggregate_status') = 0,
+  'ALTER TABLE outbox ADD KEY idx_outbox_aggregate_status (aggregate_id, status, id)',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,33 @@
+-- Seat inventory for the native seat allocator. seat_routes has one row per
+-- train, day and coach class; allocating locks it, so allocators in several
+-- ticket-workers take turns, and bumps version so the others know their
+-- cached seat map is stale.
+CREATE TABLE IF NOT EXISTS seat_routes (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  route_key VARCHAR(128) NOT NULL,
+  version BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY uk_seat_routes_route_key (route_key)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- One row per seat handed out. segment_mask has bit i set when the seat is
+-- taken between station i and i+1; the seat map is rebuilt from the ACTIVE
+-- rows of a route.
+CREATE TABLE IF NOT EXISTS seat_allocations (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  allocation_id VARCHAR(64) NOT NULL,
+  route_key VARCHAR(128) NOT NULL,
+  order_id VARCHAR(64) NOT NULL,
+  coach_no INT NOT NULL,
+  seat_row INT NOT NULL,
+  seat_letter CHAR(1) NOT NULL,
+  from_index INT NOT NULL,
+  to_index INT NOT NULL,
+  segment_mask BIGINT UNSIGNED NOT NULL,
+  status VARCHAR(16) NOT NULL DEFAULT 'ACTIVE',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uk_seat_allocations_allocation_id (allocation_id),
+  KEY idx_seat_allocations_route_status (route_key, status),
+  KEY idx_seat_allocations_order_id (order_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,23 @@
+-- What ticket-worker needs to allocate seats for an order: how many seats
+-- were held and the stations travelled between. from_index and to_index are
+-- both 0 for orders reserved without a station range.
+SET @ddl := IF(
+  (SELECT COUNT(*) FROM information_schema.COLUMNS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'orders' AND COLUMN_NAME = 'hold_qty') = 0,
+  'ALTER TABLE orders
+     ADD COLUMN hold_qty INT NOT NULL DEFAULT 0 AFTER hold_id,
+     ADD COLUMN from_index INT NOT NULL DEFAULT 0 AFTER travel_date,
+     ADD COLUMN to_index INT NOT NULL DEFAULT 0 AFTER from_index',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+-- Orders with passengers held one seat per passenger.
+UPDATE orders o
+SET hold_qty = (SELECT COUNT(*) FROM order_passengers p WHERE p.order_id = o.order_id)
+WHERE o.hold_qty = 0;
+
+-- A ticket lists the seats of all passengers of the order.
+ALTER TABLE tickets MODIFY COLUMN passenger_name VARCHAR(512) NOT NULL;
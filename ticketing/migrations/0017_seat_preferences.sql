@@ -0,0 +1,21 @@
+-- Seat wishes passed on to the seat allocator: a window or aisle seat per
+-- passenger, and whether the passengers of an order should sit together.
+SET @ddl := IF(
+  (SELECT COUNT(*) FROM information_schema.COLUMNS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'order_passengers' AND COLUMN_NAME = 'seat_preference') = 0,
+  'ALTER TABLE order_passengers ADD COLUMN seat_preference VARCHAR(16) NOT NULL DEFAULT '''' AFTER name',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @ddl := IF(
+  (SELECT COUNT(*) FROM information_schema.COLUMNS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'orders' AND COLUMN_NAME = 'keep_together') = 0,
+  'ALTER TABLE orders ADD COLUMN keep_together TINYINT(1) NOT NULL DEFAULT 0 AFTER to_index',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
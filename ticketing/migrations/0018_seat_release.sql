@@ -0,0 +1,11 @@
+-- Seats given back when an order is cancelled or changed keep their row with
+-- status RELEASED; released_at records when.
+SET @ddl := IF(
+  (SELECT COUNT(*) FROM information_schema.COLUMNS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'seat_allocations' AND COLUMN_NAME = 'released_at') = 0,
+  'ALTER TABLE seat_allocations ADD COLUMN released_at TIMESTAMP NULL DEFAULT NULL AFTER status',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,39 @@
+-- One ticket per passenger. passenger_id is the passenger's document key
+-- ("ID_TYPE:ID_NUMBER", as in order_passengers); tickets issued before
+-- this have it empty and passenger_name holding the seats of the order.
+SET @ddl := IF(
+  (SELECT COUNT(*) FROM information_schema.COLUMNS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'tickets' AND COLUMN_NAME = 'passenger_id') = 0,
+  'ALTER TABLE tickets
+     ADD COLUMN passenger_id VARCHAR(100) NOT NULL DEFAULT '''' AFTER order_id,
+     ADD COLUMN train_id VARCHAR(32) NOT NULL DEFAULT '''' AFTER passenger_name,
+     ADD COLUMN travel_date VARCHAR(16) NOT NULL DEFAULT '''' AFTER train_id,
+     ADD COLUMN coach_no INT NOT NULL DEFAULT 0 AFTER travel_date,
+     ADD COLUMN seat_no VARCHAR(32) NOT NULL DEFAULT '''' AFTER coach_no,
+     ADD COLUMN from_index INT NOT NULL DEFAULT 0 AFTER seat_no,
+     ADD COLUMN to_index INT NOT NULL DEFAULT 0 AFTER from_index',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @ddl := IF(
+  (SELECT COUNT(*) FROM information_schema.STATISTICS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'tickets' AND INDEX_NAME = 'uk_tickets_order_passenger') = 0,
+  'ALTER TABLE tickets ADD UNIQUE KEY uk_tickets_order_passenger (order_id, passenger_id)',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @ddl := IF(
+  (SELECT COUNT(*) FROM information_schema.STATISTICS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'tickets' AND INDEX_NAME = 'uk_tickets_order_id') > 0,
+  'ALTER TABLE tickets DROP INDEX uk_tickets_order_id',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,25 @@
+-- Electronic tickets: the signed token of every ticket and the QR code and
+-- PDF itinerary rendered from it. Rows go with their tickets when a ticket
+-- change reissues them.
+CREATE TABLE IF NOT EXISTS ticket_documents (
+  ticket_id VARCHAR(64) NOT NULL,
+  order_id VARCHAR(64) NOT NULL,
+  token TEXT NOT NULL,
+  qr_png MEDIUMBLOB NOT NULL,
+  pdf MEDIUMBLOB NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (ticket_id),
+  KEY idx_ticket_documents_order_id (order_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Set when gate staff verify the ticket; a ticket is used only once.
+SET @ddl := IF(
+  (SELECT COUNT(*) FROM information_schema.COLUMNS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'tickets' AND COLUMN_NAME = 'used_at') = 0,
+  'ALTER TABLE tickets ADD COLUMN used_at TIMESTAMP NULL AFTER change_id',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,65 @@
+-- Ticket lifecycle: ISSUED -> CHECKED_IN -> USED, or VOIDED / REFUNDED.
+-- Tickets replaced by a ticket change are kept as VOIDED next to their
+-- replacements, so the unique key now includes change_id.
+SET @ddl := IF(
+  (SELECT COUNT(*) FROM information_schema.COLUMNS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'tickets' AND COLUMN_NAME = 'status') = 0,
+  'ALTER TABLE tickets
+     ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT ''ISSUED'' AFTER change_id,
+     ADD COLUMN checked_in_at TIMESTAMP NULL AFTER status',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+UPDATE tickets SET status = 'USED' WHERE used_at IS NOT NULL AND status = 'ISSUED';
+
+SET @ddl := IF(
+  (SELECT COUNT(*) FROM information_schema.STATISTICS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'tickets' AND INDEX_NAME = 'uk_tickets_order_passenger_change') = 0,
+  'ALTER TABLE tickets ADD UNIQUE KEY uk_tickets_order_passenger_change (order_id, passenger_id, change_id)',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @ddl := IF(
+  (SELECT COUNT(*) FROM information_schema.STATISTICS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'tickets' AND INDEX_NAME = 'uk_tickets_order_passenger') > 0,
+  'ALTER TABLE tickets DROP INDEX uk_tickets_order_passenger',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+CREATE TABLE IF NOT EXISTS ticket_status_history (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  ticket_id VARCHAR(64) NOT NULL,
+  order_id VARCHAR(64) NOT NULL,
+  event VARCHAR(16) NOT NULL,
+  from_status VARCHAR(16) NOT NULL,
+  to_status VARCHAR(16) NOT NULL,
+  actor VARCHAR(64) NOT NULL,
+  reason VARCHAR(255) NOT NULL DEFAULT '',
+  station_index INT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  KEY idx_ticket_status_history_ticket (ticket_id, id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- query-service projection of the tickets of an order.
+CREATE TABLE IF NOT EXISTS query_order_tickets (
+  ticket_id VARCHAR(64) PRIMARY KEY,
+  order_id VARCHAR(64) NOT NULL,
+  passenger_id VARCHAR(100) NOT NULL DEFAULT '',
+  passenger_name VARCHAR(512) NOT NULL DEFAULT '',
+  train_id VARCHAR(32) NOT NULL DEFAULT '',
+  travel_date VARCHAR(16) NOT NULL DEFAULT '',
+  coach_no INT NOT NULL DEFAULT 0,
+  seat_no VARCHAR(32) NOT NULL DEFAULT '',
+  status VARCHAR(16) NOT NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  KEY idx_query_order_tickets_order (order_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,17 @@
+-- ticket-worker keeps the order events of one order in order while one of
+-- them waits on order.events.retry: the order is held, and its later events
+-- are parked here until the held event has been handled or dead-lettered.
+CREATE TABLE IF NOT EXISTS order_event_holds (
+  event_key VARCHAR(128) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (event_key)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS order_event_parking (
+  id BIGINT NOT NULL AUTO_INCREMENT,
+  event_key VARCHAR(128) NOT NULL,
+  payload MEDIUMBLOB NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  KEY idx_order_event_parking_key (event_key, id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
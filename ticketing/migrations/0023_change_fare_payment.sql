@@ -0,0 +1,11 @@
+-- A change to a dearer ticket collects the difference through its own
+-- payment before the old seats are returned.
+SET @ddl := IF(
+  (SELECT COUNT(*) FROM information_schema.COLUMNS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'ticket_changes' AND COLUMN_NAME = 'fare_payment_id') = 0,
+  'ALTER TABLE ticket_changes ADD COLUMN fare_payment_id VARCHAR(64) NOT NULL DEFAULT '''' AFTER new_amount_cents',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
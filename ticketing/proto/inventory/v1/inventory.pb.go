@@ -0,0 +1,881 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        v4.25.3
+// source: inventory/v1/inventory.proto
+
+package inventoryv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ErrorCode int32
+
+const (
+	ErrorCode_ERROR_CODE_UNSPECIFIED ErrorCode = 0
+	ErrorCode_INVALID_REQUEST        ErrorCode = 1
+	ErrorCode_INVALID_QUANTITY       ErrorCode = 2
+	ErrorCode_INSUFFICIENT_STOCK     ErrorCode = 3
+	ErrorCode_BACKPRESSURE           ErrorCode = 4
+	ErrorCode_HOLD_NOT_FOUND         ErrorCode = 5
+	ErrorCode_PARTITION_NOT_FOUND    ErrorCode = 6
+	ErrorCode_INTERNAL               ErrorCode = 7
+)
+
+// Enum value maps for ErrorCode.
+var (
+	ErrorCode_name = map[int32]string{
+		0: "ERROR_CODE_UNSPECIFIED",
+		1: "INVALID_REQUEST",
+		2: "INVALID_QUANTITY",
+		3: "INSUFFICIENT_STOCK",
+		4: "BACKPRESSURE",
+		5: "HOLD_NOT_FOUND",
+		6: "PARTITION_NOT_FOUND",
+		7: "INTERNAL",
+	}
+	ErrorCode_value = map[string]int32{
+		"ERROR_CODE_UNSPECIFIED": 0,
+		"INVALID_REQUEST":        1,
+		"INVALID_QUANTITY":       2,
+		"INSUFFICIENT_STOCK":     3,
+		"BACKPRESSURE":           4,
+		"HOLD_NOT_FOUND":         5,
+		"PARTITION_NOT_FOUND":    6,
+		"INTERNAL":               7,
+	}
+)
+
+func (x ErrorCode) Enum() *ErrorCode {
+	p := new(ErrorCode)
+	*p = x
+	return p
+}
+
+func (x ErrorCode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ErrorCode) Descriptor() protoreflect.EnumDescriptor {
+	return file_inventory_v1_inventory_proto_enumTypes[0].Descriptor()
+}
+
+func (ErrorCode) Type() protoreflect.EnumType {
+	return &file_inventory_v1_inventory_proto_enumTypes[0]
+}
+
+func (x ErrorCode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ErrorCode.Descriptor instead.
+func (ErrorCode) EnumDescriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{0}
+}
+
+type TryHoldRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PartitionKey  string                 `protobuf:"bytes,1,opt,name=partition_key,json=partitionKey,proto3" json:"partition_key,omitempty"`
+	HoldId        string                 `protobuf:"bytes,2,opt,name=hold_id,json=holdId,proto3" json:"hold_id,omitempty"`
+	Qty           int32                  `protobuf:"varint,3,opt,name=qty,proto3" json:"qty,omitempty"`
+	Capacity      int32                  `protobuf:"varint,4,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TryHoldRequest) Reset() {
+	*x = TryHoldRequest{}
+	mi := &file_inventory_v1_inventory_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TryHoldRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TryHoldRequest) ProtoMessage() {}
+
+func (x *TryHoldRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_v1_inventory_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TryHoldRequest.ProtoReflect.Descriptor instead.
+func (*TryHoldRequest) Descriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TryHoldRequest) GetPartitionKey() string {
+	if x != nil {
+		return x.PartitionKey
+	}
+	return ""
+}
+
+func (x *TryHoldRequest) GetHoldId() string {
+	if x != nil {
+		return x.HoldId
+	}
+	return ""
+}
+
+func (x *TryHoldRequest) GetQty() int32 {
+	if x != nil {
+		return x.Qty
+	}
+	return 0
+}
+
+func (x *TryHoldRequest) GetCapacity() int32 {
+	if x != nil {
+		return x.Capacity
+	}
+	return 0
+}
+
+type HoldRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PartitionKey  string                 `protobuf:"bytes,1,opt,name=partition_key,json=partitionKey,proto3" json:"partition_key,omitempty"`
+	HoldId        string                 `protobuf:"bytes,2,opt,name=hold_id,json=holdId,proto3" json:"hold_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HoldRequest) Reset() {
+	*x = HoldRequest{}
+	mi := &file_inventory_v1_inventory_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HoldRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HoldRequest) ProtoMessage() {}
+
+func (x *HoldRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_v1_inventory_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HoldRequest.ProtoReflect.Descriptor instead.
+func (*HoldRequest) Descriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *HoldRequest) GetPartitionKey() string {
+	if x != nil {
+		return x.PartitionKey
+	}
+	return ""
+}
+
+func (x *HoldRequest) GetHoldId() string {
+	if x != nil {
+		return x.HoldId
+	}
+	return ""
+}
+
+// PartitionState is the partition after the call, without the hold maps
+// the HTTP API returns.
+type PartitionState struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PartitionKey  string                 `protobuf:"bytes,1,opt,name=partition_key,json=partitionKey,proto3" json:"partition_key,omitempty"`
+	Capacity      int32                  `protobuf:"varint,2,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	Available     int32                  `protobuf:"varint,3,opt,name=available,proto3" json:"available,omitempty"`
+	Confirmed     int32                  `protobuf:"varint,4,opt,name=confirmed,proto3" json:"confirmed,omitempty"`
+	LastSeq       int64                  `protobuf:"varint,5,opt,name=last_seq,json=lastSeq,proto3" json:"last_seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PartitionState) Reset() {
+	*x = PartitionState{}
+	mi := &file_inventory_v1_inventory_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PartitionState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PartitionState) ProtoMessage() {}
+
+func (x *PartitionState) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_v1_inventory_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PartitionState.ProtoReflect.Descriptor instead.
+func (*PartitionState) Descriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PartitionState) GetPartitionKey() string {
+	if x != nil {
+		return x.PartitionKey
+	}
+	return ""
+}
+
+func (x *PartitionState) GetCapacity() int32 {
+	if x != nil {
+		return x.Capacity
+	}
+	return 0
+}
+
+func (x *PartitionState) GetAvailable() int32 {
+	if x != nil {
+		return x.Available
+	}
+	return 0
+}
+
+func (x *PartitionState) GetConfirmed() int32 {
+	if x != nil {
+		return x.Confirmed
+	}
+	return 0
+}
+
+func (x *PartitionState) GetLastSeq() int64 {
+	if x != nil {
+		return x.LastSeq
+	}
+	return 0
+}
+
+type HoldResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	State         *PartitionState        `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HoldResponse) Reset() {
+	*x = HoldResponse{}
+	mi := &file_inventory_v1_inventory_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HoldResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HoldResponse) ProtoMessage() {}
+
+func (x *HoldResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_v1_inventory_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HoldResponse.ProtoReflect.Descriptor instead.
+func (*HoldResponse) Descriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *HoldResponse) GetState() *PartitionState {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+type AvailabilityRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PartitionKey  string                 `protobuf:"bytes,1,opt,name=partition_key,json=partitionKey,proto3" json:"partition_key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AvailabilityRequest) Reset() {
+	*x = AvailabilityRequest{}
+	mi := &file_inventory_v1_inventory_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AvailabilityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AvailabilityRequest) ProtoMessage() {}
+
+func (x *AvailabilityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_v1_inventory_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AvailabilityRequest.ProtoReflect.Descriptor instead.
+func (*AvailabilityRequest) Descriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AvailabilityRequest) GetPartitionKey() string {
+	if x != nil {
+		return x.PartitionKey
+	}
+	return ""
+}
+
+type AvailabilityResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PartitionKey  string                 `protobuf:"bytes,1,opt,name=partition_key,json=partitionKey,proto3" json:"partition_key,omitempty"`
+	Available     int32                  `protobuf:"varint,2,opt,name=available,proto3" json:"available,omitempty"`
+	Found         bool                   `protobuf:"varint,3,opt,name=found,proto3" json:"found,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AvailabilityResponse) Reset() {
+	*x = AvailabilityResponse{}
+	mi := &file_inventory_v1_inventory_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AvailabilityResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AvailabilityResponse) ProtoMessage() {}
+
+func (x *AvailabilityResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_v1_inventory_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AvailabilityResponse.ProtoReflect.Descriptor instead.
+func (*AvailabilityResponse) Descriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *AvailabilityResponse) GetPartitionKey() string {
+	if x != nil {
+		return x.PartitionKey
+	}
+	return ""
+}
+
+func (x *AvailabilityResponse) GetAvailable() int32 {
+	if x != nil {
+		return x.Available
+	}
+	return 0
+}
+
+func (x *AvailabilityResponse) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+type BatchTryHoldRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*TryHoldRequest      `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchTryHoldRequest) Reset() {
+	*x = BatchTryHoldRequest{}
+	mi := &file_inventory_v1_inventory_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchTryHoldRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchTryHoldRequest) ProtoMessage() {}
+
+func (x *BatchTryHoldRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_v1_inventory_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchTryHoldRequest.ProtoReflect.Descriptor instead.
+func (*BatchTryHoldRequest) Descriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BatchTryHoldRequest) GetItems() []*TryHoldRequest {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type BatchHoldRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*HoldRequest         `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchHoldRequest) Reset() {
+	*x = BatchHoldRequest{}
+	mi := &file_inventory_v1_inventory_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchHoldRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchHoldRequest) ProtoMessage() {}
+
+func (x *BatchHoldRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_v1_inventory_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchHoldRequest.ProtoReflect.Descriptor instead.
+func (*BatchHoldRequest) Descriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *BatchHoldRequest) GetItems() []*HoldRequest {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type HoldResult struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	PartitionKey string                 `protobuf:"bytes,1,opt,name=partition_key,json=partitionKey,proto3" json:"partition_key,omitempty"`
+	HoldId       string                 `protobuf:"bytes,2,opt,name=hold_id,json=holdId,proto3" json:"hold_id,omitempty"`
+	// Unset when error_code is set.
+	State         *PartitionState `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	ErrorCode     ErrorCode       `protobuf:"varint,4,opt,name=error_code,json=errorCode,proto3,enum=inventory.v1.ErrorCode" json:"error_code,omitempty"`
+	Error         string          `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HoldResult) Reset() {
+	*x = HoldResult{}
+	mi := &file_inventory_v1_inventory_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HoldResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HoldResult) ProtoMessage() {}
+
+func (x *HoldResult) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_v1_inventory_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HoldResult.ProtoReflect.Descriptor instead.
+func (*HoldResult) Descriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *HoldResult) GetPartitionKey() string {
+	if x != nil {
+		return x.PartitionKey
+	}
+	return ""
+}
+
+func (x *HoldResult) GetHoldId() string {
+	if x != nil {
+		return x.HoldId
+	}
+	return ""
+}
+
+func (x *HoldResult) GetState() *PartitionState {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+func (x *HoldResult) GetErrorCode() ErrorCode {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ErrorCode_ERROR_CODE_UNSPECIFIED
+}
+
+func (x *HoldResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type BatchHoldResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*HoldResult          `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchHoldResponse) Reset() {
+	*x = BatchHoldResponse{}
+	mi := &file_inventory_v1_inventory_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchHoldResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchHoldResponse) ProtoMessage() {}
+
+func (x *BatchHoldResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_v1_inventory_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchHoldResponse.ProtoReflect.Descriptor instead.
+func (*BatchHoldResponse) Descriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *BatchHoldResponse) GetResults() []*HoldResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type BatchAvailabilityRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PartitionKeys []string               `protobuf:"bytes,1,rep,name=partition_keys,json=partitionKeys,proto3" json:"partition_keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchAvailabilityRequest) Reset() {
+	*x = BatchAvailabilityRequest{}
+	mi := &file_inventory_v1_inventory_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchAvailabilityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchAvailabilityRequest) ProtoMessage() {}
+
+func (x *BatchAvailabilityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_v1_inventory_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchAvailabilityRequest.ProtoReflect.Descriptor instead.
+func (*BatchAvailabilityRequest) Descriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *BatchAvailabilityRequest) GetPartitionKeys() []string {
+	if x != nil {
+		return x.PartitionKeys
+	}
+	return nil
+}
+
+type BatchAvailabilityResponse struct {
+	state         protoimpl.MessageState  `protogen:"open.v1"`
+	Results       []*AvailabilityResponse `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchAvailabilityResponse) Reset() {
+	*x = BatchAvailabilityResponse{}
+	mi := &file_inventory_v1_inventory_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchAvailabilityResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchAvailabilityResponse) ProtoMessage() {}
+
+func (x *BatchAvailabilityResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_v1_inventory_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchAvailabilityResponse.ProtoReflect.Descriptor instead.
+func (*BatchAvailabilityResponse) Descriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *BatchAvailabilityResponse) GetResults() []*AvailabilityResponse {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+var File_inventory_v1_inventory_proto protoreflect.FileDescriptor
+
+const file_inventory_v1_inventory_proto_rawDesc = "" +
+	"\n" +
+	"\x1cinventory/v1/inventory.proto\x12\finventory.v1\"|\n" +
+	"\x0eTryHoldRequest\x12#\n" +
+	"\rpartition_key\x18\x01 \x01(\tR\fpartitionKey\x12\x17\n" +
+	"\ahold_id\x18\x02 \x01(\tR\x06holdId\x12\x10\n" +
+	"\x03qty\x18\x03 \x01(\x05R\x03qty\x12\x1a\n" +
+	"\bcapacity\x18\x04 \x01(\x05R\bcapacity\"K\n" +
+	"\vHoldRequest\x12#\n" +
+	"\rpartition_key\x18\x01 \x01(\tR\fpartitionKey\x12\x17\n" +
+	"\ahold_id\x18\x02 \x01(\tR\x06holdId\"\xa8\x01\n" +
+	"\x0ePartitionState\x12#\n" +
+	"\rpartition_key\x18\x01 \x01(\tR\fpartitionKey\x12\x1a\n" +
+	"\bcapacity\x18\x02 \x01(\x05R\bcapacity\x12\x1c\n" +
+	"\tavailable\x18\x03 \x01(\x05R\tavailable\x12\x1c\n" +
+	"\tconfirmed\x18\x04 \x01(\x05R\tconfirmed\x12\x19\n" +
+	"\blast_seq\x18\x05 \x01(\x03R\alastSeq\"B\n" +
+	"\fHoldResponse\x122\n" +
+	"\x05state\x18\x01 \x01(\v2\x1c.inventory.v1.PartitionStateR\x05state\":\n" +
+	"\x13AvailabilityRequest\x12#\n" +
+	"\rpartition_key\x18\x01 \x01(\tR\fpartitionKey\"o\n" +
+	"\x14AvailabilityResponse\x12#\n" +
+	"\rpartition_key\x18\x01 \x01(\tR\fpartitionKey\x12\x1c\n" +
+	"\tavailable\x18\x02 \x01(\x05R\tavailable\x12\x14\n" +
+	"\x05found\x18\x03 \x01(\bR\x05found\"I\n" +
+	"\x13BatchTryHoldRequest\x122\n" +
+	"\x05items\x18\x01 \x03(\v2\x1c.inventory.v1.TryHoldRequestR\x05items\"C\n" +
+	"\x10BatchHoldRequest\x12/\n" +
+	"\x05items\x18\x01 \x03(\v2\x19.inventory.v1.HoldRequestR\x05items\"\xcc\x01\n" +
+	"\n" +
+	"HoldResult\x12#\n" +
+	"\rpartition_key\x18\x01 \x01(\tR\fpartitionKey\x12\x17\n" +
+	"\ahold_id\x18\x02 \x01(\tR\x06holdId\x122\n" +
+	"\x05state\x18\x03 \x01(\v2\x1c.inventory.v1.PartitionStateR\x05state\x126\n" +
+	"\n" +
+	"error_code\x18\x04 \x01(\x0e2\x17.inventory.v1.ErrorCodeR\terrorCode\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\"G\n" +
+	"\x11BatchHoldResponse\x122\n" +
+	"\aresults\x18\x01 \x03(\v2\x18.inventory.v1.HoldResultR\aresults\"A\n" +
+	"\x18BatchAvailabilityRequest\x12%\n" +
+	"\x0epartition_keys\x18\x01 \x03(\tR\rpartitionKeys\"Y\n" +
+	"\x19BatchAvailabilityResponse\x12<\n" +
+	"\aresults\x18\x01 \x03(\v2\".inventory.v1.AvailabilityResponseR\aresults*\xb7\x01\n" +
+	"\tErrorCode\x12\x1a\n" +
+	"\x16ERROR_CODE_UNSPECIFIED\x10\x00\x12\x13\n" +
+	"\x0fINVALID_REQUEST\x10\x01\x12\x14\n" +
+	"\x10INVALID_QUANTITY\x10\x02\x12\x16\n" +
+	"\x12INSUFFICIENT_STOCK\x10\x03\x12\x10\n" +
+	"\fBACKPRESSURE\x10\x04\x12\x12\n" +
+	"\x0eHOLD_NOT_FOUND\x10\x05\x12\x17\n" +
+	"\x13PARTITION_NOT_FOUND\x10\x06\x12\f\n" +
+	"\bINTERNAL\x10\a2\xee\x05\n" +
+	"\x10InventoryService\x12C\n" +
+	"\aTryHold\x12\x1c.inventory.v1.TryHoldRequest\x1a\x1a.inventory.v1.HoldResponse\x12D\n" +
+	"\vReleaseHold\x12\x19.inventory.v1.HoldRequest\x1a\x1a.inventory.v1.HoldResponse\x12D\n" +
+	"\vConfirmHold\x12\x19.inventory.v1.HoldRequest\x1a\x1a.inventory.v1.HoldResponse\x12H\n" +
+	"\x0fReturnConfirmed\x12\x19.inventory.v1.HoldRequest\x1a\x1a.inventory.v1.HoldResponse\x12X\n" +
+	"\x0fGetAvailability\x12!.inventory.v1.AvailabilityRequest\x1a\".inventory.v1.AvailabilityResponse\x12R\n" +
+	"\fBatchTryHold\x12!.inventory.v1.BatchTryHoldRequest\x1a\x1f.inventory.v1.BatchHoldResponse\x12S\n" +
+	"\x10BatchReleaseHold\x12\x1e.inventory.v1.BatchHoldRequest\x1a\x1f.inventory.v1.BatchHoldResponse\x12S\n" +
+	"\x10BatchConfirmHold\x12\x1e.inventory.v1.BatchHoldRequest\x1a\x1f.inventory.v1.BatchHoldResponse\x12g\n" +
+	"\x14BatchGetAvailability\x12&.inventory.v1.BatchAvailabilityRequest\x1a'.inventory.v1.BatchAvailabilityResponseB*Z(ticketing/proto/inventory/v1;inventoryv1b\x06proto3"
+
+var (
+	file_inventory_v1_inventory_proto_rawDescOnce sync.Once
+	file_inventory_v1_inventory_proto_rawDescData []byte
+)
+
+func file_inventory_v1_inventory_proto_rawDescGZIP() []byte {
+	file_inventory_v1_inventory_proto_rawDescOnce.Do(func() {
+		file_inventory_v1_inventory_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_inventory_v1_inventory_proto_rawDesc), len(file_inventory_v1_inventory_proto_rawDesc)))
+	})
+	return file_inventory_v1_inventory_proto_rawDescData
+}
+
+var file_inventory_v1_inventory_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_inventory_v1_inventory_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_inventory_v1_inventory_proto_goTypes = []any{
+	(ErrorCode)(0),                    // 0: inventory.v1.ErrorCode
+	(*TryHoldRequest)(nil),            // 1: inventory.v1.TryHoldRequest
+	(*HoldRequest)(nil),               // 2: inventory.v1.HoldRequest
+	(*PartitionState)(nil),            // 3: inventory.v1.PartitionState
+	(*HoldResponse)(nil),              // 4: inventory.v1.HoldResponse
+	(*AvailabilityRequest)(nil),       // 5: inventory.v1.AvailabilityRequest
+	(*AvailabilityResponse)(nil),      // 6: inventory.v1.AvailabilityResponse
+	(*BatchTryHoldRequest)(nil),       // 7: inventory.v1.BatchTryHoldRequest
+	(*BatchHoldRequest)(nil),          // 8: inventory.v1.BatchHoldRequest
+	(*HoldResult)(nil),                // 9: inventory.v1.HoldResult
+	(*BatchHoldResponse)(nil),         // 10: inventory.v1.BatchHoldResponse
+	(*BatchAvailabilityRequest)(nil),  // 11: inventory.v1.BatchAvailabilityRequest
+	(*BatchAvailabilityResponse)(nil), // 12: inventory.v1.BatchAvailabilityResponse
+}
+var file_inventory_v1_inventory_proto_depIdxs = []int32{
+	3,  // 0: inventory.v1.HoldResponse.state:type_name -> inventory.v1.PartitionState
+	1,  // 1: inventory.v1.BatchTryHoldRequest.items:type_name -> inventory.v1.TryHoldRequest
+	2,  // 2: inventory.v1.BatchHoldRequest.items:type_name -> inventory.v1.HoldRequest
+	3,  // 3: inventory.v1.HoldResult.state:type_name -> inventory.v1.PartitionState
+	0,  // 4: inventory.v1.HoldResult.error_code:type_name -> inventory.v1.ErrorCode
+	9,  // 5: inventory.v1.BatchHoldResponse.results:type_name -> inventory.v1.HoldResult
+	6,  // 6: inventory.v1.BatchAvailabilityResponse.results:type_name -> inventory.v1.AvailabilityResponse
+	1,  // 7: inventory.v1.InventoryService.TryHold:input_type -> inventory.v1.TryHoldRequest
+	2,  // 8: inventory.v1.InventoryService.ReleaseHold:input_type -> inventory.v1.HoldRequest
+	2,  // 9: inventory.v1.InventoryService.ConfirmHold:input_type -> inventory.v1.HoldRequest
+	2,  // 10: inventory.v1.InventoryService.ReturnConfirmed:input_type -> inventory.v1.HoldRequest
+	5,  // 11: inventory.v1.InventoryService.GetAvailability:input_type -> inventory.v1.AvailabilityRequest
+	7,  // 12: inventory.v1.InventoryService.BatchTryHold:input_type -> inventory.v1.BatchTryHoldRequest
+	8,  // 13: inventory.v1.InventoryService.BatchReleaseHold:input_type -> inventory.v1.BatchHoldRequest
+	8,  // 14: inventory.v1.InventoryService.BatchConfirmHold:input_type -> inventory.v1.BatchHoldRequest
+	11, // 15: inventory.v1.InventoryService.BatchGetAvailability:input_type -> inventory.v1.BatchAvailabilityRequest
+	4,  // 16: inventory.v1.InventoryService.TryHold:output_type -> inventory.v1.HoldResponse
+	4,  // 17: inventory.v1.InventoryService.ReleaseHold:output_type -> inventory.v1.HoldResponse
+	4,  // 18: inventory.v1.InventoryService.ConfirmHold:output_type -> inventory.v1.HoldResponse
+	4,  // 19: inventory.v1.InventoryService.ReturnConfirmed:output_type -> inventory.v1.HoldResponse
+	6,  // 20: inventory.v1.InventoryService.GetAvailability:output_type -> inventory.v1.AvailabilityResponse
+	10, // 21: inventory.v1.InventoryService.BatchTryHold:output_type -> inventory.v1.BatchHoldResponse
+	10, // 22: inventory.v1.InventoryService.BatchReleaseHold:output_type -> inventory.v1.BatchHoldResponse
+	10, // 23: inventory.v1.InventoryService.BatchConfirmHold:output_type -> inventory.v1.BatchHoldResponse
+	12, // 24: inventory.v1.InventoryService.BatchGetAvailability:output_type -> inventory.v1.BatchAvailabilityResponse
+	16, // [16:25] is the sub-list for method output_type
+	7,  // [7:16] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_inventory_v1_inventory_proto_init() }
+func file_inventory_v1_inventory_proto_init() {
+	if File_inventory_v1_inventory_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_inventory_v1_inventory_proto_rawDesc), len(file_inventory_v1_inventory_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_inventory_v1_inventory_proto_goTypes,
+		DependencyIndexes: file_inventory_v1_inventory_proto_depIdxs,
+		EnumInfos:         file_inventory_v1_inventory_proto_enumTypes,
+		MessageInfos:      file_inventory_v1_inventory_proto_msgTypes,
+	}.Build()
+	File_inventory_v1_inventory_proto = out.File
+	file_inventory_v1_inventory_proto_goTypes = nil
+	file_inventory_v1_inventory_proto_depIdxs = nil
+}
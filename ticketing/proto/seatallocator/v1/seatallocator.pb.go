@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.35.1
+// 	protoc-gen-go v1.36.8
 // 	protoc        v4.25.3
 // source: seatallocator/v1/seatallocator.proto
 
@@ -11,6 +11,7 @@ import (
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
 	sync "sync"
+	unsafe "unsafe"
 )
 
 const (
@@ -20,17 +21,76 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type SeatPreference int32
+
+const (
+	SeatPreference_SEAT_PREFERENCE_UNSPECIFIED SeatPreference = 0
+	// Seats A and F.
+	SeatPreference_SEAT_PREFERENCE_WINDOW SeatPreference = 1
+	// Seats C and D.
+	SeatPreference_SEAT_PREFERENCE_AISLE SeatPreference = 2
+)
+
+// Enum value maps for SeatPreference.
+var (
+	SeatPreference_name = map[int32]string{
+		0: "SEAT_PREFERENCE_UNSPECIFIED",
+		1: "SEAT_PREFERENCE_WINDOW",
+		2: "SEAT_PREFERENCE_AISLE",
+	}
+	SeatPreference_value = map[string]int32{
+		"SEAT_PREFERENCE_UNSPECIFIED": 0,
+		"SEAT_PREFERENCE_WINDOW":      1,
+		"SEAT_PREFERENCE_AISLE":       2,
+	}
+)
+
+func (x SeatPreference) Enum() *SeatPreference {
+	p := new(SeatPreference)
+	*p = x
+	return p
+}
+
+func (x SeatPreference) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SeatPreference) Descriptor() protoreflect.EnumDescriptor {
+	return file_seatallocator_v1_seatallocator_proto_enumTypes[0].Descriptor()
+}
+
+func (SeatPreference) Type() protoreflect.EnumType {
+	return &file_seatallocator_v1_seatallocator_proto_enumTypes[0]
+}
+
+func (x SeatPreference) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SeatPreference.Descriptor instead.
+func (SeatPreference) EnumDescriptor() ([]byte, []int) {
+	return file_seatallocator_v1_seatallocator_proto_rawDescGZIP(), []int{0}
+}
+
 type AllocateSeatRequest struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	OrderId    string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	TrainId    string                 `protobuf:"bytes,2,opt,name=train_id,json=trainId,proto3" json:"train_id,omitempty"`
+	TravelDate string                 `protobuf:"bytes,3,opt,name=travel_date,json=travelDate,proto3" json:"travel_date,omitempty"`
+	CoachType  string                 `protobuf:"bytes,4,opt,name=coach_type,json=coachType,proto3" json:"coach_type,omitempty"`
+	FromIndex  uint32                 `protobuf:"varint,5,opt,name=from_index,json=fromIndex,proto3" json:"from_index,omitempty"`
+	ToIndex    uint32                 `protobuf:"varint,6,opt,name=to_index,json=toIndex,proto3" json:"to_index,omitempty"`
+	// Number of seats to allocate, one per passenger; 0 is treated as 1.
+	// Either all of them are allocated or none is.
+	Qty uint32 `protobuf:"varint,7,opt,name=qty,proto3" json:"qty,omitempty"`
+	// Preference of each passenger, in the order seats are returned;
+	// passengers past the end of the list have none.
+	Preferences []SeatPreference `protobuf:"varint,8,rep,packed,name=preferences,proto3,enum=seatallocator.v1.SeatPreference" json:"preferences,omitempty"`
+	// Prefer adjacent seats in one coach, then any seats in one coach, before
+	// spreading the passengers over the train.
+	KeepTogether  bool `protobuf:"varint,9,opt,name=keep_together,json=keepTogether,proto3" json:"keep_together,omitempty"`
 	unknownFields protoimpl.UnknownFields
-
-	OrderId    string `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
-	TrainId    string `protobuf:"bytes,2,opt,name=train_id,json=trainId,proto3" json:"train_id,omitempty"`
-	TravelDate string `protobuf:"bytes,3,opt,name=travel_date,json=travelDate,proto3" json:"travel_date,omitempty"`
-	CoachType  string `protobuf:"bytes,4,opt,name=coach_type,json=coachType,proto3" json:"coach_type,omitempty"`
-	FromIndex  uint32 `protobuf:"varint,5,opt,name=from_index,json=fromIndex,proto3" json:"from_index,omitempty"`
-	ToIndex    uint32 `protobuf:"varint,6,opt,name=to_index,json=toIndex,proto3" json:"to_index,omitempty"`
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *AllocateSeatRequest) Reset() {
@@ -105,12 +165,39 @@ func (x *AllocateSeatRequest) GetToIndex() uint32 {
 	return 0
 }
 
+func (x *AllocateSeatRequest) GetQty() uint32 {
+	if x != nil {
+		return x.Qty
+	}
+	return 0
+}
+
+func (x *AllocateSeatRequest) GetPreferences() []SeatPreference {
+	if x != nil {
+		return x.Preferences
+	}
+	return nil
+}
+
+func (x *AllocateSeatRequest) GetKeepTogether() bool {
+	if x != nil {
+		return x.KeepTogether
+	}
+	return false
+}
+
 type AllocateSeatResponse struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// First of seat_nos, kept for clients that allocate a single seat.
+	SeatNo  string   `protobuf:"bytes,1,opt,name=seat_no,json=seatNo,proto3" json:"seat_no,omitempty"`
+	SeatNos []string `protobuf:"bytes,2,rep,name=seat_nos,json=seatNos,proto3" json:"seat_nos,omitempty"`
+	// Whether the seat in the same position of seat_nos meets that
+	// passenger's preference; always true for passengers without one.
+	PreferenceHonoured []bool `protobuf:"varint,3,rep,packed,name=preference_honoured,json=preferenceHonoured,proto3" json:"preference_honoured,omitempty"`
+	// Whether the seats are adjacent in one coach.
+	KeptTogether  bool `protobuf:"varint,4,opt,name=kept_together,json=keptTogether,proto3" json:"kept_together,omitempty"`
 	unknownFields protoimpl.UnknownFields
-
-	SeatNo string `protobuf:"bytes,1,opt,name=seat_no,json=seatNo,proto3" json:"seat_no,omitempty"`
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *AllocateSeatResponse) Reset() {
@@ -150,66 +237,389 @@ func (x *AllocateSeatResponse) GetSeatNo() string {
 	return ""
 }
 
-var File_seatallocator_v1_seatallocator_proto protoreflect.FileDescriptor
+func (x *AllocateSeatResponse) GetSeatNos() []string {
+	if x != nil {
+		return x.SeatNos
+	}
+	return nil
+}
 
-var file_seatallocator_v1_seatallocator_proto_rawDesc = []byte{
-	0x0a, 0x24, 0x73, 0x65, 0x61, 0x74, 0x61, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x2f,
-	0x76, 0x31, 0x2f, 0x73, 0x65, 0x61, 0x74, 0x61, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x6f, 0x72,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x10, 0x73, 0x65, 0x61, 0x74, 0x61, 0x6c, 0x6c, 0x6f,
-	0x63, 0x61, 0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x22, 0xc5, 0x01, 0x0a, 0x13, 0x41, 0x6c, 0x6c,
-	0x6f, 0x63, 0x61, 0x74, 0x65, 0x53, 0x65, 0x61, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x12, 0x19, 0x0a, 0x08, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x07, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x49, 0x64, 0x12, 0x19, 0x0a, 0x08, 0x74,
-	0x72, 0x61, 0x69, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x74,
-	0x72, 0x61, 0x69, 0x6e, 0x49, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x72, 0x61, 0x76, 0x65, 0x6c,
-	0x5f, 0x64, 0x61, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x72, 0x61,
-	0x76, 0x65, 0x6c, 0x44, 0x61, 0x74, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x6f, 0x61, 0x63, 0x68,
-	0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6f, 0x61,
-	0x63, 0x68, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x66, 0x72, 0x6f, 0x6d, 0x5f, 0x69,
-	0x6e, 0x64, 0x65, 0x78, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x66, 0x72, 0x6f, 0x6d,
-	0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x6f, 0x5f, 0x69, 0x6e, 0x64, 0x65,
-	0x78, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x74, 0x6f, 0x49, 0x6e, 0x64, 0x65, 0x78,
-	0x22, 0x2f, 0x0a, 0x14, 0x41, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x65, 0x53, 0x65, 0x61, 0x74,
-	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x73, 0x65, 0x61, 0x74,
-	0x5f, 0x6e, 0x6f, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x65, 0x61, 0x74, 0x4e,
-	0x6f, 0x32, 0x6e, 0x0a, 0x0d, 0x53, 0x65, 0x61, 0x74, 0x41, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74,
-	0x6f, 0x72, 0x12, 0x5d, 0x0a, 0x0c, 0x41, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x65, 0x53, 0x65,
-	0x61, 0x74, 0x12, 0x25, 0x2e, 0x73, 0x65, 0x61, 0x74, 0x61, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74,
-	0x6f, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x65, 0x53, 0x65,
-	0x61, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x26, 0x2e, 0x73, 0x65, 0x61, 0x74,
-	0x61, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x6c, 0x6c,
-	0x6f, 0x63, 0x61, 0x74, 0x65, 0x53, 0x65, 0x61, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x42, 0x32, 0x5a, 0x30, 0x74, 0x69, 0x63, 0x6b, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x2f, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x73, 0x65, 0x61, 0x74, 0x61, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74,
-	0x6f, 0x72, 0x2f, 0x76, 0x31, 0x3b, 0x73, 0x65, 0x61, 0x74, 0x61, 0x6c, 0x6c, 0x6f, 0x63, 0x61,
-	0x74, 0x6f, 0x72, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+func (x *AllocateSeatResponse) GetPreferenceHonoured() []bool {
+	if x != nil {
+		return x.PreferenceHonoured
+	}
+	return nil
+}
+
+func (x *AllocateSeatResponse) GetKeptTogether() bool {
+	if x != nil {
+		return x.KeptTogether
+	}
+	return false
+}
+
+type ReleaseSeatRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	TrainId       string                 `protobuf:"bytes,2,opt,name=train_id,json=trainId,proto3" json:"train_id,omitempty"`
+	TravelDate    string                 `protobuf:"bytes,3,opt,name=travel_date,json=travelDate,proto3" json:"travel_date,omitempty"`
+	CoachType     string                 `protobuf:"bytes,4,opt,name=coach_type,json=coachType,proto3" json:"coach_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReleaseSeatRequest) Reset() {
+	*x = ReleaseSeatRequest{}
+	mi := &file_seatallocator_v1_seatallocator_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
+func (x *ReleaseSeatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReleaseSeatRequest) ProtoMessage() {}
+
+func (x *ReleaseSeatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seatallocator_v1_seatallocator_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReleaseSeatRequest.ProtoReflect.Descriptor instead.
+func (*ReleaseSeatRequest) Descriptor() ([]byte, []int) {
+	return file_seatallocator_v1_seatallocator_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ReleaseSeatRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *ReleaseSeatRequest) GetTrainId() string {
+	if x != nil {
+		return x.TrainId
+	}
+	return ""
+}
+
+func (x *ReleaseSeatRequest) GetTravelDate() string {
+	if x != nil {
+		return x.TravelDate
+	}
+	return ""
+}
+
+func (x *ReleaseSeatRequest) GetCoachType() string {
+	if x != nil {
+		return x.CoachType
+	}
+	return ""
+}
+
+type ReleaseSeatResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Seats the order held on the route; empty if it held none.
+	SeatNos       []string `protobuf:"bytes,1,rep,name=seat_nos,json=seatNos,proto3" json:"seat_nos,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReleaseSeatResponse) Reset() {
+	*x = ReleaseSeatResponse{}
+	mi := &file_seatallocator_v1_seatallocator_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReleaseSeatResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReleaseSeatResponse) ProtoMessage() {}
+
+func (x *ReleaseSeatResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_seatallocator_v1_seatallocator_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReleaseSeatResponse.ProtoReflect.Descriptor instead.
+func (*ReleaseSeatResponse) Descriptor() ([]byte, []int) {
+	return file_seatallocator_v1_seatallocator_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ReleaseSeatResponse) GetSeatNos() []string {
+	if x != nil {
+		return x.SeatNos
+	}
+	return nil
+}
+
+type GetSeatMapRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TrainId       string                 `protobuf:"bytes,1,opt,name=train_id,json=trainId,proto3" json:"train_id,omitempty"`
+	TravelDate    string                 `protobuf:"bytes,2,opt,name=travel_date,json=travelDate,proto3" json:"travel_date,omitempty"`
+	CoachType     string                 `protobuf:"bytes,3,opt,name=coach_type,json=coachType,proto3" json:"coach_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSeatMapRequest) Reset() {
+	*x = GetSeatMapRequest{}
+	mi := &file_seatallocator_v1_seatallocator_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSeatMapRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSeatMapRequest) ProtoMessage() {}
+
+func (x *GetSeatMapRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seatallocator_v1_seatallocator_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSeatMapRequest.ProtoReflect.Descriptor instead.
+func (*GetSeatMapRequest) Descriptor() ([]byte, []int) {
+	return file_seatallocator_v1_seatallocator_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetSeatMapRequest) GetTrainId() string {
+	if x != nil {
+		return x.TrainId
+	}
+	return ""
+}
+
+func (x *GetSeatMapRequest) GetTravelDate() string {
+	if x != nil {
+		return x.TravelDate
+	}
+	return ""
+}
+
+func (x *GetSeatMapRequest) GetCoachType() string {
+	if x != nil {
+		return x.CoachType
+	}
+	return ""
+}
+
+type SeatState struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	SeatNo string                 `protobuf:"bytes,1,opt,name=seat_no,json=seatNo,proto3" json:"seat_no,omitempty"`
+	// Bit i is set when the seat is taken between station i and i+1.
+	OccupiedSegments uint64 `protobuf:"varint,2,opt,name=occupied_segments,json=occupiedSegments,proto3" json:"occupied_segments,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *SeatState) Reset() {
+	*x = SeatState{}
+	mi := &file_seatallocator_v1_seatallocator_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SeatState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SeatState) ProtoMessage() {}
+
+func (x *SeatState) ProtoReflect() protoreflect.Message {
+	mi := &file_seatallocator_v1_seatallocator_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SeatState.ProtoReflect.Descriptor instead.
+func (*SeatState) Descriptor() ([]byte, []int) {
+	return file_seatallocator_v1_seatallocator_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SeatState) GetSeatNo() string {
+	if x != nil {
+		return x.SeatNo
+	}
+	return ""
+}
+
+func (x *SeatState) GetOccupiedSegments() uint64 {
+	if x != nil {
+		return x.OccupiedSegments
+	}
+	return 0
+}
+
+type GetSeatMapResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Every seat of the route, free ones included, in seat order.
+	Seats         []*SeatState `protobuf:"bytes,1,rep,name=seats,proto3" json:"seats,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSeatMapResponse) Reset() {
+	*x = GetSeatMapResponse{}
+	mi := &file_seatallocator_v1_seatallocator_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSeatMapResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSeatMapResponse) ProtoMessage() {}
+
+func (x *GetSeatMapResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_seatallocator_v1_seatallocator_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSeatMapResponse.ProtoReflect.Descriptor instead.
+func (*GetSeatMapResponse) Descriptor() ([]byte, []int) {
+	return file_seatallocator_v1_seatallocator_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetSeatMapResponse) GetSeats() []*SeatState {
+	if x != nil {
+		return x.Seats
+	}
+	return nil
+}
+
+var File_seatallocator_v1_seatallocator_proto protoreflect.FileDescriptor
+
+const file_seatallocator_v1_seatallocator_proto_rawDesc = "" +
+	"\n" +
+	"$seatallocator/v1/seatallocator.proto\x12\x10seatallocator.v1\"\xc0\x02\n" +
+	"\x13AllocateSeatRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x19\n" +
+	"\btrain_id\x18\x02 \x01(\tR\atrainId\x12\x1f\n" +
+	"\vtravel_date\x18\x03 \x01(\tR\n" +
+	"travelDate\x12\x1d\n" +
+	"\n" +
+	"coach_type\x18\x04 \x01(\tR\tcoachType\x12\x1d\n" +
+	"\n" +
+	"from_index\x18\x05 \x01(\rR\tfromIndex\x12\x19\n" +
+	"\bto_index\x18\x06 \x01(\rR\atoIndex\x12\x10\n" +
+	"\x03qty\x18\a \x01(\rR\x03qty\x12B\n" +
+	"\vpreferences\x18\b \x03(\x0e2 .seatallocator.v1.SeatPreferenceR\vpreferences\x12#\n" +
+	"\rkeep_together\x18\t \x01(\bR\fkeepTogether\"\xa0\x01\n" +
+	"\x14AllocateSeatResponse\x12\x17\n" +
+	"\aseat_no\x18\x01 \x01(\tR\x06seatNo\x12\x19\n" +
+	"\bseat_nos\x18\x02 \x03(\tR\aseatNos\x12/\n" +
+	"\x13preference_honoured\x18\x03 \x03(\bR\x12preferenceHonoured\x12#\n" +
+	"\rkept_together\x18\x04 \x01(\bR\fkeptTogether\"\x8a\x01\n" +
+	"\x12ReleaseSeatRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x19\n" +
+	"\btrain_id\x18\x02 \x01(\tR\atrainId\x12\x1f\n" +
+	"\vtravel_date\x18\x03 \x01(\tR\n" +
+	"travelDate\x12\x1d\n" +
+	"\n" +
+	"coach_type\x18\x04 \x01(\tR\tcoachType\"0\n" +
+	"\x13ReleaseSeatResponse\x12\x19\n" +
+	"\bseat_nos\x18\x01 \x03(\tR\aseatNos\"n\n" +
+	"\x11GetSeatMapRequest\x12\x19\n" +
+	"\btrain_id\x18\x01 \x01(\tR\atrainId\x12\x1f\n" +
+	"\vtravel_date\x18\x02 \x01(\tR\n" +
+	"travelDate\x12\x1d\n" +
+	"\n" +
+	"coach_type\x18\x03 \x01(\tR\tcoachType\"Q\n" +
+	"\tSeatState\x12\x17\n" +
+	"\aseat_no\x18\x01 \x01(\tR\x06seatNo\x12+\n" +
+	"\x11occupied_segments\x18\x02 \x01(\x04R\x10occupiedSegments\"G\n" +
+	"\x12GetSeatMapResponse\x121\n" +
+	"\x05seats\x18\x01 \x03(\v2\x1b.seatallocator.v1.SeatStateR\x05seats*h\n" +
+	"\x0eSeatPreference\x12\x1f\n" +
+	"\x1bSEAT_PREFERENCE_UNSPECIFIED\x10\x00\x12\x1a\n" +
+	"\x16SEAT_PREFERENCE_WINDOW\x10\x01\x12\x19\n" +
+	"\x15SEAT_PREFERENCE_AISLE\x10\x022\xa3\x02\n" +
+	"\rSeatAllocator\x12]\n" +
+	"\fAllocateSeat\x12%.seatallocator.v1.AllocateSeatRequest\x1a&.seatallocator.v1.AllocateSeatResponse\x12Z\n" +
+	"\vReleaseSeat\x12$.seatallocator.v1.ReleaseSeatRequest\x1a%.seatallocator.v1.ReleaseSeatResponse\x12W\n" +
+	"\n" +
+	"GetSeatMap\x12#.seatallocator.v1.GetSeatMapRequest\x1a$.seatallocator.v1.GetSeatMapResponseB2Z0ticketing/proto/seatallocator/v1;seatallocatorv1b\x06proto3"
+
 var (
 	file_seatallocator_v1_seatallocator_proto_rawDescOnce sync.Once
-	file_seatallocator_v1_seatallocator_proto_rawDescData = file_seatallocator_v1_seatallocator_proto_rawDesc
+	file_seatallocator_v1_seatallocator_proto_rawDescData []byte
 )
 
 func file_seatallocator_v1_seatallocator_proto_rawDescGZIP() []byte {
 	file_seatallocator_v1_seatallocator_proto_rawDescOnce.Do(func() {
-		file_seatallocator_v1_seatallocator_proto_rawDescData = protoimpl.X.CompressGZIP(file_seatallocator_v1_seatallocator_proto_rawDescData)
+		file_seatallocator_v1_seatallocator_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_seatallocator_v1_seatallocator_proto_rawDesc), len(file_seatallocator_v1_seatallocator_proto_rawDesc)))
 	})
 	return file_seatallocator_v1_seatallocator_proto_rawDescData
 }
 
-var file_seatallocator_v1_seatallocator_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_seatallocator_v1_seatallocator_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_seatallocator_v1_seatallocator_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
 var file_seatallocator_v1_seatallocator_proto_goTypes = []any{
-	(*AllocateSeatRequest)(nil),  // 0: seatallocator.v1.AllocateSeatRequest
-	(*AllocateSeatResponse)(nil), // 1: seatallocator.v1.AllocateSeatResponse
+	(SeatPreference)(0),          // 0: seatallocator.v1.SeatPreference
+	(*AllocateSeatRequest)(nil),  // 1: seatallocator.v1.AllocateSeatRequest
+	(*AllocateSeatResponse)(nil), // 2: seatallocator.v1.AllocateSeatResponse
+	(*ReleaseSeatRequest)(nil),   // 3: seatallocator.v1.ReleaseSeatRequest
+	(*ReleaseSeatResponse)(nil),  // 4: seatallocator.v1.ReleaseSeatResponse
+	(*GetSeatMapRequest)(nil),    // 5: seatallocator.v1.GetSeatMapRequest
+	(*SeatState)(nil),            // 6: seatallocator.v1.SeatState
+	(*GetSeatMapResponse)(nil),   // 7: seatallocator.v1.GetSeatMapResponse
 }
 var file_seatallocator_v1_seatallocator_proto_depIdxs = []int32{
-	0, // 0: seatallocator.v1.SeatAllocator.AllocateSeat:input_type -> seatallocator.v1.AllocateSeatRequest
-	1, // 1: seatallocator.v1.SeatAllocator.AllocateSeat:output_type -> seatallocator.v1.AllocateSeatResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	0, // 0: seatallocator.v1.AllocateSeatRequest.preferences:type_name -> seatallocator.v1.SeatPreference
+	6, // 1: seatallocator.v1.GetSeatMapResponse.seats:type_name -> seatallocator.v1.SeatState
+	1, // 2: seatallocator.v1.SeatAllocator.AllocateSeat:input_type -> seatallocator.v1.AllocateSeatRequest
+	3, // 3: seatallocator.v1.SeatAllocator.ReleaseSeat:input_type -> seatallocator.v1.ReleaseSeatRequest
+	5, // 4: seatallocator.v1.SeatAllocator.GetSeatMap:input_type -> seatallocator.v1.GetSeatMapRequest
+	2, // 5: seatallocator.v1.SeatAllocator.AllocateSeat:output_type -> seatallocator.v1.AllocateSeatResponse
+	4, // 6: seatallocator.v1.SeatAllocator.ReleaseSeat:output_type -> seatallocator.v1.ReleaseSeatResponse
+	7, // 7: seatallocator.v1.SeatAllocator.GetSeatMap:output_type -> seatallocator.v1.GetSeatMapResponse
+	5, // [5:8] is the sub-list for method output_type
+	2, // [2:5] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_seatallocator_v1_seatallocator_proto_init() }
@@ -221,18 +631,18 @@ func file_seatallocator_v1_seatallocator_proto_init() {
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
-			RawDescriptor: file_seatallocator_v1_seatallocator_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   2,
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_seatallocator_v1_seatallocator_proto_rawDesc), len(file_seatallocator_v1_seatallocator_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   7,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_seatallocator_v1_seatallocator_proto_goTypes,
 		DependencyIndexes: file_seatallocator_v1_seatallocator_proto_depIdxs,
+		EnumInfos:         file_seatallocator_v1_seatallocator_proto_enumTypes,
 		MessageInfos:      file_seatallocator_v1_seatallocator_proto_msgTypes,
 	}.Build()
 	File_seatallocator_v1_seatallocator_proto = out.File
-	file_seatallocator_v1_seatallocator_proto_rawDesc = nil
 	file_seatallocator_v1_seatallocator_proto_goTypes = nil
 	file_seatallocator_v1_seatallocator_proto_depIdxs = nil
 }
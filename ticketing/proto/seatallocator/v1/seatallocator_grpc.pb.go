@@ -20,13 +20,22 @@ const _ = grpc.SupportPackageIsVersion9
 
 const (
 	SeatAllocator_AllocateSeat_FullMethodName = "/seatallocator.v1.SeatAllocator/AllocateSeat"
+	SeatAllocator_ReleaseSeat_FullMethodName  = "/seatallocator.v1.SeatAllocator/ReleaseSeat"
+	SeatAllocator_GetSeatMap_FullMethodName   = "/seatallocator.v1.SeatAllocator/GetSeatMap"
 )
 
 // SeatAllocatorClient is the client API for SeatAllocator service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type SeatAllocatorClient interface {
+	// Allocates the seats of an order. Idempotent by order_id: an order that
+	// already holds seats on the route gets the same seats back.
 	AllocateSeat(ctx context.Context, in *AllocateSeatRequest, opts ...grpc.CallOption) (*AllocateSeatResponse, error)
+	// Frees every seat the order holds on the route. Releasing an order that
+	// holds no seats succeeds and releases nothing.
+	ReleaseSeat(ctx context.Context, in *ReleaseSeatRequest, opts ...grpc.CallOption) (*ReleaseSeatResponse, error)
+	// Reports which segments every seat of the route is taken for.
+	GetSeatMap(ctx context.Context, in *GetSeatMapRequest, opts ...grpc.CallOption) (*GetSeatMapResponse, error)
 }
 
 type seatAllocatorClient struct {
@@ -47,11 +56,38 @@ func (c *seatAllocatorClient) AllocateSeat(ctx context.Context, in *AllocateSeat
 	return out, nil
 }
 
+func (c *seatAllocatorClient) ReleaseSeat(ctx context.Context, in *ReleaseSeatRequest, opts ...grpc.CallOption) (*ReleaseSeatResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReleaseSeatResponse)
+	err := c.cc.Invoke(ctx, SeatAllocator_ReleaseSeat_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seatAllocatorClient) GetSeatMap(ctx context.Context, in *GetSeatMapRequest, opts ...grpc.CallOption) (*GetSeatMapResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSeatMapResponse)
+	err := c.cc.Invoke(ctx, SeatAllocator_GetSeatMap_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SeatAllocatorServer is the server API for SeatAllocator service.
 // All implementations must embed UnimplementedSeatAllocatorServer
 // for forward compatibility.
 type SeatAllocatorServer interface {
+	// Allocates the seats of an order. Idempotent by order_id: an order that
+	// already holds seats on the route gets the same seats back.
 	AllocateSeat(context.Context, *AllocateSeatRequest) (*AllocateSeatResponse, error)
+	// Frees every seat the order holds on the route. Releasing an order that
+	// holds no seats succeeds and releases nothing.
+	ReleaseSeat(context.Context, *ReleaseSeatRequest) (*ReleaseSeatResponse, error)
+	// Reports which segments every seat of the route is taken for.
+	GetSeatMap(context.Context, *GetSeatMapRequest) (*GetSeatMapResponse, error)
 	mustEmbedUnimplementedSeatAllocatorServer()
 }
 
@@ -65,6 +101,12 @@ type UnimplementedSeatAllocatorServer struct{}
 func (UnimplementedSeatAllocatorServer) AllocateSeat(context.Context, *AllocateSeatRequest) (*AllocateSeatResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AllocateSeat not implemented")
 }
+func (UnimplementedSeatAllocatorServer) ReleaseSeat(context.Context, *ReleaseSeatRequest) (*ReleaseSeatResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseSeat not implemented")
+}
+func (UnimplementedSeatAllocatorServer) GetSeatMap(context.Context, *GetSeatMapRequest) (*GetSeatMapResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSeatMap not implemented")
+}
 func (UnimplementedSeatAllocatorServer) mustEmbedUnimplementedSeatAllocatorServer() {}
 func (UnimplementedSeatAllocatorServer) testEmbeddedByValue()                       {}
 
@@ -104,6 +146,42 @@ func _SeatAllocator_AllocateSeat_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SeatAllocator_ReleaseSeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseSeatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeatAllocatorServer).ReleaseSeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SeatAllocator_ReleaseSeat_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeatAllocatorServer).ReleaseSeat(ctx, req.(*ReleaseSeatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SeatAllocator_GetSeatMap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSeatMapRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeatAllocatorServer).GetSeatMap(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SeatAllocator_GetSeatMap_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeatAllocatorServer).GetSeatMap(ctx, req.(*GetSeatMapRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // SeatAllocator_ServiceDesc is the grpc.ServiceDesc for SeatAllocator service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -115,6 +193,14 @@ var SeatAllocator_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AllocateSeat",
 			Handler:    _SeatAllocator_AllocateSeat_Handler,
 		},
+		{
+			MethodName: "ReleaseSeat",
+			Handler:    _SeatAllocator_ReleaseSeat_Handler,
+		},
+		{
+			MethodName: "GetSeatMap",
+			Handler:    _SeatAllocator_GetSeatMap_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "seatallocator/v1/seatallocator.proto",
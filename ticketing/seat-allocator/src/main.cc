@@ -16,6 +16,7 @@ namespace {
 
 constexpr uint32_t kSegmentCount = 64;
 constexpr uint32_t kDefaultSeatCount = 200;
+constexpr size_t kSeatsPerCoach = 50;
 
 using ::grpc::Server;
 using ::grpc::ServerBuilder;
@@ -23,7 +24,12 @@ using ::grpc::ServerContext;
 using ::grpc::Status;
 using ::seatallocator::v1::AllocateSeatRequest;
 using ::seatallocator::v1::AllocateSeatResponse;
+using ::seatallocator::v1::GetSeatMapRequest;
+using ::seatallocator::v1::GetSeatMapResponse;
+using ::seatallocator::v1::ReleaseSeatRequest;
+using ::seatallocator::v1::ReleaseSeatResponse;
 using ::seatallocator::v1::SeatAllocator;
+using ::seatallocator::v1::SeatPreference;
 
 struct SeatRecord {
   std::bitset<kSegmentCount> occupied_segments;
@@ -33,25 +39,115 @@ class RouteInventory {
  public:
   explicit RouteInventory(uint32_t seat_count) : seats_(seat_count) {}
 
-  bool Allocate(uint32_t from_index, uint32_t to_index, std::string* seat_no_out) {
+  // Allocates qty seats to order_id for [from_index, to_index), all or none. With
+  // keep_together it first looks for qty consecutive free seats in one
+  // coach. *kept_together_out tells whether the seats are adjacent. An order
+  // that already holds seats gets them back unchanged.
+  bool Allocate(const std::string& order_id, uint32_t from_index, uint32_t to_index,
+                uint32_t qty, bool keep_together, std::vector<std::string>* seat_nos_out,
+                bool* kept_together_out) {
     if (from_index >= to_index || to_index > kSegmentCount) {
       return false;
     }
+    std::vector<size_t> picked;
+    auto held_it = held_by_order_.find(order_id);
+    if (held_it != held_by_order_.end()) {
+      // Idempotent by order: hand back the seats it already holds.
+      for (const Held& held : held_it->second) {
+        picked.push_back(held.seat);
+        seat_nos_out->push_back(FormatSeatNo(held.seat));
+      }
+      *kept_together_out = Adjacent(picked);
+      return true;
+    }
     const std::bitset<kSegmentCount> demand_mask = BuildMask(from_index, to_index);
+    if (keep_together && qty > 1) {
+      picked = FindRun(demand_mask, qty);
+    }
+    if (picked.empty()) {
+      for (size_t i = 0; i < seats_.size() && picked.size() < qty; ++i) {
+        if (IsFree(i, demand_mask)) {
+          picked.push_back(i);
+        }
+      }
+    }
+    if (picked.size() < qty) {
+      return false;
+    }
+    std::vector<Held>& held = held_by_order_[order_id];
+    for (size_t i : picked) {
+      seats_[i].occupied_segments |= demand_mask;
+      held.push_back(Held{i, demand_mask});
+      seat_nos_out->push_back(FormatSeatNo(i));
+    }
+    *kept_together_out = Adjacent(picked);
+    return true;
+  }
+
+  // Frees every seat order_id holds and appends them to seat_nos_out.
+  void Release(const std::string& order_id, std::vector<std::string>* seat_nos_out) {
+    auto it = held_by_order_.find(order_id);
+    if (it == held_by_order_.end()) {
+      return;
+    }
+    for (const Held& held : it->second) {
+      seats_[held.seat].occupied_segments &= ~held.mask;
+      seat_nos_out->push_back(FormatSeatNo(held.seat));
+    }
+    held_by_order_.erase(it);
+  }
+
+  void FillSeatMap(GetSeatMapResponse* resp) const {
+    for (size_t i = 0; i < seats_.size(); ++i) {
+      auto* seat = resp->add_seats();
+      seat->set_seat_no(FormatSeatNo(i));
+      seat->set_occupied_segments(seats_[i].occupied_segments.to_ullong());
+    }
+  }
+
+ private:
+  struct Held {
+    size_t seat;
+    std::bitset<kSegmentCount> mask;
+  };
+
+  // picked is in seat order.
+  static bool Adjacent(const std::vector<size_t>& picked) {
+    return picked.back() - picked.front() + 1 == picked.size() &&
+           picked.front() / kSeatsPerCoach == picked.back() / kSeatsPerCoach;
+  }
+
+  bool IsFree(size_t idx, const std::bitset<kSegmentCount>& demand_mask) const {
+    // O-D compatibility check with bit mask: can allocate only when no overlap.
+    return (seats_[idx].occupied_segments & demand_mask).none();
+  }
+
+  // Returns the first run of qty free seats that does not cross a coach, or
+  // nothing.
+  std::vector<size_t> FindRun(const std::bitset<kSegmentCount>& demand_mask, uint32_t qty) const {
+    size_t run_start = 0;
+    size_t run_len = 0;
     for (size_t i = 0; i < seats_.size(); ++i) {
-      auto& seat = seats_[i];
-      // O-D compatibility check with bit mask: can allocate only when no overlap.
-      if ((seat.occupied_segments & demand_mask).any()) {
+      if (!IsFree(i, demand_mask) || (run_len > 0 && i % kSeatsPerCoach == 0)) {
+        run_len = 0;
+      }
+      if (!IsFree(i, demand_mask)) {
         continue;
       }
-      seat.occupied_segments |= demand_mask;
-      *seat_no_out = FormatSeatNo(i);
-      return true;
+      if (run_len == 0) {
+        run_start = i;
+      }
+      if (++run_len == qty) {
+        std::vector<size_t> run;
+        for (size_t j = run_start; j <= i; ++j) {
+          run.push_back(j);
+        }
+        return run;
+      }
     }
-    return false;
+    return {};
   }
 
- private:
   static std::bitset<kSegmentCount> BuildMask(uint32_t from_index, uint32_t to_index) {
     std::bitset<kSegmentCount> mask;
     for (uint32_t i = from_index; i < to_index; ++i) {
@@ -61,15 +157,15 @@ class RouteInventory {
   }
 
   static std::string FormatSeatNo(size_t idx) {
-    constexpr size_t seats_per_coach = 50;
-    const size_t coach = idx / seats_per_coach + 1;
-    const size_t seat = idx % seats_per_coach + 1;
+    const size_t coach = idx / kSeatsPerCoach + 1;
+    const size_t seat = idx % kSeatsPerCoach + 1;
     std::ostringstream os;
     os << coach << "-" << seat;
     return os.str();
   }
 
   std::vector<SeatRecord> seats_;
+  std::unordered_map<std::string, std::vector<Held>> held_by_order_;
 };
 
 class SeatAllocatorService final : public SeatAllocator::Service {
@@ -81,24 +177,73 @@ class SeatAllocatorService final : public SeatAllocator::Service {
       return Status(grpc::StatusCode::INVALID_ARGUMENT, "train_id/travel_date/coach_type required");
     }
 
-    const std::string route_key =
-        req->train_id() + "|" + req->travel_date() + "|" + req->coach_type();
+    std::lock_guard<std::mutex> lock(mu_);
+    RouteInventory& route = Route(req->train_id(), req->travel_date(), req->coach_type());
+
+    const uint32_t qty = req->qty() == 0 ? 1 : req->qty();
+    std::vector<std::string> seat_nos;
+    bool kept_together = false;
+    if (!route.Allocate(req->order_id(), req->from_index(), req->to_index(), qty,
+                        req->keep_together(), &seat_nos, &kept_together)) {
+      return Status(grpc::StatusCode::RESOURCE_EXHAUSTED, "no seat available for requested O-D");
+    }
+    resp->set_seat_no(seat_nos.front());
+    for (size_t i = 0; i < seat_nos.size(); ++i) {
+      resp->add_seat_nos(seat_nos[i]);
+      // Seats here are numbered, not lettered, so window and aisle
+      // preferences cannot be met.
+      const bool no_preference =
+          static_cast<int>(i) >= req->preferences_size() ||
+          req->preferences(static_cast<int>(i)) == SeatPreference::SEAT_PREFERENCE_UNSPECIFIED;
+      resp->add_preference_honoured(no_preference);
+    }
+    resp->set_kept_together(kept_together);
+    return Status::OK;
+  }
+
+  Status ReleaseSeat(ServerContext*,
+                     const ReleaseSeatRequest* req,
+                     ReleaseSeatResponse* resp) override {
+    if (req->order_id().empty() || req->train_id().empty() || req->travel_date().empty() ||
+        req->coach_type().empty()) {
+      return Status(grpc::StatusCode::INVALID_ARGUMENT,
+                    "order_id/train_id/travel_date/coach_type required");
+    }
 
     std::lock_guard<std::mutex> lock(mu_);
-    auto it = routes_.find(route_key);
-    if (it == routes_.end()) {
-      it = routes_.emplace(route_key, RouteInventory(kDefaultSeatCount)).first;
+    std::vector<std::string> seat_nos;
+    RouteInventory& route = Route(req->train_id(), req->travel_date(), req->coach_type());
+    route.Release(req->order_id(), &seat_nos);
+    for (const std::string& seat_no : seat_nos) {
+      resp->add_seat_nos(seat_no);
     }
+    return Status::OK;
+  }
 
-    std::string seat_no;
-    if (!it->second.Allocate(req->from_index(), req->to_index(), &seat_no)) {
-      return Status(grpc::StatusCode::RESOURCE_EXHAUSTED, "no seat available for requested O-D");
+  Status GetSeatMap(ServerContext*,
+                    const GetSeatMapRequest* req,
+                    GetSeatMapResponse* resp) override {
+    if (req->train_id().empty() || req->travel_date().empty() || req->coach_type().empty()) {
+      return Status(grpc::StatusCode::INVALID_ARGUMENT, "train_id/travel_date/coach_type required");
     }
-    resp->set_seat_no(seat_no);
+
+    std::lock_guard<std::mutex> lock(mu_);
+    Route(req->train_id(), req->travel_date(), req->coach_type()).FillSeatMap(resp);
     return Status::OK;
   }
 
  private:
+  // Returns the inventory of a route, creating it on first use. Requires mu_.
+  RouteInventory& Route(const std::string& train_id, const std::string& travel_date,
+                        const std::string& coach_type) {
+    const std::string route_key = train_id + "|" + travel_date + "|" + coach_type;
+    auto it = routes_.find(route_key);
+    if (it == routes_.end()) {
+      it = routes_.emplace(route_key, RouteInventory(kDefaultSeatCount)).first;
+    }
+    return it->second;
+  }
+
   std::mutex mu_;
   std::unordered_map<std::string, RouteInventory> routes_;
 };
@@ -3,32 +3,78 @@ import argparse
 import hashlib
 import hmac
 import json
+import random
 import time
+import urllib.error
 import urllib.parse
 import urllib.request
+import uuid
 from typing import Any, Tuple
 
 
-def sign(secret: str, order_id: str, provider_txn_id: str, status: str) -> str:
-    payload = f"{order_id}|{provider_txn_id}|{status.upper()}".encode("utf-8")
+def sign(
+    secret: str,
+    order_id: str,
+    provider_txn_id: str,
+    status: str,
+    amount_cents: int,
+    currency: str,
+    timestamp: int,
+    nonce: str,
+    payment_id: str = "",
+) -> str:
+    payload = (
+        f"{order_id}|{payment_id}|{provider_txn_id}|{status.upper()}|"
+        f"{amount_cents}|{currency.upper()}|{timestamp}|{nonce}"
+    ).encode("utf-8")
     return hmac.new(secret.encode("utf-8"), payload, hashlib.sha256).hexdigest()
 
 
-def post_json(url: str, payload: dict[str, Any]) -> Tuple[int, dict[str, Any]]:
+def auth_headers(token: str) -> dict[str, str]:
+    return {"Authorization": f"Bearer {token}"} if token else {}
+
+
+def post_json(url: str, payload: dict[str, Any], token: str = "") -> Tuple[int, dict[str, Any]]:
     body = json.dumps(payload).encode("utf-8")
-    req = urllib.request.Request(url, data=body, headers={"Content-Type": "application/json"}, method="POST")
+    headers = {"Content-Type": "application/json", **auth_headers(token)}
+    req = urllib.request.Request(url, data=body, headers=headers, method="POST")
     with urllib.request.urlopen(req, timeout=5) as resp:
         raw = resp.read().decode("utf-8")
         return resp.status, json.loads(raw) if raw else {}
 
 
-def get_json(url: str) -> Tuple[int, dict[str, Any]]:
-    req = urllib.request.Request(url, method="GET")
+def get_json(url: str, token: str = "") -> Tuple[int, dict[str, Any]]:
+    req = urllib.request.Request(url, headers=auth_headers(token), method="GET")
     with urllib.request.urlopen(req, timeout=5) as resp:
         raw = resp.read().decode("utf-8")
         return resp.status, json.loads(raw) if raw else {}
 
 
+def id_card_number() -> str:
+    weights = (7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2)
+    body = "110101" + f"{random.randint(1970, 2005)}0101" + f"{random.randint(0, 999):03d}"
+    checksum = sum(int(d) * w for d, w in zip(body, weights)) % 11
+    return body + "10X98765432"[checksum]
+
+
+def passengers(count: int) -> list[dict[str, str]]:
+    return [{"name": f"乘客{i + 1}", "id_number": id_card_number()} for i in range(count)]
+
+
+def login(order_url: str, username: str, password: str) -> str:
+    credentials = {"username": username, "password": password}
+    try:
+        post_json(f"{order_url}/auth/register", credentials)
+    except urllib.error.HTTPError as exc:
+        if exc.code != 409:
+            raise
+    _, result = post_json(f"{order_url}/auth/login", credentials)
+    token = result.get("Token")
+    if not token:
+        raise RuntimeError(f"login failed: {result}")
+    return token
+
+
 def main() -> None:
     parser = argparse.ArgumentParser(description="Minimal E2E: order -> reserve(try-hold) -> pay(confirm-hold) -> ticketed")
     parser.add_argument("--order-url", default="http://127.0.0.1:8081")
@@ -38,14 +84,20 @@ def main() -> None:
     parser.add_argument("--capacity", type=int, default=500)
     parser.add_argument("--sign-key", default="dev-payment-sign-key")
     parser.add_argument("--wait-seconds", type=int, default=30)
+    parser.add_argument("--username", default="e2e-user")
+    parser.add_argument("--password", default="e2e-password")
     args = parser.parse_args()
 
+    token = login(args.order_url, args.username, args.password)
+
     idempotency_key = f"e2e-{int(time.time() * 1000)}"
     provider_txn_id = f"txn-{int(time.time() * 1000)}"
+    amount_cents = 18800
 
     _, created = post_json(
         f"{args.order_url}/orders",
-        {"idempotency_key": idempotency_key, "amount_cents": 18800},
+        {"idempotency_key": idempotency_key, "amount_cents": amount_cents},
+        token,
     )
     order_id = created.get("OrderID")
     if not order_id:
@@ -57,20 +109,28 @@ def main() -> None:
             "order_id": order_id,
             "partition_key": args.partition_key,
             "hold_id": order_id,
-            "qty": args.qty,
+            "passengers": passengers(args.qty),
             "capacity": args.capacity,
         },
+        token,
     )
 
-    signature = sign(args.sign_key, order_id, provider_txn_id, "SUCCESS")
+    timestamp = int(time.time())
+    nonce = uuid.uuid4().hex
+    signature = sign(args.sign_key, order_id, provider_txn_id, "SUCCESS", amount_cents, "CNY", timestamp, nonce)
     post_json(
         f"{args.order_url}/payments/callback",
         {
             "order_id": order_id,
             "provider_txn_id": provider_txn_id,
             "status": "SUCCESS",
+            "amount_cents": amount_cents,
+            "currency": "CNY",
             "partition_key": args.partition_key,
             "hold_id": order_id,
+            "key_id": "default",
+            "timestamp": timestamp,
+            "nonce": nonce,
             "signature": signature,
         },
     )
@@ -88,7 +148,7 @@ def main() -> None:
             pass
         time.sleep(1)
 
-    _, order_final = get_json(f"{args.order_url}/orders/get?order_id={urllib.parse.quote(order_id)}")
+    _, order_final = get_json(f"{args.order_url}/orders/get?order_id={urllib.parse.quote(order_id)}", token)
     result = {
         "order_id": order_id,
         "order_status": order_final.get("Status"),
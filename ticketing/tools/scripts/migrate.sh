@@ -4,5 +4,25 @@ set -eu
 docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0001_init.sql
 docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0002_query_readmodel.sql
 docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0003_ticket_outbox.sql
+docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0004_ticket_change.sql
+docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0005_order_saga.sql
+docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0006_order_status_history.sql
+docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0007_order_listing.sql
+docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0008_users.sql
+docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0009_order_passengers.sql
+docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0010_payment_intents.sql
+docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0011_payment_paid_amount.sql
+docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0012_payment_refunds.sql
+docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0013_payment_reconciliation.sql
+docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0014_order_outbox_retry.sql
+docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0015_seat_allocations.sql
+docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0016_ticket_itinerary.sql
+docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0017_seat_preferences.sql
+docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0018_seat_release.sql
+docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0019_passenger_tickets.sql
+docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0020_ticket_documents.sql
+docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0021_ticket_status.sql
+docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0022_order_event_parking.sql
+docker exec -i ticketing-mysql mysql -uroot -proot ticketing < migrations/0023_change_fare_payment.sql
 
 echo "migrations applied"